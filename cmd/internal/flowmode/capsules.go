@@ -0,0 +1,19 @@
+package flowmode
+
+import (
+	"reflect"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/pkg/river/typeexpr"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// capsules are the Go types which the type of an argument block may refer to
+// with capsule("NAME"). Every value which implements one of these interfaces
+// matches its capsule type.
+var capsules = typeexpr.Capsules{
+	"loki.LogsReceiver":  reflect.TypeOf((*loki.LogsReceiver)(nil)).Elem(),
+	"otelcol.Consumer":   reflect.TypeOf((*otelcol.Consumer)(nil)).Elem(),
+	"storage.Appendable": reflect.TypeOf((*storage.Appendable)(nil)).Elem(),
+}
@@ -7,16 +7,24 @@ import (
 	"io"
 	"os"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/service"
+	clusterservice "github.com/grafana/agent/service/cluster"
+	httpservice "github.com/grafana/agent/service/http"
 )
 
 func fmtCommand() *cobra.Command {
 	f := &flowFmt{
-		write: false,
+		write:    false,
+		validate: false,
+		strict:   false,
 	}
 
 	cmd := &cobra.Command{
@@ -27,7 +35,11 @@ River configuration file.
 
 If the file argument is not supplied or if the file argument is "-", then fmt will read from stdin.
 
-The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.`,
+The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.
+
+The --validate flag can be used to also validate the file as a Grafana Agent Flow configuration. Validation checks for unknown components, invalid references between components, cycles, and invalid component arguments without running any components. When --validate is provided, the formatted result is only written to disk if -w is provided, and is never written to stdout.
+
+Problems found with blocks which reference the exports of other components are reported as warnings, since exports are only known once components run. The --strict flag can be used with --validate to treat these warnings as errors.`,
 		Args:         cobra.RangeArgs(0, 1),
 		SilenceUsage: true,
 		Aliases:      []string{"format"},
@@ -42,6 +54,10 @@ The -w flag can be used to write the formatted file back to disk. -w can not be
 				err = f.Run(args[0])
 			}
 
+			if errors.Is(err, errValidation) {
+				return err
+			}
+
 			var diags diag.Diagnostics
 			if errors.As(err, &diags) {
 				for _, diag := range diags {
@@ -55,20 +71,28 @@ The -w flag can be used to write the formatted file back to disk. -w can not be
 	}
 
 	cmd.Flags().BoolVarP(&f.write, "write", "w", f.write, "write result to (source) file instead of stdout")
+	cmd.Flags().BoolVar(&f.validate, "validate", f.validate, "validate the file as a Flow configuration without running it")
+	cmd.Flags().BoolVar(&f.strict, "strict", f.strict, "treat validation warnings as errors")
 	return cmd
 }
 
 type flowFmt struct {
-	write bool
+	write    bool
+	validate bool
+	strict   bool
 }
 
 func (ff *flowFmt) Run(configFile string) error {
+	if ff.strict && !ff.validate {
+		return fmt.Errorf("--strict can only be used with --validate")
+	}
+
 	switch configFile {
 	case "-":
 		if ff.write {
 			return fmt.Errorf("cannot use -w with standard input")
 		}
-		return format("<stdin>", nil, os.Stdin, false)
+		return format("<stdin>", nil, os.Stdin, false, ff.validate, ff.strict)
 
 	default:
 		fi, err := os.Stat(configFile)
@@ -84,11 +108,11 @@ func (ff *flowFmt) Run(configFile string) error {
 			return err
 		}
 		defer f.Close()
-		return format(configFile, fi, f, ff.write)
+		return format(configFile, fi, f, ff.write, ff.validate, ff.strict)
 	}
 }
 
-func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
+func format(filename string, fi os.FileInfo, r io.Reader, write bool, validate bool, strict bool) error {
 	bb, err := io.ReadAll(r)
 	if err != nil {
 		return err
@@ -99,6 +123,12 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
 		return err
 	}
 
+	if validate {
+		if err := validateFlowFile(filename, bb, strict); err != nil {
+			return err
+		}
+	}
+
 	var buf bytes.Buffer
 	if err := printer.Fprint(&buf, f); err != nil {
 		return err
@@ -108,6 +138,9 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
 	_, _ = buf.Write([]byte{'\n'})
 
 	if !write {
+		if validate {
+			return nil
+		}
 		_, err := io.Copy(os.Stdout, &buf)
 		return err
 	}
@@ -121,3 +154,80 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
 	_, err = io.Copy(wf, &buf)
 	return err
 }
+
+// errValidation is returned when a file fails validation. Diagnostics for the
+// file are printed before errValidation is returned.
+var errValidation = errors.New("encountered errors during validation")
+
+// validateFlowFile validates the contents of a Flow configuration file
+// without running any components. Diagnostics found during validation are
+// printed to stderr. If strict is true, warnings also fail validation.
+func validateFlowFile(filename string, bb []byte, strict bool) error {
+	services, err := validationServices()
+	if err != nil {
+		return err
+	}
+
+	diags, err := validateFlowDiagnostics(filename, bb, services)
+	if err != nil {
+		return err
+	} else if len(diags) == 0 {
+		return nil
+	}
+
+	p := diag.NewPrinter(diag.PrinterConfig{
+		Color:              !color.NoColor,
+		ContextLinesBefore: 1,
+		ContextLinesAfter:  1,
+	})
+	_ = p.Fprint(os.Stderr, map[string][]byte{filename: bb}, diags)
+
+	if diags.HasErrors() || strict {
+		return errValidation
+	}
+	return nil
+}
+
+// validationServices returns the services used when validating Flow files.
+// Services are never run during validation, but must exist so that blocks
+// configuring them and components depending on them can be validated.
+func validationServices() ([]service.Service, error) {
+	l, err := logging.New(io.Discard, logging.DefaultOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterService, err := clusterservice.New(clusterservice.Options{Log: l})
+	if err != nil {
+		return nil, err
+	}
+
+	return []service.Service{
+		httpservice.New(httpservice.Options{Logger: l}),
+		clusterService,
+	}, nil
+}
+
+// validateFlowDiagnostics returns the diagnostics found when validating the
+// contents of a Flow configuration file without running any components.
+func validateFlowDiagnostics(filename string, bb []byte, services []service.Service) (diag.Diagnostics, error) {
+	file, err := flow.ReadFile(filename, bb)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := logging.New(io.Discard, logging.DefaultOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation never builds components or fetches imported files, so it
+	// doesn't need a data directory and leaves nothing behind. This also
+	// keeps it cheap enough for the language server to run on every change.
+	f := flow.New(flow.Options{
+		Logger:   l,
+		Services: services,
+		Capsules: capsules,
+	})
+	return f.Validate(file, nil), nil
+}
@@ -0,0 +1,60 @@
+package flowmode
+
+import (
+	"errors"
+	"os"
+
+	"github.com/go-kit/log"
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/flow/lsp"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/service"
+)
+
+func lspCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a language server for River configuration files",
+		Long: `The lsp subcommand runs a language server for Grafana Agent Flow
+configuration files, communicating with an editor over stdin and stdout using
+the Language Server Protocol.
+
+The language server reports syntax and validation errors, completes the names,
+arguments, and blocks of components, shows documentation on hover, navigates
+between components and the expressions referencing their exports, and formats
+files. Logs are written to stderr.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			services, err := validationServices()
+			if err != nil {
+				return err
+			}
+
+			defs := make([]service.Definition, 0, len(services))
+			for _, svc := range services {
+				defs = append(defs, svc.Definition())
+			}
+
+			srv := lsp.NewServer(lsp.Options{
+				Logger: log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+				Validate: func(name string, bb []byte) diag.Diagnostics {
+					diags, err := validateFlowDiagnostics(name, bb, services)
+					if err != nil && !errors.As(err, &diags) {
+						return diag.Diagnostics{{Severity: diag.SeverityLevelError, Message: err.Error()}}
+					}
+					return diags
+				},
+				Services: defs,
+			})
+
+			ctx, cancel := interruptContext()
+			defer cancel()
+			return srv.Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,133 @@
+package flowmode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diff"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+)
+
+func riverDiffCommand() *cobra.Command {
+	var outputFormat = "text"
+
+	cmd := &cobra.Command{
+		Use:   "river-diff [flags] OLD NEW",
+		Short: "Show the structural differences between two River files",
+		Long: `The river-diff subcommand compares two River files and prints the blocks
+and attributes which were added, removed, or moved, and the attributes whose
+value changed. Formatting and comments are ignored when comparing files.
+
+The --format flag selects the output format. The text format is meant to be
+read by people. The json format is a patch which can be applied to a file with
+the apply subcommand.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			a, err := parseRiverFile(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := parseRiverFile(args[1])
+			if err != nil {
+				return err
+			}
+
+			patch := diff.Diff(a, b)
+
+			switch outputFormat {
+			case "text":
+				_, err := fmt.Fprint(os.Stdout, patch.String())
+				return err
+			case "json":
+				if patch == nil {
+					patch = diff.Patch{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(patch)
+			default:
+				return fmt.Errorf("unsupported format %q", outputFormat)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "format", outputFormat, "output format (text, json)")
+	cmd.AddCommand(riverDiffApplyCommand())
+	return cmd
+}
+
+func riverDiffApplyCommand() *cobra.Command {
+	var write bool
+
+	cmd := &cobra.Command{
+		Use:   "apply [flags] PATCH FILE",
+		Short: "Apply a patch created by river-diff to a River file",
+		Long: `The apply subcommand applies a JSON patch created by river-diff --format=json
+to a River file. Comments in the file are kept, and the result is formatted
+the same way as grafana-agent fmt formats files.
+
+Applying the patch fails if the file doesn't contain a statement changed by
+the patch, or if the statement differs from the one the patch was created
+from.
+
+The -w flag can be used to write the result back to FILE. When -w is not
+provided, the result is written to stdout.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			bb, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var patch diff.Patch
+			if err := json.Unmarshal(bb, &patch); err != nil {
+				return fmt.Errorf("reading patch: %w", err)
+			}
+
+			f, err := parseRiverFile(args[1])
+			if err != nil {
+				return err
+			}
+			res, err := patch.Apply(f)
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, res); err != nil {
+				return err
+			}
+			_, _ = buf.Write([]byte{'\n'})
+
+			if !write {
+				_, err := os.Stdout.Write(buf.Bytes())
+				return err
+			}
+			fi, err := os.Stat(args[1])
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(args[1], buf.Bytes(), fi.Mode().Perm())
+		},
+	}
+
+	cmd.Flags().BoolVarP(&write, "write", "w", write, "write result to (source) file instead of stdout")
+	return cmd
+}
+
+func parseRiverFile(filename string) (*ast.File, error) {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseFile(filename, bb)
+}
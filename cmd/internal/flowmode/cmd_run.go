@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/converter"
@@ -20,13 +21,16 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/pkg/boringcrypto"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/config/instrumentation"
 	"github.com/grafana/agent/pkg/flow"
 	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/profiling"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/usagestats"
+	"github.com/grafana/agent/service"
+	clusterservice "github.com/grafana/agent/service/cluster"
 	httpservice "github.com/grafana/agent/service/http"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
@@ -37,13 +41,14 @@ import (
 
 func runCommand() *cobra.Command {
 	r := &flowRun{
-		inMemoryAddr:     "agent.internal:12345",
-		httpListenAddr:   "127.0.0.1:12345",
-		storagePath:      "data-agent/",
-		uiPrefix:         "/",
-		disableReporting: false,
-		enablePprof:      true,
-		configFormat:     "flow",
+		inMemoryAddr:          "agent.internal:12345",
+		httpListenAddr:        "127.0.0.1:12345",
+		storagePath:           "data-agent/",
+		uiPrefix:              "/",
+		disableReporting:      false,
+		enablePprof:           true,
+		configFormat:          "flow",
+		componentStuckTimeout: time.Minute,
 	}
 
 	cmd := &cobra.Command{
@@ -67,6 +72,7 @@ debugging UI can be changed by providing a different value to
 Additionally, the HTTP server exposes the following debug endpoints:
 
   /debug/pprof   Go performance profiling tools
+  /-/support     Support bundle with the state of the running agent
 
 If reloading the config file fails, Grafana Agent Flow will continue running in
 its last valid state. Components which failed may be be listed as unhealthy,
@@ -87,6 +93,10 @@ depending on the nature of the reload error.
 	cmd.Flags().StringVar(&r.uiPrefix, "server.http.ui-path-prefix", r.uiPrefix, "Prefix to serve the HTTP UI at")
 	cmd.Flags().
 		BoolVar(&r.enablePprof, "server.http.enable-pprof", r.enablePprof, "Enable /debug/pprof profiling endpoints.")
+	cmd.Flags().
+		DurationVar(&r.componentStuckTimeout, "component.stuck-timeout", r.componentStuckTimeout, "How long components may take to be built, updated, or shut down before they're reported as stuck. 0 disables stuck detection.")
+	cmd.Flags().
+		BoolVar(&r.componentCPUAccounting, "component.cpu-accounting", r.componentCPUAccounting, "Continuously record CPU profiles to report the CPU time of each component.")
 	cmd.Flags().
 		BoolVar(&r.clusterEnabled, "cluster.enabled", r.clusterEnabled, "Start in clustered mode")
 	cmd.Flags().
@@ -97,18 +107,27 @@ depending on the nature of the reload error.
 		StringVar(&r.clusterJoinAddr, "cluster.join-addresses", r.clusterJoinAddr, "Comma-separated list of addresses to join the cluster at")
 	cmd.Flags().
 		BoolVar(&r.disableReporting, "disable-reporting", r.disableReporting, "Disable reporting of enabled components to Grafana.")
-	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, "The format of the source file. Supported formats: 'flow', 'prometheus'.")
+	cmd.Flags().
+		BoolVar(&r.disableSupportBundle, "disable-support-bundle", r.disableSupportBundle, "Disable the /-/support endpoint for generating support bundles.")
+	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, "The format of the source file. Supported formats: 'flow', 'river-json', 'prometheus'.")
 	cmd.Flags().BoolVar(&r.configBypassConversionErrors, "config.bypass-conversion-errors", r.configBypassConversionErrors, "Enable bypassing errors when converting")
 	return cmd
 }
 
+// cpuAccountingWindow is the duration of each CPU profile recorded when
+// component CPU accounting is enabled.
+const cpuAccountingWindow = 10 * time.Second
+
 type flowRun struct {
 	inMemoryAddr                 string
 	httpListenAddr               string
 	storagePath                  string
 	uiPrefix                     string
 	enablePprof                  bool
+	componentCPUAccounting       bool
+	componentStuckTimeout        time.Duration
 	disableReporting             bool
+	disableSupportBundle         bool
 	clusterEnabled               bool
 	clusterNodeName              string
 	clusterAdvAddr               string
@@ -132,6 +151,7 @@ func (fr *flowRun) Run(configFile string) error {
 	if err != nil {
 		return fmt.Errorf("building logger: %w", err)
 	}
+	defer func() { _ = l.Close() }()
 
 	t, err := tracing.New(tracing.DefaultOptions)
 	if err != nil {
@@ -160,56 +180,55 @@ func (fr *flowRun) Run(configFile string) error {
 	// Before doing this, we need to ensure that anything using the default
 	// registry that we want to keep can be given a custom registry so desired
 	// metrics are still exposed.
-	reg := prometheus.DefaultRegisterer
-	reg.MustRegister(newResourcesCollector(l))
+	var cpuAccountant *profiling.CPUAccountant
+	if fr.componentCPUAccounting {
+		cpuAccountant = profiling.NewCPUAccountant(log.With(l, "subsystem", "cpu_accounting"), cpuAccountingWindow)
 
-	clusterer, err := cluster.New(l, reg, fr.clusterEnabled, fr.clusterNodeName, fr.httpListenAddr, fr.clusterAdvAddr, fr.clusterJoinAddr)
-	if err != nil {
-		return fmt.Errorf("building clusterer: %w", err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = cpuAccountant.Run(ctx)
+		}()
 	}
-	defer func() {
-		err := clusterer.Stop()
-		if err != nil {
-			level.Error(l).Log("msg", "failed to terminate clusterer", "err", err)
-		}
-	}()
 
-	var httpData httpservice.Data
+	reg := prometheus.DefaultRegisterer
+	reg.MustRegister(newResourcesCollector(l, cpuAccountant))
 
-	f := flow.New(flow.Options{
-		Logger:         l,
-		Tracer:         t,
-		Clusterer:      clusterer,
-		DataPath:       fr.storagePath,
-		Reg:            reg,
-		HTTPPathPrefix: "/api/v0/component/",
-		HTTPListenAddr: fr.inMemoryAddr,
+	var (
+		f *flow.Flow
 
-		// Send requests to fr.inMemoryAddr directly to our in-memory listener.
-		DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
-			// NOTE(rfratto): this references a variable because httpData isn't
-			// non-zero by the time we are constructing the Flow controller.
-			//
-			// This is a temporary hack while services are being built out.
-			//
-			// It is not possibl for this to panic, since we will always have the service
-			// constructed by the time anything in Flow invokes this function.
-			return httpData.DialFunc(ctx, network, address)
-		},
-	})
+		// Reloads usually change a small part of the config file, so the parts
+		// which didn't change are reused from the previous load.
+		configParser parser.Incremental
+	)
 
-	reload := func() error {
-		flowCfg, err := loadFlowFile(configFile, fr.configFormat, fr.configBypassConversionErrors)
+	reload := func() (flow.ReloadSummary, error) {
+		flowCfg, err := loadFlowFile(&configParser, configFile, fr.configFormat, fr.configBypassConversionErrors)
 		defer instrumentation.InstrumentLoad(err == nil)
 
 		if err != nil {
-			return fmt.Errorf("reading config file %q: %w", configFile, err)
+			return flow.ReloadSummary{}, fmt.Errorf("reading config file %q: %w", configFile, err)
 		}
-		if err := f.LoadFile(flowCfg, nil); err != nil {
-			return fmt.Errorf("error during the initial gragent load: %w", err)
+		summary, err := f.ReloadFile(flowCfg, nil)
+		if err != nil {
+			return summary, fmt.Errorf("error during the initial gragent load: %w", err)
 		}
 
-		return nil
+		return summary, nil
+	}
+
+	clusterService, err := clusterservice.New(clusterservice.Options{
+		Log:     log.With(l, "service", "cluster"),
+		Metrics: reg,
+
+		EnableClustering: fr.clusterEnabled,
+		NodeName:         fr.clusterNodeName,
+		AdvertiseAddress: fr.clusterAdvAddr,
+		JoinPeers:        splitPeers(fr.clusterJoinAddr),
+		HTTPListenAddr:   fr.httpListenAddr,
+	})
+	if err != nil {
+		return fmt.Errorf("building clustering service: %w", err)
 	}
 
 	httpService := httpservice.New(httpservice.Options{
@@ -217,32 +236,55 @@ func (fr *flowRun) Run(configFile string) error {
 		Tracer:   t,
 		Gatherer: prometheus.DefaultGatherer,
 
-		Clusterer:  clusterer,
-		ReadyFunc:  func() bool { return f.Ready() },
-		ReloadFunc: reload,
+		ReadyFunc: func() bool { return f.Ready() },
+		ReloadFunc: func() (string, error) {
+			summary, err := reload()
+			return summary.String(), err
+		},
 
 		HTTPListenAddr:   fr.httpListenAddr,
 		MemoryListenAddr: fr.inMemoryAddr,
 		UIPrefix:         fr.uiPrefix,
 		EnablePProf:      fr.enablePprof,
+		CPUAccountant:    cpuAccountant,
+
+		EnableSupportBundle: !fr.disableSupportBundle,
+		RecentLogs:          l.RecentLogs,
+		LoadedConfig:        func() ([]byte, error) { return f.RedactedConfig() },
 	})
-	httpData = httpService.Data().(httpservice.Data)
+	httpData := httpService.Data().(httpservice.Data)
 
-	// Flow controller
-	{
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			f.Run(ctx)
-		}()
+	var onComponentsRemoved func(globalIDs []string)
+	if cpuAccountant != nil {
+		onComponentsRemoved = cpuAccountant.Remove
 	}
 
-	// HTTP service
+	f = flow.New(flow.Options{
+		Logger:         l,
+		Tracer:         t,
+		DataPath:       fr.storagePath,
+		Reg:            reg,
+		HTTPPathPrefix: "/api/v0/component/",
+		HTTPListenAddr: fr.inMemoryAddr,
+
+		// Send requests to fr.inMemoryAddr directly to our in-memory listener.
+		DialFunc: httpData.DialFunc,
+
+		Services:     []service.Service{httpService, clusterService},
+		StuckTimeout: fr.componentStuckTimeout,
+		Capsules:     capsules,
+
+		// Components which are removed no longer need their CPU time
+		// accounted.
+		OnComponentsRemoved: onComponentsRemoved,
+	})
+
+	// Flow controller. Services are run by the controller.
 	{
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			_ = httpService.Run(ctx, f)
+			f.Run(ctx)
 		}()
 	}
 
@@ -260,16 +302,13 @@ func (fr *flowRun) Run(configFile string) error {
 		}()
 	}
 
-	// Start the Clusterer's Node implementation.
-	err = clusterer.Start(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to start the clusterer: %w", err)
-	}
-
-	// Perform the initial reload. This is done after starting the HTTP server so
-	// that /metric and pprof endpoints are available while the Flow controller
-	// is loading.
-	if err := reload(); err != nil {
+	// Perform the initial reload. The controller runs the HTTP service as soon
+	// as the http block has been evaluated, and the service starts listening
+	// without waiting for the load to finish, so that /metrics and pprof
+	// endpoints are available while the rest of the config is loading. Routes
+	// of services which depend on the HTTP service are added once the load
+	// finishes.
+	if _, err := reload(); err != nil {
 		var diags diag.Diagnostics
 		if errors.As(err, &diags) {
 			bb, _ := os.ReadFile(configFile)
@@ -300,10 +339,10 @@ func (fr *flowRun) Run(configFile string) error {
 		case <-ctx.Done():
 			return nil
 		case <-reloadSignal:
-			if err := reload(); err != nil {
+			if summary, err := reload(); err != nil {
 				level.Error(l).Log("msg", "failed to reload config", "err", err)
 			} else {
-				level.Info(l).Log("msg", "config reloaded")
+				level.Info(l).Log("msg", "config reloaded", "summary", summary)
 			}
 		}
 	}
@@ -321,12 +360,17 @@ func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
 	}
 }
 
-func loadFlowFile(filename string, converterSourceFormat string, converterBypassErrors bool) (*flow.File, error) {
+func loadFlowFile(p *parser.Incremental, filename string, converterSourceFormat string, converterBypassErrors bool) (*flow.File, error) {
 	bb, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
+	if converterSourceFormat == "river-json" {
+		instrumentation.InstrumentConfig(bb)
+		return flow.ReadJSONFile(filename, bb)
+	}
+
 	if converterSourceFormat != "flow" {
 		var diags convert_diag.Diagnostics
 		bb, diags = converter.Convert(bb, converter.Input(converterSourceFormat))
@@ -339,7 +383,18 @@ func loadFlowFile(filename string, converterSourceFormat string, converterBypass
 
 	instrumentation.InstrumentConfig(bb)
 
-	return flow.ReadFile(filename, bb)
+	return flow.ReadFileIncremental(p, filename, bb)
+}
+
+// splitPeers splits a comma-separated list of peers, ignoring empty entries.
+func splitPeers(s string) []string {
+	var peers []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			peers = append(peers, p)
+		}
+	}
+	return peers
 }
 
 func interruptContext() (context.Context, context.CancelFunc) {
@@ -0,0 +1,50 @@
+package flowmode
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/component"
+)
+
+func schemaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema [COMPONENT ...]",
+		Short: "Print the schema of components as JSON",
+		Long: `The schema subcommand prints a JSON document describing the arguments and
+exports of components compiled into this binary.
+
+If no components are given, every registered component is described. The
+arguments and exports of each component are described as JSON Schemas,
+including which attributes and blocks are required, the default values of
+optional attributes, and which attributes hold secrets.`,
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			names := args
+			if len(names) == 0 {
+				names = component.AllNames()
+			}
+
+			schemas := make([]component.Schema, 0, len(names))
+			for _, name := range names {
+				reg, ok := component.Get(name)
+				if !ok {
+					return fmt.Errorf("unknown component %q", name)
+				}
+				schemas = append(schemas, reg.Schema())
+			}
+
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Components []component.Schema `json:"components"`
+			}{schemas})
+		},
+	}
+
+	return cmd
+}
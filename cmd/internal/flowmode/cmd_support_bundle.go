@@ -0,0 +1,128 @@
+package flowmode
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func supportBundleCommand() *cobra.Command {
+	sb := &supportBundle{
+		addr:     "http://127.0.0.1:12345",
+		output:   "agent-support-bundle.zip",
+		duration: 30 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "support-bundle [flags]",
+		Short: "Download a support bundle from a running agent",
+		Long: `The support-bundle subcommand downloads a support bundle from the /-/support
+endpoint of a running Grafana Agent Flow and writes it to a zip file.
+
+A support bundle contains the running config with secrets redacted, the state
+of every component, the dependencies between components, clustering peers,
+the agent's own metrics, its most recent logs, and profiles recorded over the
+duration given by --duration.
+
+Credentials for the agent's HTTP server can be given as part of
+--server.http.addr for basic authentication, or with --bearer-token-file.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return sb.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&sb.addr, "server.http.addr", sb.addr, "URL of the agent's HTTP server")
+	cmd.Flags().StringVarP(&sb.output, "output", "o", sb.output, "File to write the support bundle to")
+	cmd.Flags().DurationVar(&sb.duration, "duration", sb.duration, "How long to record profiles for, in whole seconds")
+	cmd.Flags().StringVar(&sb.bearerTokenFile, "bearer-token-file", sb.bearerTokenFile, "File holding the bearer token to authenticate with")
+	cmd.Flags().StringVar(&sb.caFile, "tls.ca-file", sb.caFile, "File holding the CA certificates to verify the agent's certificate with")
+	return cmd
+}
+
+type supportBundle struct {
+	addr            string
+	output          string
+	duration        time.Duration
+	bearerTokenFile string
+	caFile          string
+}
+
+func (sb *supportBundle) Run() error {
+	if sb.duration < time.Second {
+		return fmt.Errorf("--duration must be at least 1s")
+	}
+
+	u, err := url.Parse(sb.addr)
+	if err != nil {
+		return fmt.Errorf("invalid --server.http.addr: %w", err)
+	}
+	u = u.JoinPath("/-/support")
+	u.RawQuery = url.Values{"duration": {strconv.Itoa(int(sb.duration.Seconds()))}}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if sb.bearerTokenFile != "" {
+		bb, err := os.ReadFile(sb.bearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("reading bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(bb)))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if sb.caFile != "" {
+		bb, err := os.ReadFile(sb.caFile)
+		if err != nil {
+			return fmt.Errorf("reading CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bb) {
+			return fmt.Errorf("no CA certificates found in %s", sb.caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	fmt.Fprintf(os.Stderr, "Generating support bundle, this takes %s...\n", sb.duration)
+
+	// Leave time for the agent to collect everything after profiling.
+	cli := &http.Client{Transport: transport, Timeout: sb.duration + time.Minute}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting support bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("requesting support bundle: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	f, err := os.Create(sb.output)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing support bundle: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Support bundle written to %s\n", sb.output)
+	return nil
+}
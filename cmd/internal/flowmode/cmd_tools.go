@@ -16,6 +16,10 @@ func toolsCommand() *cobra.Command {
 
 	cmd.AddCommand(
 		getTools("prometheus.remote_write", remotewrite.InstallTools),
+		lspCommand(),
+		riverDiffCommand(),
+		schemaCommand(),
+		supportBundleCommand(),
 	)
 
 	return cmd
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/flow/profiling"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/shirou/gopsutil/v3/net"
 	"github.com/shirou/gopsutil/v3/process"
@@ -14,8 +15,12 @@ import (
 // resourcesCollector is a prometheus.Collector which exposes process-level
 // statistics. It is similar to the process collector in
 // github.com/prometheus/client_golang but includes support for more platforms.
+//
+// resourcesCollector also exposes the resources used by individual Flow
+// components.
 type resourcesCollector struct {
 	log log.Logger
+	cpu *profiling.CPUAccountant // May be nil if CPU accounting is disabled.
 
 	processStartTime *prometheus.Desc
 	cpuTotal         *prometheus.Desc
@@ -23,14 +28,19 @@ type resourcesCollector struct {
 	virtMemory       *prometheus.Desc
 	rxBytes          *prometheus.Desc
 	txBytes          *prometheus.Desc
+
+	componentGoroutines *prometheus.Desc
+	componentCPUTotal   *prometheus.Desc
 }
 
 var _ prometheus.Collector = (*resourcesCollector)(nil)
 
-// newResourcesCollector creates a new resourcesCollector.
-func newResourcesCollector(l log.Logger) *resourcesCollector {
+// newResourcesCollector creates a new resourcesCollector. CPU time of
+// components is only reported if cpu is non-nil.
+func newResourcesCollector(l log.Logger, cpu *profiling.CPUAccountant) *resourcesCollector {
 	rc := &resourcesCollector{
 		log: l,
+		cpu: cpu,
 
 		processStartTime: prometheus.NewDesc(
 			"agent_resources_process_start_time_seconds",
@@ -67,6 +77,18 @@ func newResourcesCollector(l log.Logger) *resourcesCollector {
 			"Total bytes, host-wide, sent across all given network interface.",
 			nil, nil,
 		),
+
+		componentGoroutines: prometheus.NewDesc(
+			"agent_component_goroutines",
+			"Current number of goroutines started by a component.",
+			[]string{"component_id"}, nil,
+		),
+
+		componentCPUTotal: prometheus.NewDesc(
+			"agent_component_cpu_seconds_total",
+			"Total CPU time spent by a component in seconds, estimated from CPU profiles.",
+			[]string{"component_id"}, nil,
+		),
 	}
 
 	return rc
@@ -79,9 +101,15 @@ func (rc *resourcesCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- rc.virtMemory
 	ch <- rc.rxBytes
 	ch <- rc.txBytes
+	ch <- rc.componentGoroutines
+	if rc.cpu != nil {
+		ch <- rc.componentCPUTotal
+	}
 }
 
 func (rc *resourcesCollector) Collect(ch chan<- prometheus.Metric) {
+	rc.collectComponents(ch)
+
 	proc, err := process.NewProcess(int32(os.Getpid()))
 	if err != nil {
 		level.Error(rc.log).Log("msg", "failed to get process", "err", err)
@@ -152,6 +180,32 @@ func (rc *resourcesCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
+func (rc *resourcesCollector) collectComponents(ch chan<- prometheus.Metric) {
+	if goroutines, err := profiling.Goroutines(); err != nil {
+		rc.reportError(rc.componentGoroutines, err)
+	} else {
+		for id, count := range goroutines {
+			ch <- prometheus.MustNewConstMetric(
+				rc.componentGoroutines,
+				prometheus.GaugeValue,
+				float64(count),
+				id,
+			)
+		}
+	}
+
+	if rc.cpu != nil {
+		for id, total := range rc.cpu.Totals() {
+			ch <- prometheus.MustNewConstMetric(
+				rc.componentCPUTotal,
+				prometheus.CounterValue,
+				total,
+				id,
+			)
+		}
+	}
+}
+
 func (rc *resourcesCollector) reportError(d *prometheus.Desc, err error) {
 	level.Error(rc.log).Log("msg", "failed to collect resources metric", "name", d.String(), "err", err)
 }
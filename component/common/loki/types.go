@@ -27,6 +27,11 @@ const finalEntryTimeout = 5 * time.Second
 // communication.
 type LogsReceiver interface {
 	Chan() chan Entry
+
+	// SendLogLine sends a single log line to the receiver, blocking until it
+	// is accepted or ctx is canceled. It allows receivers to be used in the
+	// write_to argument of the logging block.
+	SendLogLine(ctx context.Context, labels model.LabelSet, ts time.Time, line string) error
 }
 
 type logsReceiver struct {
@@ -37,6 +42,21 @@ func (l *logsReceiver) Chan() chan Entry {
 	return l.entries
 }
 
+// SendLogLine implements LogsReceiver.
+func (l *logsReceiver) SendLogLine(ctx context.Context, labels model.LabelSet, ts time.Time, line string) error {
+	e := Entry{
+		Labels: labels,
+		Entry:  logproto.Entry{Timestamp: ts, Line: line},
+	}
+
+	select {
+	case l.entries <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func NewLogsReceiver() LogsReceiver {
 	return NewLogsReceiverWithChannel(make(chan Entry))
 }
@@ -61,6 +61,8 @@ package component
 import (
 	"context"
 	"net/http"
+
+	"github.com/grafana/agent/pkg/flow/livedebugging"
 )
 
 // The Arguments contains the input fields for a specific component, which is
@@ -126,10 +128,13 @@ type HTTPComponent interface {
 	Handler() http.Handler
 }
 
-// ClusteredComponent is an extension interface for components which implement
-// clustering-specific behavior.
-type ClusteredComponent interface {
+// LiveDebuggingComponent is an extension interface for components which can
+// stream samples of the data flowing through them, such as log entries or
+// metric samples, for live debugging.
+type LiveDebuggingComponent interface {
 	Component
 
-	ClusterUpdatesRegistration() bool
+	// LiveDebugging returns the tap the component publishes the data flowing
+	// through it to. LiveDebugging must always return the same tap.
+	LiveDebugging() *livedebugging.Tap
 }
@@ -35,6 +35,11 @@ type Health struct {
 	// An optional time to indicate when the component last modified something
 	// which updated its health.
 	UpdateTime time.Time `river:"update_time,attr,optional"`
+
+	// The number of times the component was restarted by the Flow controller
+	// after exiting. Set by the Flow controller; components should not set
+	// this field.
+	Restarts int `river:"restarts,attr,optional"`
 }
 
 // HealthType holds the health value for a component.
@@ -59,6 +64,10 @@ const (
 
 	// HealthTypeExited represents a component which has stopped running.
 	HealthTypeExited
+
+	// HealthTypeStuck represents a component which didn't return from being
+	// built, updated, or shut down in time. Set by the Flow controller.
+	HealthTypeStuck
 )
 
 // String returns the string representation of ht.
@@ -70,6 +79,8 @@ func (ht HealthType) String() string {
 		return "unhealthy"
 	case HealthTypeExited:
 		return "exited"
+	case HealthTypeStuck:
+		return "stuck"
 	default:
 		return "unknown"
 	}
@@ -91,6 +102,8 @@ func (ht *HealthType) UnmarshalText(text []byte) error {
 		*ht = HealthTypeUnknown
 	case "exited":
 		*ht = HealthTypeExited
+	case "stuck":
+		*ht = HealthTypeStuck
 	default:
 		return fmt.Errorf("invalid health type %q", string(text))
 	}
@@ -101,7 +114,8 @@ func (ht *HealthType) UnmarshalText(text []byte) error {
 // considered to be the least healthy.
 //
 // Health types are first prioritized by [HealthTypeExited], followed by
-// [HealthTypeUnhealthy], [HealthTypeUnknown], and [HealthTypeHealthy].
+// [HealthTypeStuck], [HealthTypeUnhealthy], [HealthTypeUnknown], and
+// [HealthTypeHealthy].
 //
 // If multiple arguments have the same Health type, the Health with the most
 // recent timestamp is returned.
@@ -137,5 +151,6 @@ var healthPriority = [...]int{
 	HealthTypeHealthy:   0,
 	HealthTypeUnknown:   1,
 	HealthTypeUnhealthy: 2,
-	HealthTypeExited:    3,
+	HealthTypeStuck:     3,
+	HealthTypeExited:    4,
 }
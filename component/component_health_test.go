@@ -40,6 +40,28 @@ func TestMergeHealth(t *testing.T) {
 			}},
 			expectIndex: 1,
 		},
+		{
+			name: "exited > stuck",
+			healths: []component.Health{{
+				Health:     component.HealthTypeStuck,
+				UpdateTime: jan1,
+			}, {
+				Health:     component.HealthTypeExited,
+				UpdateTime: jan1,
+			}},
+			expectIndex: 1,
+		},
+		{
+			name: "stuck > unhealthy",
+			healths: []component.Health{{
+				Health:     component.HealthTypeUnhealthy,
+				UpdateTime: jan1,
+			}, {
+				Health:     component.HealthTypeStuck,
+				UpdateTime: jan1,
+			}},
+			expectIndex: 1,
+		},
 		{
 			name: "unhealthy > healthy",
 			healths: []component.Health{{
@@ -109,6 +109,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			State       string    `json:"state"`
 			Message     string    `json:"message"`
 			UpdatedTime time.Time `json:"updatedTime"`
+			Restarts    int       `json:"restarts,omitempty"`
 		}
 
 		componentDetailJSON struct {
@@ -125,6 +126,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			Exports          json.RawMessage      `json:"exports,omitempty"`
 			DebugInfo        json.RawMessage      `json:"debugInfo,omitempty"`
 			CreatedModuleIDs []string             `json:"createdModuleIDs,omitempty"`
+			LiveDebugging    bool                 `json:"liveDebugging,omitempty"`
 		}
 	)
 
@@ -156,6 +158,8 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 
+	_, liveDebugging := info.Component.(LiveDebuggingComponent)
+
 	return json.Marshal(&componentDetailJSON{
 		Name:         info.Registration.Name,
 		Type:         "block",
@@ -168,11 +172,13 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			State:       info.Health.Health.String(),
 			Message:     info.Health.Message,
 			UpdatedTime: info.Health.UpdateTime,
+			Restarts:    info.Health.Restarts,
 		},
 		Arguments:        arguments,
 		Exports:          exports,
 		DebugInfo:        debugInfo,
 		CreatedModuleIDs: info.ModuleIDs,
+		LiveDebugging:    liveDebugging,
 	})
 }
 
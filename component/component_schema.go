@@ -0,0 +1,42 @@
+package component
+
+import (
+	"reflect"
+
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// Schema describes the arguments and exports of a registered component as
+// JSON Schemas.
+type Schema struct {
+	// Name of the component.
+	Name string `json:"name"`
+
+	// Singleton is true if the component doesn't support a label.
+	Singleton bool `json:"singleton"`
+
+	// Arguments describes the body of the component's block. The schema
+	// includes default values of optional attributes.
+	Arguments *schema.JSONSchema `json:"arguments"`
+
+	// Exports describes the fields exported by the component. Exports is nil
+	// if the component doesn't export any fields.
+	Exports *schema.JSONSchema `json:"exports,omitempty"`
+}
+
+// Schema returns the schema of the component described by r. The schema is
+// derived from the river struct tags of r.Args and r.Exports.
+func (r Registration) Schema() Schema {
+	s := Schema{
+		Name:      r.Name,
+		Singleton: r.Singleton,
+		Arguments: schema.Body{}.JSONSchema(),
+	}
+	if r.Args != nil {
+		s.Arguments = schema.For(reflect.TypeOf(r.Args)).JSONSchema()
+	}
+	if r.Exports != nil {
+		s.Exports = schema.For(reflect.TypeOf(r.Exports)).JSONSchema()
+	}
+	return s
+}
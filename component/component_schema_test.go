@@ -0,0 +1,54 @@
+package component
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistration_Schema(t *testing.T) {
+	type arguments struct {
+		Input    string `river:"input,attr"`
+		Interval int    `river:"interval,attr,optional"`
+	}
+	type exports struct {
+		Output string `river:"output,attr"`
+	}
+
+	reg := Registration{
+		Name:    "test.schema",
+		Args:    arguments{},
+		Exports: exports{},
+	}
+
+	bb, err := json.Marshal(reg.Schema())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"name": "test.schema",
+		"singleton": false,
+		"arguments": {
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"input": {"type": "string"},
+				"interval": {"type": "integer"}
+			},
+			"required": ["input"],
+			"additionalProperties": false
+		},
+		"exports": {
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"output": {"type": "string"}
+			},
+			"required": ["output"],
+			"additionalProperties": false
+		}
+	}`, string(bb))
+
+	// Components without exports don't have an exports schema.
+	reg.Exports = nil
+	require.Nil(t, reg.Schema().Exports)
+}
@@ -2,12 +2,15 @@ package process
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/loki/process/stages"
+	"github.com/grafana/agent/pkg/flow/livedebugging"
 )
 
 // TODO(thampiotr): We should reconsider which parts of this component should be exported and which should
@@ -40,7 +43,8 @@ type Exports struct {
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component              = (*Component)(nil)
+	_ component.LiveDebuggingComponent = (*Component)(nil)
 )
 
 // Component implements the loki.process component.
@@ -54,6 +58,8 @@ type Component struct {
 	processOut   chan loki.Entry
 	entryHandler loki.EntryHandler
 	stages       []stages.StageConfig
+
+	tap livedebugging.Tap // Entries leaving the pipeline
 }
 
 // New creates a new loki.process component.
@@ -155,6 +161,10 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ctx.Done():
 			return
 		case entry := <-c.processOut:
+			c.tap.Publish(func() string {
+				return fmt.Sprintf("%s %s %s", entry.Timestamp.Format(time.RFC3339Nano), entry.Labels, entry.Line)
+			})
+
 			c.mut.RLock()
 			for _, f := range c.fanout {
 				select {
@@ -169,6 +179,12 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// LiveDebugging implements component.LiveDebuggingComponent. Entries are
+// published after they went through the pipeline.
+func (c *Component) LiveDebugging() *livedebugging.Tap {
+	return &c.tap
+}
+
 func stagesChanged(prev, next []stages.StageConfig) bool {
 	if len(prev) != len(next) {
 		return true
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"reflect"
 	"sync"
 
@@ -157,6 +158,17 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// Handler implements component.HTTPComponent and serves the push API through
+// the HTTP server of the agent.
+func (c *Component) Handler() http.Handler {
+	c.serverMut.Lock()
+	defer c.serverMut.Unlock()
+	if c.server == nil {
+		return nil
+	}
+	return c.server.Handler()
+}
+
 func (c *Component) stop() {
 	c.serverMut.Lock()
 	defer c.serverMut.Unlock()
@@ -31,6 +31,7 @@ type PushAPIServer struct {
 	serverConfig *fnet.ServerConfig
 	server       *fnet.TargetServer
 	handler      loki.EntryHandler
+	routes       *mux.Router // Routes of the push API, shared by server and Handler
 
 	rwMutex       sync.RWMutex
 	labels        model.LabelSet
@@ -48,7 +49,9 @@ func NewPushAPIServer(logger log.Logger,
 		logger:       logger,
 		serverConfig: serverConfig,
 		handler:      handler,
+		routes:       mux.NewRouter(),
 	}
+	s.mountRoutes(s.routes)
 
 	srv, err := fnet.NewTargetServer(logger, "loki_source_api", registerer, serverConfig)
 	if err != nil {
@@ -62,26 +65,34 @@ func NewPushAPIServer(logger log.Logger,
 func (s *PushAPIServer) Run() error {
 	level.Info(s.logger).Log("msg", "starting push API server")
 
-	err := s.server.MountAndRun(func(router *mux.Router) {
-		// This redirecting is so we can avoid breaking changes where we originally implemented it with
-		// the loki prefix.
-		router.Path("/api/v1/push").Methods("POST").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			r.URL.Path = "/loki/api/v1/push"
-			r.RequestURI = "/loki/api/v1/push"
-			s.handleLoki(w, r)
-		}))
-		router.Path("/api/v1/raw").Methods("POST").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			r.URL.Path = "/loki/api/v1/raw"
-			r.RequestURI = "/loki/api/v1/raw"
-			s.handlePlaintext(w, r)
-		}))
-		router.Path("/ready").Methods("GET").Handler(http.HandlerFunc(s.ready))
-		router.Path("/loki/api/v1/push").Methods("POST").Handler(http.HandlerFunc(s.handleLoki))
-		router.Path("/loki/api/v1/raw").Methods("POST").Handler(http.HandlerFunc(s.handlePlaintext))
-	})
+	err := s.server.MountAndRun(s.mountRoutes)
 	return err
 }
 
+func (s *PushAPIServer) mountRoutes(router *mux.Router) {
+	// This redirecting is so we can avoid breaking changes where we originally implemented it with
+	// the loki prefix.
+	router.Path("/api/v1/push").Methods("POST").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/loki/api/v1/push"
+		r.RequestURI = "/loki/api/v1/push"
+		s.handleLoki(w, r)
+	}))
+	router.Path("/api/v1/raw").Methods("POST").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/loki/api/v1/raw"
+		r.RequestURI = "/loki/api/v1/raw"
+		s.handlePlaintext(w, r)
+	}))
+	router.Path("/ready").Methods("GET").Handler(http.HandlerFunc(s.ready))
+	router.Path("/loki/api/v1/push").Methods("POST").Handler(http.HandlerFunc(s.handleLoki))
+	router.Path("/loki/api/v1/raw").Methods("POST").Handler(http.HandlerFunc(s.handlePlaintext))
+}
+
+// Handler returns the routes of the push API, so that they can also be served
+// by a server other than the one of the PushAPIServer.
+func (s *PushAPIServer) Handler() http.Handler {
+	return s.routes
+}
+
 func (s *PushAPIServer) ServerConfig() fnet.ServerConfig {
 	return *s.serverConfig
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/prometheus/operator"
+	"github.com/grafana/agent/service/cluster"
 )
 
 type Component struct {
@@ -17,15 +18,27 @@ type Component struct {
 
 	onUpdate  chan struct{}
 	opts      component.Options
+	cluster   cluster.Node
 	healthMut sync.RWMutex
 	health    component.Health
 
 	kind string
 }
 
+var (
+	_ component.Component = (*Component)(nil)
+	_ cluster.Component   = (*Component)(nil)
+)
+
 func New(o component.Options, args component.Arguments, kind string) (*Component, error) {
+	clusterNode, err := component.ServiceData[cluster.Node](o, cluster.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Component{
 		opts:     o,
+		cluster:  clusterNode,
 		onUpdate: make(chan struct{}, 1),
 		kind:     kind,
 	}
@@ -71,7 +84,7 @@ func (c *Component) Run(ctx context.Context) error {
 			// NOT on cluster changes.
 			if !nextConfig.Equals(runningConfig) {
 				runningConfig = nextConfig
-				manager := newCrdManager(c.opts, c.opts.Logger, nextConfig, c.kind)
+				manager := newCrdManager(c.opts, c.cluster, c.opts.Logger, nextConfig, c.kind)
 				c.manager = manager
 				if cancel != nil {
 					cancel()
@@ -111,11 +124,20 @@ func (c *Component) DebugInfo() interface{} {
 	return c.manager.DebugInfo()
 }
 
-// ClusterUpdatesRegistration implements component.ClusterComponent.
-func (c *Component) ClusterUpdatesRegistration() bool {
+// NotifyClusterChange implements cluster.Component. Targets are filtered
+// again when clustering is enabled for the component.
+func (c *Component) NotifyClusterChange() {
 	c.mut.Lock()
 	defer c.mut.Unlock()
-	return c.config.Clustering.Enabled
+
+	if !c.config.Clustering.Enabled {
+		return
+	}
+
+	select {
+	case c.onUpdate <- struct{}{}:
+	default:
+	}
 }
 
 func (c *Component) reportHealth(err error) {
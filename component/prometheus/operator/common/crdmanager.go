@@ -48,9 +48,10 @@ type crdManager struct {
 	scrapeManager     *scrape.Manager
 	clusteringUpdated chan struct{}
 
-	opts   component.Options
-	logger log.Logger
-	args   *operator.Arguments
+	opts    component.Options
+	logger  log.Logger
+	args    *operator.Arguments
+	cluster cluster.Node
 
 	client *kubernetes.Clientset
 
@@ -63,7 +64,7 @@ const (
 	KindProbe          string = "probe"
 )
 
-func newCrdManager(opts component.Options, logger log.Logger, args *operator.Arguments, kind string) *crdManager {
+func newCrdManager(opts component.Options, clusterNode cluster.Node, logger log.Logger, args *operator.Arguments, kind string) *crdManager {
 	switch kind {
 	case KindPodMonitor, KindServiceMonitor, KindProbe:
 	default:
@@ -71,6 +72,7 @@ func newCrdManager(opts component.Options, logger log.Logger, args *operator.Arg
 	}
 	return &crdManager{
 		opts:              opts,
+		cluster:           clusterNode,
 		logger:            logger,
 		args:              args,
 		discoveryConfigs:  map[string]discovery.Configs{},
@@ -129,13 +131,13 @@ func (c *crdManager) Run(ctx context.Context) error {
 		case m := <-c.discoveryManager.SyncCh():
 			cachedTargets = m
 			if c.args.Clustering.Enabled {
-				m = filterTargets(m, c.opts.Clusterer.Node)
+				m = filterTargets(m, c.cluster)
 			}
 			targetSetsChan <- m
 		case <-c.clusteringUpdated:
 			// if clustering updates while running, just re-filter the targets and pass them
 			// into scrape manager again, instead of reloading everything
-			targetSetsChan <- filterTargets(cachedTargets, c.opts.Clusterer.Node)
+			targetSetsChan <- filterTargets(cachedTargets, c.cluster)
 		}
 	}
 }
@@ -43,6 +43,7 @@ func (args *Arguments) SetToDefault() {
 type Component struct {
 	opts               component.Options
 	handler            http.Handler
+	routes             *mux.Router // Routes served by the server of the component and by Handler
 	fanout             *agentprom.Fanout
 	uncheckedCollector *util.UncheckedCollector
 
@@ -62,7 +63,9 @@ func New(opts component.Options, args Arguments) (*Component, error) {
 		handler:            remote.NewWriteHandler(opts.Logger, fanout),
 		fanout:             fanout,
 		uncheckedCollector: uncheckedCollector,
+		routes:             mux.NewRouter(),
 	}
+	c.mountRoutes(c.routes)
 
 	if err := c.Update(args); err != nil {
 		return nil, err
@@ -104,9 +107,7 @@ func (c *Component) Update(args component.Arguments) error {
 	}
 	c.server = s
 
-	err = c.server.MountAndRun(func(router *mux.Router) {
-		router.Path("/api/v1/metrics/write").Methods("POST").Handler(c.handler)
-	})
+	err = c.server.MountAndRun(c.mountRoutes)
 	if err != nil {
 		return err
 	}
@@ -115,6 +116,16 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+func (c *Component) mountRoutes(router *mux.Router) {
+	router.Path("/api/v1/metrics/write").Methods("POST").Handler(c.handler)
+}
+
+// Handler implements component.HTTPComponent and serves the write endpoint
+// through the HTTP server of the agent.
+func (c *Component) Handler() http.Handler {
+	return c.routes
+}
+
 func (c *Component) createNewServer(args Arguments) (error, *fnet.TargetServer) {
 	// [server.Server] registers new metrics every time it is created. To
 	// avoid issues with re-registering metrics with the same name, we create a
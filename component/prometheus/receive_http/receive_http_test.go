@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -77,6 +78,49 @@ func TestForwardsMetrics(t *testing.T) {
 	verifyExpectations(t, input, expected, actualSamples, args, ctx)
 }
 
+func TestHandler(t *testing.T) {
+	timestamp := time.Now().Add(time.Second).UnixMilli()
+	input := []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "cluster", Value: "local"}, {Name: "foo", Value: "bar"}},
+		Samples: []prompb.Sample{{Timestamp: timestamp, Value: 12}},
+	}}
+
+	actualSamples := make(chan testSample, 100)
+
+	args := Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    getFreePort(t),
+			},
+			GRPC: testGRPCConfig(t),
+		},
+		ForwardTo: testAppendable(actualSamples),
+	}
+	comp, err := New(testOptions(t), args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		require.NoError(t, comp.Run(ctx))
+	}()
+
+	// The write endpoint is also served by the handler exposed to the HTTP
+	// server of the agent.
+	srv := httptest.NewServer(comp.Handler())
+	defer srv.Close()
+
+	err = request(ctx, srv.URL+"/api/v1/metrics/write", &prompb.WriteRequest{Timeseries: input})
+	require.NoError(t, err)
+
+	select {
+	case actual := <-actualSamples:
+		require.Equal(t, testSample{ts: timestamp, val: 12, l: labels.FromStrings("cluster", "local", "foo", "bar")}, actual)
+	case <-ctx.Done():
+		t.Fatalf("test timed out")
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	timestamp := time.Now().Add(time.Second).UnixMilli()
 	input01 := []prompb.TimeSeries{{
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/agent/component"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/flow/livedebugging"
 	lru "github.com/hashicorp/golang-lru/v2"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/exemplar"
@@ -74,13 +75,15 @@ type Component struct {
 	cacheDeletes     prometheus_client.Counter
 	fanout           *prometheus.Fanout
 	exited           atomic.Bool
+	tap              livedebugging.Tap // Samples and the labels they were relabeled to
 
 	cacheMut sync.RWMutex
 	cache    *lru.Cache[uint64, *labelAndID]
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component              = (*Component)(nil)
+	_ component.LiveDebuggingComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.relabel component.
@@ -134,6 +137,12 @@ func New(o component.Options, args Arguments) (*Component, error) {
 			}
 
 			newLbl := c.relabel(v, l)
+			c.tap.Publish(func() string {
+				if newLbl.IsEmpty() {
+					return fmt.Sprintf("%s %v %d => dropped", l, v, t)
+				}
+				return fmt.Sprintf("%s %v %d => %s", l, v, t, newLbl)
+			})
 			if newLbl.IsEmpty() {
 				return 0, nil
 			}
@@ -210,6 +219,12 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// LiveDebugging implements component.LiveDebuggingComponent. Samples are
+// published along with the labels they were relabeled to.
+func (c *Component) LiveDebugging() *livedebugging.Tap {
+	return &c.tap
+}
+
 func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
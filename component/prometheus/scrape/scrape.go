@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
 	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/service/cluster"
 	client_prometheus "github.com/prometheus/client_golang/prometheus"
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
@@ -27,8 +28,9 @@ func init() {
 	scrape.UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
 
 	component.Register(component.Registration{
-		Name: "prometheus.scrape",
-		Args: Arguments{},
+		Name:          "prometheus.scrape",
+		Args:          Arguments{},
+		NeedsServices: []string{cluster.ServiceName},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -112,7 +114,8 @@ func (arg *Arguments) Validate() error {
 
 // Component implements the prometheus.scrape component.
 type Component struct {
-	opts component.Options
+	opts    component.Options
+	cluster cluster.Node
 
 	reloadTargets chan struct{}
 
@@ -125,10 +128,16 @@ type Component struct {
 
 var (
 	_ component.Component = (*Component)(nil)
+	_ cluster.Component   = (*Component)(nil)
 )
 
 // New creates a new prometheus.scrape component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	clusterNode, err := component.ServiceData[cluster.Node](o, cluster.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	flowAppendable := prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
 	scrapeOptions := &scrape.Options{
 		ExtraMetrics: args.ExtraMetrics,
@@ -141,13 +150,14 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	targetsGauge := client_prometheus.NewGauge(client_prometheus.GaugeOpts{
 		Name: "agent_prometheus_scrape_targets_gauge",
 		Help: "Number of targets this component is configured to scrape"})
-	err := o.Registerer.Register(targetsGauge)
+	err = o.Registerer.Register(targetsGauge)
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Component{
 		opts:          o,
+		cluster:       clusterNode,
 		reloadTargets: make(chan struct{}, 1),
 		scraper:       scraper,
 		appendable:    flowAppendable,
@@ -194,7 +204,7 @@ func (c *Component) Run(ctx context.Context) error {
 
 			// NOTE(@tpaschalis) First approach, manually building the
 			// 'clustered' targets implementation every time.
-			ct := discovery.NewDistributedTargets(cl, c.opts.Clusterer.Node, tgs)
+			ct := discovery.NewDistributedTargets(cl, c.cluster, tgs)
 			promTargets := c.componentTargetsToProm(jobName, ct.Get())
 
 			select {
@@ -316,11 +326,20 @@ func (c *Component) DebugInfo() interface{} {
 	}
 }
 
-// ClusterUpdatesRegistration implements component.ClusterComponent.
-func (c *Component) ClusterUpdatesRegistration() bool {
+// NotifyClusterChange implements cluster.Component. Targets are redistributed
+// between peers when clustering is enabled for the component.
+func (c *Component) NotifyClusterChange() {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
-	return c.args.Clustering.Enabled
+
+	if !c.args.Clustering.Enabled {
+		return
+	}
+
+	select {
+	case c.reloadTargets <- struct{}{}:
+	default:
+	}
 }
 
 func (c *Component) componentTargetsToProm(jobName string, tgs []discovery.Target) map[string][]*targetgroup.Group {
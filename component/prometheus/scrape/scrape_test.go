@@ -71,6 +71,9 @@ func TestForwardingToAppendable(t *testing.T) {
 	opts := component.Options{
 		Logger:     util.TestFlowLogger(t),
 		Registerer: prometheus_client.NewRegistry(),
+		GetServiceData: func(name string) (any, error) {
+			return cluster.NewLocalNode(""), nil
+		},
 	}
 
 	nilReceivers := []storage.Appendable{nil, nil}
@@ -155,8 +158,8 @@ func TestCustomDialer(t *testing.T) {
 
 	opts := component.Options{
 		Logger: util.TestFlowLogger(t),
-		Clusterer: &cluster.Clusterer{
-			Node: cluster.NewLocalNode("inmemory:80"),
+		GetServiceData: func(name string) (any, error) {
+			return cluster.NewLocalNode("inmemory:80"), nil
 		},
 		Registerer: prometheus_client.NewRegistry(),
 		DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
@@ -16,6 +16,7 @@ import (
 	component_config "github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus/scrape"
+	"github.com/grafana/agent/service/cluster"
 )
 
 const (
@@ -32,8 +33,9 @@ const (
 
 func init() {
 	component.Register(component.Registration{
-		Name: "pyroscope.scrape",
-		Args: Arguments{},
+		Name:          "pyroscope.scrape",
+		Args:          Arguments{},
+		NeedsServices: []string{cluster.ServiceName},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -223,7 +225,8 @@ func (arg *Arguments) Validate() error {
 
 // Component implements the pprof.scrape component.
 type Component struct {
-	opts component.Options
+	opts    component.Options
+	cluster cluster.Node
 
 	reloadTargets chan struct{}
 
@@ -233,14 +236,23 @@ type Component struct {
 	appendable *pyroscope.Fanout
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component = (*Component)(nil)
+	_ cluster.Component   = (*Component)(nil)
+)
 
 // New creates a new pprof.scrape component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	clusterNode, err := component.ServiceData[cluster.Node](o, cluster.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	flowAppendable := pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer)
 	scraper := NewManager(flowAppendable, o.Logger)
 	c := &Component{
 		opts:          o,
+		cluster:       clusterNode,
 		reloadTargets: make(chan struct{}, 1),
 		scraper:       scraper,
 		appendable:    flowAppendable,
@@ -283,7 +295,7 @@ func (c *Component) Run(ctx context.Context) error {
 
 			// NOTE(@tpaschalis) First approach, manually building the
 			// 'clustered' targets implementation every time.
-			ct := discovery.NewDistributedTargets(clustering, c.opts.Clusterer.Node, tgs)
+			ct := discovery.NewDistributedTargets(clustering, c.cluster, tgs)
 			promTargets := c.componentTargetsToProm(jobName, ct.Get())
 
 			select {
@@ -337,11 +349,20 @@ func convertLabelSet(tg discovery.Target) model.LabelSet {
 	return lset
 }
 
-// ClusterUpdatesRegistration implements component.ClusterComponent.
-func (c *Component) ClusterUpdatesRegistration() bool {
+// NotifyClusterChange implements cluster.Component. Targets are redistributed
+// between peers when clustering is enabled for the component.
+func (c *Component) NotifyClusterChange() {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
-	return c.args.Clustering.Enabled
+
+	if !c.args.Clustering.Enabled {
+		return
+	}
+
+	select {
+	case c.reloadTargets <- struct{}{}:
+	default:
+	}
 }
 
 // DebugInfo implements component.DebugComponent.
@@ -32,7 +32,9 @@ func TestComponent(t *testing.T) {
 		Logger:        util.TestFlowLogger(t),
 		Registerer:    prometheus.NewRegistry(),
 		OnStateChange: func(e component.Exports) {},
-		Clusterer:     &cluster.Clusterer{Node: cluster.NewLocalNode("")},
+		GetServiceData: func(name string) (any, error) {
+			return cluster.NewLocalNode(""), nil
+		},
 	}, arg)
 	require.NoError(t, err)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -193,7 +195,9 @@ func TestUpdateWhileScraping(t *testing.T) {
 		Logger:        util.TestFlowLogger(t),
 		Registerer:    prometheus.NewRegistry(),
 		OnStateChange: func(e component.Exports) {},
-		Clusterer:     &cluster.Clusterer{Node: cluster.NewLocalNode("")},
+		GetServiceData: func(name string) (any, error) {
+			return cluster.NewLocalNode(""), nil
+		},
 	}, args)
 	require.NoError(t, err)
 	scraping := atomic.NewBool(false)
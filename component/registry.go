@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/go-kit/log"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/regexp"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
@@ -41,9 +41,10 @@ type ModuleController interface {
 
 // Module is a controller for running components within a Module.
 type Module interface {
-	// LoadConfig parses River config and loads it into the Module.
-	// LoadConfig can be called multiple times, and called prior to
-	// [Module.Run].
+	// LoadConfig parses River config and loads it into the Module. The
+	// config may also use the JSON representation of River described by the
+	// riverjson package. LoadConfig can be called multiple times, and called
+	// prior to [Module.Run].
 	LoadConfig(config []byte, args map[string]any) error
 
 	// Run starts the Module. No components within the Module
@@ -96,11 +97,6 @@ type Options struct {
 	// attribute denoting the component ID.
 	Tracer trace.TracerProvider
 
-	// Clusterer allows components to work in a clustered fashion. The
-	// clusterer is shared between all components initialized by a Flow
-	// controller.
-	Clusterer *cluster.Clusterer
-
 	// HTTPListenAddr is the address the server is configured to listen on.
 	HTTPListenAddr string
 
@@ -113,6 +109,34 @@ type Options struct {
 	// component. Requests received by a component handler will have this already
 	// trimmed off.
 	HTTPPath string
+
+	// GetServiceData retrieves the data of a service by name. The component
+	// must have declared a dependency on the service in the NeedsServices
+	// field of its Registration; GetServiceData returns an error otherwise, or
+	// if the service doesn't exist.
+	//
+	// Use the ServiceData function for typed access to the data of a service.
+	GetServiceData func(name string) (any, error)
+}
+
+// ServiceData retrieves the data of the service called name from opts and
+// casts it to T. An error is returned if the service data can't be retrieved
+// or if it isn't of type T.
+func ServiceData[T any](opts Options, name string) (T, error) {
+	var zero T
+	if opts.GetServiceData == nil {
+		return zero, fmt.Errorf("service %q is not available", name)
+	}
+
+	data, err := opts.GetServiceData(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := data.(T)
+	if !ok {
+		return zero, fmt.Errorf("data of service %q has type %T, expected %s", name, data, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return typed, nil
 }
 
 // Registration describes a single component.
@@ -160,6 +184,13 @@ type Registration struct {
 	// A component which does not expose exports must leave this set to nil.
 	Exports Exports
 
+	// NeedsServices holds the names of services the component depends on. The
+	// component is evaluated after the services it depends on, and can
+	// retrieve their data through [Options.GetServiceData].
+	//
+	// Components which depend on a service that doesn't exist fail to load.
+	NeedsServices []string
+
 	// Build should construct a new component from an initial Arguments and set
 	// of options.
 	Build func(opts Options, args Arguments) (Component, error)
@@ -231,8 +262,30 @@ func validatePrefixMatch(check parsedName, against map[string]parsedName) error
 	return nil
 }
 
+// ValidateName returns an error if name is not a valid component name or if
+// it would be ambiguous with the name of a registered component. It can be
+// used to validate names of components which are not registered globally,
+// such as components defined by a config file.
+func ValidateName(name string) error {
+	parsed, err := parseComponentName(name)
+	if err != nil {
+		return err
+	}
+	return validatePrefixMatch(parsed, parsedNames)
+}
+
 // Get finds a registered component by name.
 func Get(name string) (Registration, bool) {
 	r, ok := registered[name]
 	return r, ok
 }
+
+// AllNames returns the names of all registered components, sorted by name.
+func AllNames() []string {
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
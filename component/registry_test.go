@@ -1,6 +1,7 @@
 package component
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -69,3 +70,37 @@ func Test_validatePrefixMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceData(t *testing.T) {
+	type data struct{ Value string }
+
+	opts := Options{
+		GetServiceData: func(name string) (any, error) {
+			if name != "test" {
+				return nil, fmt.Errorf("service %q not found", name)
+			}
+			return data{Value: "hello"}, nil
+		},
+	}
+
+	t.Run("Typed data", func(t *testing.T) {
+		d, err := ServiceData[data](opts, "test")
+		require.NoError(t, err)
+		require.Equal(t, "hello", d.Value)
+	})
+
+	t.Run("Unknown service", func(t *testing.T) {
+		_, err := ServiceData[data](opts, "missing")
+		require.EqualError(t, err, `service "missing" not found`)
+	})
+
+	t.Run("Wrong type", func(t *testing.T) {
+		_, err := ServiceData[string](opts, "test")
+		require.EqualError(t, err, `data of service "test" has type component.data, expected string`)
+	})
+
+	t.Run("No services", func(t *testing.T) {
+		_, err := ServiceData[data](Options{}, "test")
+		require.EqualError(t, err, `service "test" is not available`)
+	})
+}
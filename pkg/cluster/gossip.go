@@ -255,6 +255,16 @@ func (n *GossipNode) Start() (err error) {
 	return n.innerNode.Start(n.cfg.JoinPeers)
 }
 
+// Rejoin connects a started node to peers. Rejoin can be used to discover
+// peers which weren't known when the node was started, or to recover from a
+// network partition.
+func (n *GossipNode) Rejoin(peers []string) error {
+	if !n.started.Load() {
+		return fmt.Errorf("node not started")
+	}
+	return n.innerNode.Start(peers)
+}
+
 // Stop leaves the cluster and terminates n. n cannot be re-used after
 // stopping.
 //
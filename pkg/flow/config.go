@@ -1,11 +1,13 @@
 package flow
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/encoding/riverjson"
 	"github.com/grafana/agent/pkg/river/parser"
 )
 
@@ -22,6 +24,16 @@ type Argument struct {
 
 	// Default value for the argument.
 	Default any `river:"default,attr,optional"`
+
+	// Type expression which values for the argument must match, such as
+	// list(map(string)).
+	Type string `river:"type,attr,optional"`
+
+	// Constraints which values for the argument must satisfy.
+	Regex         string   `river:"regex,attr,optional"`
+	Min           *float64 `river:"min,attr,optional"`
+	Max           *float64 `river:"max,attr,optional"`
+	AllowedValues []any    `river:"allowed_values,attr,optional"`
 }
 
 // File holds the contents of a parsed Flow file.
@@ -42,7 +54,43 @@ func ReadFile(name string, bb []byte) (*File, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newFile(name, node)
+}
+
+// ReadFileIncremental is like ReadFile, but parses bb with p. Blocks which
+// didn't change since the previous version of the file parsed by p are
+// reused rather than parsed again.
+func ReadFileIncremental(p *parser.Incremental, name string, bb []byte) (*File, error) {
+	node, err := p.ParseFile(name, bb)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(name, node)
+}
 
+// ReadJSONFile parses the JSON representation of a River file specified by
+// bb into a File. See riverjson.ParseFile for the expected format. name
+// should be the name of the file used for reporting errors.
+func ReadJSONFile(name string, bb []byte) (*File, error) {
+	node, err := riverjson.ParseFile(name, bb)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(name, node)
+}
+
+// readModuleFile parses the source of a module into a File. Modules may be
+// written in River or in its JSON representation; JSON sources are detected
+// by starting with an array, which isn't valid River.
+func readModuleFile(name string, bb []byte) (*File, error) {
+	if trimmed := bytes.TrimSpace(bb); len(trimmed) > 0 && trimmed[0] == '[' {
+		return ReadJSONFile(name, bb)
+	}
+	return ReadFile(name, bb)
+}
+
+// newFile creates a File from an already parsed River file.
+func newFile(name string, node *ast.File) (*File, error) {
 	// Look for predefined non-components blocks (i.e., logging), and store
 	// everything else into a list of components.
 	//
@@ -74,6 +122,12 @@ func ReadFile(name string, bb []byte) (*File, error) {
 				configs = append(configs, stmt)
 			case "export":
 				configs = append(configs, stmt)
+			case "declare":
+				configs = append(configs, stmt)
+			case "function":
+				configs = append(configs, stmt)
+			case "import.file", "import.git", "import.http":
+				configs = append(configs, stmt)
 			default:
 				components = append(components, stmt)
 			}
@@ -52,6 +52,29 @@ func TestReadFileWithConfigBlock(t *testing.T) {
 	require.Equal(t, "logging", getBlockID(f.ConfigBlocks[0]))
 }
 
+func TestReadJSONFile(t *testing.T) {
+	content := `[
+		{ "name": "logging", "type": "block", "body": [
+			{ "name": "log_format", "type": "attr", "value": { "type": "string", "value": "json" } }
+		] },
+		{ "name": "testcomponents.passthrough", "type": "block", "label": "static", "body": [
+			{ "name": "input", "type": "attr", "value": { "type": "expr", "value": "env(\"HOME\")" } }
+		] }
+	]`
+
+	f, err := flow.ReadJSONFile(t.Name(), []byte(content))
+	require.NoError(t, err)
+	require.NotNil(t, f)
+
+	require.Len(t, f.Components, 1)
+	require.Equal(t, "testcomponents.passthrough.static", getBlockID(f.Components[0]))
+	require.Len(t, f.ConfigBlocks, 1)
+	require.Equal(t, "logging", getBlockID(f.ConfigBlocks[0]))
+
+	_, err = flow.ReadJSONFile(t.Name(), []byte(`[{ "name": "logging", "type": "attr" }]`))
+	require.ErrorContains(t, err, `$[0]: missing required field "value"`)
+}
+
 func TestReadFile_Defaults(t *testing.T) {
 	f, err := flow.ReadFile(t.Name(), []byte(``))
 	require.NotNil(t, f)
@@ -0,0 +1,340 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+const declareFile = `
+	declare "greeter" {
+		argument "name" {}
+
+		argument "greeting" {
+			optional = true
+			default  = "hello"
+		}
+
+		testcomponents.passthrough "greet" {
+			input = argument.greeting.value + ", " + argument.name.value
+		}
+
+		export "message" {
+			value = testcomponents.passthrough.greet.output
+		}
+	}
+
+	greeter "alice" {
+		name = "alice"
+	}
+
+	greeter "bob" {
+		name     = "bob"
+		greeting = "hi"
+	}
+
+	testcomponents.passthrough "forwarded" {
+		input = greeter.bob.message
+	}
+`
+
+func TestDeclare(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(declareFile))
+	require.NoError(t, err)
+	require.Len(t, f.ConfigBlocks, 1)
+
+	err = ctrl.LoadFile(f, nil)
+	require.NoError(t, err)
+
+	_, out := getFields(t, ctrl.loader.Graph(), "greeter.alice")
+	require.Equal(t, map[string]any{"message": "hello, alice"}, out)
+
+	_, out = getFields(t, ctrl.loader.Graph(), "greeter.bob")
+	require.Equal(t, map[string]any{"message": "hi, bob"}, out)
+
+	_, out = getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.forwarded")
+	require.Equal(t, "hi, bob", out.(testcomponents.PassthroughExports).Output)
+}
+
+func TestDeclare_UpdateBody(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(declareFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	// Changing only the body of the declaration must reload existing instances
+	// even though their arguments didn't change.
+	updated := strings.Replace(declareFile, `", " + argument.name.value`, `" " + argument.name.value + "!"`, 1)
+	f, err = ReadFile(t.Name(), []byte(updated))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	_, out := getFields(t, ctrl.loader.Graph(), "greeter.alice")
+	require.Equal(t, map[string]any{"message": "hello alice!"}, out)
+}
+
+func TestDeclare_Invalid(t *testing.T) {
+	tt := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "missing required argument",
+			config: `
+				declare "example" {
+					argument "input" {}
+				}
+				example "default" {}
+			`,
+			expectedError: `Missing required argument "input" for component "example"`,
+		},
+		{
+			name: "unknown argument",
+			config: `
+				declare "example" {}
+				example "default" {
+					input = "foo"
+				}
+			`,
+			expectedError: `Component "example" does not declare an argument named "input"`,
+		},
+		{
+			name: "nested block",
+			config: `
+				declare "example" {}
+				example "default" {
+					input {}
+				}
+			`,
+			expectedError: `Component "example" only supports attributes`,
+		},
+		{
+			name: "missing label",
+			config: `
+				declare "example" {}
+				example {}
+			`,
+			expectedError: `Component "example" must have a label`,
+		},
+		{
+			name: "conflicts with builtin component",
+			config: `
+				declare "testcomponents" {}
+			`,
+			expectedError: `conflicts with`,
+		},
+		{
+			name: "conflicts with config block",
+			config: `
+				declare "logging" {}
+			`,
+			expectedError: `declare block label "logging" conflicts with the logging config block`,
+		},
+		{
+			name: "conflicts with stdlib",
+			config: `
+				declare "concat" {}
+			`,
+			expectedError: `declare block label "concat" conflicts with a standard library identifier`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := New(testOptions(t))
+
+			f, err := ReadFile(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+
+			err = ctrl.LoadFile(f, nil)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestDeclare_ArgumentTypes(t *testing.T) {
+	const declaration = `
+		declare "example" {
+			argument "targets" {
+				type = "list(map(string))"
+				min  = 1
+			}
+
+			argument "level" {
+				optional       = true
+				default        = "info"
+				type           = "string"
+				allowed_values = ["debug", "info", "warn", "error"]
+			}
+
+			argument "url" {
+				optional = true
+				type     = "string"
+				regex    = "https?://.+"
+			}
+		}
+	`
+
+	tt := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			config: `
+				example "default" {
+					targets = [{ __address__ = "localhost:12345" }]
+					level   = "debug"
+					url     = "http://localhost:9009"
+				}
+			`,
+		},
+		{
+			name: "type mismatch",
+			config: `
+				example "default" {
+					targets = "localhost:12345"
+				}
+			`,
+			expectedError: `invalid value for argument "targets" to module: expected list(map(string)), got string`,
+		},
+		{
+			name: "nested type mismatch",
+			config: `
+				example "default" {
+					targets = [{ __address__ = 12345 }]
+				}
+			`,
+			expectedError: `invalid value for argument "targets" to module: [0]["__address__"]: expected string, got number`,
+		},
+		{
+			name: "min",
+			config: `
+				example "default" {
+					targets = []
+				}
+			`,
+			expectedError: `invalid value for argument "targets" to module: number of elements must be at least 1`,
+		},
+		{
+			name: "allowed values",
+			config: `
+				example "default" {
+					targets = [{}]
+					level   = "trace"
+				}
+			`,
+			expectedError: `invalid value for argument "level" to module: must be one of ["debug", "info", "warn", "error"]`,
+		},
+		{
+			name: "regex",
+			config: `
+				example "default" {
+					targets = [{}]
+					url     = "localhost:9009"
+				}
+			`,
+			expectedError: `invalid value for argument "url" to module: must match regex "https?://.+"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := New(testOptions(t))
+
+			f, err := ReadFile(t.Name(), []byte(declaration+tc.config))
+			require.NoError(t, err)
+
+			err = ctrl.LoadFile(f, nil)
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestDeclare_ArgumentDiagnosticPosition(t *testing.T) {
+	config := `declare "example" {
+	argument "input" {
+		type = "number"
+	}
+}
+
+example "default" {
+	input = "foo"
+}`
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(config))
+	require.NoError(t, err)
+
+	// Invalid values for arguments are reported at the block which provided
+	// them rather than within the declaration.
+	var diags diag.Diagnostics
+	require.ErrorAs(t, ctrl.LoadFile(f, nil), &diags)
+	require.Len(t, diags, 1)
+	require.Equal(t, `invalid value for argument "input" to module: expected number, got string`, diags[0].Message)
+	require.Equal(t, 7, diags[0].StartPos.Line)
+	require.Equal(t, 9, diags[0].EndPos.Line)
+}
+
+func TestDeclare_InvalidArgumentBlock(t *testing.T) {
+	tt := []struct {
+		name          string
+		argument      string
+		expectedError string
+	}{
+		{
+			name:          "unknown type",
+			argument:      `type = "lst(string)"`,
+			expectedError: `invalid type "lst(string)": unknown type "lst"`,
+		},
+		{
+			name:          "invalid regex",
+			argument:      `regex = "("`,
+			expectedError: `invalid regex`,
+		},
+		{
+			name: "invalid default",
+			argument: `
+				optional = true
+				default  = 5
+				type     = "string"
+			`,
+			expectedError: `invalid default value: expected string, got number`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := New(testOptions(t))
+
+			config := `
+				declare "example" {
+					argument "input" {
+						` + tc.argument + `
+					}
+				}
+				example "default" {
+					input = "foo"
+				}
+			`
+			f, err := ReadFile(t.Name(), []byte(config))
+			require.NoError(t, err)
+
+			err = ctrl.LoadFile(f, nil)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
@@ -49,12 +49,15 @@ import (
 	"context"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log/level"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/typeexpr"
+	"github.com/grafana/agent/service"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
 )
@@ -78,10 +81,6 @@ type Options struct {
 	// nil.
 	Tracer *tracing.Tracer
 
-	// Clusterer for implementing distributed behavior among components running
-	// on different nodes.
-	Clusterer *cluster.Clusterer
-
 	// Directory where components can write data. Constructed components will be
 	// given a subdirectory of DataPath using the local ID of the component.
 	//
@@ -112,27 +111,56 @@ type Options struct {
 	// loaded config file.
 	OnExportsChange func(exports map[string]any)
 
+	// OnComponentsRemoved is called with the global IDs of components which
+	// are removed from the controller, either by a reload or because the
+	// module running them is torn down. Components running inside a removed
+	// module component may not be reported separately. May be nil.
+	OnComponentsRemoved func(globalIDs []string)
+
 	// DialFunc is a function to use for components to properly connect to
 	// HTTPListenAddr. If nil, DialFunc defaults to (&net.Dialer{}).DialContext.
 	DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// Services are the services run by the controller. Services are started
+	// when Run is called and run until the controller exits. Components can
+	// declare a dependency on a service by name to access its data.
+	//
+	// Services must have unique names which don't collide with the names of
+	// config blocks or components.
+	Services []service.Service
+
+	// StuckTimeout is how long components may take to return from being
+	// built, updated, or shut down. Components which don't return in time are
+	// reported as stuck, and the controller continues without waiting for
+	// them. If StuckTimeout is zero, the controller always waits for
+	// components to return.
+	StuckTimeout time.Duration
+
+	// Capsules are the Go types which capsule type expressions in argument
+	// blocks may refer to by name, such as capsule("storage.Appendable").
+	// Capsule names which aren't found match values by the name of their Go
+	// type.
+	Capsules typeexpr.Capsules
 }
 
 // Flow is the Flow system.
 type Flow struct {
-	log       *logging.Logger
-	tracer    *tracing.Tracer
-	clusterer *cluster.Clusterer
-	opts      Options
+	log    *logging.Logger
+	tracer *tracing.Tracer
+	opts   Options
 
 	updateQueue *controller.Queue
 	sched       *controller.Scheduler
 	loader      *controller.Loader
 	modules     *moduleRegistry
 
-	loadFinished chan struct{}
+	loadFinished  chan struct{}
+	importUpdated chan struct{}
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
+	lastFile   *File          // File from the most recent successful load; nil if no load succeeded
+	lastArgs   map[string]any // Module arguments from the most recent successful load
 }
 
 // New creates a new, unstarted Flow controller. Call Run to run the controller.
@@ -145,9 +173,8 @@ func New(o Options) *Flow {
 // given modReg.
 func newController(modReg *moduleRegistry, o Options) *Flow {
 	var (
-		log       = o.Logger
-		tracer    = o.Tracer
-		clusterer = o.Clusterer
+		log    = o.Logger
+		tracer = o.Tracer
 	)
 
 	if tracer == nil {
@@ -164,53 +191,68 @@ func newController(modReg *moduleRegistry, o Options) *Flow {
 		dialFunc = (&net.Dialer{}).DialContext
 	}
 
-	var (
-		queue  = controller.NewQueue()
-		sched  = controller.NewScheduler()
-		loader = controller.NewLoader(controller.ComponentGlobals{
-			Logger:        log,
-			TraceProvider: tracer,
-			Clusterer:     clusterer,
-			DataPath:      o.DataPath,
-			OnComponentUpdate: func(cn *controller.ComponentNode) {
-				// Changed components should be queued for reevaluation.
-				queue.Enqueue(cn)
-			},
-			OnExportsChange: o.OnExportsChange,
-			Registerer:      o.Reg,
-			HTTPPathPrefix:  o.HTTPPathPrefix,
-			HTTPListenAddr:  o.HTTPListenAddr,
-			DialFunc:        dialFunc,
-			ControllerID:    o.ControllerID,
-			NewModuleController: func(id string) controller.ModuleController {
-				return newModuleController(&moduleControllerOptions{
-					ModuleRegistry: modReg,
-					Logger:         log,
-					Tracer:         tracer,
-					Clusterer:      clusterer,
-					Reg:            o.Reg,
-					DataPath:       o.DataPath,
-					HTTPListenAddr: o.HTTPListenAddr,
-					HTTPPath:       o.HTTPPathPrefix,
-					DialFunc:       o.DialFunc,
-					ID:             id,
-				})
-			},
-		})
-	)
-	return &Flow{
+	f := &Flow{
 		log:    log,
 		tracer: tracer,
 		opts:   o,
 
-		clusterer:   clusterer,
-		updateQueue: queue,
-		sched:       sched,
-		loader:      loader,
+		updateQueue: controller.NewQueue(),
+		sched:       controller.NewScheduler(o.StuckTimeout),
 		modules:     modReg,
 
-		loadFinished: make(chan struct{}, 1),
+		loadFinished:  make(chan struct{}, 1),
+		importUpdated: make(chan struct{}, 1),
 	}
+
+	f.loader = controller.NewLoader(controller.ComponentGlobals{
+		Logger:        log,
+		TraceProvider: tracer,
+		DataPath:      o.DataPath,
+		OnComponentUpdate: func(cn *controller.ComponentNode) {
+			// Changed components should be queued for reevaluation.
+			f.updateQueue.Enqueue(cn)
+		},
+		UpdateQueue: f.updateQueue,
+		OnImportUpdate: func(*controller.ImportConfigNode) {
+			// The declarations of imported files are used to build the graph, so
+			// the most recent file must be loaded again.
+			select {
+			case f.importUpdated <- struct{}{}:
+			default:
+			}
+		},
+		OnExportsChange:     o.OnExportsChange,
+		OnComponentsRemoved: o.OnComponentsRemoved,
+		Registerer:          o.Reg,
+		HTTPPathPrefix:      o.HTTPPathPrefix,
+		HTTPListenAddr:      o.HTTPListenAddr,
+		DialFunc:            dialFunc,
+		ControllerID:        o.ControllerID,
+		NewModuleController: func(id string) controller.ModuleController {
+			return newModuleController(&moduleControllerOptions{
+				ModuleRegistry: modReg,
+				Logger:         log,
+				Tracer:         tracer,
+				Reg:            o.Reg,
+				DataPath:       o.DataPath,
+				HTTPListenAddr: o.HTTPListenAddr,
+				HTTPPath:       o.HTTPPathPrefix,
+				DialFunc:       o.DialFunc,
+				ID:             id,
+				Services:       o.Services,
+				StuckTimeout:   o.StuckTimeout,
+				Capsules:       o.Capsules,
+
+				OnComponentsRemoved: o.OnComponentsRemoved,
+			})
+		},
+		Services:     o.Services,
+		ServiceHost:  f,
+		StuckTimeout: o.StuckTimeout,
+		Capsules:     o.Capsules,
+	})
+
+	return f
 }
 
 // Run starts the Flow controller, blocking until the provided context is
@@ -220,34 +262,47 @@ func (f *Flow) Run(ctx context.Context) {
 	defer f.loader.Cleanup()
 	defer level.Debug(f.log).Log("msg", "flow controller exiting")
 
+	// Start services immediately; they don't depend on the config file being
+	// loaded. Services which support configuration wait for their first
+	// config before running. Components are only scheduled after a
+	// successful load.
+	services := f.loader.Services()
+	runnables := make([]controller.RunnableNode, 0, len(services))
+	for _, svc := range services {
+		runnables = append(runnables, svc)
+	}
+	if err := f.sched.Synchronize(runnables); err != nil {
+		level.Error(f.log).Log("msg", "failed to start services", "err", err)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
 		case <-f.updateQueue.Chan():
-			// We need to pop _everything_ from the queue and evaluate each of them.
-			// If we only pop a single element, other components may sit waiting for
-			// evaluation forever.
-			for {
-				updated := f.updateQueue.TryDequeue()
-				if updated == nil {
-					break
-				}
-
-				level.Debug(f.log).Log("msg", "handling component with updated state", "node_id", updated.NodeID())
-				f.loader.EvaluateDependencies(updated)
+			// We need to pop _everything_ from the queue and evaluate it as a
+			// single batch. If we only pop a single element, other components may
+			// sit waiting for evaluation forever, and components which depend on
+			// more than one updated component would be evaluated multiple times.
+			updated := f.updateQueue.DequeueAll()
+			if len(updated) == 0 {
+				continue
+			}
+
+			level.Debug(f.log).Log("msg", "handling components with updated state", "count", len(updated))
+			f.loader.EvaluateDependencies(updated)
+
+		case <-f.importUpdated:
+			level.Info(f.log).Log("msg", "reloading config after an imported file changed")
+			if err := f.reloadLast(); err != nil {
+				level.Error(f.log).Log("msg", "failed to reload config after an imported file changed", "err", err)
 			}
 
 		case <-f.loadFinished:
 			level.Info(f.log).Log("msg", "scheduling loaded components")
 
-			components := f.loader.Components()
-			runnables := make([]controller.RunnableNode, 0, len(components))
-			for _, uc := range components {
-				runnables = append(runnables, uc)
-			}
-			err := f.sched.Synchronize(runnables)
+			err := f.sched.Synchronize(f.runnables())
 			if err != nil {
 				level.Error(f.log).Log("msg", "failed to load components", "err", err)
 			}
@@ -255,6 +310,27 @@ func (f *Flow) Run(ctx context.Context) {
 	}
 }
 
+// runnables returns the services, currently loaded components, and import
+// blocks to run.
+func (f *Flow) runnables() []controller.RunnableNode {
+	var (
+		services   = f.loader.Services()
+		components = f.loader.Components()
+		imports    = f.loader.Imports()
+		runnables  = make([]controller.RunnableNode, 0, len(services)+len(components)+len(imports))
+	)
+	for _, svc := range services {
+		runnables = append(runnables, svc)
+	}
+	for _, uc := range components {
+		runnables = append(runnables, uc)
+	}
+	for _, imp := range imports {
+		runnables = append(runnables, imp)
+	}
+	return runnables
+}
+
 // LoadFile synchronizes the state of the controller with the current config
 // file. Components in the graph will be marked as unhealthy if there was an
 // error encountered during Load.
@@ -262,14 +338,71 @@ func (f *Flow) Run(ctx context.Context) {
 // The controller will only start running components after Load is called once
 // without any configuration errors.
 func (f *Flow) LoadFile(file *File, args map[string]any) error {
+	_, err := f.ReloadFile(file, args)
+	return err
+}
+
+// ReloadSummary summarizes how components changed after loading a file. Each
+// field holds a sorted list of component IDs.
+type ReloadSummary struct {
+	Added     []string // Components which did not exist before.
+	Removed   []string // Components which no longer exist.
+	Updated   []string // Existing components which were reevaluated.
+	Unchanged []string // Existing components which were not reevaluated.
+}
+
+// String returns a one-line description of the number of components in each
+// category.
+func (s ReloadSummary) String() string {
+	return controller.ReloadSummary(s).String()
+}
+
+// ReloadFile is like LoadFile, but also returns a summary of the components
+// which were added, removed, updated, or left unchanged.
+//
+// Only components which are new, whose block changed, or which depend on a
+// changed component are reevaluated. Changes to whitespace and comments do not
+// cause a component to be reevaluated.
+func (f *Flow) ReloadFile(file *File, args map[string]any) (ReloadSummary, error) {
+	// Imported files are fetched before loadMut is taken, so that slow
+	// sources don't block readers of the controller while the file loads.
+	f.loader.FetchImports(args, file.ConfigBlocks)
+
 	f.loadMut.Lock()
 	defer f.loadMut.Unlock()
 
+	summary, err := f.apply(file, args)
+	if err == nil {
+		// Only files which loaded successfully are loaded again when an
+		// imported file changes.
+		f.lastFile, f.lastArgs = file, args
+	}
+	return summary, err
+}
+
+// reloadLast loads the file from the most recent successful call to
+// ReloadFile again.
+// It is used to load new declarations when an imported file changes.
+func (f *Flow) reloadLast() error {
+	f.loadMut.Lock()
+	defer f.loadMut.Unlock()
+
+	if f.lastFile == nil {
+		return nil
+	}
+	_, err := f.apply(f.lastFile, f.lastArgs)
+	return err
+}
+
+// apply loads file into the loader and schedules the loaded components.
+// loadMut must be held when calling apply.
+func (f *Flow) apply(file *File, args map[string]any) (ReloadSummary, error) {
 	diags := f.loader.Apply(args, file.Components, file.ConfigBlocks)
+	summary := ReloadSummary(f.loader.LastReloadSummary())
 	if !f.loadedOnce.Load() && diags.HasErrors() {
 		// The first call to Load should not run any components if there were
 		// errors in the configuration file.
-		return diags
+		return summary, diags
 	}
 	f.loadedOnce.Store(true)
 
@@ -278,7 +411,17 @@ func (f *Flow) LoadFile(file *File, args map[string]any) error {
 	default:
 		// A refresh is already scheduled
 	}
-	return diags.ErrorOrNil()
+	return summary, diags.ErrorOrNil()
+}
+
+// Validate checks file for errors without building, updating, or running any
+// components. The state of the controller isn't changed by Validate.
+//
+// Since components aren't built, Validate can't know the exports of
+// components. Problems found with blocks which reference exports of other
+// components are reported as warnings.
+func (f *Flow) Validate(file *File, args map[string]any) diag.Diagnostics {
+	return f.loader.Validate(args, file.Components, file.ConfigBlocks)
 }
 
 // Ready returns whether the Flow controller has finished its initial load.
@@ -74,14 +74,27 @@ func (f *Flow) getComponentDetail(cn *controller.ComponentNode, graph *dag.Graph
 	// block is referenced in the graph.
 	//
 	// TODO(rfratto): add support for config block nodes in the API and UI.
+	//
+	// References to a block with for_each are shown as references to each of
+	// its instances.
 	for _, dep := range graph.Dependencies(cn) {
-		if _, ok := dep.(*controller.ComponentNode); ok {
+		switch dep := dep.(type) {
+		case *controller.ComponentNode:
 			references = append(references, dep.NodeID())
+		case *controller.ForEachNode:
+			references = append(references, dep.InstanceIDs()...)
 		}
 	}
 	for _, dep := range graph.Dependants(cn) {
-		if _, ok := dep.(*controller.ComponentNode); ok {
+		switch dep := dep.(type) {
+		case *controller.ComponentNode:
 			referencedBy = append(referencedBy, dep.NodeID())
+		case *controller.ForEachNode:
+			for _, forEachDep := range graph.Dependants(dep) {
+				if _, ok := forEachDep.(*controller.ComponentNode); ok {
+					referencedBy = append(referencedBy, forEachDep.NodeID())
+				}
+			}
 		}
 	}
 
@@ -1,10 +1,67 @@
 package flow
 
+import (
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/service"
+	"golang.org/x/exp/slices"
+)
+
+// GetService implements [service.Host]. It returns the service with the given
+// name from the services of the controller.
+func (f *Flow) GetService(name string) (service.Service, bool) {
+	for _, svc := range f.opts.Services {
+		if svc.Definition().Name == name {
+			return svc, true
+		}
+	}
+	return nil, false
+}
+
 // GetServiceConsumers implements [service.Host]. It returns a slice of
 // [component.Component] and [service.Service]s which declared a dependency on
 // the named service.
+//
+// Components which haven't been built yet are not included.
+// GetServiceConsumers waits for a config file which is being loaded to finish
+// loading, so services must not call it before they can serve requests.
 func (f *Flow) GetServiceConsumers(serviceName string) []any {
-	// TODO(rfratto): return non-nil once it is possible for a service or
-	// component to declare a dependency on a named service.
-	return nil
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	var consumers []any
+
+	// Dependencies between services are static, so they're found through
+	// their definitions. This allows services to find their consumers before
+	// the config file has been loaded.
+	for _, sn := range f.loader.Services() {
+		if slices.Contains(sn.Definition().DependsOn, serviceName) {
+			consumers = append(consumers, sn.Service())
+		}
+	}
+
+	graph := f.loader.OriginalGraph()
+	if node := graph.GetByID(serviceName); node != nil {
+		for _, dep := range graph.Dependants(node) {
+			if cn, ok := dep.(*controller.ComponentNode); ok {
+				if c := cn.Component(); c != nil {
+					consumers = append(consumers, c)
+				}
+			}
+		}
+	}
+
+	// Services are only part of the graph of the root controller, so
+	// components in modules are found through their registration instead.
+	for _, mod := range f.modules.List() {
+		for _, cn := range mod.f.loader.Components() {
+			if !slices.Contains(cn.Registration().NeedsServices, serviceName) {
+				continue
+			}
+			if c := cn.Component(); c != nil {
+				consumers = append(consumers, c)
+			}
+		}
+	}
+
+	return consumers
 }
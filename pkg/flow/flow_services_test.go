@@ -0,0 +1,82 @@
+package flow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlow_GetServiceConsumers(t *testing.T) {
+	var (
+		svcA = &testService{def: service.Definition{Name: testcomponents.ServiceConsumerService}}
+		svcB = &testService{def: service.Definition{Name: "dependant", DependsOn: []string{testcomponents.ServiceConsumerService}}}
+	)
+
+	opts := testOptions(t)
+	opts.Services = []service.Service{svcA, svcB}
+	ctrl := New(opts)
+
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.service_consumer "a" { }
+		testcomponents.passthrough "b" {
+			input = "hello"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	consumers := ctrl.GetServiceConsumers(testcomponents.ServiceConsumerService)
+	require.Len(t, consumers, 2)
+
+	var (
+		foundComponent bool
+		foundService   bool
+	)
+	for _, consumer := range consumers {
+		switch consumer := consumer.(type) {
+		case component.Component:
+			_, foundComponent = consumer.(*testcomponents.ServiceConsumer)
+		case service.Service:
+			foundService = consumer == svcB
+		}
+	}
+	require.True(t, foundComponent, "service_consumer component should be a consumer")
+	require.True(t, foundService, "dependant service should be a consumer")
+
+	require.Empty(t, ctrl.GetServiceConsumers("dependant"))
+	require.Empty(t, ctrl.GetServiceConsumers("missing"))
+}
+
+func TestFlow_GetService(t *testing.T) {
+	svc := &testService{def: service.Definition{Name: "example"}}
+
+	opts := testOptions(t)
+	opts.Services = []service.Service{svc}
+	ctrl := New(opts)
+
+	found, ok := ctrl.GetService("example")
+	require.True(t, ok)
+	require.Same(t, svc, found)
+
+	_, ok = ctrl.GetService("missing")
+	require.False(t, ok)
+}
+
+type testService struct {
+	def service.Definition
+}
+
+func (s *testService) Definition() service.Definition { return s.def }
+
+func (s *testService) Run(ctx context.Context, host service.Host) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (s *testService) Update(newConfig any) error { return nil }
+
+func (s *testService) Data() any { return "data" }
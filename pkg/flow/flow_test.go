@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/grafana/agent/component"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
@@ -66,12 +65,50 @@ func testOptions(t *testing.T) Options {
 	s, err := logging.New(os.Stderr, logging.DefaultOptions)
 	require.NoError(t, err)
 
-	c := &cluster.Clusterer{Node: cluster.NewLocalNode("")}
-
 	return Options{
-		Logger:    s,
-		DataPath:  t.TempDir(),
-		Reg:       nil,
-		Clusterer: c,
+		Logger:   s,
+		DataPath: t.TempDir(),
+		Reg:      nil,
+	}
+}
+
+func TestController_OnComponentsRemoved(t *testing.T) {
+	var removed []string
+
+	opts := testOptions(t)
+	opts.OnComponentsRemoved = func(globalIDs []string) {
+		removed = append(removed, globalIDs...)
 	}
+	ctrl := New(opts)
+
+	loadFile := func(t *testing.T, config string) {
+		t.Helper()
+
+		f, err := ReadFile(t.Name(), []byte(config))
+		require.NoError(t, err)
+		require.NoError(t, ctrl.LoadFile(f, nil))
+	}
+
+	loadFile(t, `
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+		}
+
+		testcomponents.passthrough "greeting" {
+			for_each = ["alice", "bob"]
+			input    = "hello, " + each.value
+		}
+	`)
+	require.Empty(t, removed)
+
+	loadFile(t, `
+		testcomponents.passthrough "greeting" {
+			for_each = ["alice"]
+			input    = "hello, " + each.value
+		}
+	`)
+	require.ElementsMatch(t, []string{
+		"testcomponents.passthrough.static",
+		`testcomponents.passthrough.greeting["bob"]`,
+	}, removed)
 }
@@ -0,0 +1,238 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEach(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	loadFile := func(t *testing.T, config string) {
+		t.Helper()
+
+		f, err := ReadFile(t.Name(), []byte(config))
+		require.NoError(t, err)
+		require.NoError(t, ctrl.LoadFile(f, nil))
+	}
+
+	loadFile(t, `
+		testcomponents.passthrough "greeting" {
+			for_each = ["alice", "bob"]
+			input    = "hello, " + each.value
+		}
+
+		testcomponents.passthrough "forwarded" {
+			input = testcomponents.passthrough.greeting["bob"].output
+		}
+	`)
+
+	_, out := getFields(t, ctrl.loader.Graph(), `testcomponents.passthrough.greeting["alice"]`)
+	require.Equal(t, "hello, alice", out.(testcomponents.PassthroughExports).Output)
+
+	_, out = getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.forwarded")
+	require.Equal(t, "hello, bob", out.(testcomponents.PassthroughExports).Output)
+
+	// Instances whose key remains in for_each must be reused across reloads.
+	alice := ctrl.loader.Graph().GetByID(`testcomponents.passthrough.greeting["alice"]`)
+	require.NotNil(t, alice)
+
+	loadFile(t, `
+		testcomponents.passthrough "greeting" {
+			for_each = {
+				alice = "hi",
+				carol = "hey",
+			}
+			input = each.value + ", " + each.key
+		}
+	`)
+
+	g := ctrl.loader.Graph()
+	require.Same(t, alice, g.GetByID(`testcomponents.passthrough.greeting["alice"]`))
+	require.Nil(t, g.GetByID(`testcomponents.passthrough.greeting["bob"]`))
+
+	_, out = getFields(t, g, `testcomponents.passthrough.greeting["alice"]`)
+	require.Equal(t, "hi, alice", out.(testcomponents.PassthroughExports).Output)
+	_, out = getFields(t, g, `testcomponents.passthrough.greeting["carol"]`)
+	require.Equal(t, "hey, carol", out.(testcomponents.PassthroughExports).Output)
+
+	// Blocks without instances are exposed as an empty object.
+	loadFile(t, `
+		testcomponents.passthrough "greeting" {
+			for_each = []
+			input    = each.value
+		}
+	`)
+	require.IsType(t, &controller.ForEachNode{}, ctrl.loader.Graph().GetByID("testcomponents.passthrough.greeting"))
+	require.Equal(t, map[string]any{
+		"passthrough": map[string]any{
+			"greeting": map[string]any{},
+		},
+	}, ctrl.loader.Variables()["testcomponents"])
+}
+
+func TestForEach_ModuleArgument(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(`
+		declare "greeter" {
+			argument "names" {}
+
+			argument "active" {
+				optional = true
+				default  = true
+			}
+
+			testcomponents.passthrough "greet" {
+				for_each = argument.names.value
+				enabled  = argument.active.value
+				input    = "hello, " + each.key
+			}
+
+			export "greetings" {
+				value = testcomponents.passthrough.greet
+			}
+		}
+
+		greeter "default" {
+			names = ["alice"]
+		}
+
+		greeter "disabled" {
+			names  = ["alice"]
+			active = false
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	_, out := getFields(t, ctrl.loader.Graph(), "greeter.default")
+	require.Contains(t, out.(map[string]any)["greetings"], "alice")
+
+	_, out = getFields(t, ctrl.loader.Graph(), "greeter.disabled")
+	require.Empty(t, out.(map[string]any)["greetings"])
+}
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("TEST_ENABLED", "false")
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.passthrough "enabled" {
+			enabled = true
+			input   = "hello"
+		}
+
+		testcomponents.passthrough "disabled" {
+			enabled = env("TEST_ENABLED") == "true"
+			input   = "hello"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	g := ctrl.loader.Graph()
+	require.NotNil(t, g.GetByID("testcomponents.passthrough.enabled"))
+	require.Nil(t, g.GetByID("testcomponents.passthrough.disabled"))
+}
+
+func TestForEach_Invalid(t *testing.T) {
+	tt := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "invalid type",
+			config: `
+				testcomponents.passthrough "example" {
+					for_each = 5
+				}
+			`,
+			expectedError: "failed to evaluate for_each: value must be an array of strings or an object",
+		},
+		{
+			name: "duplicate keys",
+			config: `
+				testcomponents.passthrough "example" {
+					for_each = ["a", "a"]
+				}
+			`,
+			expectedError: `failed to evaluate for_each: array contains "a" more than once`,
+		},
+		{
+			name: "key with path separators",
+			config: `
+				testcomponents.passthrough "example" {
+					for_each = ["/../../etc"]
+				}
+			`,
+			expectedError: "failed to evaluate for_each: element 0 of the array must not contain path separators",
+		},
+		{
+			name: "object key with ..",
+			config: `
+				testcomponents.passthrough "example" {
+					for_each = { ".." = "a" }
+				}
+			`,
+			expectedError: `failed to evaluate for_each: object key ".." must not contain ".."`,
+		},
+		{
+			name: "references a component",
+			config: `
+				testcomponents.passthrough "source" {
+					input = "a"
+				}
+
+				testcomponents.passthrough "example" {
+					for_each = [testcomponents.passthrough.source.output]
+				}
+			`,
+			expectedError: `identifier "testcomponents" does not exist`,
+		},
+		{
+			name: "singleton",
+			config: `
+				testcomponents.singleton {
+					for_each = ["a"]
+				}
+			`,
+			expectedError: `Component "testcomponents.singleton" is a singleton and does not support for_each`,
+		},
+		{
+			name: "each outside of for_each",
+			config: `
+				testcomponents.passthrough "example" {
+					input = each.value
+				}
+			`,
+			expectedError: `component "each.value" does not exist`,
+		},
+		{
+			name: "enabled is not a bool",
+			config: `
+				testcomponents.passthrough "example" {
+					enabled = "yes"
+				}
+			`,
+			expectedError: "failed to evaluate enabled",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := New(testOptions(t))
+
+			f, err := ReadFile(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+
+			err = ctrl.LoadFile(f, nil)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/stretchr/testify/require"
+)
+
+const functionFile = `
+	function "greet" {
+		params = ["greeting", "name"]
+		result = greeting + ", " + name
+	}
+
+	function "shout" {
+		params = ["name"]
+		result = greet("HELLO", name) + "!"
+	}
+
+	testcomponents.passthrough "greeting" {
+		input = shout("alice")
+	}
+
+	declare "greeter" {
+		argument "name" {}
+
+		function "wave" {
+			params = ["name"]
+			result = "*waves at " + name + "*"
+		}
+
+		export "message" {
+			value = wave(argument.name.value)
+		}
+	}
+
+	greeter "bob" {
+		name = "bob"
+	}
+`
+
+func TestFunction(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(functionFile))
+	require.NoError(t, err)
+	require.Len(t, f.ConfigBlocks, 3)
+
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	_, out := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.greeting")
+	require.Equal(t, "HELLO, alice!", out.(testcomponents.PassthroughExports).Output)
+
+	// Functions defined inside of a declare block are usable by the module of
+	// each instance.
+	_, out = getFields(t, ctrl.loader.Graph(), "greeter.bob")
+	require.Equal(t, map[string]any{"message": "*waves at bob*"}, out)
+
+	// Changing the body of a function re-evaluates its callers.
+	updated := strings.Replace(functionFile, `+ "!"`, `+ "?"`, 1)
+	f, err = ReadFile(t.Name(), []byte(updated))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	_, out = getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.greeting")
+	require.Equal(t, "HELLO, alice?", out.(testcomponents.PassthroughExports).Output)
+}
+
+func TestFunction_Invalid(t *testing.T) {
+	tt := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "missing result",
+			config: `
+				function "example" {
+					params = ["a"]
+				}
+			`,
+			expectedError: `missing required attribute "result" in function "example"`,
+		},
+		{
+			name: "duplicate function",
+			config: `
+				function "example" { result = 1 }
+				function "example" { result = 2 }
+			`,
+			expectedError: `"function.example" block already declared`,
+		},
+		{
+			name: "conflicts with builtin component",
+			config: `
+				function "testcomponents" { result = 1 }
+			`,
+			expectedError: `conflicts with`,
+		},
+		{
+			name: "conflicts with stdlib",
+			config: `
+				function "concat" { result = 1 }
+			`,
+			expectedError: `function block label "concat" conflicts with a standard library identifier`,
+		},
+		{
+			name: "conflicts with declare",
+			config: `
+				declare "example" {}
+				function "example" { result = 1 }
+			`,
+			expectedError: `function block label "example" conflicts with a declare block of the same name`,
+		},
+		{
+			name: "references a component",
+			config: `
+				testcomponents.passthrough "a" {
+					input = "a"
+				}
+
+				function "example" {
+					result = testcomponents.passthrough.a.output
+				}
+
+				testcomponents.passthrough "b" {
+					input = example()
+				}
+			`,
+			expectedError: `identifier "testcomponents" does not exist`,
+		},
+		{
+			name: "unbounded recursion",
+			config: `
+				function "example" {
+					params = ["n"]
+					result = example(n + 1)
+				}
+
+				testcomponents.passthrough "a" {
+					input = example(0)
+				}
+			`,
+			expectedError: `example exceeded the maximum call depth of 100`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := New(testOptions(t))
+
+			f, err := ReadFile(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+
+			err = ctrl.LoadFile(f, nil)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
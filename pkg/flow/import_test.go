@@ -0,0 +1,267 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/stretchr/testify/require"
+)
+
+const importedFile = `
+	function "shout" {
+		params = ["name"]
+		result = name + "!"
+	}
+
+	declare "greeter" {
+		argument "name" {}
+
+		testcomponents.passthrough "greet" {
+			input = "hello, " + argument.name.value
+		}
+
+		export "message" {
+			value = testcomponents.passthrough.greet.output
+		}
+	}
+`
+
+const importingFile = `
+	%s "lib" {
+		%s
+	}
+
+	lib.greeter "alice" {
+		name = lib.shout("alice")
+	}
+
+	testcomponents.passthrough "forwarded" {
+		input = lib.greeter.alice.message
+	}
+`
+
+func TestImport_File(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lib.river")
+	require.NoError(t, os.WriteFile(filename, []byte(importedFile), 0644))
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(fmt.Sprintf(importingFile, "import.file", fmt.Sprintf("filename = %q", filename))))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	_, out := getFields(t, ctrl.loader.Graph(), "lib.greeter.alice")
+	require.Equal(t, map[string]any{"message": "hello, alice!"}, out)
+
+	_, out = getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.forwarded")
+	require.Equal(t, "hello, alice!", out.(testcomponents.PassthroughExports).Output)
+}
+
+func TestImport_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(importedFile))
+	}))
+	defer srv.Close()
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(fmt.Sprintf(importingFile, "import.http", fmt.Sprintf("url = %q", srv.URL))))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	_, out := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.forwarded")
+	require.Equal(t, "hello, alice!", out.(testcomponents.PassthroughExports).Output)
+}
+
+func TestImport_Validate(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(importedFile))
+	}))
+	defer srv.Close()
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(fmt.Sprintf(importingFile, "import.http", fmt.Sprintf("url = %q", srv.URL))))
+	require.NoError(t, err)
+
+	// Imported files aren't fetched during validation, so blocks which use
+	// them can only be reported as warnings.
+	diags := ctrl.Validate(f, nil)
+	require.False(t, diags.HasErrors(), "unexpected errors: %s", diags.Error())
+	require.NotEmpty(t, diags)
+	require.Zero(t, requests.Load())
+}
+
+func TestImport_Reload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lib.river")
+	require.NoError(t, os.WriteFile(filename, []byte(importedFile), 0644))
+
+	ctrl := New(testOptions(t))
+
+	args := fmt.Sprintf("filename = %q\npoll_frequency = \"10ms\"", filename)
+	f, err := ReadFile(t.Name(), []byte(fmt.Sprintf(importingFile, "import.file", args)))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	// Changing the imported file reloads the declarations and functions which
+	// use it.
+	updated := strings.Replace(importedFile, `"hello, "`, `"hi, "`, 1)
+	require.NoError(t, os.WriteFile(filename, []byte(updated), 0644))
+
+	require.Eventually(t, func() bool {
+		_, out := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.forwarded")
+		return out.(testcomponents.PassthroughExports).Output == "hi, alice!"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestImport_FailedReload(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "lib.river")
+	require.NoError(t, os.WriteFile(filename, []byte(importedFile), 0644))
+	otherFilename := filepath.Join(dir, "other.river")
+	other := strings.Replace(importedFile, `"hello, "`, `"hey, "`, 1)
+	require.NoError(t, os.WriteFile(otherFilename, []byte(other), 0644))
+
+	ctrl := New(testOptions(t))
+
+	args := fmt.Sprintf("filename = %q\npoll_frequency = \"10ms\"", filename)
+	f, err := ReadFile(t.Name(), []byte(fmt.Sprintf(importingFile, "import.file", args)))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	// The import of the new file is fetched before the load fails because of
+	// the duplicate component.
+	otherArgs := fmt.Sprintf("filename = %q\npoll_frequency = \"10ms\"", otherFilename)
+	broken, err := ReadFile(t.Name(), []byte(fmt.Sprintf(importingFile, "import.file", otherArgs)+`
+		testcomponents.passthrough "forwarded" {
+			input = "duplicate"
+		}
+	`))
+	require.NoError(t, err)
+	require.Error(t, ctrl.LoadFile(broken, nil))
+
+	ctrl.loadMut.RLock()
+	require.Same(t, f, ctrl.lastFile)
+	ctrl.loadMut.RUnlock()
+
+	// The import keeps polling the file of the last successful load.
+	updated := strings.Replace(importedFile, `"hello, "`, `"hi, "`, 1)
+	require.NoError(t, os.WriteFile(filename, []byte(updated), 0644))
+
+	require.Eventually(t, func() bool {
+		_, out := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.forwarded")
+		return out.(testcomponents.PassthroughExports).Output == "hi, alice!"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestImport_Invalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lib.river")
+	require.NoError(t, os.WriteFile(filename, []byte(importedFile), 0644))
+
+	invalidFilename := filepath.Join(t.TempDir(), "invalid.river")
+	require.NoError(t, os.WriteFile(invalidFilename, []byte(`
+		testcomponents.passthrough "example" {
+			input = "example"
+		}
+	`), 0644))
+
+	tt := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "missing file",
+			config: `
+				import.file "lib" {
+					filename = "/does/not/exist.river"
+				}
+			`,
+			expectedError: `Failed to load import`,
+		},
+		{
+			name: "imported file with components",
+			config: fmt.Sprintf(`
+				import.file "lib" {
+					filename = %q
+				}
+			`, invalidFilename),
+			expectedError: `imported files may only contain declare and function blocks`,
+		},
+		{
+			name: "duplicate namespace",
+			config: fmt.Sprintf(`
+				import.file "lib" {
+					filename = %[1]q
+				}
+
+				import.http "lib" {
+					url = "http://localhost/%[1]s"
+				}
+			`, filename),
+			expectedError: `import block label "lib" already used by import.file.lib`,
+		},
+		{
+			name: "conflicts with builtin component",
+			config: fmt.Sprintf(`
+				import.file "testcomponents" {
+					filename = %q
+				}
+			`, filename),
+			expectedError: `conflicts with`,
+		},
+		{
+			name: "conflicts with declare",
+			config: fmt.Sprintf(`
+				declare "lib" {}
+
+				import.file "lib" {
+					filename = %q
+				}
+			`, filename),
+			expectedError: `import block label "lib" conflicts with a declare block of the same name`,
+		},
+		{
+			name: "undefined declaration",
+			config: fmt.Sprintf(`
+				import.file "lib" {
+					filename = %q
+				}
+
+				lib.missing "example" {}
+			`, filename),
+			expectedError: `Unrecognized component name "lib.missing"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := New(testOptions(t))
+
+			f, err := ReadFile(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+
+			err = ctrl.LoadFile(f, nil)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -16,14 +17,17 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/profiling"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/typeexpr"
 	"github.com/grafana/agent/pkg/river/vm"
+	"github.com/grafana/agent/service"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
 )
 
 // ComponentID is a fully-qualified name of a component. Each element in
@@ -67,16 +71,32 @@ type DialFunc func(ctx context.Context, network, address string) (net.Conn, erro
 type ComponentGlobals struct {
 	Logger              *logging.Logger                  // Logger shared between all managed components.
 	TraceProvider       trace.TracerProvider             // Tracer shared between all managed components.
-	Clusterer           *cluster.Clusterer               // Clusterer shared between all managed components.
 	DataPath            string                           // Shared directory where component data may be stored
 	OnComponentUpdate   func(cn *ComponentNode)          // Informs controller that we need to reevaluate
+	UpdateQueue         *Queue                           // Queue of components waiting for reevaluation of their dependants; may be nil
+	OnImportUpdate      func(cn *ImportConfigNode)       // Informs controller that an imported file changed
 	OnExportsChange     func(exports map[string]any)     // Invoked when the managed component updated its exports
+	OnComponentsRemoved func(globalIDs []string)         // Invoked with the global IDs of components removed from the controller
 	Registerer          prometheus.Registerer            // Registerer for serving agent and component metrics
 	HTTPPathPrefix      string                           // HTTP prefix for components.
 	HTTPListenAddr      string                           // Base address for server
 	DialFunc            DialFunc                         // Function to connect to HTTPListenAddr.
 	ControllerID        string                           // ID of controller.
 	NewModuleController func(id string) ModuleController // Func to generate a module controller.
+	Services            []service.Service                // Services available to components.
+	ServiceHost         service.Host                     // Host given to services when they're run.
+	StuckTimeout        time.Duration                    // Time after which components that don't respond are stuck; 0 disables detection.
+	Capsules            typeexpr.Capsules                // Go types which capsule type expressions of arguments may refer to.
+}
+
+// getService returns the service from globals with the given name.
+func (globals ComponentGlobals) getService(name string) (service.Service, bool) {
+	for _, svc := range globals.Services {
+		if svc.Definition().Name == name {
+			return svc, true
+		}
+	}
+	return nil, false
 }
 
 // ComponentNode is a controller node which manages a user-defined component.
@@ -86,15 +106,18 @@ type ComponentGlobals struct {
 // from a River block.
 type ComponentNode struct {
 	id                ComponentID
+	blockID           ComponentID // ID of the River block; differs from id for instances of for_each blocks
+	instance          bool        // Whether the component is an instance of a for_each block
 	globalID          string
 	label             string
 	componentName     string
 	nodeID            string // Cached from id.String() to avoid allocating new strings every time NodeID is called.
 	reg               component.Registration
 	managedOpts       component.Options
-	registry          *prometheus.Registry
+	registry          atomic.Pointer[prometheus.Registry] // Registry of the current instance of the managed component
 	exportsType       reflect.Type
 	moduleController  ModuleController
+	stuckTimeout      time.Duration
 	OnComponentUpdate func(cn *ComponentNode) // Informs controller that we need to reevaluate
 
 	mut     sync.RWMutex
@@ -102,8 +125,22 @@ type ComponentNode struct {
 	eval    *vm.Evaluator
 	managed component.Component // Inner managed component
 	args    component.Arguments // Evaluated arguments for the managed component
+	declare *declareTemplate    // Declaration of the component; nil for builtin components
+	each    map[string]any      // Value of the each variable; nil if the component isn't an instance
+
+	inflight    chan struct{}       // Closed once a stuck build or update returns; nil if nothing is stuck
+	pendingArgs component.Arguments // Arguments to apply once a stuck build or update returns
+
+	restartEval *vm.Evaluator  // Evaluator for the restart block; nil if the block isn't set
+	restartErr  error          // Error from splitting the restart block from the component block
+	restart     restartOptions // Evaluated restart options
 
-	doingEval atomic.Bool
+	doingEval  atomic.Bool
+	restarts   atomic.Int64 // Number of times the managed component was restarted
+	stuckCount atomic.Int64 // Number of times the managed component got stuck
+
+	stuckMut sync.RWMutex
+	stuck    *stuckInfo // Set while the managed component is stuck
 
 	// NOTE(rfratto): health and exports have their own mutex because they may be
 	// set asynchronously while mut is still being held (i.e., when calling Evaluate
@@ -122,17 +159,45 @@ var _ BlockNode = (*ComponentNode)(nil)
 // NewComponentNode creates a new ComponentNode from an initial ast.BlockStmt.
 // The underlying managed component isn't created until Evaluate is called.
 func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode {
-	var (
-		id     = BlockComponentID(b)
-		nodeID = id.String()
-	)
-
 	reg, ok := component.Get(ComponentID(b.Name).String())
 	if !ok {
 		// NOTE(rfratto): It's normally not possible to get to this point; the
 		// blocks should have been validated by the graph loader in advance to
 		// guarantee that b is an expected component.
-		panic("NewComponentNode: could not find registration for component " + nodeID)
+		panic("NewComponentNode: could not find registration for component " + BlockComponentID(b).String())
+	}
+	return newComponentNode(globals, reg, b, nil)
+}
+
+// NewDeclaredComponentNode creates a new ComponentNode for an instance of the
+// custom component defined by decl. The underlying managed component isn't
+// created until Evaluate is called.
+func NewDeclaredComponentNode(globals ComponentGlobals, decl *DeclareNode, b *ast.BlockStmt) *ComponentNode {
+	return newDeclaredComponentNode(globals, decl, b, nil)
+}
+
+func newDeclaredComponentNode(globals ComponentGlobals, decl *DeclareNode, b *ast.BlockStmt, inst *componentInstance) *ComponentNode {
+	tmpl := newDeclareTemplate(decl.Block())
+	cn := newComponentNode(globals, declaredRegistration(decl.Label(), tmpl), b, inst)
+	cn.declare = tmpl
+	return cn
+}
+
+// newComponentNode creates a new ComponentNode. If inst is non-nil, the
+// ComponentNode is the instance of a for_each block with the given key, and
+// its ID has the key of the instance appended to the ID of the block.
+func newComponentNode(globals ComponentGlobals, reg component.Registration, b *ast.BlockStmt, inst *componentInstance) *ComponentNode {
+	var (
+		blockID = BlockComponentID(b)
+		id      = blockID
+		nodeID  = blockID.String()
+		each    map[string]any
+	)
+	if inst != nil {
+		id = append(ComponentID{}, blockID...)
+		id = append(id, inst.Key)
+		nodeID = instanceNodeID(blockID, inst.Key)
+		each = inst.Each
 	}
 
 	initHealth := component.Health{
@@ -153,6 +218,8 @@ func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode
 
 	cn := &ComponentNode{
 		id:                id,
+		blockID:           blockID,
+		instance:          inst != nil,
 		globalID:          globalID,
 		label:             b.Label,
 		nodeID:            nodeID,
@@ -160,18 +227,20 @@ func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode
 		reg:               reg,
 		exportsType:       getExportsType(reg),
 		moduleController:  globals.NewModuleController(globalID),
+		stuckTimeout:      globals.StuckTimeout,
 		OnComponentUpdate: globals.OnComponentUpdate,
 
-		block: b,
-		eval:  vm.New(b.Body),
+		restart: defaultRestartOptions,
 
 		// Prepopulate arguments and exports with their zero values.
 		args:    reg.Args,
 		exports: reg.Exports,
+		each:    each,
 
 		evalHealth: initHealth,
 		runHealth:  initHealth,
 	}
+	cn.setBlock(b)
 	cn.managedOpts = getManagedOptions(globals, cn)
 
 	return cn
@@ -184,26 +253,58 @@ func getManagedOptions(globals ComponentGlobals, cn *ComponentNode) component.Op
 		prefix = "/" + prefix
 	}
 
-	cn.registry = prometheus.NewRegistry()
+	// The node IDs of instances of for_each blocks contain quotes, which
+	// aren't valid in file names on all platforms, so the data path of
+	// instances is derived from their ID instead. Instance keys can't contain
+	// path separators or "..", so the data path always stays within the data
+	// path of the controller.
+	dataPathID := cn.globalID
+	if cn.instance {
+		dataPathID = path.Join(globals.ControllerID, cn.id.String())
+	}
+
+	// The keys of instances may contain characters which must be escaped in
+	// URLs.
+	httpPath := (&url.URL{Path: path.Join(prefix, cn.globalID) + "/"}).EscapedPath()
+
 	return component.Options{
-		ID:     cn.globalID,
-		Logger: log.With(globals.Logger, "component", cn.globalID),
-		Registerer: prometheus.WrapRegistererWith(prometheus.Labels{
-			"component_id": cn.globalID,
-		}, cn.registry),
-		Tracer:    tracing.WrapTracer(globals.TraceProvider, cn.globalID),
-		Clusterer: globals.Clusterer,
-
-		DataPath:       filepath.Join(globals.DataPath, cn.globalID),
+		ID:         cn.globalID,
+		Logger:     log.With(globals.Logger, "component", cn.globalID),
+		Registerer: cn.newRegisterer(),
+		Tracer:     tracing.WrapTracer(globals.TraceProvider, cn.globalID),
+
+		DataPath:       filepath.Join(globals.DataPath, dataPathID),
 		HTTPListenAddr: globals.HTTPListenAddr,
 		DialFunc:       globals.DialFunc,
-		HTTPPath:       path.Join(prefix, cn.globalID) + "/",
+		HTTPPath:       httpPath,
 
 		OnStateChange:    cn.setExports,
 		ModuleController: cn.moduleController,
+
+		GetServiceData: func(name string) (any, error) {
+			if !slices.Contains(cn.reg.NeedsServices, name) {
+				return nil, fmt.Errorf("component %q did not declare a dependency on service %q", cn.nodeID, name)
+			}
+			svc, found := globals.getService(name)
+			if !found {
+				return nil, fmt.Errorf("service %q does not exist", name)
+			}
+			return svc.Data(), nil
+		},
 	}
 }
 
+// newRegisterer replaces the registry which collects the metrics of the
+// managed component with a new, empty registry, and returns a Registerer for
+// it.
+func (cn *ComponentNode) newRegisterer() prometheus.Registerer {
+	registry := prometheus.NewRegistry()
+	cn.registry.Store(registry)
+	return prometheus.WrapRegistererWith(prometheus.Labels{
+		"component_id": cn.globalID,
+	}, registry)
+}
+
 func getExportsType(reg component.Registration) reflect.Type {
 	if reg.Exports != nil {
 		return reflect.TypeOf(reg.Exports)
@@ -223,8 +324,14 @@ func (cn *ComponentNode) Component() component.Component {
 }
 
 // ID returns the component ID of the managed component from its River block.
+// The ID of an instance of a for_each block has the key of the instance
+// appended to the ID of the block.
 func (cn *ComponentNode) ID() ComponentID { return cn.id }
 
+// IsInstance returns true if the component is an instance of a block which
+// uses the for_each meta-argument.
+func (cn *ComponentNode) IsInstance() bool { return cn.instance }
+
 // Label returns the label for the block or "" if none was specified.
 func (cn *ComponentNode) Label() string { return cn.label }
 
@@ -233,7 +340,8 @@ func (cn *ComponentNode) ComponentName() string { return cn.componentName }
 
 // NodeID implements dag.Node and returns the unique ID for this node. The
 // NodeID is the string representation of the component's ID from its River
-// block.
+// block. The NodeID of an instance of a for_each block has the key of the
+// instance appended in brackets, such as `local.file.example["key"]`.
 func (cn *ComponentNode) NodeID() string { return cn.nodeID }
 
 // UpdateBlock updates the River block used to construct arguments for the
@@ -243,14 +351,100 @@ func (cn *ComponentNode) NodeID() string { return cn.nodeID }
 // UpdateBlock will panic if the block does not match the component ID of the
 // ComponentNode.
 func (cn *ComponentNode) UpdateBlock(b *ast.BlockStmt) {
-	if !BlockComponentID(b).Equals(cn.id) {
+	if !BlockComponentID(b).Equals(cn.blockID) {
 		panic("UpdateBlock called with an River block with a different component ID")
 	}
 
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
+	cn.setBlock(b)
+}
+
+// setBlock sets the River block of the component, splitting off the restart
+// meta-block from the arguments of the managed component. cn.mut must be held
+// when calling setBlock.
+func (cn *ComponentNode) setBlock(b *ast.BlockStmt) {
+	body, restartBlock, err := splitRestartBlock(b.Body)
+
 	cn.block = b
-	cn.eval = vm.New(b.Body)
+	cn.eval = vm.New(body)
+	cn.restartErr = err
+	cn.restartEval = nil
+	if restartBlock != nil {
+		cn.restartEval = vm.New(restartBlock.Body)
+	}
+}
+
+// Each returns the value of the each variable exposed to the expressions of
+// the component. Each returns nil if the component isn't an instance of a
+// for_each block.
+func (cn *ComponentNode) Each() map[string]any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.each
+}
+
+// UpdateEach updates the value of the each variable exposed to the
+// expressions of an instance of a for_each block. The new value isn't used
+// until the next time Evaluate is invoked.
+func (cn *ComponentNode) UpdateEach(each map[string]any) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.each = each
+}
+
+// instanceScope returns the scope to evaluate the block of the component
+// with. Instances of for_each blocks expose the each variable in a child of
+// scope. cn.mut must be held when calling instanceScope.
+func (cn *ComponentNode) instanceScope(scope *vm.Scope) *vm.Scope {
+	if cn.each == nil {
+		return scope
+	}
+	return &vm.Scope{
+		Parent:    scope,
+		Variables: map[string]interface{}{eachVarName: cn.each},
+	}
+}
+
+// evaluateRestartOptions evaluates the restart block of the component. The
+// default restart options are returned if the component doesn't have a
+// restart block. cn.mut must be held when calling evaluateRestartOptions.
+func (cn *ComponentNode) evaluateRestartOptions(scope *vm.Scope) (restartOptions, error) {
+	if cn.restartErr != nil {
+		return restartOptions{}, cn.restartErr
+	}
+
+	opts := defaultRestartOptions
+	if cn.restartEval == nil {
+		return opts, nil
+	}
+	if err := cn.restartEval.Evaluate(scope, &opts); err != nil {
+		return restartOptions{}, fmt.Errorf("decoding %s block: %w", restartBlockName, err)
+	}
+	return opts, nil
+}
+
+// IsDeclared returns true if the component is an instance of a component
+// defined by a declare block.
+func (cn *ComponentNode) IsDeclared() bool {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.declare != nil
+}
+
+// UpdateDeclaration updates the declare block used by a declared component.
+// The new declaration isn't used until the next time Evaluate is invoked.
+//
+// UpdateDeclaration will panic if the ComponentNode is not a declared
+// component.
+func (cn *ComponentNode) UpdateDeclaration(decl *DeclareNode) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if cn.declare == nil {
+		panic("UpdateDeclaration called on a builtin component")
+	}
+	cn.declare.Set(decl.Block())
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed component
@@ -273,43 +467,19 @@ func (cn *ComponentNode) Evaluate(scope *vm.Scope) error {
 	return err
 }
 
-// Reevaluate calls Update on the managed component with its last used
-// arguments.Reevaluate does not build the component if it is not already built
-// and does not re-evaluate the River block itself.
-// Its only use case is for components opting-in to clustering where calling
-// Update with the same Arguments may result in different functionality.
-func (cn *ComponentNode) Reevaluate() error {
+func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
 
 	cn.doingEval.Store(true)
 	defer cn.doingEval.Store(false)
 
-	if cn.managed == nil {
-		// We haven't built the managed component successfully yet.
-		return nil
-	}
-
-	// Update the existing managed component with the same arguments.
-	err := cn.managed.Update(cn.args)
-
-	switch err {
-	case nil:
-		cn.setEvalHealth(component.HealthTypeHealthy, "component evaluated")
-		return nil
-	default:
-		msg := fmt.Sprintf("component evaluation failed: %s", err)
-		cn.setEvalHealth(component.HealthTypeUnhealthy, msg)
+	scope = cn.instanceScope(scope)
+	restart, err := cn.evaluateRestartOptions(scope)
+	if err != nil {
 		return err
 	}
-}
-
-func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
-	cn.mut.Lock()
-	defer cn.mut.Unlock()
-
-	cn.doingEval.Store(true)
-	defer cn.doingEval.Store(false)
+	cn.restart = restart
 
 	argsPointer := cn.reg.CloneArguments()
 	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
@@ -320,19 +490,24 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	// components expect a non-pointer.
 	argsCopyValue := reflect.ValueOf(argsPointer).Elem().Interface()
 
+	if cn.inflight != nil {
+		// A previous build or update is stuck. Calling into the managed
+		// component again would get stuck too, so the arguments are applied
+		// once the stuck call returns.
+		cn.pendingArgs = argsCopyValue
+		return fmt.Errorf("component is stuck; arguments will be applied once it responds")
+	}
+
 	if cn.managed == nil {
 		// We haven't built the managed component successfully yet.
-		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
-		if err != nil {
-			return fmt.Errorf("building component: %w", err)
-		}
-		cn.managed = managed
-		cn.args = argsCopyValue
-
-		return nil
+		return cn.build(argsCopyValue)
 	}
 
-	if reflect.DeepEqual(cn.args, argsCopyValue) {
+	// Declared components must also be updated when their declaration changed,
+	// even if the arguments are the same.
+	declarationChanged := cn.declare != nil && cn.declare.Changed()
+
+	if reflect.DeepEqual(cn.args, argsCopyValue) && !declarationChanged {
 		// Ignore components which haven't changed. This reduces the cost of
 		// calling evaluate for components where evaluation is expensive (e.g., if
 		// re-evaluating requires re-starting some internal logic).
@@ -340,44 +515,216 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := cn.managed.Update(argsCopyValue); err != nil {
+	return cn.update(argsCopyValue)
+}
+
+// build builds the managed component with args. Builds are labeled so that
+// goroutines started by the component are attributed to it. cn.mut must be
+// held when calling build.
+func (cn *ComponentNode) build(args component.Arguments) error {
+	var managed component.Component
+	err := cn.callManaged(stuckOperationBuild, func() error {
+		var err error
+		managed, err = cn.reg.Build(cn.managedOpts, args)
+		return err
+	}, func(err error) {
+		cn.finishStuck(args, managed, err)
+	})
+	if err != nil {
+		return fmt.Errorf("building component: %w", err)
+	}
+
+	cn.managed = managed
+	cn.args = args
+	return nil
+}
+
+// update updates the managed component with args. cn.mut must be held when
+// calling update.
+func (cn *ComponentNode) update(args component.Arguments) error {
+	managed := cn.managed
+	err := cn.callManaged(stuckOperationUpdate, func() error {
+		return managed.Update(args)
+	}, func(err error) {
+		cn.finishStuck(args, nil, err)
+	})
+	if err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
 
-	cn.args = argsCopyValue
+	cn.args = args
 	return nil
 }
 
+// finishStuck is called once a stuck build or update with args returns. built
+// is the managed component if the stuck call was a build. Arguments which
+// were evaluated while the component was stuck are applied afterwards.
+// cn.mut must be held when calling finishStuck.
+func (cn *ComponentNode) finishStuck(args component.Arguments, built component.Component, err error) {
+	if err == nil {
+		if built != nil {
+			cn.managed = built
+		}
+		cn.args = args
+	}
+
+	if pending := cn.pendingArgs; pending != nil {
+		cn.pendingArgs = nil
+
+		switch {
+		case cn.managed == nil:
+			err = cn.build(pending)
+		case !reflect.DeepEqual(cn.args, pending):
+			err = cn.update(pending)
+		}
+	}
+
+	if err != nil {
+		level.Error(cn.managedOpts.Logger).Log("msg", "failed to evaluate component after it was stuck", "err", err)
+		cn.setEvalHealth(component.HealthTypeUnhealthy, fmt.Sprintf("component evaluation failed: %s", err))
+		return
+	}
+	cn.setEvalHealth(component.HealthTypeHealthy, "component evaluated")
+}
+
+// validateArguments evaluates the River block of the component into its
+// arguments type without building or updating the managed component.
+func (cn *ComponentNode) validateArguments(scope *vm.Scope) (component.Arguments, error) {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	scope = cn.instanceScope(scope)
+	if _, err := cn.evaluateRestartOptions(scope); err != nil {
+		return nil, err
+	}
+
+	argsPointer := cn.reg.CloneArguments()
+	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
+		return nil, fmt.Errorf("decoding River: %w", err)
+	}
+	return reflect.ValueOf(argsPointer).Elem().Interface(), nil
+}
+
 // Run runs the managed component in the calling goroutine until ctx is
 // canceled. Evaluate must have been called at least once without retuning an
 // error before calling Run.
 //
+// If the managed component exits before ctx is canceled, it is restarted
+// according to the restart policy of the component, waiting with an
+// exponential backoff between consecutive restarts. Components aren't
+// expected to support being run more than once, so each restart builds a new
+// instance of the component from its most recent arguments.
+//
+// The managed component is run with the pprof label
+// profiling.LabelComponentID set to the global ID of the component, so that
+// goroutines and CPU samples of the component can be attributed to it.
+//
 // Run will immediately return ErrUnevaluated if Evaluate has never been called
-// successfully. Otherwise, Run will return nil.
+// successfully. Otherwise, Run will return the error of the last run of the
+// managed component.
 func (cn *ComponentNode) Run(ctx context.Context) error {
 	cn.mut.RLock()
-	managed := cn.managed
+	managed, inflight := cn.managed, cn.inflight
 	cn.mut.RUnlock()
 
+	if managed == nil && inflight != nil {
+		// The build of the component is stuck; wait for it to return.
+		select {
+		case <-ctx.Done():
+		case <-inflight:
+			cn.mut.RLock()
+			managed = cn.managed
+			cn.mut.RUnlock()
+		}
+	}
 	if managed == nil {
 		return ErrUnevaluated
 	}
 
-	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
-
-	var exitMsg string
 	logger := cn.managedOpts.Logger
-	if err != nil {
-		level.Error(logger).Log("msg", "component exited with error", "err", err)
-		exitMsg = fmt.Sprintf("component shut down with error: %s", err)
-	} else {
-		level.Info(logger).Log("msg", "component exited")
-		exitMsg = "component shut down normally"
+	attempt := 0
+
+	for restarting := false; ; restarting = true {
+		started := time.Now()
+
+		var err error
+		if restarting {
+			managed, err = cn.rebuild()
+		}
+		if err == nil {
+			cn.setRunHealth(component.HealthTypeHealthy, "started component")
+
+			runDone := make(chan struct{})
+			go cn.watchShutdown(ctx, runDone)
+			profiling.Do(ctx, cn.globalID, func(ctx context.Context) {
+				err = managed.Run(ctx)
+			})
+			close(runDone)
+		}
+
+		var exitMsg string
+		if err != nil {
+			level.Error(logger).Log("msg", "component exited with error", "err", err)
+			exitMsg = fmt.Sprintf("component shut down with error: %s", err)
+		} else {
+			level.Info(logger).Log("msg", "component exited")
+			exitMsg = "component shut down normally"
+		}
+
+		cn.mut.RLock()
+		opts := cn.restart
+		cn.mut.RUnlock()
+
+		if ctx.Err() != nil || !opts.shouldRestart(err) {
+			cn.setRunHealth(component.HealthTypeExited, exitMsg)
+			return err
+		}
+
+		// Reset the backoff if the component ran successfully for longer than
+		// the maximum backoff since it was last started.
+		if time.Since(started) > opts.MaxBackoff {
+			attempt = 0
+		}
+		attempt++
+		delay := opts.backoff(attempt)
+
+		level.Info(logger).Log("msg", "restarting component", "policy", opts.Policy, "backoff", delay)
+		cn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("%s; restarting in %s", exitMsg, delay))
+
+		select {
+		case <-ctx.Done():
+			cn.setRunHealth(component.HealthTypeExited, exitMsg)
+			return err
+		case <-time.After(delay):
+		}
+		cn.restarts.Inc()
 	}
+}
 
-	cn.setRunHealth(component.HealthTypeExited, exitMsg)
-	return err
+// rebuild replaces the managed component with a new instance built from the
+// most recent arguments, returning the new instance.
+func (cn *ComponentNode) rebuild() (component.Component, error) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if cn.inflight != nil {
+		return nil, fmt.Errorf("rebuilding component: component is stuck")
+	}
+
+	// Components may register their metrics when they're built. The new
+	// instance gets a new registry so that its metrics don't conflict with
+	// the ones registered by the previous instance.
+	cn.managedOpts.Registerer = cn.newRegisterer()
+	if err := cn.build(cn.args); err != nil {
+		return nil, err
+	}
+	return cn.managed, nil
+}
+
+// Restarts returns the number of times the managed component was restarted
+// after exiting.
+func (cn *ComponentNode) Restarts() int64 {
+	return cn.restarts.Load()
 }
 
 // ErrUnevaluated is returned if ComponentNode.Run is called before a managed
@@ -454,7 +801,8 @@ func (cn *ComponentNode) setExports(e component.Exports) {
 //
 //  1. Health from the call to Run().
 //  2. Health from the last call to Evaluate().
-//  3. Health reported from the component.
+//  3. Health reported from the component, or the stuck health if the
+//     component is stuck.
 func (cn *ComponentNode) CurrentHealth() component.Health {
 	cn.healthMut.RLock()
 	defer cn.healthMut.RUnlock()
@@ -464,16 +812,26 @@ func (cn *ComponentNode) CurrentHealth() component.Health {
 		evalHealth = cn.evalHealth
 	)
 
-	if hc, ok := cn.managed.(component.HealthComponent); ok {
-		componentHealth := hc.CurrentHealth()
-		return component.LeastHealthy(runHealth, evalHealth, componentHealth)
+	health := component.LeastHealthy(runHealth, evalHealth)
+	if info := cn.stuckState(); info != nil {
+		// Don't ask a stuck component for its health, since that may block as
+		// well.
+		health = component.LeastHealthy(runHealth, evalHealth, stuckHealth(info))
+	} else if hc, ok := cn.managed.(component.HealthComponent); ok {
+		health = component.LeastHealthy(runHealth, evalHealth, hc.CurrentHealth())
 	}
-
-	return component.LeastHealthy(runHealth, evalHealth)
+	health.Restarts = int(cn.restarts.Load())
+	return health
 }
 
 // DebugInfo returns debugging information from the managed component (if any).
+// If the managed component is stuck, DebugInfo returns the operation it is
+// stuck in and the stacks of its goroutines instead.
 func (cn *ComponentNode) DebugInfo() interface{} {
+	if info := cn.stuckState(); info != nil {
+		return stuckDebugInfo{Stuck: *info}
+	}
+
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
 
@@ -496,6 +854,14 @@ func (cn *ComponentNode) setEvalHealth(t component.HealthType, msg string) {
 	}
 }
 
+// lastEvaluationFailed returns true if the most recent call to Evaluate
+// returned an error.
+func (cn *ComponentNode) lastEvaluationFailed() bool {
+	cn.healthMut.RLock()
+	defer cn.healthMut.RUnlock()
+	return cn.evalHealth.Health == component.HealthTypeUnhealthy
+}
+
 // setRunHealth sets the internal health from a call to Run. See Health for
 // information on how overall health is calculated.
 func (cn *ComponentNode) setRunHealth(t component.HealthType, msg string) {
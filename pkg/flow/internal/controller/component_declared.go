@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/ast"
+)
+
+// declareTemplate tracks the declare block that a declared component was
+// instantiated from. Templates are updated in place when the declaration
+// changes so that running instances can load the new body.
+type declareTemplate struct {
+	mut     sync.Mutex
+	block   *ast.BlockStmt
+	changed bool // Whether block changed since the last call to load.
+}
+
+func newDeclareTemplate(block *ast.BlockStmt) *declareTemplate {
+	return &declareTemplate{block: block}
+}
+
+// Set updates the declare block used by the template. The template will only
+// be marked as changed if the body of the block changed in a way that
+// affects its meaning.
+func (t *declareTemplate) Set(block *ast.BlockStmt) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if !ast.Equal(t.block.Body, block.Body) {
+		t.changed = true
+	}
+	t.block = block
+}
+
+// Changed returns true if the template changed since the last call to load.
+func (t *declareTemplate) Changed() bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.changed
+}
+
+// load returns the current body of the template and marks it as unchanged.
+func (t *declareTemplate) load() ast.Body {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.changed = false
+	return t.block.Body
+}
+
+// declaredRegistration returns a component registration for instances of a
+// declare block. Arguments and exports of declared components are maps keyed
+// by the labels of the argument and export blocks in the declaration.
+func declaredRegistration(name string, tmpl *declareTemplate) component.Registration {
+	return component.Registration{
+		Name:    name,
+		Args:    map[string]any{},
+		Exports: map[string]any{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return newDeclaredComponent(opts, tmpl, args.(map[string]any))
+		},
+	}
+}
+
+// declaredComponent is the managed component for an instance of a declare
+// block. It runs the body of the declaration as a module.
+type declaredComponent struct {
+	opts   component.Options
+	tmpl   *declareTemplate
+	mod    component.Module
+	loader ModuleBodyLoader
+
+	mut    sync.RWMutex
+	health component.Health
+}
+
+var (
+	_ component.Component       = (*declaredComponent)(nil)
+	_ component.HealthComponent = (*declaredComponent)(nil)
+)
+
+func newDeclaredComponent(opts component.Options, tmpl *declareTemplate, args map[string]any) (*declaredComponent, error) {
+	mod, err := opts.ModuleController.NewModule("", func(exports map[string]any) {
+		opts.OnStateChange(exports)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loader, ok := mod.(ModuleBodyLoader)
+	if !ok {
+		releaseModule(mod)
+		return nil, fmt.Errorf("module of type %T cannot be used for declared components", mod)
+	}
+
+	c := &declaredComponent{
+		opts:   opts,
+		tmpl:   tmpl,
+		mod:    mod,
+		loader: loader,
+	}
+	if err := c.Update(args); err != nil {
+		// The component will be built again on the next evaluation, so the
+		// module must be released for its ID to be reusable.
+		releaseModule(mod)
+		return nil, err
+	}
+	return c, nil
+}
+
+// releaseModule releases the ID of a module which will never be run.
+func releaseModule(mod component.Module) {
+	// Modules only release their ID once Run exits, so running it with a
+	// canceled context releases the ID immediately.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mod.Run(ctx)
+}
+
+// Run implements component.Component.
+func (c *declaredComponent) Run(ctx context.Context) error {
+	c.mod.Run(ctx)
+	return nil
+}
+
+// Update implements component.Component. Update loads the current body of
+// the declaration into the module with args as the module arguments.
+func (c *declaredComponent) Update(args component.Arguments) error {
+	err := c.loader.LoadBody(c.tmpl.load(), args.(map[string]any))
+	if err != nil {
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to load declared component: %s", err),
+			UpdateTime: time.Now(),
+		})
+		return err
+	}
+
+	c.setHealth(component.Health{
+		Health:     component.HealthTypeHealthy,
+		Message:    "declared component loaded",
+		UpdateTime: time.Now(),
+	})
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *declaredComponent) CurrentHealth() component.Health {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.health
+}
+
+func (c *declaredComponent) setHealth(h component.Health) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.health = h
+}
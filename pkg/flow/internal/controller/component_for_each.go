@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// Names of the meta-arguments of component blocks. Meta-arguments are
+// handled by the Loader and are never passed to the managed component.
+const (
+	forEachAttrName = "for_each"
+	enabledAttrName = "enabled"
+
+	// eachVarName is the name of the variable which exposes the key and value
+	// of an instance to the expressions in a block with for_each.
+	eachVarName = "each"
+)
+
+// metaArguments holds the meta-argument attributes of a component block. A
+// field is nil when the meta-argument isn't set.
+type metaArguments struct {
+	forEach *ast.AttributeStmt
+	enabled *ast.AttributeStmt
+}
+
+// splitMetaArguments separates the meta-arguments from a component block. If
+// b has meta-arguments, a copy of b without them is returned; otherwise b is
+// returned as is.
+func splitMetaArguments(b *ast.BlockStmt) (*ast.BlockStmt, metaArguments, diag.Diagnostics) {
+	var (
+		diags diag.Diagnostics
+		meta  metaArguments
+		rest  = make(ast.Body, 0, len(b.Body))
+	)
+
+	for _, stmt := range b.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok {
+			rest = append(rest, stmt)
+			continue
+		}
+
+		var target **ast.AttributeStmt
+		switch attr.Name.Name {
+		case forEachAttrName:
+			target = &meta.forEach
+		case enabledAttrName:
+			target = &meta.enabled
+		default:
+			rest = append(rest, stmt)
+			continue
+		}
+
+		if *target != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("attribute %q may only be specified once", attr.Name.Name),
+				StartPos: ast.StartPos(attr).Position(),
+				EndPos:   ast.EndPos(attr).Position(),
+			})
+			continue
+		}
+		*target = attr
+	}
+
+	if meta.forEach == nil && meta.enabled == nil {
+		return b, meta, diags
+	}
+
+	stripped := *b
+	stripped.Body = rest
+	return &stripped, meta, diags
+}
+
+// componentInstance is a single instance of a component block which uses
+// for_each.
+type componentInstance struct {
+	Key  string         // Key of the instance.
+	Each map[string]any // Value of the each variable for the instance.
+}
+
+// expandMetaArguments evaluates the meta-arguments of a component block
+// against scope. It returns whether the block is enabled and, if the block
+// uses for_each, the instances to create for it.
+func expandMetaArguments(scope *vm.Scope, meta metaArguments) (enabled bool, instances []componentInstance, diags diag.Diagnostics) {
+	enabled = true
+	if meta.enabled != nil {
+		if err := vm.New(meta.enabled.Value).Evaluate(scope, &enabled); err != nil {
+			diags.Add(metaArgumentDiag(meta.enabled, err))
+			return false, nil, diags
+		}
+	}
+	if !enabled || meta.forEach == nil {
+		return enabled, nil, diags
+	}
+
+	var val any
+	if err := vm.New(meta.forEach.Value).Evaluate(scope, &val); err != nil {
+		diags.Add(metaArgumentDiag(meta.forEach, err))
+		return false, nil, diags
+	}
+
+	instances, err := forEachInstances(val)
+	if err != nil {
+		diags.Add(metaArgumentDiag(meta.forEach, err))
+		return false, nil, diags
+	}
+	return true, instances, diags
+}
+
+// forEachInstances converts the value of a for_each attribute into a list of
+// instances. Arrays must only contain unique, non-empty strings, which are
+// used as both the key and the value of each instance. Objects create an
+// instance for each of their keys, sorted by key. Keys are validated with
+// validateInstanceKey.
+func forEachInstances(val any) ([]componentInstance, error) {
+	switch val := val.(type) {
+	case []any:
+		var (
+			instances = make([]componentInstance, 0, len(val))
+			seen      = make(map[string]struct{}, len(val))
+		)
+		for i, elem := range val {
+			key, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("element %d of the array must be a string", i)
+			} else if err := validateInstanceKey(key); err != nil {
+				return nil, fmt.Errorf("element %d of the array %w", i, err)
+			}
+			if _, dup := seen[key]; dup {
+				return nil, fmt.Errorf("array contains %q more than once", key)
+			}
+			seen[key] = struct{}{}
+
+			instances = append(instances, componentInstance{
+				Key:  key,
+				Each: map[string]any{"key": key, "value": key},
+			})
+		}
+		return instances, nil
+
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			if err := validateInstanceKey(key); err != nil {
+				return nil, fmt.Errorf("object key %q %w", key, err)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		instances := make([]componentInstance, 0, len(keys))
+		for _, key := range keys {
+			instances = append(instances, componentInstance{
+				Key:  key,
+				Each: map[string]any{"key": key, "value": val[key]},
+			})
+		}
+		return instances, nil
+
+	default:
+		return nil, fmt.Errorf("value must be an array of strings or an object")
+	}
+}
+
+// validateInstanceKey returns an error if key can't be used as the key of an
+// instance. Keys are part of the data path of instances, so they must not be
+// empty or contain path separators or "..".
+func validateInstanceKey(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("must not be empty")
+	case strings.ContainsAny(key, `/\`):
+		return fmt.Errorf("must not contain path separators")
+	case strings.Contains(key, ".."):
+		return fmt.Errorf(`must not contain ".."`)
+	}
+	return nil
+}
+
+func metaArgumentDiag(attr *ast.AttributeStmt, err error) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		Message:  fmt.Sprintf("failed to evaluate %s: %s", attr.Name.Name, err),
+		StartPos: ast.StartPos(attr).Position(),
+		EndPos:   ast.EndPos(attr).Position(),
+	}
+}
+
+// instanceNodeID returns the node ID of the instance of a component block
+// with the given key, such as `prometheus.exporter.redis.cache["a"]`.
+func instanceNodeID(blockID ComponentID, key string) string {
+	return fmt.Sprintf("%s[%q]", blockID, key)
+}
+
+// ForEachNode is a node for a component block which uses the for_each
+// meta-argument. It groups the instances created for the block, so that
+// references to the block can use the instances as an object keyed by the
+// instance keys. ForEachNode depends on every instance of the block.
+type ForEachNode struct {
+	id          ComponentID
+	nodeID      string
+	instanceIDs []string // Node IDs of the instances of the block.
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River block, including meta-arguments
+}
+
+var _ BlockNode = (*ForEachNode)(nil)
+
+// NewForEachNode creates a new ForEachNode for a block with the given
+// instances.
+func NewForEachNode(block *ast.BlockStmt, instanceIDs []string) *ForEachNode {
+	id := BlockComponentID(block)
+	return &ForEachNode{
+		id:          id,
+		nodeID:      id.String(),
+		instanceIDs: instanceIDs,
+
+		block: block,
+	}
+}
+
+// Evaluate implements BlockNode. It is a no-op since each instance of the
+// block is evaluated separately.
+func (cn *ForEachNode) Evaluate(scope *vm.Scope) error {
+	return nil
+}
+
+// ID returns the component ID of the block.
+func (cn *ForEachNode) ID() ComponentID { return cn.id }
+
+// InstanceIDs returns the node IDs of the instances of the block.
+func (cn *ForEachNode) InstanceIDs() []string { return cn.instanceIDs }
+
+// Block implements BlockNode and returns the current block of the node.
+func (cn *ForEachNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the node.
+func (cn *ForEachNode) NodeID() string { return cn.nodeID }
+
+// graphForEachIDs returns the IDs of the blocks in g which use for_each.
+func graphForEachIDs(g *dag.Graph) []ComponentID {
+	var ids []ComponentID
+	for _, n := range g.Nodes() {
+		if fe, ok := n.(*ForEachNode); ok {
+			ids = append(ids, fe.ID())
+		}
+	}
+	return ids
+}
@@ -33,11 +33,32 @@ func ComponentReferences(cn dag.Node, g *dag.Graph) ([]Reference, diag.Diagnosti
 		diags diag.Diagnostics
 	)
 
+	// Instances of blocks with for_each can reference the each variable, which
+	// isn't a node in the graph.
+	var isInstance bool
+
 	switch cn := cn.(type) {
+	case *DeclareNode:
+		// Expressions inside of declare blocks are evaluated by the modules of
+		// the declared component's instances, so they never reference nodes in
+		// this graph.
+	case *FunctionConfigNode:
+		// Functions may only reference their parameters and other functions,
+		// which are resolved when the function is called.
+	case *ImportConfigNode:
+		// Import blocks are evaluated before the graph is built, so they never
+		// reference nodes in the graph.
+	case *ForEachNode:
+		// The dependencies of blocks with for_each are wired to their instances
+		// by the Loader. Meta-arguments are evaluated before the graph is built,
+		// so they never reference nodes in the graph.
 	case BlockNode:
 		if cn.Block() != nil {
 			traversals = expressionsFromBody(cn.Block().Body)
 		}
+		if c, ok := cn.(*ComponentNode); ok {
+			isInstance = c.IsInstance()
+		}
 	}
 
 	refs := make([]Reference, 0, len(traversals))
@@ -51,10 +72,33 @@ func ComponentReferences(cn dag.Node, g *dag.Graph) ([]Reference, diag.Diagnosti
 		if _, ok := emptyScope.Lookup(t[0].Name); ok {
 			continue
 		}
+		if isInstance && t[0].Name == eachVarName {
+			continue
+		}
+
+		// Calls to functions defined in the file depend on the function block.
+		if fn, ok := g.GetByID(functionBlockID + "." + t[0].Name).(*FunctionConfigNode); ok {
+			refs = append(refs, Reference{Target: fn, Traversal: t[1:]})
+			continue
+		}
+
+		// Calls to functions of imported files depend on the import block.
+		if len(t) > 1 {
+			if imp := importNode(g, t[0].Name); imp != nil && imp.hasFunction(t[1].Name) {
+				refs = append(refs, Reference{Target: imp, Traversal: t[2:]})
+				continue
+			}
+		}
 
 		ref, resolveDiags := resolveTraversal(t, g)
-		diags = append(diags, resolveDiags...)
 		if resolveDiags.HasErrors() {
+			// The functions of imported files which were never loaded, such as
+			// during validation, are unknown; assume the traversal calls one.
+			if imp := importNode(g, t[0].Name); imp != nil && !imp.loaded() {
+				refs = append(refs, Reference{Target: imp, Traversal: t[1:]})
+				continue
+			}
+			diags = append(diags, resolveDiags...)
 			continue
 		}
 		refs = append(refs, ref)
@@ -139,6 +183,15 @@ func resolveTraversal(t Traversal, g *dag.Graph) (Reference, diag.Diagnostics) {
 
 	for {
 		if n := g.GetByID(partial.String()); n != nil {
+			if _, isService := n.(*ServiceNode); isService {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("service %q cannot be referenced", partial),
+					StartPos: ast.StartPos(t[0]).Position(),
+					EndPos:   ast.StartPos(t[len(t)-1]).Position(),
+				})
+				return Reference{}, diags
+			}
 			return Reference{
 				Target:    n.(BlockNode),
 				Traversal: rem,
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/ast"
+)
+
+// restartBlockName is the name of the meta-block inside of a component block
+// which configures how the component is restarted after it exits.
+const restartBlockName = "restart"
+
+// RestartPolicy determines whether a component is restarted after its Run
+// method returns.
+type RestartPolicy string
+
+// Supported restart policies.
+const (
+	RestartNever     RestartPolicy = "never"      // Never restart the component.
+	RestartOnFailure RestartPolicy = "on_failure" // Restart the component when it exits with an error.
+	RestartAlways    RestartPolicy = "always"     // Restart the component whenever it exits.
+)
+
+// restartOptions holds the settings of a restart meta-block.
+type restartOptions struct {
+	Policy     RestartPolicy `river:"policy,attr,optional"`
+	MinBackoff time.Duration `river:"min_backoff,attr,optional"`
+	MaxBackoff time.Duration `river:"max_backoff,attr,optional"`
+}
+
+// defaultRestartOptions are used for components which don't have a restart
+// block. Components are never restarted by default to preserve the behavior
+// of the scheduler.
+var defaultRestartOptions = restartOptions{
+	Policy:     RestartNever,
+	MinBackoff: time.Second,
+	MaxBackoff: 5 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *restartOptions) SetToDefault() {
+	*o = defaultRestartOptions
+}
+
+// Validate implements river.Validator.
+func (o *restartOptions) Validate() error {
+	switch o.Policy {
+	case RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("unknown restart policy %q, expected one of %q, %q, or %q", o.Policy, RestartNever, RestartOnFailure, RestartAlways)
+	}
+
+	if o.MinBackoff <= 0 {
+		return fmt.Errorf("min_backoff must be greater than zero")
+	}
+	if o.MaxBackoff < o.MinBackoff {
+		return fmt.Errorf("max_backoff must be greater than or equal to min_backoff")
+	}
+	return nil
+}
+
+// shouldRestart reports whether a component which exited with err should be
+// restarted.
+func (o restartOptions) shouldRestart(err error) bool {
+	switch o.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// backoff returns how long to wait before the given attempt of restarting a
+// component. attempt starts at 1 and the returned duration doubles with each
+// attempt until it reaches MaxBackoff.
+func (o restartOptions) backoff(attempt int) time.Duration {
+	delay := o.MinBackoff
+	for i := 1; i < attempt && delay < o.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+	return delay
+}
+
+// splitRestartBlock separates the restart meta-block from the rest of a
+// component's body. The returned body must be used for decoding the
+// component's arguments. The returned block is nil if body doesn't contain a
+// restart block.
+//
+// An error is returned if body contains more than one restart block.
+func splitRestartBlock(body ast.Body) (ast.Body, *ast.BlockStmt, error) {
+	var (
+		rest    = make(ast.Body, 0, len(body))
+		restart *ast.BlockStmt
+	)
+	for _, stmt := range body {
+		if !isRestartBlock(stmt) {
+			rest = append(rest, stmt)
+			continue
+		}
+		if restart != nil {
+			return body, nil, fmt.Errorf("block %q may only be specified once", restartBlockName)
+		}
+		restart = stmt.(*ast.BlockStmt)
+	}
+	return rest, restart, nil
+}
+
+func isRestartBlock(stmt ast.Stmt) bool {
+	b, ok := stmt.(*ast.BlockStmt)
+	return ok && b.Label == "" && b.GetBlockName() == restartBlockName
+}
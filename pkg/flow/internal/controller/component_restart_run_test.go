@@ -0,0 +1,175 @@
+package controller_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestComponentNode_RunRestart(t *testing.T) {
+	t.Run("Never restarts by default", func(t *testing.T) {
+		cn := loadExitComponent(t, `
+			testcomponents.exit "test" {
+				fail = true
+			}
+		`)
+
+		require.EqualError(t, cn.Run(context.Background()), "exit requested")
+		require.Equal(t, int64(0), cn.Restarts())
+		require.Equal(t, component.HealthTypeExited, cn.CurrentHealth().Health)
+	})
+
+	t.Run("Doesn't restart normal exits on failure", func(t *testing.T) {
+		cn := loadExitComponent(t, `
+			testcomponents.exit "test" {
+				restart {
+					policy = "on_failure"
+				}
+			}
+		`)
+
+		require.NoError(t, cn.Run(context.Background()))
+		require.Equal(t, int64(0), cn.Restarts())
+	})
+
+	t.Run("Restarts with a new instance", func(t *testing.T) {
+		cn := loadExitComponent(t, `
+			testcomponents.exit "test" {
+				fail = true
+
+				restart {
+					policy      = "on_failure"
+					min_backoff = "1ms"
+					max_backoff = "10ms"
+				}
+			}
+		`)
+		first := cn.Component()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		runDone := make(chan error, 1)
+		go func() { runDone <- cn.Run(ctx) }()
+
+		require.Eventually(t, func() bool { return cn.Restarts() >= 3 }, 5*time.Second, time.Millisecond)
+		cancel()
+		require.EqualError(t, <-runDone, "exit requested")
+
+		// Every instance of the component must only have been run once.
+		require.NotSame(t, first, cn.Component())
+		require.LessOrEqual(t, cn.Exports().(testcomponents.ExitExports).Runs, 1)
+
+		health := cn.CurrentHealth()
+		require.Equal(t, component.HealthTypeExited, health.Health)
+		require.Equal(t, int(cn.Restarts()), health.Restarts)
+	})
+
+	t.Run("Restarts components which register metrics", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		cn := loadExitComponentWithRegisterer(t, reg, `
+			testcomponents.exit "test" {
+				fail = true
+
+				restart {
+					policy      = "on_failure"
+					min_backoff = "1ms"
+					max_backoff = "10ms"
+				}
+			}
+		`)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		runDone := make(chan error, 1)
+		go func() { runDone <- cn.Run(ctx) }()
+
+		require.Eventually(t, func() bool { return cn.Restarts() >= 3 }, 5*time.Second, time.Millisecond)
+		cancel()
+		require.EqualError(t, <-runDone, "exit requested")
+
+		// Only the metrics of the current instance are collected.
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		var runsTotal *dto.MetricFamily
+		for _, mf := range mfs {
+			if mf.GetName() == "testcomponents_exit_runs_total" {
+				runsTotal = mf
+			}
+		}
+		require.NotNil(t, runsTotal)
+		require.Len(t, runsTotal.GetMetric(), 1)
+		require.LessOrEqual(t, runsTotal.GetMetric()[0].GetCounter().GetValue(), float64(1))
+	})
+
+	t.Run("Stops restarting when canceled", func(t *testing.T) {
+		cn := loadExitComponent(t, `
+			testcomponents.exit "test" {
+				restart {
+					policy      = "always"
+					min_backoff = "1h"
+					max_backoff = "1h"
+				}
+			}
+		`)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runDone := make(chan error, 1)
+		go func() { runDone <- cn.Run(ctx) }()
+
+		require.Eventually(t, func() bool {
+			return cn.CurrentHealth().Health == component.HealthTypeUnhealthy
+		}, 5*time.Second, time.Millisecond)
+		cancel()
+
+		require.NoError(t, <-runDone)
+		require.Equal(t, int64(0), cn.Restarts())
+	})
+}
+
+// loadExitComponent applies file to a new loader and returns the node of the
+// testcomponents.exit "test" component.
+func loadExitComponent(t *testing.T, file string) *controller.ComponentNode {
+	t.Helper()
+	return loadExitComponentWithRegisterer(t, prometheus.NewRegistry(), file)
+}
+
+// loadExitComponentWithRegisterer is like loadExitComponent, but registers
+// the metrics of the loader, including the metrics of components, to reg.
+func loadExitComponentWithRegisterer(t *testing.T, reg prometheus.Registerer, file string) *controller.ComponentNode {
+	t.Helper()
+
+	l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	loader := controller.NewLoader(controller.ComponentGlobals{
+		Logger:            l,
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		DataPath:          t.TempDir(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+		Registerer:        reg,
+		NewModuleController: func(id string) controller.ModuleController {
+			return nil
+		},
+	})
+	diags := applyFromContent(t, loader, []byte(file), nil)
+	require.NoError(t, diags.ErrorOrNil())
+
+	for _, cn := range loader.Components() {
+		if cn.NodeID() == "testcomponents.exit.test" {
+			return cn
+		}
+	}
+	require.FailNow(t, "component testcomponents.exit.test not found")
+	return nil
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartOptions_Backoff(t *testing.T) {
+	opts := restartOptions{
+		Policy:     RestartOnFailure,
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	}
+
+	expect := []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	}
+	for i, e := range expect {
+		require.Equal(t, e, opts.backoff(i+1), "unexpected backoff for attempt %d", i+1)
+	}
+}
+
+func TestRestartOptions_ShouldRestart(t *testing.T) {
+	failure := errors.New("failed")
+
+	tt := []struct {
+		policy        RestartPolicy
+		err           error
+		expectRestart bool
+	}{
+		{RestartNever, nil, false},
+		{RestartNever, failure, false},
+		{RestartOnFailure, nil, false},
+		{RestartOnFailure, failure, true},
+		{RestartAlways, nil, true},
+		{RestartAlways, failure, true},
+	}
+
+	for _, tc := range tt {
+		opts := restartOptions{Policy: tc.policy}
+		require.Equal(t, tc.expectRestart, opts.shouldRestart(tc.err), "policy %s, error %v", tc.policy, tc.err)
+	}
+}
+
+func TestRestartOptions_Decode(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		opts := decodeRestartBlock(t, `policy = "on_failure"`)
+		require.Equal(t, RestartOnFailure, opts.Policy)
+		require.Equal(t, defaultRestartOptions.MinBackoff, opts.MinBackoff)
+		require.Equal(t, defaultRestartOptions.MaxBackoff, opts.MaxBackoff)
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		var opts restartOptions
+		err := evalRestartBlock(t, `policy = "sometimes"`, &opts)
+		require.ErrorContains(t, err, `unknown restart policy "sometimes"`)
+	})
+
+	t.Run("Invalid backoff", func(t *testing.T) {
+		var opts restartOptions
+		err := evalRestartBlock(t, `
+			policy      = "always"
+			min_backoff = "1m"
+			max_backoff = "10s"
+		`, &opts)
+		require.ErrorContains(t, err, "max_backoff must be greater than or equal to min_backoff")
+	})
+}
+
+func decodeRestartBlock(t *testing.T, body string) restartOptions {
+	t.Helper()
+
+	var opts restartOptions
+	require.NoError(t, evalRestartBlock(t, body, &opts))
+	return opts
+}
+
+func evalRestartBlock(t *testing.T, body string, opts *restartOptions) error {
+	t.Helper()
+
+	file, err := parser.ParseFile(t.Name(), []byte(body))
+	require.NoError(t, err)
+	return vm.New(file.Body).Evaluate(nil, opts)
+}
+
+func TestSplitRestartBlock(t *testing.T) {
+	t.Run("Without restart block", func(t *testing.T) {
+		body := parseComponentBody(t, `
+			value = 5
+			inner { }
+		`)
+
+		rest, restart, err := splitRestartBlock(body)
+		require.NoError(t, err)
+		require.Nil(t, restart)
+		require.Len(t, rest, 2)
+	})
+
+	t.Run("With restart block", func(t *testing.T) {
+		body := parseComponentBody(t, `
+			value = 5
+			restart {
+				policy = "always"
+			}
+			inner { }
+		`)
+
+		rest, restart, err := splitRestartBlock(body)
+		require.NoError(t, err)
+		require.NotNil(t, restart)
+		require.Len(t, rest, 2)
+		for _, stmt := range rest {
+			require.False(t, isRestartBlock(stmt))
+		}
+	})
+
+	t.Run("Duplicate restart blocks", func(t *testing.T) {
+		body := parseComponentBody(t, `
+			restart { }
+			restart { }
+		`)
+
+		_, _, err := splitRestartBlock(body)
+		require.EqualError(t, err, `block "restart" may only be specified once`)
+	})
+}
+
+func parseComponentBody(t *testing.T, body string) ast.Body {
+	t.Helper()
+
+	file, err := parser.ParseFile(t.Name(), []byte(body))
+	require.NoError(t, err)
+	return file.Body
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/profiling"
+)
+
+// Operations of a managed component which are watched for being stuck.
+const (
+	stuckOperationBuild    = "build"
+	stuckOperationUpdate   = "update"
+	stuckOperationShutdown = "shutdown"
+)
+
+// stuckInfo describes a managed component which didn't respond in time.
+type stuckInfo struct {
+	Operation string    `river:"operation,attr"`
+	Since     time.Time `river:"since,attr"`
+	Stacks    string    `river:"stacks,attr,optional"`
+}
+
+// stuckDebugInfo is returned as the debug info of stuck components. The
+// debug info of the managed component isn't retrieved while it is stuck,
+// since retrieving it may block as well.
+type stuckDebugInfo struct {
+	Stuck stuckInfo `river:"stuck,block"`
+}
+
+// callManaged calls f, which invokes the build or update operation op of the
+// managed component, in a new goroutine. If f doesn't return within the
+// stuck timeout, the component is marked as stuck and callManaged returns an
+// error without waiting for f. onLate is invoked with cn.mut held once f
+// eventually returns.
+//
+// The goroutine calling a stuck f can't be stopped and lives until f
+// returns. At most one such goroutine exists per component: while a call is
+// stuck, evaluations only record their arguments in cn.pendingArgs, and
+// onLate applies them once the stuck call returned. callManaged returns an
+// error if it's called while a previous call is still stuck.
+//
+// Builds and updates are called directly when the stuck timeout is zero.
+// cn.mut must be held when calling callManaged.
+func (cn *ComponentNode) callManaged(op string, f func() error, onLate func(err error)) error {
+	if cn.inflight != nil {
+		return fmt.Errorf("%s of the component can't be called while a previous call is stuck", op)
+	}
+
+	var err error
+	if cn.stuckTimeout <= 0 {
+		profiling.Do(context.Background(), cn.globalID, func(context.Context) {
+			err = f()
+		})
+		return err
+	}
+
+	result := make(chan error, 1)
+	go profiling.Do(context.Background(), cn.globalID, func(context.Context) {
+		result <- f()
+	})
+
+	timer := time.NewTimer(cn.stuckTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+	}
+
+	done := make(chan struct{})
+	cn.inflight = done
+	cn.markStuck(op)
+
+	go func() {
+		err := <-result
+
+		cn.mut.Lock()
+		cn.inflight = nil
+		cn.clearStuck()
+		onLate(err)
+		cn.mut.Unlock()
+
+		close(done)
+	}()
+
+	return fmt.Errorf("%s of the component did not return within %s; the component is stuck", op, cn.stuckTimeout)
+}
+
+// markStuck marks the component as stuck in the operation op and records the
+// stacks of its goroutines.
+func (cn *ComponentNode) markStuck(op string) {
+	stacks, err := profiling.Stacks(cn.globalID)
+	if err != nil {
+		stacks = fmt.Sprintf("failed to collect stacks: %s", err)
+	}
+
+	level.Warn(cn.managedOpts.Logger).Log("msg", "component is stuck", "operation", op, "timeout", cn.stuckTimeout)
+
+	cn.stuckMut.Lock()
+	defer cn.stuckMut.Unlock()
+	cn.stuck = &stuckInfo{
+		Operation: op,
+		Since:     time.Now(),
+		Stacks:    stacks,
+	}
+	cn.stuckCount.Inc()
+}
+
+// clearStuck marks the component as no longer stuck.
+func (cn *ComponentNode) clearStuck() {
+	cn.stuckMut.Lock()
+	defer cn.stuckMut.Unlock()
+
+	if cn.stuck != nil {
+		level.Info(cn.managedOpts.Logger).Log("msg", "component is no longer stuck", "operation", cn.stuck.Operation, "duration", time.Since(cn.stuck.Since))
+	}
+	cn.stuck = nil
+}
+
+// stuckState returns information about the operation the component is stuck
+// in, or nil if the component isn't stuck.
+func (cn *ComponentNode) stuckState() *stuckInfo {
+	cn.stuckMut.RLock()
+	defer cn.stuckMut.RUnlock()
+	return cn.stuck
+}
+
+// StuckCount returns the number of times the component was detected as
+// stuck.
+func (cn *ComponentNode) StuckCount() int64 {
+	return cn.stuckCount.Load()
+}
+
+// stuckHealth returns the health of a component stuck in info.
+func stuckHealth(info *stuckInfo) component.Health {
+	return component.Health{
+		Health:     component.HealthTypeStuck,
+		Message:    fmt.Sprintf("component is stuck: %s did not return since %s", info.Operation, info.Since.Format(time.RFC3339)),
+		UpdateTime: info.Since,
+	}
+}
+
+// watchShutdown marks the component as stuck if its Run method doesn't
+// return within the stuck timeout after ctx is canceled. runDone must be
+// closed once Run returns.
+func (cn *ComponentNode) watchShutdown(ctx context.Context, runDone <-chan struct{}) {
+	if cn.stuckTimeout <= 0 {
+		return
+	}
+
+	select {
+	case <-runDone:
+		return
+	case <-ctx.Done():
+	}
+
+	timer := time.NewTimer(cn.stuckTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-runDone:
+		return
+	case <-timer.C:
+	}
+
+	cn.markStuck(stuckOperationShutdown)
+	<-runDone
+	cn.clearStuck()
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentNode_StuckUpdate(t *testing.T) {
+	var (
+		release = make(chan struct{})
+
+		mut     sync.Mutex
+		applied []int
+	)
+	cn := newStuckTestNode(t, func(args stuckTestArgs) error {
+		if args.Value == 1 {
+			<-release
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		applied = append(applied, args.Value)
+		return nil
+	})
+
+	// The update blocks, so evaluation returns once the timeout elapses and the
+	// component is reported as stuck.
+	cn.UpdateBlock(parseStuckTestBlock(t, `value = 1`))
+	require.ErrorContains(t, cn.Evaluate(nil), "update of the component did not return within 10ms; the component is stuck")
+	require.Equal(t, component.HealthTypeStuck, cn.CurrentHealth().Health)
+	require.Equal(t, int64(1), cn.StuckCount())
+
+	info, ok := cn.DebugInfo().(stuckDebugInfo)
+	require.True(t, ok, "expected debug info of a stuck component")
+	require.Equal(t, stuckOperationUpdate, info.Stuck.Operation)
+
+	// Evaluating a stuck component doesn't call into it again.
+	cn.UpdateBlock(parseStuckTestBlock(t, `value = 2`))
+	require.EqualError(t, cn.Evaluate(nil), "component is stuck; arguments will be applied once it responds")
+
+	// Once the stuck update returns, the pending arguments are applied.
+	close(release)
+	require.Eventually(t, func() bool {
+		return cn.CurrentHealth().Health == component.HealthTypeHealthy
+	}, time.Second, 10*time.Millisecond)
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Equal(t, []int{1, 2}, applied)
+	require.Equal(t, stuckTestArgs{Value: 2}, cn.Arguments())
+}
+
+func TestComponentNode_StuckShutdown(t *testing.T) {
+	release := make(chan struct{})
+	cn := newRunTestNode(func(ctx context.Context) error {
+		<-ctx.Done()
+		<-release // Ignore cancellation until released.
+		return nil
+	})
+	cn.stuckTimeout = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		_ = cn.Run(ctx)
+	}()
+	cancel()
+
+	require.Eventually(t, func() bool {
+		return cn.CurrentHealth().Health == component.HealthTypeStuck
+	}, time.Second, 10*time.Millisecond)
+
+	close(release)
+	<-exited
+	require.Equal(t, component.HealthTypeExited, cn.CurrentHealth().Health)
+	require.Equal(t, int64(1), cn.StuckCount())
+}
+
+type stuckTestArgs struct {
+	Value int `river:"value,attr"`
+}
+
+func newStuckTestNode(t *testing.T, update func(args stuckTestArgs) error) *ComponentNode {
+	t.Helper()
+
+	cn := &ComponentNode{
+		id:           ComponentID{"test", "stuck"},
+		globalID:     "test.stuck",
+		reg:          component.Registration{Args: stuckTestArgs{}},
+		managedOpts:  component.Options{Logger: log.NewNopLogger()},
+		managed:      stuckTestComponent{update: update},
+		args:         stuckTestArgs{},
+		restart:      defaultRestartOptions,
+		stuckTimeout: 10 * time.Millisecond,
+
+		evalHealth: component.Health{Health: component.HealthTypeHealthy},
+		runHealth:  component.Health{Health: component.HealthTypeHealthy},
+	}
+	cn.setBlock(parseStuckTestBlock(t, `value = 0`))
+	return cn
+}
+
+func parseStuckTestBlock(t *testing.T, body string) *ast.BlockStmt {
+	t.Helper()
+
+	file, err := parser.ParseFile(t.Name(), []byte("test.stuck {\n"+body+"\n}"))
+	require.NoError(t, err)
+	return file.Body[0].(*ast.BlockStmt)
+}
+
+type stuckTestComponent struct {
+	update func(args stuckTestArgs) error
+}
+
+func (c stuckTestComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (c stuckTestComponent) Update(args component.Arguments) error {
+	return c.update(args.(stuckTestArgs))
+}
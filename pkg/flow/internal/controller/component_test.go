@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"context"
 	"path/filepath"
+	"runtime/pprof"
 	"testing"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/profiling"
 	"github.com/stretchr/testify/require"
 )
 
@@ -38,3 +43,50 @@ func TestLocalID(t *testing.T) {
 	require.Equal(t, "/http/local.id/", filepath.ToSlash(mo.HTTPPath))
 	require.Equal(t, "/data/local.id", filepath.ToSlash(mo.DataPath))
 }
+
+func TestInstanceID(t *testing.T) {
+	mo := getManagedOptions(ComponentGlobals{
+		DataPath:       "/data/",
+		HTTPPathPrefix: "/http/",
+		ControllerID:   "module.file",
+		NewModuleController: func(id string) ModuleController {
+			return nil
+		},
+	}, &ComponentNode{
+		id:       ComponentID{"local", "id", "a b"},
+		instance: true,
+		nodeID:   `local.id["a b"]`,
+		globalID: `module.file/local.id["a b"]`,
+	})
+	require.Equal(t, "/http/module.file/local.id%5B%22a%20b%22%5D/", filepath.ToSlash(mo.HTTPPath))
+	require.Equal(t, "/data/module.file/local.id.a b", filepath.ToSlash(mo.DataPath))
+}
+
+func TestComponentNode_RunProfilingLabels(t *testing.T) {
+	var label string
+	cn := newRunTestNode(func(ctx context.Context) error {
+		label, _ = pprof.Label(ctx, profiling.LabelComponentID)
+		return nil
+	})
+	cn.globalID = "module.file/local.id"
+
+	require.NoError(t, cn.Run(context.Background()))
+	require.Equal(t, "module.file/local.id", label)
+}
+
+// newRunTestNode returns a node which runs a component calling run. The node
+// never restarts the component.
+func newRunTestNode(run func(ctx context.Context) error) *ComponentNode {
+	return &ComponentNode{
+		managedOpts: component.Options{Logger: log.NewNopLogger()},
+		managed:     runTestComponent{run: run},
+		restart:     defaultRestartOptions,
+	}
+}
+
+type runTestComponent struct {
+	run func(ctx context.Context) error
+}
+
+func (c runTestComponent) Run(ctx context.Context) error         { return c.run(ctx) }
+func (c runTestComponent) Update(args component.Arguments) error { return nil }
@@ -3,13 +3,20 @@ package controller
 import (
 	"fmt"
 
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/importsource"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/scanner"
+	"github.com/grafana/agent/pkg/river/vm"
 )
 
 const (
 	argumentBlockID = "argument"
+	declareBlockID  = "declare"
 	exportBlockID   = "export"
+	functionBlockID = "function"
+	importBlockID   = "import" // Namespace of the import.file, import.git, and import.http blocks
 	loggingBlockID  = "logging"
 	tracingBlockID  = "tracing"
 )
@@ -20,13 +27,21 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals) (BlockNode, d
 	switch block.GetBlockName() {
 	case argumentBlockID:
 		return NewArgumentConfigNode(block, globals), nil
+	case declareBlockID:
+		return NewDeclareNode(block), nil
 	case exportBlockID:
 		return NewExportConfigNode(block, globals), nil
+	case functionBlockID:
+		return NewFunctionConfigNode(block, globals)
 	case loggingBlockID:
 		return NewLoggingConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
 	default:
+		if sourceType, ok := importsource.GetSourceType(block.GetBlockName()); ok {
+			return NewImportConfigNode(block, globals, sourceType), nil
+		}
+
 		var diags diag.Diagnostics
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -46,6 +61,9 @@ type ConfigNodeMap struct {
 	tracing     *TracingConfigNode
 	argumentMap map[string]*ArgumentConfigNode
 	exportMap   map[string]*ExportConfigNode
+	declareMap  map[string]*DeclareNode
+	functionMap map[string]*FunctionConfigNode
+	importMap   map[string]*ImportConfigNode
 }
 
 // NewConfigNodeMap will create an initial ConfigNodeMap. Append must be called
@@ -56,6 +74,9 @@ func NewConfigNodeMap() *ConfigNodeMap {
 		tracing:     nil,
 		argumentMap: map[string]*ArgumentConfigNode{},
 		exportMap:   map[string]*ExportConfigNode{},
+		declareMap:  map[string]*DeclareNode{},
+		functionMap: map[string]*FunctionConfigNode{},
+		importMap:   map[string]*ImportConfigNode{},
 	}
 }
 
@@ -69,6 +90,21 @@ func (nodeMap *ConfigNodeMap) Append(configNode BlockNode) diag.Diagnostics {
 		nodeMap.argumentMap[n.Label()] = n
 	case *ExportConfigNode:
 		nodeMap.exportMap[n.Label()] = n
+	case *DeclareNode:
+		nodeMap.declareMap[n.Label()] = n
+	case *FunctionConfigNode:
+		nodeMap.functionMap[n.Label()] = n
+	case *ImportConfigNode:
+		if orig, ok := nodeMap.importMap[n.Label()]; ok {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("import block label %q already used by %s", n.Label(), orig.NodeID()),
+				StartPos: ast.StartPos(n.Block()).Position(),
+				EndPos:   ast.EndPos(n.Block()).Position(),
+			})
+			break
+		}
+		nodeMap.importMap[n.Label()] = n
 	case *LoggingConfigNode:
 		nodeMap.logging = n
 	case *TracingConfigNode:
@@ -95,6 +131,15 @@ func (nodeMap *ConfigNodeMap) Validate(isInModule bool, args map[string]any) dia
 	newDiags = nodeMap.ValidateUnsupportedArguments(args)
 	diags = append(diags, newDiags...)
 
+	newDiags = nodeMap.ValidateDeclareNames()
+	diags = append(diags, newDiags...)
+
+	newDiags = nodeMap.ValidateFunctionNames()
+	diags = append(diags, newDiags...)
+
+	newDiags = nodeMap.ValidateImportNames()
+	diags = append(diags, newDiags...)
+
 	return diags
 }
 
@@ -162,3 +207,152 @@ func (nodeMap *ConfigNodeMap) ValidateUnsupportedArguments(args map[string]any)
 
 	return diags
 }
+
+// ValidateDeclareNames will validate that the name of each declare block is a
+// valid component name that doesn't conflict with builtin components or other
+// config blocks.
+func (nodeMap *ConfigNodeMap) ValidateDeclareNames() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for name, node := range nodeMap.declareMap {
+		var err error
+
+		switch {
+		case !scanner.IsValidIdentifier(name):
+			err = fmt.Errorf("declare block label %q must be a valid identifier", name)
+		case isConfigBlockName(name):
+			err = fmt.Errorf("declare block label %q conflicts with the %s config block", name, name)
+		case isStdlibIdentifier(name):
+			err = fmt.Errorf("declare block label %q conflicts with a standard library identifier", name)
+		default:
+			if validateErr := component.ValidateName(name); validateErr != nil {
+				err = fmt.Errorf("declare block label %q conflicts with a builtin component: %s", name, validateErr)
+			}
+		}
+
+		if err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  err.Error(),
+				StartPos: node.Block().LabelPos.Position(),
+				EndPos:   node.Block().LabelPos.Add(len(name) + 1).Position(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// ValidateFunctionNames will validate that the name of each function block
+// is a valid identifier that doesn't conflict with other names which can be
+// referenced by expressions.
+func (nodeMap *ConfigNodeMap) ValidateFunctionNames() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for name, node := range nodeMap.functionMap {
+		var err error
+
+		switch {
+		case name == "":
+			// Missing labels are reported when the function is created.
+			continue
+		case !scanner.IsValidIdentifier(name):
+			err = fmt.Errorf("function block label %q must be a valid identifier", name)
+		case isConfigBlockName(name):
+			err = fmt.Errorf("function block label %q conflicts with the %s config block", name, name)
+		case isStdlibIdentifier(name):
+			err = fmt.Errorf("function block label %q conflicts with a standard library identifier", name)
+		case nodeMap.declareMap[name] != nil:
+			err = fmt.Errorf("function block label %q conflicts with a declare block of the same name", name)
+		default:
+			if validateErr := component.ValidateName(name); validateErr != nil {
+				err = fmt.Errorf("function block label %q conflicts with a builtin component: %s", name, validateErr)
+			}
+		}
+
+		if err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  err.Error(),
+				StartPos: node.Block().LabelPos.Position(),
+				EndPos:   node.Block().LabelPos.Add(len(name) + 1).Position(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// ValidateImportNames will validate that the label of each import block is a
+// valid identifier that doesn't conflict with other names which can be
+// referenced by blocks or expressions, since the label is used as the
+// namespace of the imported declarations.
+func (nodeMap *ConfigNodeMap) ValidateImportNames() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for name, node := range nodeMap.importMap {
+		var err error
+
+		switch {
+		case !scanner.IsValidIdentifier(name):
+			err = fmt.Errorf("import block label %q must be a valid identifier", name)
+		case isConfigBlockName(name):
+			err = fmt.Errorf("import block label %q conflicts with the %s config block", name, name)
+		case isStdlibIdentifier(name):
+			err = fmt.Errorf("import block label %q conflicts with a standard library identifier", name)
+		case nodeMap.declareMap[name] != nil:
+			err = fmt.Errorf("import block label %q conflicts with a declare block of the same name", name)
+		case nodeMap.functionMap[name] != nil:
+			err = fmt.Errorf("import block label %q conflicts with a function block of the same name", name)
+		default:
+			if validateErr := component.ValidateName(name); validateErr != nil {
+				err = fmt.Errorf("import block label %q conflicts with a builtin component: %s", name, validateErr)
+			}
+		}
+
+		if err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  err.Error(),
+				StartPos: node.Block().LabelPos.Position(),
+				EndPos:   node.Block().LabelPos.Add(len(name) + 1).Position(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// allDeclares returns the declarations which can be instantiated by
+// components. Declarations of imported files are keyed by NAMESPACE.NAME.
+func (nodeMap *ConfigNodeMap) allDeclares() map[string]*DeclareNode {
+	declares := make(map[string]*DeclareNode, len(nodeMap.declareMap))
+	for name, n := range nodeMap.declareMap {
+		declares[name] = n
+	}
+	for namespace, n := range nodeMap.importMap {
+		for name, decl := range n.Declares() {
+			declares[namespace+"."+name] = decl
+		}
+	}
+	return declares
+}
+
+// isConfigBlockName returns true if name is the name of a config block.
+func isConfigBlockName(name string) bool {
+	switch name {
+	case argumentBlockID, declareBlockID, exportBlockID, functionBlockID, importBlockID, loggingBlockID, tracingBlockID:
+		return true
+	default:
+		return false
+	}
+}
+
+// isStdlibIdentifier returns true if name is defined by the River standard
+// library.
+func isStdlibIdentifier(name string) bool {
+	// An empty scope only searches the standard library.
+	var emptyScope vm.Scope
+	_, found := emptyScope.Lookup(name)
+	return found
+}
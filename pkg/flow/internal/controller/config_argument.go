@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/typeexpr"
 	"github.com/grafana/agent/pkg/river/vm"
 )
 
@@ -12,12 +13,15 @@ type ArgumentConfigNode struct {
 	label         string
 	nodeID        string
 	componentName string
+	capsules      typeexpr.Capsules
 
 	mut          sync.RWMutex
 	block        *ast.BlockStmt // Current River blocks to derive config from
 	eval         *vm.Evaluator
 	defaultValue any
 	optional     bool
+	typ          typeexpr.Type
+	constraints  typeexpr.Constraints
 }
 
 var _ BlockNode = (*ArgumentConfigNode)(nil)
@@ -29,6 +33,7 @@ func NewArgumentConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *Argu
 		label:         block.Label,
 		nodeID:        BlockComponentID(block).String(),
 		componentName: block.GetBlockName(),
+		capsules:      globals.Capsules,
 
 		block: block,
 		eval:  vm.New(block.Body),
@@ -36,16 +41,22 @@ func NewArgumentConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *Argu
 }
 
 type argumentBlock struct {
-	Optional bool `river:"optional,attr,optional"`
-	Default  any  `river:"default,attr,optional"`
+	Optional      bool     `river:"optional,attr,optional"`
+	Default       any      `river:"default,attr,optional"`
+	Type          string   `river:"type,attr,optional"`
+	Regex         string   `river:"regex,attr,optional"`
+	Min           *float64 `river:"min,attr,optional"`
+	Max           *float64 `river:"max,attr,optional"`
+	AllowedValues []any    `river:"allowed_values,attr,optional"`
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed config block
 // by re-evaluating its River block with the provided scope. The managed config block
 // will be built the first time Evaluate is called.
 //
-// Evaluate will return an error if the River block cannot be evaluated or if
-// decoding to arguments fails.
+// Evaluate will return an error if the River block cannot be evaluated, if
+// decoding to arguments fails, or if the default value doesn't match the type
+// and constraints of the argument.
 func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
@@ -55,12 +66,54 @@ func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding River: %w", err)
 	}
 
+	typ := typeexpr.Any
+	if argument.Type != "" {
+		var err error
+		if typ, err = typeexpr.Parse(argument.Type, cn.capsules); err != nil {
+			return fmt.Errorf("invalid type %q: %w", argument.Type, err)
+		}
+	}
+	constraints := typeexpr.Constraints{
+		Regex:         argument.Regex,
+		Min:           argument.Min,
+		Max:           argument.Max,
+		AllowedValues: argument.AllowedValues,
+	}
+	if err := constraints.Validate(); err != nil {
+		return err
+	}
+
 	cn.defaultValue = argument.Default
 	cn.optional = argument.Optional
+	cn.typ = typ
+	cn.constraints = constraints
 
+	if argument.Default != nil {
+		if err := cn.check(argument.Default); err != nil {
+			return fmt.Errorf("invalid default value: %w", err)
+		}
+	}
 	return nil
 }
 
+// Check returns an error if v doesn't match the type and constraints of the
+// argument. Null values are accepted for optional arguments.
+func (cn *ArgumentConfigNode) Check(v any) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.check(v)
+}
+
+func (cn *ArgumentConfigNode) check(v any) error {
+	if v == nil && cn.optional {
+		return nil
+	}
+	if err := cn.typ.Check(v); err != nil {
+		return err
+	}
+	return cn.constraints.Check(v)
+}
+
 func (cn *ArgumentConfigNode) Optional() bool {
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
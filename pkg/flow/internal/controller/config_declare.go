@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// DeclareNode is a config node for a declare block, which defines a custom
+// component that can be instantiated by other blocks in the same file.
+//
+// The body of a declare block is not evaluated by the DeclareNode itself;
+// every instance of the declared component loads the body as a module.
+type DeclareNode struct {
+	label         string
+	nodeID        string
+	componentName string
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River block to derive the declaration from
+}
+
+var _ BlockNode = (*DeclareNode)(nil)
+
+// NewDeclareNode creates a new DeclareNode from an initial ast.BlockStmt.
+func NewDeclareNode(block *ast.BlockStmt) *DeclareNode {
+	return &DeclareNode{
+		label:         block.Label,
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+
+		block: block,
+	}
+}
+
+// Evaluate implements BlockNode. It is a no-op since declarations are
+// evaluated by the modules of the components which instantiate them.
+func (cn *DeclareNode) Evaluate(scope *vm.Scope) error {
+	return nil
+}
+
+// Label returns the name of the declared component.
+func (cn *DeclareNode) Label() string { return cn.label }
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *DeclareNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *DeclareNode) NodeID() string { return cn.nodeID }
+
+// declaredArgument describes an argument block inside of a declare block.
+type declaredArgument struct {
+	Block *ast.BlockStmt
+
+	// Required is true if the argument block does not set optional = true.
+	// Required is always false when optional is set to an expression other
+	// than a literal, since it can't be known without evaluating the module.
+	Required bool
+}
+
+// Arguments returns the argument blocks defined at the top level of the
+// declaration, keyed by their label.
+func (cn *DeclareNode) Arguments() map[string]declaredArgument {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	args := make(map[string]declaredArgument)
+	for _, stmt := range cn.block.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || block.GetBlockName() != argumentBlockID {
+			continue
+		}
+
+		required := true
+		for _, stmt := range block.Body {
+			attr, ok := stmt.(*ast.AttributeStmt)
+			if !ok || attr.Name.Name != "optional" {
+				continue
+			}
+
+			lit, ok := attr.Value.(*ast.LiteralExpr)
+			required = ok && lit.Kind == token.BOOL && lit.Value == "false"
+		}
+
+		args[block.Label] = declaredArgument{Block: block, Required: required}
+	}
+	return args
+}
+
+// declaredExports returns the exports of a declared component with the given
+// body before any of its exports are known. Every export block in the body is
+// present in the result with a null value.
+func declaredExports(body ast.Body) map[string]any {
+	exports := make(map[string]any)
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || block.GetBlockName() != exportBlockID {
+			continue
+		}
+		exports[block.Label] = nil
+	}
+	return exports
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// FunctionConfigNode is a config node for a function block, which defines a
+// pure function that can be called by expressions in the same file.
+//
+// Functions are exposed to expressions through the scope built by the
+// valueCache rather than by evaluating the node; nodes which call a function
+// depend on its FunctionConfigNode so that changes to the function are
+// propagated.
+type FunctionConfigNode struct {
+	label  string
+	nodeID string
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River block to derive the function from
+	fn    *vm.Function
+}
+
+var _ BlockNode = (*FunctionConfigNode)(nil)
+
+// NewFunctionConfigNode creates a new FunctionConfigNode from an initial
+// ast.BlockStmt. Diagnostics are returned if the block doesn't describe a
+// valid function.
+func NewFunctionConfigNode(block *ast.BlockStmt, globals ComponentGlobals) (*FunctionConfigNode, diag.Diagnostics) {
+	fn, diags := vm.NewFunction(block)
+
+	return &FunctionConfigNode{
+		label:  block.Label,
+		nodeID: BlockComponentID(block).String(),
+
+		block: block,
+		fn:    fn,
+	}, diags
+}
+
+// Evaluate implements BlockNode. It is a no-op since functions are evaluated
+// each time they are called.
+func (cn *FunctionConfigNode) Evaluate(scope *vm.Scope) error {
+	return nil
+}
+
+// Label returns the name of the function.
+func (cn *FunctionConfigNode) Label() string { return cn.label }
+
+// Function returns the function defined by the node.
+func (cn *FunctionConfigNode) Function() *vm.Function {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.fn
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *FunctionConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *FunctionConfigNode) NodeID() string { return cn.nodeID }
+
+// graphFunctions returns the functions defined by the function blocks in g.
+func graphFunctions(g *dag.Graph) []*vm.Function {
+	var fns []*vm.Function
+	for _, n := range g.Nodes() {
+		if fn, ok := n.(*FunctionConfigNode); ok {
+			fns = append(fns, fn.Function())
+		}
+	}
+	return fns
+}
@@ -0,0 +1,476 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/internal/importsource"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/scanner"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// importFetchTimeout is how long fetching an imported file, including
+// configuring its source, may take before it is abandoned.
+const importFetchTimeout = time.Minute
+
+// ImportConfigNode is a config node for an import block, which loads the
+// declare and function blocks of another River file into a namespace named
+// after the label of the import block.
+//
+// Imported files are fetched when the arguments of the node change. The
+// content of the file is kept across loads of the config, so reloading the
+// config doesn't fetch the file again. While the node runs, the file is
+// fetched periodically, and OnImportUpdate is invoked when its content
+// changes so the controller can load the new declarations.
+type ImportConfigNode struct {
+	label    string
+	nodeID   string
+	logger   log.Logger
+	source   importsource.ImportSource
+	onUpdate func(cn *ImportConfigNode)
+
+	// updated is written to when the arguments of the source change.
+	updated chan struct{}
+
+	// sourceMut serializes configuring source and fetching from it.
+	// sourceArgs are the arguments source is configured with, which may
+	// differ from args when Fetch was called for a load which was never
+	// applied.
+	sourceMut  sync.Mutex
+	sourceArgs any
+
+	mut            sync.RWMutex
+	block          *ast.BlockStmt // Current River block to derive the arguments from
+	args           any            // Arguments the current content was fetched with; nil if nothing was fetched
+	prefetched     *importFetch   // Result of the last call to Fetch which wasn't used by Evaluate yet
+	content        []byte
+	declares       map[string]*DeclareNode // Declarations keyed by their name in the namespace
+	functions      []*vm.Function
+	version        int // Incremented every time the content changes
+	appliedVersion int // Version when ContentChanged was last called
+}
+
+// importFetch is the result of fetching an imported file with a set of
+// arguments.
+type importFetch struct {
+	args    any
+	content []byte
+	err     error
+}
+
+var (
+	_ BlockNode    = (*ImportConfigNode)(nil)
+	_ RunnableNode = (*ImportConfigNode)(nil)
+)
+
+// NewImportConfigNode creates a new ImportConfigNode from an initial
+// ast.BlockStmt. The imported file isn't fetched until Fetch or Evaluate is
+// called.
+func NewImportConfigNode(block *ast.BlockStmt, globals ComponentGlobals, sourceType importsource.SourceType) *ImportConfigNode {
+	var (
+		nodeID   = BlockComponentID(block).String()
+		globalID = nodeID
+	)
+	if globals.ControllerID != "" {
+		globalID = path.Join(globals.ControllerID, nodeID)
+	}
+
+	return &ImportConfigNode{
+		label:  block.Label,
+		nodeID: nodeID,
+		logger: log.With(globals.Logger, "import", globalID),
+		source: importsource.New(sourceType, importsource.Options{
+			ID:       globalID,
+			DataPath: filepath.Join(globals.DataPath, globalID),
+		}),
+		onUpdate: globals.OnImportUpdate,
+
+		updated: make(chan struct{}, 1),
+
+		block: block,
+	}
+}
+
+// Fetch evaluates the arguments of the import from block against scope and,
+// if they changed since the file was last loaded, fetches the imported file.
+// block isn't used by the node until it is passed to UpdateBlock. The result
+// is kept for the next call to Evaluate with the same arguments, so that
+// Evaluate doesn't wait for the file while the caller holds its locks.
+// Errors are reported by Evaluate.
+func (cn *ImportConfigNode) Fetch(block *ast.BlockStmt, scope *vm.Scope) {
+	args, changed, err := cn.evaluateArguments(block, scope)
+	if err != nil || !changed {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), importFetchTimeout)
+	defer cancel()
+	content, err := cn.fetch(ctx, args)
+
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.prefetched = &importFetch{args: args, content: content, err: err}
+}
+
+// ValidateArguments evaluates the arguments of the import against scope
+// without configuring the source or fetching the imported file.
+func (cn *ImportConfigNode) ValidateArguments(scope *vm.Scope) error {
+	_, _, err := cn.evaluateArguments(cn.Block(), scope)
+	return err
+}
+
+// Evaluate implements BlockNode and updates the arguments of the import. If
+// the arguments changed since the last successful evaluation, the file
+// prefetched by Fetch is loaded. The file is fetched by Evaluate if Fetch
+// wasn't called with the same arguments.
+func (cn *ImportConfigNode) Evaluate(scope *vm.Scope) error {
+	args, changed, err := cn.evaluateArguments(cn.Block(), scope)
+	if err != nil || !changed {
+		return err
+	}
+
+	cn.mut.Lock()
+	prefetched := cn.prefetched
+	cn.prefetched = nil
+	cn.mut.Unlock()
+
+	var content []byte
+	if prefetched != nil && reflect.DeepEqual(prefetched.args, args) {
+		content, err = prefetched.content, prefetched.err
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), importFetchTimeout)
+		content, err = cn.fetch(ctx, args)
+		cancel()
+	}
+	if err != nil {
+		return err
+	}
+
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if _, err := cn.setContent(content); err != nil {
+		return err
+	}
+	cn.args = args
+
+	// Let Run know about the new poll frequency.
+	select {
+	case cn.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evaluateArguments decodes block against scope into a new arguments value
+// of the source. changed reports whether the arguments differ from the ones
+// the current content was fetched with.
+func (cn *ImportConfigNode) evaluateArguments(block *ast.BlockStmt, scope *vm.Scope) (args any, changed bool, err error) {
+	cn.mut.RLock()
+	prevArgs := cn.args
+	cn.mut.RUnlock()
+
+	args = cn.source.Arguments()
+	if err := vm.New(block.Body).Evaluate(scope, args); err != nil {
+		return nil, false, fmt.Errorf("decoding River: %w", err)
+	}
+	return args, prevArgs == nil || !reflect.DeepEqual(prevArgs, args), nil
+}
+
+// fetch fetches the imported file with args, configuring the source first if
+// it was configured with other arguments. mut must not be held when calling
+// fetch.
+func (cn *ImportConfigNode) fetch(ctx context.Context, args any) ([]byte, error) {
+	cn.sourceMut.Lock()
+	defer cn.sourceMut.Unlock()
+
+	if cn.sourceArgs == nil || !reflect.DeepEqual(cn.sourceArgs, args) {
+		// The source may be partially configured if Update fails, so it is
+		// configured again the next time it is used.
+		cn.sourceArgs = nil
+		if err := cn.source.Update(ctx, args); err != nil {
+			return nil, err
+		}
+		cn.sourceArgs = args
+	}
+	content, err := cn.source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching imported file: %w", err)
+	}
+	return content, nil
+}
+
+// setContent parses content and loads its declarations. setContent returns
+// true if the content differs from the previous content. Invalid content is
+// rejected and the previous content is kept. mut must be held when calling
+// setContent.
+func (cn *ImportConfigNode) setContent(content []byte) (bool, error) {
+	if cn.args != nil && bytes.Equal(cn.content, content) {
+		return false, nil
+	}
+
+	declares, functions, err := cn.parseContent(content)
+	if err != nil {
+		return false, err
+	}
+
+	cn.content = content
+	cn.declares = declares
+	cn.functions = functions
+	cn.version++
+	return true, nil
+}
+
+// parseContent parses the declare and function blocks of an imported file.
+// Imported files may not contain any other statements.
+func (cn *ImportConfigNode) parseContent(content []byte) (map[string]*DeclareNode, []*vm.Function, error) {
+	file, err := parser.ParseFile(cn.nodeID, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		diags     diag.Diagnostics
+		declares  = make(map[string]*DeclareNode)
+		functions []*vm.Function
+		names     = make(map[string]struct{})
+	)
+
+	for _, stmt := range file.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || (block.GetBlockName() != declareBlockID && block.GetBlockName() != functionBlockID) {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "imported files may only contain declare and function blocks",
+				StartPos: ast.StartPos(stmt).Position(),
+				EndPos:   ast.EndPos(stmt).Position(),
+			})
+			continue
+		}
+
+		var nameErr error
+		switch _, dup := names[block.Label]; {
+		case block.Label == "":
+			// Missing labels of functions are reported by vm.NewFunction.
+			if block.GetBlockName() == declareBlockID {
+				nameErr = fmt.Errorf("declare blocks must have a label")
+			}
+		case !scanner.IsValidIdentifier(block.Label):
+			nameErr = fmt.Errorf("%s block label %q must be a valid identifier", block.GetBlockName(), block.Label)
+		case dup:
+			nameErr = fmt.Errorf("%s block label %q is already declared in the imported file", block.GetBlockName(), block.Label)
+		}
+		names[block.Label] = struct{}{}
+		if nameErr != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  nameErr.Error(),
+				StartPos: ast.StartPos(block).Position(),
+				EndPos:   block.NamePos.Add(len(block.GetBlockName()) - 1).Position(),
+			})
+		}
+
+		switch block.GetBlockName() {
+		case declareBlockID:
+			declares[block.Label] = newImportedDeclareNode(cn, block)
+		case functionBlockID:
+			fn, fnDiags := vm.NewFunction(block)
+			diags = append(diags, fnDiags...)
+			functions = append(functions, fn)
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	return declares, functions, nil
+}
+
+// newImportedDeclareNode creates a DeclareNode for a declare block of the
+// file imported by cn. The declared component is named NAMESPACE.LABEL.
+// Imported declarations aren't nodes in the graph; instances of them depend
+// on cn instead.
+func newImportedDeclareNode(cn *ImportConfigNode, block *ast.BlockStmt) *DeclareNode {
+	return &DeclareNode{
+		label:         cn.label + "." + block.Label,
+		nodeID:        cn.nodeID + "." + block.Label,
+		componentName: declareBlockID,
+
+		block: block,
+	}
+}
+
+// Run implements RunnableNode. Run fetches the imported file at the poll
+// frequency of the import and invokes OnImportUpdate when its content
+// changes.
+func (cn *ImportConfigNode) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cn.pollFrequency()):
+			cn.poll(ctx)
+		case <-cn.updated:
+			// no-op; force the poll frequency to be reread.
+		}
+	}
+}
+
+// pollFrequency returns how long to wait before fetching the imported file
+// again.
+func (cn *ImportConfigNode) pollFrequency() time.Duration {
+	if freq := cn.source.PollFrequency(); freq > 0 {
+		return freq
+	}
+	// The source hasn't been configured yet; Evaluate will let Run know once
+	// it is.
+	return time.Minute
+}
+
+// poll fetches the imported file with the arguments of the last successful
+// evaluation and loads it if its content changed. Failures are logged, and
+// the previous content is kept.
+func (cn *ImportConfigNode) poll(ctx context.Context) {
+	cn.mut.RLock()
+	args := cn.args
+	cn.mut.RUnlock()
+	if args == nil {
+		// The arguments were never evaluated successfully.
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, importFetchTimeout)
+	content, err := cn.fetch(ctx, args)
+	cancel()
+	if err != nil {
+		level.Error(cn.logger).Log("msg", "failed to fetch imported file", "err", err)
+		return
+	}
+
+	cn.mut.Lock()
+	if !reflect.DeepEqual(cn.args, args) {
+		// The arguments changed while fetching; the content was already
+		// loaded by Evaluate.
+		cn.mut.Unlock()
+		return
+	}
+	changed, err := cn.setContent(content)
+	cn.mut.Unlock()
+
+	if err != nil {
+		level.Error(cn.logger).Log("msg", "imported file is invalid, keeping its previous content", "err", err)
+		return
+	}
+	if changed {
+		level.Info(cn.logger).Log("msg", "imported file changed")
+		if cn.onUpdate != nil {
+			cn.onUpdate(cn)
+		}
+	}
+}
+
+// ContentChanged returns true if the content of the imported file changed
+// since the last call to ContentChanged.
+func (cn *ImportConfigNode) ContentChanged() bool {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	changed := cn.version != cn.appliedVersion
+	cn.appliedVersion = cn.version
+	return changed
+}
+
+// loaded returns true if the imported file was loaded at least once.
+func (cn *ImportConfigNode) loaded() bool {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args != nil
+}
+
+// Label returns the namespace the file is imported into.
+func (cn *ImportConfigNode) Label() string { return cn.label }
+
+// Declares returns the declarations of the imported file, keyed by their name
+// in the namespace of the import.
+func (cn *ImportConfigNode) Declares() map[string]*DeclareNode {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.declares
+}
+
+// Functions returns the functions defined by the imported file.
+func (cn *ImportConfigNode) Functions() []*vm.Function {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.functions
+}
+
+// hasFunction returns true if the imported file defines a function with the
+// given name.
+func (cn *ImportConfigNode) hasFunction(name string) bool {
+	for _, fn := range cn.Functions() {
+		if fn.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateBlock updates the River block used to evaluate the arguments of the
+// import. The new block isn't used until the next time Evaluate is invoked.
+//
+// UpdateBlock will panic if the block does not match the ID of the
+// ImportConfigNode.
+func (cn *ImportConfigNode) UpdateBlock(b *ast.BlockStmt) {
+	if BlockComponentID(b).String() != cn.nodeID {
+		panic("UpdateBlock called with an River block with a different ID")
+	}
+
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.block = b
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *ImportConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *ImportConfigNode) NodeID() string { return cn.nodeID }
+
+// importNode returns the node in g which imports a file into namespace, or
+// nil if there is none.
+func importNode(g *dag.Graph, namespace string) *ImportConfigNode {
+	for _, t := range importsource.Types {
+		if n, ok := g.GetByID(t.BlockName() + "." + namespace).(*ImportConfigNode); ok {
+			return n
+		}
+	}
+	return nil
+}
+
+// graphImports returns the functions of the files imported by the import
+// blocks in g, keyed by namespace.
+func graphImports(g *dag.Graph) map[string][]*vm.Function {
+	imports := make(map[string][]*vm.Function)
+	for _, n := range g.Nodes() {
+		if imp, ok := n.(*ImportConfigNode); ok {
+			imports[imp.Label()] = imp.Functions()
+		}
+	}
+	return imports
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,11 +13,11 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/internal/importsource"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/diag"
-	"github.com/grafana/ckit"
-	"github.com/grafana/ckit/peer"
+	"github.com/grafana/agent/pkg/river/vm"
 	"github.com/hashicorp/go-multierror"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -34,11 +36,16 @@ type Loader struct {
 	graph             *dag.Graph
 	originalGraph     *dag.Graph
 	components        []*ComponentNode
+	imports           []*ImportConfigNode
+	services          []*ServiceNode // Services of the root controller; empty for modules
 	cache             *valueCache
 	blocks            []*ast.BlockStmt // Most recently loaded blocks, used for writing
 	cm                *controllerMetrics
 	cc                *controllerCollector
 	moduleExportIndex int
+	reloadSummary     ReloadSummary                // Summary of the most recent call to Apply
+	fetchedImports    map[string]*ImportConfigNode // Import nodes created by FetchImports for the next call to Apply
+	validating        bool                         // Set for Loaders which only validate blocks; imports aren't fetched
 }
 
 // NewLoader creates a new Loader. Components built by the Loader will be built
@@ -52,36 +59,16 @@ func NewLoader(globals ComponentGlobals) *Loader {
 		graph:         &dag.Graph{},
 		originalGraph: &dag.Graph{},
 		cache:         newValueCache(),
-		cm:            newControllerMetrics(globals.ControllerID),
+		cm:            newControllerMetrics(globals.ControllerID, globals.UpdateQueue),
 	}
 	l.cc = newControllerCollector(l, globals.ControllerID)
+	l.services = l.newServiceNodes()
 
 	if globals.Registerer != nil {
 		globals.Registerer.MustRegister(l.cc)
 		globals.Registerer.MustRegister(l.cm)
 	}
 
-	globals.Clusterer.Node.Observe(ckit.FuncObserver(func(peers []peer.Peer) (reregister bool) {
-		tracer := l.tracer.Tracer("")
-		spanCtx, span := tracer.Start(context.Background(), "ClusterStateChange", trace.WithSpanKind(trace.SpanKindInternal))
-		defer span.End()
-		for _, cmp := range l.Components() {
-			if cc, ok := cmp.managed.(component.ClusteredComponent); ok {
-				if cc.ClusterUpdatesRegistration() {
-					_, span := tracer.Start(spanCtx, "ClusteredComponentReevaluation", trace.WithSpanKind(trace.SpanKindInternal))
-					span.SetAttributes(attribute.String("node_id", cmp.NodeID()))
-					defer span.End()
-
-					err := cmp.Reevaluate()
-					if err != nil {
-						level.Error(l.log).Log("msg", "failed to reevaluate component", "componentID", cmp.NodeID(), "err", err)
-					}
-				}
-			}
-		}
-		return true
-	}))
-
 	return l
 }
 
@@ -93,7 +80,14 @@ func NewLoader(globals ComponentGlobals) *Loader {
 // matches the component ID specified by any of the provided River blocks.
 // Reused components will be updated to point at the new River block.
 //
-// Apply will perform an evaluation of all loaded components before returning.
+// Apply only evaluates components which are new, whose block changed
+// structurally since the previous call to Apply, whose block calls functions,
+// whose last evaluation failed, or which depend on another node that was
+// evaluated for one of these reasons. Config blocks are always evaluated,
+// except for import blocks, which are evaluated before the graph is built and
+// only fetch their file again if their arguments changed. The changes made by
+// Apply are available from LastReloadSummary.
+//
 // The provided parentContext can be used to provide global variables and
 // functions to components. A child context will be constructed from the parent
 // to expose values of other components.
@@ -104,19 +98,39 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 	l.cm.controllerEvaluation.Set(1)
 	defer l.cm.controllerEvaluation.Set(0)
 
+	// Snapshot the current graph and module arguments before they're modified
+	// so that changes can be detected.
+	var (
+		prevGraph = newGraphSnapshot(l.graph)
+		prevArgs  = l.cache.ModuleArguments()
+	)
+
 	for key, value := range args {
 		l.cache.CacheModuleArgument(key, value)
 	}
 	l.cache.SyncModuleArgs(args)
 
 	newGraph, diags := l.loadNewGraph(args, componentBlocks, configBlocks)
+	l.fetchedImports = nil
 	if diags.HasErrors() {
+		// No components were changed.
+		l.reloadSummary = ReloadSummary{}
 		return diags
 	}
+	l.cache.SyncFunctions(graphFunctions(&newGraph))
+	l.cache.SyncImports(graphImports(&newGraph))
+	l.cache.SyncForEachIDs(graphForEachIDs(&newGraph))
 
 	var (
 		components   = make([]*ComponentNode, 0, len(componentBlocks))
 		componentIDs = make([]ComponentID, 0, len(componentBlocks))
+		imports      []*ImportConfigNode
+		summary      ReloadSummary
+
+		// changed holds nodes which were evaluated because they or one of their
+		// dependencies changed. Dependants of changed nodes must also be
+		// evaluated.
+		changed = make(map[dag.Node]struct{})
 	)
 
 	tracer := l.tracer.Tracer("")
@@ -135,8 +149,34 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 
 	l.cache.ClearModuleExports()
 
-	// Evaluate all the components.
+	// Evaluate all the components which changed.
 	_ = dag.WalkTopological(&newGraph, newGraph.Leaves(), func(n dag.Node) error {
+		nodeChanged := prevGraph.Changed(n.(BlockNode))
+		for _, dep := range newGraph.Dependencies(n) {
+			if _, ok := changed[dep]; ok {
+				nodeChanged = true
+				break
+			}
+		}
+
+		if c, ok := n.(*ComponentNode); ok {
+			components = append(components, c)
+			componentIDs = append(componentIDs, c.ID())
+
+			switch {
+			case !prevGraph.Exists(c):
+				summary.Added = append(summary.Added, c.NodeID())
+			case nodeChanged || c.lastEvaluationFailed():
+				summary.Updated = append(summary.Updated, c.NodeID())
+			default:
+				// Nothing affecting the component changed, so its arguments and
+				// exports in the cache are still up to date.
+				summary.Unchanged = append(summary.Unchanged, c.NodeID())
+				return nil
+			}
+			changed[n] = struct{}{}
+		}
+
 		_, span := tracer.Start(spanCtx, "EvaluateNode", trace.WithSpanKind(trace.SpanKindInternal))
 		span.SetAttributes(attribute.String("node_id", n.NodeID()))
 		defer span.End()
@@ -150,13 +190,18 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 
 		switch c := n.(type) {
 		case *ComponentNode:
-			components = append(components, c)
-			componentIDs = append(componentIDs, c.ID())
-
 			if err = l.evaluate(logger, c); err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
-					diags = append(diags, evalDiags...)
+					for _, d := range evalDiags {
+						// Diagnostics without a position, such as those for invalid
+						// module arguments, are reported at the component's block.
+						if !d.StartPos.Valid() {
+							d.StartPos = ast.StartPos(c.Block()).Position()
+							d.EndPos = ast.EndPos(c.Block()).Position()
+						}
+						diags = append(diags, d)
+					}
 				} else {
 					diags.Add(diag.Diagnostic{
 						Severity: diag.SeverityLevelError,
@@ -166,8 +211,27 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 					})
 				}
 			}
+		case *ImportConfigNode:
+			// Imports were evaluated before the graph was built. Nodes which use
+			// an import must be evaluated again if the imported file changed.
+			imports = append(imports, c)
+			if c.ContentChanged() {
+				nodeChanged = true
+			}
+			if nodeChanged {
+				changed[n] = struct{}{}
+			}
 		case BlockNode:
-			if err = l.evaluate(logger, c); err != nil {
+			var argErr invalidArgumentError
+			if err = l.evaluate(logger, c); errors.As(err, &argErr) {
+				// The invalid value came from the caller of the module, so the
+				// diagnostic has no position within the module. The caller reports
+				// it at its own block.
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  argErr.Error(),
+				})
+			} else if err != nil {
 				diags.Add(diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
 					Message:  fmt.Sprintf("Failed to evaluate node for config block: %s", err),
@@ -178,6 +242,16 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 			if exp, ok := n.(*ExportConfigNode); ok {
 				l.cache.CacheModuleExportValue(exp.Label(), exp.Value())
 			}
+			if arg, ok := n.(*ArgumentConfigNode); ok {
+				prev, hadPrev := prevArgs[arg.Label()]
+				cur, hasCur := l.cache.ModuleArgument(arg.Label())
+				if hadPrev != hasCur || !reflect.DeepEqual(prev, cur) {
+					nodeChanged = true
+				}
+			}
+			if nodeChanged {
+				changed[n] = struct{}{}
+			}
 		}
 
 		// We only use the error for updating the span status; we don't return the
@@ -190,8 +264,35 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 		return nil
 	})
 
+	// Drop per-node metrics for nodes which no longer exist.
+	var removedComponents []string
+	for _, n := range l.graph.Nodes() {
+		if newGraph.GetByID(n.NodeID()) != nil {
+			continue
+		}
+		l.cm.nodeEvaluationTime.DeleteLabelValues(n.NodeID())
+		if cn, ok := n.(*ComponentNode); ok {
+			removedComponents = append(removedComponents, cn.globalID)
+		}
+	}
+	if l.globals.OnComponentsRemoved != nil && len(removedComponents) > 0 {
+		l.globals.OnComponentsRemoved(removedComponents)
+	}
+
+	summary.Removed = prevGraph.Removed(&newGraph)
+	summary.sort()
+	level.Info(logger).Log(
+		"msg", "applied config",
+		"added", len(summary.Added),
+		"removed", len(summary.Removed),
+		"updated", len(summary.Updated),
+		"unchanged", len(summary.Unchanged),
+	)
+
 	l.components = components
+	l.imports = imports
 	l.graph = &newGraph
+	l.reloadSummary = summary
 	l.cache.SyncIDs(componentIDs)
 	l.blocks = componentBlocks
 	l.cm.componentEvaluationTime.Observe(time.Since(start).Seconds())
@@ -202,8 +303,31 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 	return diags
 }
 
-// Cleanup unregisters any existing metrics.
+// LastReloadSummary returns the summary of the most recent call to Apply.
+func (l *Loader) LastReloadSummary() ReloadSummary {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.reloadSummary
+}
+
+// Cleanup unregisters any existing metrics, drops the per-node metrics, and
+// reports the components of the loader as removed.
 func (l *Loader) Cleanup() {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	// The nodes of the loader are gone once it's cleaned up, such as when the
+	// module it belongs to is torn down.
+	l.cm.nodeEvaluationTime.Reset()
+
+	if l.globals.OnComponentsRemoved != nil && len(l.components) > 0 {
+		removed := make([]string, 0, len(l.components))
+		for _, cn := range l.components {
+			removed = append(removed, cn.globalID)
+		}
+		l.globals.OnComponentsRemoved(removed)
+	}
+
 	if l.globals.Registerer == nil {
 		return
 	}
@@ -214,11 +338,21 @@ func (l *Loader) Cleanup() {
 // loadNewGraph creates a new graph from the provided blocks and validates it.
 func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
 	var g dag.Graph
+	// Fill our graph with services. Blocks which configure services are
+	// removed from componentBlocks.
+	componentBlocks, diags := l.populateServiceNodes(&g, componentBlocks)
+
 	// Fill our graph with config blocks.
-	diags := l.populateConfigBlockNodes(args, &g, configBlocks)
+	nodeMap, configBlockDiags := l.populateConfigBlockNodes(args, &g, configBlocks)
+	diags = append(diags, configBlockDiags...)
+
+	// Load the files of import blocks, which may declare components.
+	metaScope := l.metaArgumentScope(nodeMap, args)
+	importDiags := l.loadImports(nodeMap, metaScope)
+	diags = append(diags, importDiags...)
 
 	// Fill our graph with components.
-	componentNodeDiags := l.populateComponentNodes(&g, componentBlocks)
+	componentNodeDiags := l.populateComponentNodes(&g, componentBlocks, nodeMap.allDeclares(), metaScope)
 	diags = append(diags, componentNodeDiags...)
 
 	// Write up the edges of the graph
@@ -241,16 +375,26 @@ func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockS
 	return g, diags
 }
 
-// populateConfigBlockNodes adds any config blocks to the graph.
-func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, configBlocks []*ast.BlockStmt) diag.Diagnostics {
+// populateConfigBlockNodes adds any config blocks to the graph. The config
+// nodes which were added are returned.
+func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, configBlocks []*ast.BlockStmt) (*ConfigNodeMap, diag.Diagnostics) {
 	var (
 		diags   diag.Diagnostics
 		nodeMap = NewConfigNodeMap()
 	)
 
 	for _, block := range configBlocks {
-		node, newConfigNodeDiags := NewConfigNode(block, l.globals)
-		diags = append(diags, newConfigNodeDiags...)
+		var node BlockNode
+		if imp := l.existingImportNode(block); imp != nil {
+			// Import nodes are reused so that imported files are only fetched
+			// again when their arguments change.
+			imp.UpdateBlock(block)
+			node = imp
+		} else {
+			var newConfigNodeDiags diag.Diagnostics
+			node, newConfigNodeDiags = NewConfigNode(block, l.globals)
+			diags = append(diags, newConfigNodeDiags...)
+		}
 
 		if g.GetByID(node.NodeID()) != nil {
 			diags.Add(diag.Diagnostic{
@@ -287,18 +431,231 @@ func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, con
 		g.Add(c)
 	}
 
+	return nodeMap, diags
+}
+
+// existingImportNode returns the import node for block from the current graph
+// or from the nodes created by FetchImports, or nil if there is none. l.mut
+// must be held when calling existingImportNode.
+func (l *Loader) existingImportNode(block *ast.BlockStmt) *ImportConfigNode {
+	id := BlockComponentID(block).String()
+	if imp, ok := l.graph.GetByID(id).(*ImportConfigNode); ok {
+		return imp
+	}
+	return l.fetchedImports[id]
+}
+
+// FetchImports fetches the files of the import blocks in configBlocks whose
+// arguments changed since their file was last loaded. A following call to
+// Apply with the same blocks loads the fetched files instead of fetching them
+// while holding the locks of l, so that slow or unreachable sources don't
+// block the Loader. Failures are reported by Apply.
+func (l *Loader) FetchImports(args map[string]any, configBlocks []*ast.BlockStmt) {
+	var (
+		nodeMap = NewConfigNodeMap()
+		blocks  = make(map[*ImportConfigNode]*ast.BlockStmt)
+	)
+
+	l.mut.Lock()
+	for _, block := range configBlocks {
+		switch name := block.GetBlockName(); name {
+		case argumentBlockID, functionBlockID:
+			// Import blocks may use module arguments and functions.
+			if node, diags := NewConfigNode(block, l.globals); !diags.HasErrors() {
+				_ = nodeMap.Append(node)
+			}
+		default:
+			sourceType, ok := importsource.GetSourceType(name)
+			if !ok {
+				continue
+			}
+			imp := l.existingImportNode(block)
+			if imp == nil {
+				imp = NewImportConfigNode(block, l.globals, sourceType)
+				if l.fetchedImports == nil {
+					l.fetchedImports = make(map[string]*ImportConfigNode)
+				}
+				l.fetchedImports[imp.NodeID()] = imp
+			}
+			if diags := nodeMap.Append(imp); !diags.HasErrors() {
+				blocks[imp] = block
+			}
+		}
+	}
+	l.mut.Unlock()
+
+	scope := l.metaArgumentScope(nodeMap, args)
+	for imp, block := range blocks {
+		imp.Fetch(block, scope)
+	}
+}
+
+// metaArgumentScope returns the scope used to evaluate the meta-arguments of
+// component blocks. Meta-arguments are evaluated before the graph is built,
+// so they may only reference the functions in nodeMap and the module
+// arguments in args. Optional module arguments which aren't provided use
+// their default value.
+func (l *Loader) metaArgumentScope(nodeMap *ConfigNodeMap, args map[string]any) *vm.Scope {
+	fns := make([]*vm.Function, 0, len(nodeMap.functionMap))
+	for _, n := range nodeMap.functionMap {
+		if fn := n.Function(); fn != nil {
+			fns = append(fns, fn)
+		}
+	}
+
+	scope := &vm.Scope{
+		Parent:    vm.NewFunctionScope(nil, fns),
+		Variables: make(map[string]interface{}),
+	}
+
+	arguments := make(map[string]any, len(nodeMap.argumentMap))
+	for name, n := range nodeMap.argumentMap {
+		if value, ok := args[name]; ok {
+			arguments[name] = map[string]any{"value": value}
+			continue
+		}
+		if err := n.Evaluate(scope); err == nil && n.Optional() {
+			arguments[name] = map[string]any{"value": n.Default()}
+		}
+	}
+	if len(arguments) > 0 {
+		scope.Variables["argument"] = arguments
+	}
+	return scope
+}
+
+// loadImports evaluates the import blocks in nodeMap against scope, loading
+// the files of imports whose arguments changed. The functions of each
+// imported file are added to scope under the namespace of the import so that
+// meta-arguments can call them.
+//
+// Imports which fail to load keep the declarations of the file they last
+// loaded. Loaders which only validate blocks check the arguments of imports
+// without fetching their files.
+func (l *Loader) loadImports(nodeMap *ConfigNodeMap, scope *vm.Scope) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for namespace, n := range nodeMap.importMap {
+		evaluate := n.Evaluate
+		if l.validating {
+			evaluate = n.ValidateArguments
+		}
+
+		if err := evaluate(scope); err != nil {
+			var evalDiags diag.Diagnostics
+			if errors.As(err, &evalDiags) {
+				// Diagnostics from parsing the imported file.
+				diags = append(diags, evalDiags...)
+			} else {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Failed to load import: %s", err),
+					StartPos: ast.StartPos(n.Block()).Position(),
+					EndPos:   ast.EndPos(n.Block()).Position(),
+				})
+			}
+		}
+
+		if fns := n.Functions(); len(fns) > 0 {
+			scope.Variables[namespace] = vm.NewFunctionNamespace(fns)
+		}
+	}
+
 	return diags
 }
 
-// populateComponentNodes adds any components to the graph.
-func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.BlockStmt) diag.Diagnostics {
+// newServiceNodes creates a ServiceNode for every service in the globals of
+// l. Services are only run by the root controller, so no nodes are created
+// for modules.
+func (l *Loader) newServiceNodes() []*ServiceNode {
+	if l.isModule() {
+		return nil
+	}
+
+	nodes := make([]*ServiceNode, 0, len(l.globals.Services))
+	for _, svc := range l.globals.Services {
+		nodes = append(nodes, NewServiceNode(l.globals.ServiceHost, svc))
+	}
+	return nodes
+}
+
+// populateServiceNodes adds the services of l to the graph and assigns them
+// the blocks which configure them. The remaining blocks, which describe
+// components, are returned.
+func (l *Loader) populateServiceNodes(g *dag.Graph, blocks []*ast.BlockStmt) ([]*ast.BlockStmt, diag.Diagnostics) {
+	if len(l.services) == 0 {
+		return blocks, nil
+	}
+
+	var (
+		diags         diag.Diagnostics
+		serviceBlocks = make(map[string]*ast.BlockStmt, len(l.services))
+		remaining     = make([]*ast.BlockStmt, 0, len(blocks))
+	)
+
+	for _, svc := range l.services {
+		g.Add(svc)
+	}
+
+	for _, block := range blocks {
+		name := block.GetBlockName()
+		if _, isService := g.GetByID(name).(*ServiceNode); !isService {
+			remaining = append(remaining, block)
+			continue
+		}
+
+		if block.Label != "" {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Service %q does not support labels", name),
+				StartPos: block.LabelPos.Position(),
+				EndPos:   block.LabelPos.Add(len(block.Label) + 1).Position(),
+			})
+			continue
+		}
+		if orig, redefined := serviceBlocks[name]; redefined {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Service %q already configured at %s", name, ast.StartPos(orig).Position()),
+				StartPos: block.NamePos.Position(),
+				EndPos:   block.NamePos.Add(len(name) - 1).Position(),
+			})
+			continue
+		}
+		serviceBlocks[name] = block
+	}
+
+	// Services which aren't configured by the file use their default config.
+	for _, svc := range l.services {
+		svc.UpdateBlock(serviceBlocks[svc.NodeID()])
+	}
+
+	return remaining, diags
+}
+
+// Services returns the services managed by the Loader. Services are only
+// managed by the Loader of the root controller.
+func (l *Loader) Services() []*ServiceNode {
+	return l.services
+}
+
+// populateComponentNodes adds any components to the graph. Blocks whose name
+// matches a label in declares are instances of declared components.
+//
+// The meta-arguments of each block are evaluated against metaScope. Blocks
+// which aren't enabled are skipped, and blocks which use for_each add a
+// ComponentNode for each of their instances along with a ForEachNode which
+// groups the instances.
+func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.BlockStmt, declares map[string]*DeclareNode, metaScope *vm.Scope) diag.Diagnostics {
 	var (
 		diags    diag.Diagnostics
 		blockMap = make(map[string]*ast.BlockStmt, len(componentBlocks))
 	)
 	for _, block := range componentBlocks {
-		var c *ComponentNode
-		id := BlockComponentID(block).String()
+		var (
+			blockID = BlockComponentID(block)
+			id      = blockID.String()
+		)
 
 		if orig, redefined := blockMap[id]; redefined {
 			diags.Add(diag.Diagnostic{
@@ -311,13 +668,31 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 		}
 		blockMap[id] = block
 
-		if exist := l.graph.GetByID(id); exist != nil {
-			// Re-use the existing component and update its block
-			c = exist.(*ComponentNode)
-			c.UpdateBlock(block)
+		// Meta-arguments are handled here and must not be seen by the
+		// component.
+		componentBlock, meta, metaDiags := splitMetaArguments(block)
+		diags = append(diags, metaDiags...)
+		if metaDiags.HasErrors() {
+			continue
+		}
+
+		var registration component.Registration
+
+		decl, isDeclared := declares[block.GetBlockName()]
+		if isDeclared {
+			declDiags := validateDeclaredInstance(decl, componentBlock)
+			diags = append(diags, declDiags...)
+			if declDiags.HasErrors() {
+				continue
+			}
+		} else if l.validating && isUnloadedImport(g, block.GetBlockName()) {
+			// Imported files aren't fetched during validation, so the declaration
+			// of the instance and its arguments are unknown.
+			decl = NewDeclareNode(&ast.BlockStmt{Name: []string{declareBlockID}, Label: block.GetBlockName()})
 		} else {
 			componentName := block.GetBlockName()
-			registration, exists := component.Get(componentName)
+			var exists bool
+			registration, exists = component.Get(componentName)
 			if !exists {
 				diags.Add(diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
@@ -358,11 +733,147 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 				continue
 			}
 
-			// Create a new component
-			c = NewComponentNode(l.globals, block)
+			if registration.Singleton && meta.forEach != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Component %q is a singleton and does not support %s", componentName, forEachAttrName),
+					StartPos: ast.StartPos(meta.forEach).Position(),
+					EndPos:   ast.EndPos(meta.forEach).Position(),
+				})
+				continue
+			}
 		}
 
-		g.Add(c)
+		enabled, instances, expandDiags := expandMetaArguments(metaScope, meta)
+		diags = append(diags, expandDiags...)
+		if expandDiags.HasErrors() || !enabled {
+			continue
+		}
+
+		if meta.forEach == nil {
+			g.Add(l.componentNode(id, componentBlock, registration, decl, nil))
+			continue
+		}
+
+		instanceIDs := make([]string, 0, len(instances))
+		for i := range instances {
+			inst := &instances[i]
+			c := l.componentNode(instanceNodeID(blockID, inst.Key), componentBlock, registration, decl, inst)
+			g.Add(c)
+			instanceIDs = append(instanceIDs, c.NodeID())
+		}
+		g.Add(NewForEachNode(block, instanceIDs))
+	}
+
+	return diags
+}
+
+// isUnloadedImport returns true if name is in the namespace of an import
+// block in g whose file was never loaded.
+func isUnloadedImport(g *dag.Graph, name string) bool {
+	namespace, _, ok := strings.Cut(name, ".")
+	if !ok {
+		return false
+	}
+	imp := importNode(g, namespace)
+	return imp != nil && !imp.loaded()
+}
+
+// componentNode returns the ComponentNode with the given node ID for block.
+// The node from the current graph is reused and updated if it exists;
+// otherwise, a new node is created. decl is nil for builtin components, and
+// inst is nil for blocks which don't use for_each.
+func (l *Loader) componentNode(nodeID string, block *ast.BlockStmt, reg component.Registration, decl *DeclareNode, inst *componentInstance) *ComponentNode {
+	if c, ok := l.graph.GetByID(nodeID).(*ComponentNode); ok && c.IsDeclared() == (decl != nil) {
+		// Re-use the existing component and update its block
+		c.UpdateBlock(block)
+		if decl != nil {
+			c.UpdateDeclaration(decl)
+		}
+		if inst != nil {
+			c.UpdateEach(inst.Each)
+		}
+		return c
+	}
+
+	if decl != nil {
+		// Create a new instance of a declared component
+		return newDeclaredComponentNode(l.globals, decl, block, inst)
+	}
+	return newComponentNode(l.globals, reg, block, inst)
+}
+
+// validateDeclaredInstance validates the arguments given to an instance of a
+// declared component against the argument blocks of its declaration.
+//
+// Only the names of arguments and whether required arguments are set are
+// checked here. Values are checked against the type and constraints of their
+// argument block when the body of the instance is evaluated, since they are
+// usually not known before then.
+func validateDeclaredInstance(decl *DeclareNode, block *ast.BlockStmt) diag.Diagnostics {
+	var (
+		diags    diag.Diagnostics
+		declArgs = decl.Arguments()
+		name     = block.GetBlockName()
+		seen     = make(map[string]struct{}, len(block.Body))
+	)
+
+	if block.Label == "" {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("Component %q must have a label", name),
+			StartPos: block.NamePos.Position(),
+			EndPos:   block.NamePos.Add(len(name) - 1).Position(),
+		})
+	}
+
+	for _, stmt := range block.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			seen[stmt.Name.Name] = struct{}{}
+			if _, ok := declArgs[stmt.Name.Name]; ok {
+				continue
+			}
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Component %q does not declare an argument named %q", name, stmt.Name.Name),
+				StartPos: ast.StartPos(stmt.Name).Position(),
+				EndPos:   ast.EndPos(stmt.Name).Position(),
+			})
+
+		default:
+			if isRestartBlock(stmt) {
+				// The restart meta-block is handled by the ComponentNode itself.
+				continue
+			}
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Component %q only supports attributes", name),
+				StartPos: ast.StartPos(stmt).Position(),
+				EndPos:   ast.EndPos(stmt).Position(),
+			})
+		}
+	}
+
+	for argName, arg := range declArgs {
+		if argName == forEachAttrName || argName == enabledAttrName {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Argument %q of component %q conflicts with the %s meta-argument", argName, name, argName),
+				StartPos: ast.StartPos(arg.Block).Position(),
+				EndPos:   ast.EndPos(arg.Block).Position(),
+			})
+			continue
+		}
+		if _, ok := seen[argName]; ok || !arg.Required {
+			continue
+		}
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("Missing required argument %q for component %q", argName, name),
+			StartPos: block.NamePos.Position(),
+			EndPos:   block.NamePos.Add(len(name) - 1).Position(),
+		})
 	}
 
 	return diags
@@ -378,6 +889,61 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 			g.AddEdge(dag.Edge{From: n, To: ref.Target})
 		}
 		diags = append(diags, nodeDiags...)
+
+		switch n := n.(type) {
+		case *ComponentNode:
+			// Instances of declared components depend on their declaration, or
+			// on the import of the file which declared them.
+			if n.IsDeclared() {
+				if decl := g.GetByID(declareBlockID + "." + n.ComponentName()); decl != nil {
+					g.AddEdge(dag.Edge{From: n, To: decl})
+				} else if namespace, _, ok := strings.Cut(n.ComponentName(), "."); ok {
+					if imp := importNode(g, namespace); imp != nil {
+						g.AddEdge(dag.Edge{From: n, To: imp})
+					}
+				}
+			}
+
+			// Components depend on the services they need.
+			for _, name := range n.Registration().NeedsServices {
+				if svc, ok := g.GetByID(name).(*ServiceNode); ok {
+					g.AddEdge(dag.Edge{From: n, To: svc})
+					continue
+				}
+				if _, found := l.globals.getService(name); found {
+					// The service is run by the root controller.
+					continue
+				}
+
+				block := n.Block()
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Component %q depends on service %q, which does not exist", n.ComponentName(), name),
+					StartPos: block.NamePos.Position(),
+					EndPos:   block.NamePos.Add(len(n.ComponentName()) - 1).Position(),
+				})
+			}
+
+		case *ForEachNode:
+			// Blocks with for_each depend on each of their instances.
+			for _, id := range n.InstanceIDs() {
+				if inst := g.GetByID(id); inst != nil {
+					g.AddEdge(dag.Edge{From: n, To: inst})
+				}
+			}
+
+		case *ServiceNode:
+			for _, name := range n.Definition().DependsOn {
+				if dep, ok := g.GetByID(name).(*ServiceNode); ok {
+					g.AddEdge(dag.Edge{From: n, To: dep})
+					continue
+				}
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Service %q depends on service %q, which does not exist", n.NodeID(), name),
+				})
+			}
+		}
 	}
 
 	return diags
@@ -396,6 +962,13 @@ func (l *Loader) Components() []*ComponentNode {
 	return l.components
 }
 
+// Imports returns the current set of loaded import blocks.
+func (l *Loader) Imports() []*ImportConfigNode {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.imports
+}
+
 // Graph returns a copy of the DAG managed by the Loader.
 func (l *Loader) Graph() *dag.Graph {
 	l.mut.RLock()
@@ -412,13 +985,18 @@ func (l *Loader) OriginalGraph() *dag.Graph {
 }
 
 // EvaluateDependencies re-evaluates components which depend directly or
-// indirectly on c. EvaluateDependencies should be called whenever a component
-// updates its exports.
+// indirectly on any of the components in updated. EvaluateDependencies should
+// be called whenever components update their exports.
 //
-// The provided parentContext can be used to provide global variables and
-// functions to components. A child context will be constructed from the parent
-// to expose values of other components.
-func (l *Loader) EvaluateDependencies(c *ComponentNode) {
+// Dependants are evaluated in topological order, and each dependant is
+// evaluated at most once regardless of how many of the updated components it
+// depends on. Callers should batch together components whose exports changed
+// around the same time to avoid evaluating shared dependants multiple times.
+func (l *Loader) EvaluateDependencies(updated []*ComponentNode) {
+	if len(updated) == 0 {
+		return
+	}
+
 	tracer := l.tracer.Tracer("")
 
 	l.mut.RLock()
@@ -428,12 +1006,17 @@ func (l *Loader) EvaluateDependencies(c *ComponentNode) {
 	defer l.cm.controllerEvaluation.Set(0)
 	start := time.Now()
 
+	initiators := make([]string, 0, len(updated))
+	for _, c := range updated {
+		initiators = append(initiators, c.NodeID())
+	}
+
 	spanCtx, span := tracer.Start(context.Background(), "GraphEvaluatePartial", trace.WithSpanKind(trace.SpanKindInternal))
-	span.SetAttributes(attribute.String("initiator", c.NodeID()))
+	span.SetAttributes(attribute.StringSlice("initiators", initiators))
 	defer span.End()
 
 	logger := log.With(l.log, "trace_id", span.SpanContext().TraceID())
-	level.Info(logger).Log("msg", "starting partial graph evaluation")
+	level.Info(logger).Log("msg", "starting partial graph evaluation", "updated_components", len(updated))
 	defer func() {
 		span.SetStatus(codes.Ok, "")
 
@@ -442,17 +1025,19 @@ func (l *Loader) EvaluateDependencies(c *ComponentNode) {
 		l.cm.componentEvaluationTime.Observe(duration.Seconds())
 	}()
 
-	// Make sure we're in-sync with the current exports of c.
-	l.cache.CacheExports(c.ID(), c.Exports())
-
-	_ = dag.WalkReverse(l.graph, []dag.Node{c}, func(n dag.Node) error {
-		if n == c {
-			// Skip over the starting component; the starting component passed to
-			// EvaluateDependencies had its exports changed and none of its input
-			// arguments will need re-evaluation.
-			return nil
+	// Make sure we're in-sync with the current exports of the updated
+	// components. Components which were removed from the graph since they were
+	// queued are ignored.
+	startNodes := make([]dag.Node, 0, len(updated))
+	for _, c := range updated {
+		if l.graph.GetByID(c.NodeID()) != c {
+			continue
 		}
+		l.cache.CacheExports(c.ID(), c.Exports())
+		startNodes = append(startNodes, c)
+	}
 
+	_ = dag.WalkReverseTopological(l.graph, startNodes, func(n dag.Node) error {
 		_, span := tracer.Start(spanCtx, "EvaluateNode", trace.WithSpanKind(trace.SpanKindInternal))
 		span.SetAttributes(attribute.String("node_id", n.NodeID()))
 		defer span.End()
@@ -486,6 +1071,11 @@ func (l *Loader) EvaluateDependencies(c *ComponentNode) {
 // evaluate constructs the final context for the BlockNode and
 // evaluates it. mut must be held when calling evaluate.
 func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
+	start := time.Now()
+	defer func() {
+		l.cm.nodeEvaluationTime.WithLabelValues(bn.NodeID()).Observe(time.Since(start).Seconds())
+	}()
+
 	ectx := l.cache.BuildContext()
 	err := bn.Evaluate(ectx)
 
@@ -496,12 +1086,16 @@ func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
 		l.cache.CacheArguments(c.ID(), c.Arguments())
 		l.cache.CacheExports(c.ID(), c.Exports())
 	case *ArgumentConfigNode:
-		if _, found := l.cache.moduleArguments[c.Label()]; !found {
+		if v, found := l.cache.moduleArguments[c.Label()]; !found {
 			if c.Optional() {
 				l.cache.CacheModuleArgument(c.Label(), c.Default())
 			} else {
 				err = fmt.Errorf("missing required argument %q to module", c.Label())
 			}
+		} else if err == nil {
+			if checkErr := c.Check(v); checkErr != nil {
+				err = invalidArgumentError{name: c.Label(), err: checkErr}
+			}
 		}
 	}
 
@@ -512,6 +1106,17 @@ func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
 	return nil
 }
 
+// invalidArgumentError is returned when the value provided for a module
+// argument doesn't match the type or constraints of its argument block.
+type invalidArgumentError struct {
+	name string
+	err  error
+}
+
+func (e invalidArgumentError) Error() string {
+	return fmt.Sprintf("invalid value for argument %q to module: %s", e.name, e.err)
+}
+
 func multierrToDiags(errors error) diag.Diagnostics {
 	var diags diag.Diagnostics
 	for _, err := range errors.(*multierror.Error).Errors {
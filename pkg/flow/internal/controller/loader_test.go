@@ -6,7 +6,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/logging"
@@ -69,7 +68,6 @@ func TestLoader(t *testing.T) {
 		return controller.ComponentGlobals{
 			Logger:            l,
 			TraceProvider:     trace.NewNoopTracerProvider(),
-			Clusterer:         noOpClusterer(),
 			DataPath:          t.TempDir(),
 			OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
 			Registerer:        prometheus.NewRegistry(),
@@ -218,7 +216,6 @@ func TestScopeWithFailingComponent(t *testing.T) {
 			DataPath:          t.TempDir(),
 			OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
 			Registerer:        prometheus.NewRegistry(),
-			Clusterer:         noOpClusterer(),
 			NewModuleController: func(id string) controller.ModuleController {
 				return nil
 			},
@@ -232,8 +229,262 @@ func TestScopeWithFailingComponent(t *testing.T) {
 	require.True(t, strings.Contains(diags.Error(), `unrecognized attribute name "frequenc"`))
 }
 
-func noOpClusterer() *cluster.Clusterer {
-	return &cluster.Clusterer{Node: cluster.NewLocalNode("")}
+// TestLoader_EvaluateDependencies ensures that components which depend on
+// multiple updated components are only evaluated once per batch.
+func TestLoader_EvaluateDependencies(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "a" {
+			input = "hello, world!"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.b.output
+		}
+	`
+
+	reg := prometheus.NewRegistry()
+	l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	loader := controller.NewLoader(controller.ComponentGlobals{
+		Logger:            l,
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		DataPath:          t.TempDir(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+		Registerer:        reg,
+		NewModuleController: func(id string) controller.ModuleController {
+			return nil
+		},
+	})
+	diags := applyFromContent(t, loader, []byte(testFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+	require.Equal(t, uint64(1), nodeEvaluations(t, reg, "testcomponents.passthrough.c"))
+
+	var updated []*controller.ComponentNode
+	for _, cn := range loader.Components() {
+		switch cn.NodeID() {
+		case "testcomponents.passthrough.a", "testcomponents.passthrough.b":
+			updated = append(updated, cn)
+		}
+	}
+	require.Len(t, updated, 2)
+
+	loader.EvaluateDependencies(updated)
+
+	// a had its exports changed and isn't a dependant of another updated
+	// component, so it shouldn't be evaluated again. b depends on a so it must
+	// be evaluated, and c must be evaluated exactly once even though it
+	// indirectly depends on both a and b.
+	require.Equal(t, uint64(1), nodeEvaluations(t, reg, "testcomponents.passthrough.a"))
+	require.Equal(t, uint64(2), nodeEvaluations(t, reg, "testcomponents.passthrough.b"))
+	require.Equal(t, uint64(2), nodeEvaluations(t, reg, "testcomponents.passthrough.c"))
+}
+
+// TestLoader_Metrics ensures that the queue size metric reports the depth of
+// the update queue and that metrics are removed when the loader is cleaned
+// up.
+func TestLoader_Metrics(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "a" {
+			input = "hello, world!"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+	`
+
+	var (
+		reg     = prometheus.NewRegistry()
+		queue   = controller.NewQueue()
+		removed []string
+	)
+	l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	loader := controller.NewLoader(controller.ComponentGlobals{
+		Logger:            l,
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		DataPath:          t.TempDir(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+		UpdateQueue:       queue,
+		OnComponentsRemoved: func(globalIDs []string) {
+			removed = append(removed, globalIDs...)
+		},
+		Registerer: reg,
+		NewModuleController: func(id string) controller.ModuleController {
+			return nil
+		},
+	})
+	diags := applyFromContent(t, loader, []byte(testFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+	require.Equal(t, float64(0), gaugeValue(t, reg, "agent_component_evaluation_queue_size"))
+
+	for _, cn := range loader.Components() {
+		queue.Enqueue(cn)
+	}
+	require.Equal(t, float64(2), gaugeValue(t, reg, "agent_component_evaluation_queue_size"))
+
+	loader.EvaluateDependencies(queue.DequeueAll())
+	require.Equal(t, float64(0), gaugeValue(t, reg, "agent_component_evaluation_queue_size"))
+	require.Equal(t, uint64(2), nodeEvaluations(t, reg, "testcomponents.passthrough.b"))
+
+	// Cleaning up the loader, such as when its module is torn down, removes
+	// its metrics and reports its components as removed.
+	loader.Cleanup()
+	require.Equal(t, uint64(0), nodeEvaluations(t, reg, "testcomponents.passthrough.b"))
+	require.ElementsMatch(t, []string{
+		"testcomponents.passthrough.a",
+		"testcomponents.passthrough.b",
+	}, removed)
+}
+
+// TestLoader_PartialReload ensures that Apply only evaluates components whose
+// blocks changed along with their dependants.
+func TestLoader_PartialReload(t *testing.T) {
+	startFile := `
+		testcomponents.passthrough "a" {
+			input = "hello, world!"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "static" {
+			input = "static"
+		}
+
+		testcomponents.passthrough "remove_me" {
+			input = "goodbye"
+		}
+	`
+
+	reg := prometheus.NewRegistry()
+	l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	loader := controller.NewLoader(controller.ComponentGlobals{
+		Logger:            l,
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		DataPath:          t.TempDir(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+		Registerer:        reg,
+		NewModuleController: func(id string) controller.ModuleController {
+			return nil
+		},
+	})
+	diags := applyFromContent(t, loader, []byte(startFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+	require.Equal(t, controller.ReloadSummary{
+		Added: []string{
+			"testcomponents.passthrough.a",
+			"testcomponents.passthrough.b",
+			"testcomponents.passthrough.remove_me",
+			"testcomponents.passthrough.static",
+		},
+	}, loader.LastReloadSummary())
+
+	t.Run("Whitespace and comments", func(t *testing.T) {
+		reformatted := `
+			// Comments and formatting don't change the meaning of a block.
+			testcomponents.passthrough "a" { input = "hello, world!" }
+
+			testcomponents.passthrough "b" {
+				input = testcomponents.passthrough.a.output // Forward a
+			}
+
+			testcomponents.passthrough "static" {
+				input =    "static"
+			}
+
+			testcomponents.passthrough "remove_me" {
+				input = "goodbye"
+			}
+		`
+		diags := applyFromContent(t, loader, []byte(reformatted), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, controller.ReloadSummary{
+			Unchanged: []string{
+				"testcomponents.passthrough.a",
+				"testcomponents.passthrough.b",
+				"testcomponents.passthrough.remove_me",
+				"testcomponents.passthrough.static",
+			},
+		}, loader.LastReloadSummary())
+
+		for _, id := range []string{"a", "b", "static", "remove_me"} {
+			require.Equal(t, uint64(1), nodeEvaluations(t, reg, "testcomponents.passthrough."+id))
+		}
+	})
+
+	t.Run("Changed component and dependants", func(t *testing.T) {
+		updated := `
+			testcomponents.passthrough "a" {
+				input = "hello, world!!"
+			}
+
+			testcomponents.passthrough "b" {
+				input = testcomponents.passthrough.a.output
+			}
+
+			testcomponents.passthrough "static" {
+				input = "static"
+			}
+
+			testcomponents.passthrough "c" {
+				input = testcomponents.passthrough.static.output
+			}
+		`
+		diags := applyFromContent(t, loader, []byte(updated), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, controller.ReloadSummary{
+			Added:     []string{"testcomponents.passthrough.c"},
+			Removed:   []string{"testcomponents.passthrough.remove_me"},
+			Updated:   []string{"testcomponents.passthrough.a", "testcomponents.passthrough.b"},
+			Unchanged: []string{"testcomponents.passthrough.static"},
+		}, loader.LastReloadSummary())
+
+		require.Equal(t, uint64(2), nodeEvaluations(t, reg, "testcomponents.passthrough.a"))
+		require.Equal(t, uint64(2), nodeEvaluations(t, reg, "testcomponents.passthrough.b"))
+		require.Equal(t, uint64(1), nodeEvaluations(t, reg, "testcomponents.passthrough.static"))
+		require.Equal(t, uint64(1), nodeEvaluations(t, reg, "testcomponents.passthrough.c"))
+	})
+}
+
+// nodeEvaluations returns the number of times the node with the given ID has
+// been evaluated.
+func nodeEvaluations(t *testing.T, g prometheus.Gatherer, nodeID string) uint64 {
+	t.Helper()
+
+	families, err := g.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "agent_component_node_evaluation_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "node_id" && lp.GetValue() == nodeID {
+					return m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gatherer, name string) float64 {
+	t.Helper()
+
+	families, err := g.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) > 0 {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return 0
 }
 
 func applyFromContent(t *testing.T, l *controller.Loader, componentBytes []byte, configBytes []byte) diag.Diagnostics {
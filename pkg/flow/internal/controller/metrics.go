@@ -8,10 +8,13 @@ import (
 type controllerMetrics struct {
 	controllerEvaluation    prometheus.Gauge
 	componentEvaluationTime prometheus.Histogram
+	evaluationQueueSize     prometheus.GaugeFunc
+	nodeEvaluationTime      *prometheus.HistogramVec
 }
 
-// newControllerMetrics inits the metrics for the components controller
-func newControllerMetrics(id string) *controllerMetrics {
+// newControllerMetrics inits the metrics for the components controller. The
+// size of queue is reported if it's non-nil.
+func newControllerMetrics(id string, queue *Queue) *controllerMetrics {
 	cm := &controllerMetrics{}
 
 	cm.controllerEvaluation = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -27,22 +30,48 @@ func newControllerMetrics(id string) *controllerMetrics {
 			ConstLabels: map[string]string{"controller_id": id},
 		},
 	)
+
+	cm.evaluationQueueSize = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "agent_component_evaluation_queue_size",
+		Help:        "Number of components with updated exports waiting for their dependants to be evaluated",
+		ConstLabels: map[string]string{"controller_id": id},
+	}, func() float64 {
+		if queue == nil {
+			return 0
+		}
+		return float64(queue.Len())
+	})
+
+	cm.nodeEvaluationTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "agent_component_node_evaluation_seconds",
+			Help:        "Time spent evaluating an individual node in the graph",
+			ConstLabels: map[string]string{"controller_id": id},
+		},
+		[]string{"node_id"},
+	)
 	return cm
 }
 
 func (cm *controllerMetrics) Collect(ch chan<- prometheus.Metric) {
 	cm.componentEvaluationTime.Collect(ch)
 	cm.controllerEvaluation.Collect(ch)
+	cm.evaluationQueueSize.Collect(ch)
+	cm.nodeEvaluationTime.Collect(ch)
 }
 
 func (cm *controllerMetrics) Describe(ch chan<- *prometheus.Desc) {
 	cm.componentEvaluationTime.Describe(ch)
-	cm.componentEvaluationTime.Describe(ch)
+	cm.controllerEvaluation.Describe(ch)
+	cm.evaluationQueueSize.Describe(ch)
+	cm.nodeEvaluationTime.Describe(ch)
 }
 
 type controllerCollector struct {
 	l                      *Loader
 	runningComponentsTotal *prometheus.Desc
+	componentRestartsTotal *prometheus.Desc
+	componentStuckTotal    *prometheus.Desc
 }
 
 func newControllerCollector(l *Loader, id string) *controllerCollector {
@@ -54,6 +83,18 @@ func newControllerCollector(l *Loader, id string) *controllerCollector {
 			[]string{"health_type"},
 			map[string]string{"controller_id": id},
 		),
+		componentRestartsTotal: prometheus.NewDesc(
+			"agent_component_restarts_total",
+			"Total number of times a component was restarted after exiting.",
+			[]string{"component_id"},
+			map[string]string{"controller_id": id},
+		),
+		componentStuckTotal: prometheus.NewDesc(
+			"agent_component_stuck_total",
+			"Total number of times a component didn't respond to being built, updated, or shut down in time.",
+			[]string{"component_id"},
+			map[string]string{"controller_id": id},
+		),
 	}
 }
 
@@ -63,7 +104,10 @@ func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 	for _, component := range cc.l.Components() {
 		health := component.CurrentHealth().Health.String()
 		componentsByHealth[health]++
-		component.registry.Collect(ch)
+		component.registry.Load().Collect(ch)
+
+		ch <- prometheus.MustNewConstMetric(cc.componentRestartsTotal, prometheus.CounterValue, float64(component.Restarts()), component.globalID)
+		ch <- prometheus.MustNewConstMetric(cc.componentStuckTotal, prometheus.CounterValue, float64(component.StuckCount()), component.globalID)
 	}
 
 	for health, count := range componentsByHealth {
@@ -73,4 +117,6 @@ func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 
 func (cc *controllerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- cc.runningComponentsTotal
+	ch <- cc.componentRestartsTotal
+	ch <- cc.componentStuckTotal
 }
@@ -1,6 +1,9 @@
 package controller
 
-import "github.com/grafana/agent/component"
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/ast"
+)
 
 // ModuleController is a lower-level interface for module controllers which
 // allows probing for the list of managed modules.
@@ -10,3 +13,13 @@ type ModuleController interface {
 	// ModuleIDs returns the list of managed modules in unspecified order.
 	ModuleIDs() []string
 }
+
+// ModuleBodyLoader is implemented by modules which can load an already parsed
+// River body instead of River text. Modules created by a ModuleController
+// must implement ModuleBodyLoader to support components defined by declare
+// blocks.
+type ModuleBodyLoader interface {
+	// LoadBody loads the statements in body into the module. LoadBody can be
+	// called multiple times, and called prior to running the module.
+	LoadBody(body ast.Body, args map[string]any) error
+}
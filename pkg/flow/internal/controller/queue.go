@@ -2,18 +2,21 @@ package controller
 
 import "sync"
 
-// Queue is an unordered queue of components.
+// Queue is a queue of components which coalesces repeated entries.
 //
 // Queue is intended for tracking components that have updated their Exports
-// for later reevaluation.
+// for later reevaluation. Components are dequeued in the order they were first
+// enqueued; enqueuing a component which is already in the Queue does not
+// change its position.
 type Queue struct {
 	mut    sync.Mutex
 	queued map[*ComponentNode]struct{}
+	order  []*ComponentNode
 
 	updateCh chan struct{}
 }
 
-// NewQueue returns a new unordered component queue.
+// NewQueue returns a new component queue.
 func NewQueue() *Queue {
 	return &Queue{
 		updateCh: make(chan struct{}, 1),
@@ -26,7 +29,12 @@ func NewQueue() *Queue {
 func (q *Queue) Enqueue(c *ComponentNode) {
 	q.mut.Lock()
 	defer q.mut.Unlock()
-	q.queued[c] = struct{}{}
+
+	if _, queued := q.queued[c]; !queued {
+		q.queued[c] = struct{}{}
+		q.order = append(q.order, c)
+	}
+
 	select {
 	case q.updateCh <- struct{}{}:
 	default:
@@ -36,16 +44,45 @@ func (q *Queue) Enqueue(c *ComponentNode) {
 // Chan returns a channel which is written to when the queue is non-empty.
 func (q *Queue) Chan() <-chan struct{} { return q.updateCh }
 
-// TryDequeue dequeues a randomly queued component. TryDequeue will return nil
+// Len returns the number of components currently in the Queue.
+func (q *Queue) Len() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	return len(q.order)
+}
+
+// TryDequeue dequeues the oldest queued component. TryDequeue will return nil
 // if the queue is empty.
 func (q *Queue) TryDequeue() *ComponentNode {
 	q.mut.Lock()
 	defer q.mut.Unlock()
 
-	for c := range q.queued {
-		delete(q.queued, c)
-		return c
+	if len(q.order) == 0 {
+		return nil
+	}
+
+	c := q.order[0]
+	q.order[0] = nil // Allow c to be garbage collected once it's processed.
+	q.order = q.order[1:]
+	delete(q.queued, c)
+	return c
+}
+
+// DequeueAll removes and returns every queued component in the order they
+// were enqueued. DequeueAll returns nil if the queue is empty.
+//
+// Callers should prefer DequeueAll over repeated calls to TryDequeue so that
+// components which share dependants can be reevaluated as a single batch.
+func (q *Queue) DequeueAll() []*ComponentNode {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if len(q.order) == 0 {
+		return nil
 	}
 
-	return nil
+	all := q.order
+	q.order = nil
+	q.queued = make(map[*ComponentNode]struct{})
+	return all
 }
@@ -14,3 +14,45 @@ func TestEnqueueDequeue(t *testing.T) {
 	fn := q.TryDequeue()
 	require.True(t, fn == tn)
 }
+
+func TestEnqueueCoalesces(t *testing.T) {
+	var (
+		first  = &ComponentNode{nodeID: "first"}
+		second = &ComponentNode{nodeID: "second"}
+	)
+
+	q := NewQueue()
+	q.Enqueue(first)
+	q.Enqueue(second)
+	q.Enqueue(first)
+	require.Equal(t, 2, q.Len())
+
+	// Enqueuing an existing component must not change its position.
+	require.True(t, q.TryDequeue() == first)
+	require.True(t, q.TryDequeue() == second)
+	require.Nil(t, q.TryDequeue())
+}
+
+func TestDequeueAll(t *testing.T) {
+	var (
+		first  = &ComponentNode{nodeID: "first"}
+		second = &ComponentNode{nodeID: "second"}
+	)
+
+	q := NewQueue()
+	require.Nil(t, q.DequeueAll())
+
+	q.Enqueue(second)
+	q.Enqueue(first)
+	q.Enqueue(second)
+
+	all := q.DequeueAll()
+	require.Len(t, all, 2)
+	require.True(t, all[0] == second)
+	require.True(t, all[1] == first)
+	require.Equal(t, 0, q.Len())
+
+	// Components can be enqueued again after being dequeued.
+	q.Enqueue(first)
+	require.Equal(t, 1, q.Len())
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/river/ast"
+	"golang.org/x/exp/slices"
+)
+
+// ReloadSummary summarizes how the components of a Loader changed after a
+// call to Apply. Each field holds a sorted list of component node IDs.
+type ReloadSummary struct {
+	Added     []string // Components which did not exist before.
+	Removed   []string // Components which no longer exist.
+	Updated   []string // Existing components which were reevaluated.
+	Unchanged []string // Existing components which were not reevaluated.
+}
+
+// String returns a one-line description of the number of components in each
+// category.
+func (s ReloadSummary) String() string {
+	return fmt.Sprintf(
+		"added=%d removed=%d updated=%d unchanged=%d",
+		len(s.Added), len(s.Removed), len(s.Updated), len(s.Unchanged),
+	)
+}
+
+func (s *ReloadSummary) sort() {
+	sort.Strings(s.Added)
+	sort.Strings(s.Removed)
+	sort.Strings(s.Updated)
+	sort.Strings(s.Unchanged)
+}
+
+// graphSnapshot records the nodes of a graph and their blocks before a new
+// graph is loaded. Existing nodes are reused across loads and have their
+// blocks updated in place, so the blocks must be recorded ahead of time to
+// detect changes.
+type graphSnapshot struct {
+	nodes  map[string]dag.Node
+	blocks map[string]*ast.BlockStmt
+	each   map[string]map[string]any // Value of the each variable of instances
+}
+
+func newGraphSnapshot(g *dag.Graph) *graphSnapshot {
+	nodes := g.Nodes()
+
+	s := &graphSnapshot{
+		nodes:  make(map[string]dag.Node, len(nodes)),
+		blocks: make(map[string]*ast.BlockStmt, len(nodes)),
+		each:   make(map[string]map[string]any),
+	}
+	for _, n := range nodes {
+		s.nodes[n.NodeID()] = n
+		if bn, ok := n.(BlockNode); ok {
+			s.blocks[n.NodeID()] = bn.Block()
+		}
+		if cn, ok := n.(*ComponentNode); ok && cn.IsInstance() {
+			s.each[n.NodeID()] = cn.Each()
+		}
+	}
+	return s
+}
+
+// Exists returns true if a node with the same ID as n existed in the
+// snapshot.
+func (s *graphSnapshot) Exists(n dag.Node) bool {
+	_, ok := s.nodes[n.NodeID()]
+	return ok
+}
+
+// Changed returns true if n is a new node or if its block differs
+// structurally from the block recorded in the snapshot. Differences in
+// whitespace and comments are ignored.
+//
+// Config nodes are recreated on every load, so they are compared by block
+// only; components are also considered changed if they were replaced by a
+// different node with the same ID.
+//
+// Instances of blocks with for_each are also considered changed if the value
+// of their each variable changed, and blocks with for_each are considered
+// changed if their set of instances changed.
+//
+// Blocks which call functions are always considered changed, since functions
+// such as env may return a different value on every load.
+func (s *graphSnapshot) Changed(n BlockNode) bool {
+	prev, ok := s.nodes[n.NodeID()]
+	if !ok {
+		return true
+	}
+	switch n := n.(type) {
+	case *ComponentNode:
+		if prev != dag.Node(n) {
+			return true
+		}
+		if n.IsInstance() && !reflect.DeepEqual(s.each[n.NodeID()], n.Each()) {
+			return true
+		}
+	case *ForEachNode:
+		prevForEach, ok := prev.(*ForEachNode)
+		if !ok || !slices.Equal(prevForEach.InstanceIDs(), n.InstanceIDs()) {
+			return true
+		}
+	}
+
+	block := n.Block()
+	return !ast.Equal(s.blocks[n.NodeID()], block) || containsCall(block)
+}
+
+// containsCall returns true if n contains a function call.
+func containsCall(n ast.Node) bool {
+	var v callFinder
+	ast.Walk(&v, n)
+	return v.found
+}
+
+type callFinder struct{ found bool }
+
+func (v *callFinder) Visit(n ast.Node) ast.Visitor {
+	if _, ok := n.(*ast.CallExpr); ok {
+		v.found = true
+	}
+	if v.found {
+		return nil
+	}
+	return v
+}
+
+// Removed returns the IDs of components in the snapshot which are no longer
+// in g.
+func (s *graphSnapshot) Removed(g *dag.Graph) []string {
+	var removed []string
+	for id, n := range s.nodes {
+		if _, ok := n.(*ComponentNode); !ok {
+			continue
+		}
+		if g.GetByID(id) == nil {
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // RunnableNode is any dag.Node which can also be run.
@@ -14,25 +15,32 @@ type RunnableNode interface {
 
 // Scheduler runs components.
 type Scheduler struct {
-	ctx     context.Context
-	cancel  context.CancelFunc
-	running sync.WaitGroup
-
-	tasksMut sync.Mutex
-	tasks    map[string]*task
+	ctx         context.Context
+	cancel      context.CancelFunc
+	running     sync.WaitGroup
+	stopTimeout time.Duration
+
+	tasksMut  sync.Mutex
+	tasks     map[string]*task
+	abandoned map[*task]struct{} // Tasks which didn't stop in time and haven't exited yet.
 }
 
 // NewScheduler creates a new Scheduler. Call Synchronize to manage the set of
 // components which are running.
 //
+// Synchronize waits at most stopTimeout for removed components to stop. If
+// stopTimeout is zero, Synchronize waits until removed components stop.
+//
 // Call Close to stop the Scheduler and all running components.
-func NewScheduler() *Scheduler {
+func NewScheduler(stopTimeout time.Duration) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
-		ctx:    ctx,
-		cancel: cancel,
+		ctx:         ctx,
+		cancel:      cancel,
+		stopTimeout: stopTimeout,
 
-		tasks: make(map[string]*task),
+		tasks:     make(map[string]*task),
+		abandoned: make(map[*task]struct{}),
 	}
 }
 
@@ -44,6 +52,11 @@ func NewScheduler() *Scheduler {
 //
 // Existing components will be restarted if they stopped since the previous
 // call to Synchronize.
+//
+// Components which don't stop within the stop timeout of the Scheduler are
+// abandoned and left to stop in the background. Abandoned components are no
+// longer managed by Synchronize, so a new RunnableNode with the same ID is
+// launched by the next call to Synchronize which includes it.
 func (s *Scheduler) Synchronize(rr []RunnableNode) error {
 	s.tasksMut.Lock()
 	defer s.tasksMut.Unlock()
@@ -58,16 +71,20 @@ func (s *Scheduler) Synchronize(rr []RunnableNode) error {
 	}
 
 	// Stop tasks that are not defined in rr.
-	var stopping sync.WaitGroup
+	var (
+		stopping sync.WaitGroup
+		stopped  = make(map[string]*task)
+	)
 	for id, t := range s.tasks {
 		if _, keep := newRunnables[id]; keep {
 			continue
 		}
 
+		stopped[id] = t
 		stopping.Add(1)
 		go func(t *task) {
 			defer stopping.Done()
-			t.Stop()
+			t.Stop(s.stopTimeout)
 		}(t)
 	}
 
@@ -80,6 +97,7 @@ func (s *Scheduler) Synchronize(rr []RunnableNode) error {
 		var (
 			nodeID      = id
 			newRunnable = r
+			t           *task
 		)
 
 		opts := taskOptions{
@@ -90,16 +108,31 @@ func (s *Scheduler) Synchronize(rr []RunnableNode) error {
 
 				s.tasksMut.Lock()
 				defer s.tasksMut.Unlock()
-				delete(s.tasks, nodeID)
+
+				// Another task with the same ID may have been launched after t
+				// was abandoned.
+				if s.tasks[nodeID] == t {
+					delete(s.tasks, nodeID)
+				}
+				delete(s.abandoned, t)
 			},
 		}
 
+		// OnDone can't run before t is assigned, since it waits for tasksMut.
 		s.running.Add(1)
-		s.tasks[nodeID] = newTask(opts)
+		t = newTask(opts)
+		s.tasks[nodeID] = t
 	}
 
-	// Wait for all stopping runnables to exit.
+	// Wait for all stopping runnables to exit, and abandon those which didn't
+	// exit in time.
 	stopping.Wait()
+	for id, t := range stopped {
+		delete(s.tasks, id)
+		if !t.Exited() {
+			s.abandoned[t] = struct{}{}
+		}
+	}
 	return nil
 }
 
@@ -142,7 +175,30 @@ func newTask(opts taskOptions) *task {
 	return t
 }
 
-func (t *task) Stop() {
+// Exited returns true if the task has exited.
+func (t *task) Exited() bool {
+	select {
+	case <-t.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops the task, waiting at most timeout for it to exit. Stop waits
+// until the task exits if timeout is zero.
+func (t *task) Stop(timeout time.Duration) {
 	t.cancel()
-	<-t.exited
+	if timeout <= 0 {
+		<-t.exited
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-t.exited:
+	case <-timer.C:
+	}
 }
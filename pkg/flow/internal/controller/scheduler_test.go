@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
@@ -24,7 +25,7 @@ func TestScheduler_Synchronize(t *testing.T) {
 			return nil
 		}
 
-		sched := controller.NewScheduler()
+		sched := controller.NewScheduler(0)
 		sched.Synchronize([]controller.RunnableNode{
 			fakeRunnable{ID: "component-a", Component: mockComponent{RunFunc: runFunc}},
 			fakeRunnable{ID: "component-b", Component: mockComponent{RunFunc: runFunc}},
@@ -46,7 +47,7 @@ func TestScheduler_Synchronize(t *testing.T) {
 			return nil
 		}
 
-		sched := controller.NewScheduler()
+		sched := controller.NewScheduler(0)
 
 		for i := 0; i < 10; i++ {
 			// If a new runnable is created, runFunc will panic since the WaitGroup
@@ -72,7 +73,7 @@ func TestScheduler_Synchronize(t *testing.T) {
 			return nil
 		}
 
-		sched := controller.NewScheduler()
+		sched := controller.NewScheduler(0)
 
 		sched.Synchronize([]controller.RunnableNode{
 			fakeRunnable{ID: "component-a", Component: mockComponent{RunFunc: runFunc}},
@@ -84,6 +85,73 @@ func TestScheduler_Synchronize(t *testing.T) {
 		finished.Wait()
 		require.NoError(t, sched.Close())
 	})
+
+	t.Run("Stops waiting for stuck jobs", func(t *testing.T) {
+		var started sync.WaitGroup
+		started.Add(1)
+
+		release := make(chan struct{})
+		runFunc := func(ctx context.Context) error {
+			started.Done()
+			<-ctx.Done()
+			<-release // Ignore cancellation until released.
+			return nil
+		}
+
+		sched := controller.NewScheduler(10 * time.Millisecond)
+
+		sched.Synchronize([]controller.RunnableNode{
+			fakeRunnable{ID: "component-a", Component: mockComponent{RunFunc: runFunc}},
+		})
+		started.Wait()
+
+		// Synchronize must return even though component-a doesn't exit.
+		require.NoError(t, sched.Synchronize([]controller.RunnableNode{}))
+
+		close(release)
+		require.NoError(t, sched.Close())
+	})
+
+	t.Run("Relaunches abandoned jobs", func(t *testing.T) {
+		var (
+			starts  = make(chan struct{}, 2)
+			release = make(chan struct{})
+		)
+		runFunc := func(ctx context.Context) error {
+			starts <- struct{}{}
+			<-ctx.Done()
+			<-release // Ignore cancellation until released.
+			return nil
+		}
+
+		sched := controller.NewScheduler(10 * time.Millisecond)
+		rr := []controller.RunnableNode{
+			fakeRunnable{ID: "component-a", Component: mockComponent{RunFunc: runFunc}},
+		}
+
+		require.NoError(t, sched.Synchronize(rr))
+		<-starts
+
+		// component-a doesn't stop in time and is abandoned.
+		require.NoError(t, sched.Synchronize([]controller.RunnableNode{}))
+
+		// Adding component-a back launches a new instance even though the
+		// abandoned one is still running.
+		require.NoError(t, sched.Synchronize(rr))
+		select {
+		case <-starts:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "component-a was not launched again")
+		}
+
+		// The new instance is managed; synchronizing again doesn't launch
+		// another instance.
+		require.NoError(t, sched.Synchronize(rr))
+		require.Len(t, starts, 0)
+
+		close(release)
+		require.NoError(t, sched.Close())
+	})
 }
 
 type fakeRunnable struct {
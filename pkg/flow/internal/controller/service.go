@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/vm"
+	"github.com/grafana/agent/service"
+)
+
+// ServiceNode is a node in the graph which manages a Flow service. Services
+// are added to the graph of the root controller for its whole lifetime,
+// regardless of whether the config file has a block to configure them.
+//
+// Components which depend on a service and services which depend on other
+// services have an edge to the ServiceNode of their dependency.
+type ServiceNode struct {
+	host service.Host
+	svc  service.Service
+	def  service.Definition
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River block to derive the config from; nil if unset
+	eval  *vm.Evaluator
+	args  any // Most recently applied config of the service
+
+	updatedOnce chan struct{} // Closed after the first successful call to Update
+	updateOnce  sync.Once
+}
+
+var (
+	_ BlockNode    = (*ServiceNode)(nil)
+	_ RunnableNode = (*ServiceNode)(nil)
+)
+
+// NewServiceNode creates a new ServiceNode for svc. host is passed to svc
+// when it is run.
+func NewServiceNode(host service.Host, svc service.Service) *ServiceNode {
+	return &ServiceNode{
+		host: host,
+		svc:  svc,
+		def:  svc.Definition(),
+
+		updatedOnce: make(chan struct{}),
+	}
+}
+
+// NodeID implements dag.Node and returns the name of the service.
+func (sn *ServiceNode) NodeID() string { return sn.def.Name }
+
+// Service returns the service managed by sn.
+func (sn *ServiceNode) Service() service.Service { return sn.svc }
+
+// Definition returns the definition of the managed service.
+func (sn *ServiceNode) Definition() service.Definition { return sn.def }
+
+// Block implements BlockNode and returns the current block which configures
+// the service. Block returns nil if the config file doesn't configure the
+// service.
+func (sn *ServiceNode) Block() *ast.BlockStmt {
+	sn.mut.RLock()
+	defer sn.mut.RUnlock()
+	return sn.block
+}
+
+// UpdateBlock updates the River block used to configure the service. b may be
+// nil to use the default config of the service. The new block isn't used
+// until the next time Evaluate is invoked.
+func (sn *ServiceNode) UpdateBlock(b *ast.BlockStmt) {
+	sn.mut.Lock()
+	defer sn.mut.Unlock()
+
+	sn.block = b
+	sn.eval = nil
+	if b != nil {
+		sn.eval = vm.New(b.Body)
+	}
+}
+
+// Evaluate implements BlockNode. It evaluates the block of the service and
+// passes the resulting config to the service when it changed. Services which
+// don't support configuration are never updated.
+func (sn *ServiceNode) Evaluate(scope *vm.Scope) error {
+	sn.mut.Lock()
+	defer sn.mut.Unlock()
+
+	args, err := sn.evaluateConfig(scope)
+	if err != nil || args == nil {
+		return err
+	}
+
+	if sn.args != nil && reflect.DeepEqual(sn.args, args) {
+		return nil
+	}
+	if err := sn.svc.Update(args); err != nil {
+		return fmt.Errorf("updating service: %w", err)
+	}
+	sn.args = args
+	sn.updateOnce.Do(func() { close(sn.updatedOnce) })
+	return nil
+}
+
+// validateConfig evaluates the block of the service without updating the
+// service.
+func (sn *ServiceNode) validateConfig(scope *vm.Scope) error {
+	sn.mut.RLock()
+	defer sn.mut.RUnlock()
+
+	_, err := sn.evaluateConfig(scope)
+	return err
+}
+
+// evaluateConfig decodes the block of the service into a new value of its
+// ConfigType. A nil config is returned if the service doesn't support
+// configuration. sn.mut must be held when calling evaluateConfig.
+func (sn *ServiceNode) evaluateConfig(scope *vm.Scope) (any, error) {
+	if sn.def.ConfigType == nil {
+		if sn.block != nil && len(sn.block.Body) > 0 {
+			return nil, fmt.Errorf("service %q does not support being configured", sn.def.Name)
+		}
+		return nil, nil
+	}
+
+	// ConfigType may be a value or a pointer. Decode into a new pointer and
+	// dereference it if the service expects a value.
+	var (
+		configType = reflect.TypeOf(sn.def.ConfigType)
+		isPointer  = configType.Kind() == reflect.Pointer
+	)
+	if isPointer {
+		configType = configType.Elem()
+	}
+
+	// Services without a block are configured from an empty body so that
+	// their defaults are applied.
+	eval := sn.eval
+	if eval == nil {
+		eval = vm.New(ast.Body{})
+	}
+
+	argsPointer := reflect.New(configType)
+	if err := eval.Evaluate(scope, argsPointer.Interface()); err != nil {
+		return nil, fmt.Errorf("decoding River: %w", err)
+	}
+
+	if isPointer {
+		return argsPointer.Interface(), nil
+	}
+	return argsPointer.Elem().Interface(), nil
+}
+
+// Run implements RunnableNode and runs the service until ctx is canceled.
+// Services which support configuration aren't started until they have been
+// updated at least once.
+func (sn *ServiceNode) Run(ctx context.Context) error {
+	if sn.def.ConfigType != nil {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sn.updatedOnce:
+		}
+	}
+	return sn.svc.Run(ctx, sn.host)
+}
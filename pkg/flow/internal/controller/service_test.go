@@ -0,0 +1,157 @@
+package controller_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoader_Services(t *testing.T) {
+	newGlobals := func(services ...service.Service) controller.ComponentGlobals {
+		l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+		return controller.ComponentGlobals{
+			Logger:            l,
+			TraceProvider:     trace.NewNoopTracerProvider(),
+			DataPath:          t.TempDir(),
+			OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+			Registerer:        prometheus.NewRegistry(),
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+			Services: services,
+		}
+	}
+
+	t.Run("Components depend on services", func(t *testing.T) {
+		svc := &fakeService{name: "test", data: "hello"}
+		l := controller.NewLoader(newGlobals(svc))
+
+		diags := applyFromContent(t, l, []byte(`testcomponents.service_consumer "a" { }`), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes: []string{"test", "testcomponents.service_consumer.a", "logging", "tracing"},
+			OutEdges: []edge{
+				{From: "testcomponents.service_consumer.a", To: "test"},
+			},
+		})
+
+		exports := l.Components()[0].Exports().(testcomponents.ServiceConsumerExports)
+		require.Equal(t, "hello", exports.Data)
+	})
+
+	t.Run("Services depend on services", func(t *testing.T) {
+		var (
+			svcA = &fakeService{name: "a"}
+			svcB = &fakeService{name: "b", dependsOn: []string{"a"}}
+		)
+		l := controller.NewLoader(newGlobals(svcA, svcB))
+
+		diags := applyFromContent(t, l, nil, nil)
+		require.NoError(t, diags.ErrorOrNil())
+		requireGraph(t, l.Graph(), graphDefinition{
+			Nodes:    []string{"a", "b", "logging", "tracing"},
+			OutEdges: []edge{{From: "b", To: "a"}},
+		})
+	})
+
+	t.Run("Missing service", func(t *testing.T) {
+		l := controller.NewLoader(newGlobals())
+
+		diags := applyFromContent(t, l, []byte(`testcomponents.service_consumer "a" { }`), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `Component "testcomponents.service_consumer" depends on service "test", which does not exist`)
+	})
+
+	t.Run("Service blocks configure services", func(t *testing.T) {
+		svc := &fakeService{name: "test", configurable: true}
+		l := controller.NewLoader(newGlobals(svc))
+
+		diags := applyFromContent(t, l, []byte(`
+			test {
+				value = "configured"
+			}
+		`), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, []fakeServiceConfig{{Value: "configured"}}, svc.updates)
+
+		// Reapplying an unchanged config doesn't update the service again.
+		diags = applyFromContent(t, l, []byte(`
+			test {
+				value = "configured"
+			}
+		`), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Len(t, svc.updates, 1)
+
+		// Removing the block updates the service with its default config.
+		diags = applyFromContent(t, l, nil, nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, []fakeServiceConfig{{Value: "configured"}, {}}, svc.updates)
+	})
+
+	t.Run("Unconfigurable services reject blocks", func(t *testing.T) {
+		svc := &fakeService{name: "test"}
+		l := controller.NewLoader(newGlobals(svc))
+
+		diags := applyFromContent(t, l, []byte(`
+			test {
+				value = "configured"
+			}
+		`), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `service "test" does not support being configured`)
+	})
+
+	t.Run("Services can't be referenced", func(t *testing.T) {
+		svc := &fakeService{name: "test"}
+		l := controller.NewLoader(newGlobals(svc))
+
+		diags := applyFromContent(t, l, []byte(`
+			testcomponents.passthrough "a" {
+				input = test.value
+			}
+		`), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `service "test" cannot be referenced`)
+	})
+}
+
+type fakeServiceConfig struct {
+	Value string `river:"value,attr,optional"`
+}
+
+type fakeService struct {
+	name         string
+	dependsOn    []string
+	configurable bool
+	data         any
+
+	updates []fakeServiceConfig
+}
+
+var _ service.Service = (*fakeService)(nil)
+
+func (fs *fakeService) Definition() service.Definition {
+	def := service.Definition{Name: fs.name, DependsOn: fs.dependsOn}
+	if fs.configurable {
+		def.ConfigType = fakeServiceConfig{}
+	}
+	return def
+}
+
+func (fs *fakeService) Run(ctx context.Context, host service.Host) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (fs *fakeService) Update(newConfig any) error {
+	fs.updates = append(fs.updates, newConfig.(fakeServiceConfig))
+	return nil
+}
+
+func (fs *fakeService) Data() any { return fs.data }
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// Validate checks the provided blocks for errors without building, updating,
+// or running any components, and without changing the state of l.
+//
+// Validate builds a graph from the blocks, which checks for unknown
+// components, invalid references, and cycles. Each block is then evaluated
+// into its arguments type in dependency order. Because components are never
+// built, the exports of every component are assumed to be the zero value of
+// its exports type. Evaluation errors for blocks which reference the exports
+// of other components are reported as warnings, since they may be caused by
+// exports which are only known at runtime.
+//
+// The arguments of import blocks are checked, but imported files are never
+// fetched, so the declarations and functions they provide are unknown.
+// Instances of imported declarations are only checked for valid River, and
+// evaluation errors for blocks which use an import are reported as warnings.
+func (l *Loader) Validate(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) diag.Diagnostics {
+	// Build the graph with a separate Loader so that the nodes of l are never
+	// reused or updated.
+	globals := l.globals
+	globals.Registerer = nil
+	globals.OnComponentUpdate = func(*ComponentNode) {}
+
+	vl := &Loader{
+		log:     l.log,
+		tracer:  l.tracer,
+		globals: globals,
+
+		graph:         &dag.Graph{},
+		originalGraph: &dag.Graph{},
+		cache:         newValueCache(),
+		cm:            newControllerMetrics(globals.ControllerID, globals.UpdateQueue),
+		validating:    true,
+	}
+	vl.services = vl.newServiceNodes()
+
+	for key, value := range args {
+		vl.cache.CacheModuleArgument(key, value)
+	}
+
+	g, diags := vl.loadNewGraph(args, componentBlocks, configBlocks)
+	if diags.HasErrors() {
+		return diags
+	}
+	vl.cache.SyncFunctions(graphFunctions(&g))
+	vl.cache.SyncImports(graphImports(&g))
+	vl.cache.SyncForEachIDs(graphForEachIDs(&g))
+
+	_ = dag.WalkTopological(&g, g.Leaves(), func(n dag.Node) error {
+		var (
+			err      error
+			severity = diag.SeverityLevelError
+		)
+
+		switch n := n.(type) {
+		case *ComponentNode:
+			err = vl.validateComponent(n)
+			if dependsOnRuntimeValues(vl.originalGraph, n) {
+				severity = diag.SeverityLevelWarn
+			}
+		case *ServiceNode:
+			// Services must not be updated while validating.
+			err = n.validateConfig(vl.cache.BuildContext())
+		case *LoggingConfigNode, *TracingConfigNode:
+			// Evaluating logging and tracing blocks reconfigures the process, so
+			// they are only decoded.
+			err = validateConfigBlock(vl.cache.BuildContext(), n.(BlockNode))
+		case *ImportConfigNode:
+			// The arguments of imports were checked when building the graph.
+		case BlockNode:
+			err = vl.evaluate(vl.log, n)
+			if exp, ok := n.(*ExportConfigNode); ok && err == nil {
+				vl.cache.CacheModuleExportValue(exp.Label(), exp.Value())
+			}
+		}
+
+		if err != nil {
+			diags = append(diags, validationDiags(n.(BlockNode), err, severity)...)
+		}
+		return nil
+	})
+
+	return diags
+}
+
+// validateComponent evaluates the arguments of cn and caches the exports it
+// is assumed to have.
+func (l *Loader) validateComponent(cn *ComponentNode) error {
+	args, err := cn.validateArguments(l.cache.BuildContext())
+	if args != nil {
+		l.cache.CacheArguments(cn.ID(), args)
+	}
+	l.cache.CacheExports(cn.ID(), cn.validationExports())
+	return err
+}
+
+// validateConfigBlock decodes the block of a logging or tracing config node
+// without applying it.
+func validateConfigBlock(scope *vm.Scope, bn BlockNode) error {
+	block := bn.Block()
+	if block == nil {
+		// Default config nodes don't have a block.
+		return nil
+	}
+
+	var into any
+	switch bn.(type) {
+	case *LoggingConfigNode:
+		opts := logging.DefaultOptions
+		into = &opts
+	case *TracingConfigNode:
+		opts := tracing.DefaultOptions
+		into = &opts
+	default:
+		panic(fmt.Sprintf("validateConfigBlock: unexpected node type %T", bn))
+	}
+
+	if err := vm.New(block.Body).Evaluate(scope, into); err != nil {
+		return fmt.Errorf("decoding River: %w", err)
+	}
+	return nil
+}
+
+// dependsOnRuntimeValues returns true if n directly depends on a component
+// or an import in g, whose exports and imported functions are unknown during
+// validation.
+func dependsOnRuntimeValues(g *dag.Graph, n dag.Node) bool {
+	for _, dep := range g.Dependencies(n) {
+		switch dep.(type) {
+		case *ComponentNode, *ImportConfigNode:
+			return true
+		}
+	}
+	return false
+}
+
+// validationDiags converts an error from validating bn into diagnostics with
+// the given severity.
+func validationDiags(bn BlockNode, err error, severity diag.Severity) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) {
+		out := make(diag.Diagnostics, len(diags))
+		for i, d := range diags {
+			d.Severity = severity
+			out[i] = d
+		}
+		return out
+	}
+
+	var d diag.Diagnostic
+	if errors.As(err, &d) {
+		d.Severity = severity
+		return diag.Diagnostics{d}
+	}
+
+	msg := "Invalid config block: %s"
+	if _, ok := bn.(*ComponentNode); ok {
+		msg = "Invalid component arguments: %s"
+	}
+	return diag.Diagnostics{{
+		Severity: severity,
+		Message:  fmt.Sprintf(msg, err),
+		StartPos: ast.StartPos(bn.Block()).Position(),
+		EndPos:   ast.EndPos(bn.Block()).Position(),
+	}}
+}
+
+// validationExports returns the exports cn is assumed to have when
+// validating a config without building components.
+func (cn *ComponentNode) validationExports() component.Exports {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	if cn.declare != nil {
+		cn.declare.mut.Lock()
+		defer cn.declare.mut.Unlock()
+		return declaredExports(cn.declare.block.Body)
+	}
+	return cn.reg.Exports
+}
@@ -0,0 +1,133 @@
+package controller_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoader_Validate(t *testing.T) {
+	tt := []struct {
+		name           string
+		config         string
+		expectSeverity diag.Severity // Zero if no diagnostics are expected.
+		expectMessage  string
+	}{
+		{
+			name: "valid",
+			config: `
+				testcomponents.passthrough "a" {
+					input = "hello, world!"
+				}
+
+				testcomponents.passthrough "b" {
+					input = testcomponents.passthrough.a.output
+				}
+			`,
+		},
+		{
+			name: "invalid type",
+			config: `
+				testcomponents.passthrough "a" {
+					input = [1, 2, 3]
+				}
+			`,
+			expectSeverity: diag.SeverityLevelError,
+			expectMessage:  "should be string, got array",
+		},
+		{
+			name: "unknown attribute",
+			config: `
+				testcomponents.passthrough "a" {
+					input   = "hello"
+					unknown = true
+				}
+			`,
+			expectSeverity: diag.SeverityLevelError,
+			expectMessage:  `unrecognized attribute name "unknown"`,
+		},
+		{
+			name: "missing required attribute",
+			config: `
+				testcomponents.passthrough "a" {}
+			`,
+			expectSeverity: diag.SeverityLevelError,
+			expectMessage:  `missing required attribute "input"`,
+		},
+		{
+			name: "unknown reference",
+			config: `
+				testcomponents.passthrough "a" {
+					input = testcomponents.passthrough.missing.output
+				}
+			`,
+			expectSeverity: diag.SeverityLevelError,
+			expectMessage:  `component "testcomponents.passthrough.missing.output" does not exist`,
+		},
+		{
+			name: "cycle",
+			config: `
+				testcomponents.passthrough "a" {
+					input = testcomponents.passthrough.b.output
+				}
+
+				testcomponents.passthrough "b" {
+					input = testcomponents.passthrough.a.output
+				}
+			`,
+			expectSeverity: diag.SeverityLevelError,
+			expectMessage:  "cycle",
+		},
+		{
+			name: "invalid value depending on exports",
+			config: `
+				testcomponents.passthrough "a" {
+					input = "hello"
+				}
+
+				testcomponents.passthrough "b" {
+					input = testcomponents.passthrough.a.output + 1
+				}
+			`,
+			expectSeverity: diag.SeverityLevelWarn,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			l, _ := logging.New(os.Stderr, logging.DefaultOptions)
+			loader := controller.NewLoader(controller.ComponentGlobals{
+				Logger:            l,
+				TraceProvider:     trace.NewNoopTracerProvider(),
+				DataPath:          t.TempDir(),
+				OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+				Registerer:        prometheus.NewRegistry(),
+				NewModuleController: func(id string) controller.ModuleController {
+					return nil
+				},
+			})
+
+			blocks, diags := fileToBlock(t, []byte(tc.config))
+			require.NoError(t, diags.ErrorOrNil())
+
+			diags = loader.Validate(nil, blocks, nil)
+			if tc.expectSeverity == 0 {
+				require.Empty(t, diags)
+			} else {
+				require.NotEmpty(t, diags)
+				require.Equal(t, tc.expectSeverity, diags[0].Severity)
+				require.Contains(t, diags[0].Message, tc.expectMessage)
+			}
+
+			// Validation must never change the state of the loader.
+			require.Empty(t, loader.Graph().Nodes())
+			require.Empty(t, loader.Components())
+		})
+	}
+}
@@ -15,12 +15,15 @@ import (
 // components to be evaluated.
 type valueCache struct {
 	mut                sync.RWMutex
-	components         map[string]ComponentID // NodeID -> ComponentID
-	args               map[string]interface{} // NodeID -> component arguments value
-	exports            map[string]interface{} // NodeID -> component exports value
-	moduleArguments    map[string]any         // key -> module arguments value
-	moduleExports      map[string]any         // name -> value for the value of module exports
-	moduleChangedIndex int                    // Everytime a change occurs this is incremented
+	components         map[string]ComponentID            // NodeID -> ComponentID
+	args               map[string]interface{}            // NodeID -> component arguments value
+	exports            map[string]interface{}            // NodeID -> component exports value
+	moduleArguments    map[string]any                    // key -> module arguments value
+	moduleExports      map[string]any                    // name -> value for the value of module exports
+	moduleChangedIndex int                               // Everytime a change occurs this is incremented
+	functions          *vm.Scope                         // Scope holding functions defined by function blocks
+	imports            map[string]map[string]interface{} // namespace -> functions of the file imported into the namespace
+	forEachIDs         []ComponentID                     // IDs of blocks which use for_each
 }
 
 // newValueCache creates a new ValueCache.
@@ -78,6 +81,27 @@ func (vc *valueCache) CacheModuleArgument(key string, value any) {
 	}
 }
 
+// ModuleArgument returns the cached value of a module argument.
+func (vc *valueCache) ModuleArgument(key string) (any, bool) {
+	vc.mut.RLock()
+	defer vc.mut.RUnlock()
+
+	value, ok := vc.moduleArguments[key]
+	return value, ok
+}
+
+// ModuleArguments returns a copy of all cached module arguments.
+func (vc *valueCache) ModuleArguments() map[string]any {
+	vc.mut.RLock()
+	defer vc.mut.RUnlock()
+
+	args := make(map[string]any, len(vc.moduleArguments))
+	for k, v := range vc.moduleArguments {
+		args[k] = v
+	}
+	return args
+}
+
 // CacheModuleExportValue saves the value to the map
 func (vc *valueCache) CacheModuleExportValue(name string, value any) {
 	vc.mut.Lock()
@@ -158,6 +182,43 @@ func (vc *valueCache) SyncModuleArgs(args map[string]any) {
 	}
 }
 
+// SyncFunctions replaces the set of functions exposed to expressions with
+// fns.
+func (vc *valueCache) SyncFunctions(fns []*vm.Function) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	if len(fns) == 0 {
+		vc.functions = nil
+		return
+	}
+	vc.functions = vm.NewFunctionScope(nil, fns)
+}
+
+// SyncImports replaces the set of functions exposed by imported files with
+// imports, which holds the functions of each imported file by namespace.
+func (vc *valueCache) SyncImports(imports map[string][]*vm.Function) {
+	namespaces := make(map[string]map[string]interface{}, len(imports))
+	for namespace, fns := range imports {
+		if len(fns) > 0 {
+			namespaces[namespace] = vm.NewFunctionNamespace(fns)
+		}
+	}
+
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	vc.imports = namespaces
+}
+
+// SyncForEachIDs replaces the set of IDs of blocks which use for_each with
+// ids. Blocks which use for_each are always exposed as an object, even if
+// they have no instances.
+func (vc *valueCache) SyncForEachIDs(ids []ComponentID) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	vc.forEachIDs = ids
+}
+
 // BuildContext builds a vm.Scope based on the current set of cached values.
 // The arguments and exports for the same ID are merged into one object.
 func (vc *valueCache) BuildContext() *vm.Scope {
@@ -165,7 +226,7 @@ func (vc *valueCache) BuildContext() *vm.Scope {
 	defer vc.mut.RUnlock()
 
 	scope := &vm.Scope{
-		Parent:    nil,
+		Parent:    vc.functions,
 		Variables: make(map[string]interface{}),
 	}
 
@@ -181,6 +242,25 @@ func (vc *valueCache) BuildContext() *vm.Scope {
 		scope.Variables[blockName] = vc.buildValue(ids, 1)
 	}
 
+	// Functions of imported files are fields of their namespace, next to the
+	// instances of components declared by the imported files.
+	for namespace, fns := range vc.imports {
+		ns, ok := scope.Variables[namespace].(map[string]interface{})
+		if !ok {
+			ns = make(map[string]interface{})
+			scope.Variables[namespace] = ns
+		}
+		for name, fn := range fns {
+			ns[name] = fn
+		}
+	}
+
+	// Blocks which use for_each are objects holding their instances by key.
+	// Make sure blocks without instances are still present as empty objects.
+	for _, id := range vc.forEachIDs {
+		insertEmptyObject(scope.Variables, id)
+	}
+
 	// Add module arguments to the scope.
 	if len(vc.moduleArguments) > 0 {
 		scope.Variables["argument"] = make(map[string]any)
@@ -198,6 +278,25 @@ func (vc *valueCache) BuildContext() *vm.Scope {
 	return scope
 }
 
+// insertEmptyObject inserts an empty object into vars at the path described
+// by id, creating intermediate objects as needed. Existing values are left
+// untouched.
+func insertEmptyObject(vars map[string]interface{}, id ComponentID) {
+	for _, name := range id {
+		existing, found := vars[name]
+		if !found {
+			existing = make(map[string]interface{})
+			vars[name] = existing
+		}
+
+		next, ok := existing.(map[string]interface{})
+		if !ok {
+			return
+		}
+		vars = next
+	}
+}
+
 // buildValue recursively converts the set of user components into a single
 // value. offset is used to determine which element in the userComponentName
 // we're looking at.
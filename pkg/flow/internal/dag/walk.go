@@ -132,3 +132,81 @@ func WalkTopological(g *Graph, start []Node, fn WalkFunc) error {
 
 	return nil
 }
+
+// WalkReverseTopological performs a topological walk of all nodes which
+// directly or indirectly depend on the nodes in start. Nodes are visited in
+// dependency order: a node will not be visited until all of its outgoing
+// edges to other walked nodes have been visited first.
+//
+// Each node is passed to fn at most once, even if it is reachable from
+// multiple nodes in start. Nodes in start are only passed to fn if they
+// depend on another node in start.
+func WalkReverseTopological(g *Graph, start []Node, fn WalkFunc) error {
+	var (
+		affected  = make(nodeSet)
+		unchecked = make([]Node, 0, len(start))
+	)
+
+	// Find every node reachable through incoming edges from start. We seed the
+	// search with the dependants of start so that the nodes in start are
+	// excluded unless another node in start leads to them.
+	for _, n := range start {
+		for dep := range g.inEdges[n] {
+			unchecked = append(unchecked, dep)
+		}
+	}
+	for len(unchecked) > 0 {
+		check := unchecked[len(unchecked)-1]
+		unchecked = unchecked[:len(unchecked)-1]
+
+		if affected.Has(check) {
+			continue
+		}
+		affected.Add(check)
+
+		for n := range g.inEdges[check] {
+			unchecked = append(unchecked, n)
+		}
+	}
+
+	// Perform Kahn's algorithm over the affected subgraph. Edges leading to
+	// nodes outside of the subgraph are ignored, since those nodes aren't
+	// going to be walked.
+	var (
+		ready         = make([]Node, 0, len(affected))
+		remainingDeps = make(map[Node]int, len(affected))
+	)
+	for n := range affected {
+		var count int
+		for dep := range g.outEdges[n] {
+			if affected.Has(dep) {
+				count++
+			}
+		}
+		remainingDeps[n] = count
+
+		if count == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	for len(ready) > 0 {
+		check := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+
+		if err := fn(check); err != nil {
+			return err
+		}
+
+		// Every dependant of an affected node is also affected, so there's no
+		// need to check for membership here.
+		for n := range g.inEdges[check] {
+			remainingDeps[n]--
+			if remainingDeps[n] == 0 {
+				ready = append(ready, n)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package dag
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkReverseTopological(t *testing.T) {
+	var (
+		g Graph
+
+		nodeA = stringNode("a")
+		nodeB = stringNode("b")
+		nodeC = stringNode("c")
+		nodeD = stringNode("d")
+		nodeE = stringNode("e")
+	)
+	g.Add(nodeA)
+	g.Add(nodeB)
+	g.Add(nodeC)
+	g.Add(nodeD)
+	g.Add(nodeE)
+
+	// b and c both depend on a, d depends on both b and c, and e depends on
+	// d. Walking from a should visit d exactly once and only after both b and
+	// c.
+	g.AddEdge(Edge{nodeB, nodeA})
+	g.AddEdge(Edge{nodeC, nodeA})
+	g.AddEdge(Edge{nodeD, nodeB})
+	g.AddEdge(Edge{nodeD, nodeC})
+	g.AddEdge(Edge{nodeE, nodeD})
+
+	t.Run("single start node", func(t *testing.T) {
+		visited := walkReverseTopological(t, &g, nodeA)
+		require.Len(t, visited, 4)
+		requireBefore(t, visited, "b", "d")
+		requireBefore(t, visited, "c", "d")
+		requireBefore(t, visited, "d", "e")
+		require.NotContains(t, visited, "a")
+	})
+
+	t.Run("overlapping start nodes", func(t *testing.T) {
+		// b is both a start node and a dependant of a, so it must be walked.
+		// c is a start node but does not depend on any other start node.
+		visited := walkReverseTopological(t, &g, nodeA, nodeB, nodeC, nodeD)
+		require.Equal(t, []string{"b", "c", "d", "e"}, sortedCopy(visited))
+		requireBefore(t, visited, "b", "d")
+		requireBefore(t, visited, "c", "d")
+		requireBefore(t, visited, "d", "e")
+	})
+
+	t.Run("no dependants", func(t *testing.T) {
+		visited := walkReverseTopological(t, &g, nodeE)
+		require.Empty(t, visited)
+	})
+}
+
+func walkReverseTopological(t *testing.T, g *Graph, start ...Node) []string {
+	t.Helper()
+
+	var visited []string
+	err := WalkReverseTopological(g, start, func(n Node) error {
+		require.NotContains(t, visited, n.NodeID(), "node visited twice")
+		visited = append(visited, n.NodeID())
+		return nil
+	})
+	require.NoError(t, err)
+	return visited
+}
+
+func requireBefore(t *testing.T, visited []string, before, after string) {
+	t.Helper()
+
+	var beforeIdx, afterIdx = -1, -1
+	for i, id := range visited {
+		switch id {
+		case before:
+			beforeIdx = i
+		case after:
+			afterIdx = i
+		}
+	}
+	require.True(t, beforeIdx >= 0 && afterIdx >= 0, "expected both %s and %s to be visited", before, after)
+	require.Less(t, beforeIdx, afterIdx, "expected %s to be visited before %s", before, after)
+}
+
+func sortedCopy(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,70 @@
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileArguments holds the arguments of an import.file block.
+type FileArguments struct {
+	// Filename of the file to import.
+	Filename string `river:"filename,attr"`
+	// PollFrequency determines how often the file is checked for changes.
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+}
+
+// DefaultFileArguments holds the default arguments of an import.file block.
+var DefaultFileArguments = FileArguments{
+	PollFrequency: time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *FileArguments) SetToDefault() {
+	*args = DefaultFileArguments
+}
+
+// Validate implements river.Validator.
+func (args *FileArguments) Validate() error {
+	if args.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	return nil
+}
+
+// fileSource imports a file from the local filesystem.
+type fileSource struct {
+	mut  sync.RWMutex
+	args FileArguments
+}
+
+func newFileSource(Options) *fileSource {
+	return &fileSource{}
+}
+
+// Arguments implements ImportSource.
+func (s *fileSource) Arguments() any { return &FileArguments{} }
+
+// Update implements ImportSource.
+func (s *fileSource) Update(_ context.Context, args any) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.args = *args.(*FileArguments)
+	return nil
+}
+
+// Fetch implements ImportSource.
+func (s *fileSource) Fetch(context.Context) ([]byte, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return os.ReadFile(s.args.Filename)
+}
+
+// PollFrequency implements ImportSource.
+func (s *fileSource) PollFrequency() time.Duration {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args.PollFrequency
+}
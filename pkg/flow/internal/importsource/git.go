@@ -0,0 +1,112 @@
+package importsource
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component/common/vcs"
+)
+
+// GitArguments holds the arguments of an import.git block.
+type GitArguments struct {
+	Repository    string        `river:"repository,attr"`
+	Revision      string        `river:"revision,attr,optional"`
+	Path          string        `river:"path,attr"`
+	PullFrequency time.Duration `river:"pull_frequency,attr,optional"`
+
+	GitAuthConfig vcs.GitAuthConfig `river:",squash"`
+}
+
+// DefaultGitArguments holds the default arguments of an import.git block.
+var DefaultGitArguments = GitArguments{
+	Revision:      "HEAD",
+	PullFrequency: time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *GitArguments) SetToDefault() {
+	*args = DefaultGitArguments
+}
+
+// Validate implements river.Validator.
+func (args *GitArguments) Validate() error {
+	if args.PullFrequency <= 0 {
+		return fmt.Errorf("pull_frequency must be greater than 0")
+	}
+	return nil
+}
+
+// gitSource imports a file from a Git repository. Repositories are cloned
+// into the data path of the source, so that restarts only need to fetch new
+// commits.
+type gitSource struct {
+	opts Options
+
+	mut      sync.RWMutex
+	args     GitArguments
+	repo     *vcs.GitRepo
+	repoOpts vcs.GitRepoOptions
+}
+
+func newGitSource(opts Options) *gitSource {
+	return &gitSource{opts: opts}
+}
+
+// Arguments implements ImportSource.
+func (s *gitSource) Arguments() any { return &GitArguments{} }
+
+// Update implements ImportSource.
+func (s *gitSource) Update(ctx context.Context, args any) error {
+	newArgs := *args.(*GitArguments)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	repoOpts := vcs.GitRepoOptions{
+		Repository: newArgs.Repository,
+		Revision:   newArgs.Revision,
+		Auth:       newArgs.GitAuthConfig,
+	}
+
+	if s.repo == nil || !reflect.DeepEqual(repoOpts, s.repoOpts) {
+		// Each repository is cloned into its own directory so that changing the
+		// repository never reuses a clone of a different one.
+		repoPath := filepath.Join(s.opts.DataPath, fmt.Sprintf("%x", sha256.Sum256([]byte(newArgs.Repository))))
+
+		r, err := vcs.NewGitRepo(ctx, repoPath, repoOpts)
+		if err != nil {
+			return err
+		}
+		s.repo = r
+		s.repoOpts = repoOpts
+	}
+
+	s.args = newArgs
+	return nil
+}
+
+// Fetch implements ImportSource. Fetch pulls the latest commits of the
+// repository before reading the file at the configured revision.
+func (s *gitSource) Fetch(ctx context.Context) ([]byte, error) {
+	// Updating the repository changes its worktree, so the lock is held for
+	// writing.
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err := s.repo.Update(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.ReadFile(s.args.Path)
+}
+
+// PollFrequency implements ImportSource.
+func (s *gitSource) PollFrequency() time.Duration {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args.PullFrequency
+}
@@ -0,0 +1,131 @@
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	common_config "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/build"
+	prom_config "github.com/prometheus/common/config"
+)
+
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+// HTTPArguments holds the arguments of an import.http block.
+type HTTPArguments struct {
+	URL           string            `river:"url,attr"`
+	PollFrequency time.Duration     `river:"poll_frequency,attr,optional"`
+	PollTimeout   time.Duration     `river:"poll_timeout,attr,optional"`
+	Headers       map[string]string `river:"headers,attr,optional"`
+
+	Client common_config.HTTPClientConfig `river:"client,block,optional"`
+}
+
+// DefaultHTTPArguments holds the default arguments of an import.http block.
+var DefaultHTTPArguments = HTTPArguments{
+	PollFrequency: time.Minute,
+	PollTimeout:   10 * time.Second,
+	Client:        common_config.DefaultHTTPClientConfig,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *HTTPArguments) SetToDefault() {
+	*args = DefaultHTTPArguments
+}
+
+// Validate implements river.Validator.
+func (args *HTTPArguments) Validate() error {
+	if args.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	if args.PollTimeout <= 0 {
+		return fmt.Errorf("poll_timeout must be greater than 0")
+	}
+	if args.PollTimeout >= args.PollFrequency {
+		return fmt.Errorf("poll_timeout must be less than poll_frequency")
+	}
+
+	if _, err := http.NewRequest(http.MethodGet, args.URL, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// httpSource imports a file from an HTTP server.
+type httpSource struct {
+	opts Options
+
+	mut  sync.RWMutex
+	args HTTPArguments
+	cli  *http.Client
+}
+
+func newHTTPSource(opts Options) *httpSource {
+	return &httpSource{opts: opts}
+}
+
+// Arguments implements ImportSource.
+func (s *httpSource) Arguments() any { return &HTTPArguments{} }
+
+// Update implements ImportSource.
+func (s *httpSource) Update(_ context.Context, args any) error {
+	newArgs := *args.(*HTTPArguments)
+
+	cli, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		s.opts.ID,
+		prom_config.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.args = newArgs
+	s.cli = cli
+	return nil
+}
+
+// Fetch implements ImportSource.
+func (s *httpSource) Fetch(ctx context.Context) ([]byte, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, s.args.PollTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.args.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	for name, value := range s.args.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return bb, nil
+}
+
+// PollFrequency implements ImportSource.
+func (s *httpSource) PollFrequency() time.Duration {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args.PollFrequency
+}
@@ -0,0 +1,91 @@
+// Package importsource implements the sources which import blocks load River
+// files from.
+package importsource
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SourceType identifies where an import block loads its file from.
+type SourceType int
+
+const (
+	File SourceType = iota // Load a file from the local filesystem.
+	Git                    // Load a file from a Git repository.
+	HTTP                   // Load a file from an HTTP server.
+)
+
+// Types holds all of the supported source types.
+var Types = []SourceType{File, Git, HTTP}
+
+// BlockName returns the name of the import block for the source type.
+func (t SourceType) BlockName() string {
+	switch t {
+	case File:
+		return "import.file"
+	case Git:
+		return "import.git"
+	case HTTP:
+		return "import.http"
+	default:
+		panic(fmt.Sprintf("importsource: unknown source type %d", t))
+	}
+}
+
+// GetSourceType returns the source type of the import block with the given
+// name.
+func GetSourceType(blockName string) (SourceType, bool) {
+	for _, t := range Types {
+		if t.BlockName() == blockName {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// Options holds static options for an ImportSource.
+type Options struct {
+	// ID of the import block, used to identify the source in logs and
+	// requests.
+	ID string
+
+	// DataPath is a directory where the source may cache data between runs.
+	// The directory may not exist when the source is created.
+	DataPath string
+}
+
+// ImportSource retrieves the content of the file imported by an import block.
+type ImportSource interface {
+	// Arguments returns a pointer to a new value to decode the import block
+	// into. Update must be called with the decoded value.
+	Arguments() any
+
+	// Update configures the source with arguments returned by Arguments. ctx
+	// is used for any requests needed to configure the source, such as
+	// cloning a repository.
+	Update(ctx context.Context, args any) error
+
+	// Fetch retrieves the current content of the file. Fetch must not be
+	// called before Update.
+	Fetch(ctx context.Context) ([]byte, error)
+
+	// PollFrequency returns how often the source should be fetched to check
+	// for changes.
+	PollFrequency() time.Duration
+}
+
+// New creates a new, unconfigured ImportSource of the given type.
+func New(t SourceType, opts Options) ImportSource {
+	switch t {
+	case File:
+		return newFileSource(opts)
+	case Git:
+		return newGitSource(opts)
+	case HTTP:
+		return newHTTPSource(opts)
+	default:
+		panic(fmt.Sprintf("importsource: unknown source type %d", t))
+	}
+}
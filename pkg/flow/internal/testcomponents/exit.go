@@ -0,0 +1,84 @@
+package testcomponents
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/agent/component"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "testcomponents.exit",
+		Args:    ExitArguments{},
+		Exports: ExitExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return NewExit(opts, args.(ExitArguments))
+		},
+	})
+}
+
+// ExitArguments configures the testcomponents.exit component.
+type ExitArguments struct {
+	// Fail makes the component exit with an error rather than normally.
+	Fail bool `river:"fail,attr,optional"`
+}
+
+// ExitExports describes exported fields for the testcomponents.exit
+// component.
+type ExitExports struct {
+	// Runs is the number of times the current instance of the component has
+	// been run.
+	Runs int `river:"runs,attr,optional"`
+}
+
+// Exit implements the testcomponents.exit component, which exits as soon as
+// it is run. Like most components, it registers its metrics when it is
+// built.
+type Exit struct {
+	opts      component.Options
+	runs      atomic.Int64
+	fail      atomic.Bool
+	runsTotal prometheus.Counter
+}
+
+// NewExit creates a new exit component.
+func NewExit(o component.Options, cfg ExitArguments) (*Exit, error) {
+	t := &Exit{
+		opts: o,
+		runsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "testcomponents_exit_runs_total",
+			Help: "Total number of times the component was run.",
+		}),
+	}
+	o.Registerer.MustRegister(t.runsTotal)
+
+	if err := t.Update(cfg); err != nil {
+		return nil, err
+	}
+	o.OnStateChange(ExitExports{})
+	return t, nil
+}
+
+var (
+	_ component.Component = (*Exit)(nil)
+)
+
+// Run implements Component.
+func (t *Exit) Run(ctx context.Context) error {
+	t.runsTotal.Inc()
+	t.opts.OnStateChange(ExitExports{Runs: int(t.runs.Inc())})
+	if t.fail.Load() {
+		return errors.New("exit requested")
+	}
+	return nil
+}
+
+// Update implements Component.
+func (t *Exit) Update(args component.Arguments) error {
+	t.fail.Store(args.(ExitArguments).Fail)
+	return nil
+}
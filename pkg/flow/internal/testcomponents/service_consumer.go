@@ -0,0 +1,67 @@
+package testcomponents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/agent/component"
+)
+
+// ServiceConsumerService is the name of the service the
+// testcomponents.service_consumer component depends on.
+const ServiceConsumerService = "test"
+
+func init() {
+	component.Register(component.Registration{
+		Name:          "testcomponents.service_consumer",
+		Args:          ServiceConsumerArguments{},
+		Exports:       ServiceConsumerExports{},
+		NeedsServices: []string{ServiceConsumerService},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return NewServiceConsumer(opts, args.(ServiceConsumerArguments))
+		},
+	})
+}
+
+// ServiceConsumerArguments configures the testcomponents.service_consumer
+// component.
+type ServiceConsumerArguments struct{}
+
+// ServiceConsumerExports describes exported fields for the
+// testcomponents.service_consumer component.
+type ServiceConsumerExports struct {
+	Data string `river:"data,attr"`
+}
+
+// ServiceConsumer implements the testcomponents.service_consumer component,
+// which exports the data of the "test" service formatted as a string.
+type ServiceConsumer struct {
+	opts component.Options
+}
+
+var (
+	_ component.Component = (*ServiceConsumer)(nil)
+)
+
+// NewServiceConsumer creates a new service_consumer component.
+func NewServiceConsumer(o component.Options, cfg ServiceConsumerArguments) (*ServiceConsumer, error) {
+	data, err := o.GetServiceData(ServiceConsumerService)
+	if err != nil {
+		return nil, err
+	}
+	o.OnStateChange(ServiceConsumerExports{Data: fmt.Sprint(data)})
+
+	return &ServiceConsumer{opts: o}, nil
+}
+
+// Run implements Component.
+func (t *ServiceConsumer) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (t *ServiceConsumer) Update(args component.Arguments) error {
+	return nil
+}
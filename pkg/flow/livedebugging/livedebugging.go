@@ -0,0 +1,187 @@
+// Package livedebugging implements taps which stream samples of the data
+// flowing through components, such as log entries or metric samples, to
+// clients for debugging.
+//
+// Streams are sampled and rate limited per subscriber, and a tap only has a
+// bounded number of subscribers, so live debugging is safe to use on agents
+// handling production traffic.
+package livedebugging
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRate is the default maximum number of events per second sent to
+	// a subscriber.
+	DefaultRate = 10
+
+	// MaxRate is the highest rate a subscriber may request.
+	MaxRate = 100
+
+	// MaxSubscribers is the maximum number of concurrent subscribers of a tap.
+	MaxSubscribers = 4
+
+	// bufferSize is the number of events buffered for each subscriber. Events
+	// are dropped when a subscriber doesn't keep up.
+	bufferSize = 100
+)
+
+// ErrTooManySubscribers is returned by Subscribe when the tap already has
+// MaxSubscribers subscribers.
+var ErrTooManySubscribers = errors.New("too many live debugging subscribers for this component")
+
+// Options configure a subscription to a tap.
+type Options struct {
+	// Rate is the maximum number of events per second sent to the subscriber.
+	// Rates of zero or less use DefaultRate, and rates above MaxRate are
+	// lowered to MaxRate.
+	Rate float64
+
+	// SampleRatio is the fraction of published events which are considered
+	// for sending, between 0 and 1. Ratios of zero or less, or above 1, send
+	// every event allowed by Rate.
+	SampleRatio float64
+}
+
+// Event is data sent to the subscribers of a tap.
+type Event struct {
+	Time time.Time // When the event was published.
+	Data string    // Human-readable representation of the data.
+}
+
+// Tap streams the data published by a component to its subscribers. The
+// zero value is ready for use.
+//
+// Publishing to a tap without subscribers only costs an atomic load, so
+// components may publish every item flowing through them.
+type Tap struct {
+	active atomic.Bool // Whether subs is non-empty.
+
+	mut  sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Active returns true if the tap has subscribers.
+func (t *Tap) Active() bool { return t.active.Load() }
+
+// Publish offers an event to the subscribers of t. format is only called
+// when at least one subscriber accepts the event, so that data nobody reads
+// is never formatted. Publish never blocks.
+func (t *Tap) Publish(format func() string) {
+	if !t.active.Load() {
+		return
+	}
+
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+
+	var (
+		ev        Event
+		formatted bool
+	)
+	for sub := range t.subs {
+		if !sub.sample() {
+			continue
+		}
+		if !sub.limiter.Allow() {
+			sub.dropped.Add(1)
+			continue
+		}
+
+		if !formatted {
+			ev = Event{Time: time.Now(), Data: format()}
+			formatted = true
+		}
+
+		select {
+		case sub.events <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe starts streaming the events published to t. The returned
+// Subscription must be closed once it is no longer used.
+func (t *Tap) Subscribe(opts Options) (*Subscription, error) {
+	r := opts.Rate
+	switch {
+	case r <= 0:
+		r = DefaultRate
+	case r > MaxRate:
+		r = MaxRate
+	}
+
+	ratio := opts.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if len(t.subs) >= MaxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	sub := &Subscription{
+		tap:         t,
+		events:      make(chan Event, bufferSize),
+		limiter:     rate.NewLimiter(rate.Limit(r), int(r)+1),
+		sampleRatio: ratio,
+	}
+	if t.subs == nil {
+		t.subs = make(map[*Subscription]struct{})
+	}
+	t.subs[sub] = struct{}{}
+	t.active.Store(true)
+	return sub, nil
+}
+
+// Subscription is a subscriber of a tap.
+type Subscription struct {
+	tap         *Tap
+	events      chan Event
+	limiter     *rate.Limiter
+	sampleRatio float64
+	dropped     atomic.Uint64
+
+	closeOnce sync.Once
+}
+
+// Events returns the channel events are sent to. The channel is closed when
+// the subscription is closed.
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Dropped returns the number of sampled events which weren't sent to the
+// subscriber because of its rate limit or because it didn't keep up.
+func (s *Subscription) Dropped() uint64 { return s.dropped.Load() }
+
+// Close stops the subscription. Close is safe to call multiple times.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		t := s.tap
+
+		t.mut.Lock()
+		defer t.mut.Unlock()
+
+		delete(t.subs, s)
+		t.active.Store(len(t.subs) > 0)
+
+		// Publish holds a read lock while sending, so no sends can be in
+		// flight once the write lock is held.
+		close(s.events)
+	})
+}
+
+// sample returns true if an event should be considered for sending to s.
+func (s *Subscription) sample() bool {
+	return s.sampleRatio >= 1 || rand.Float64() < s.sampleRatio
+}
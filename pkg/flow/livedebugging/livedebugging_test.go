@@ -0,0 +1,105 @@
+package livedebugging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTap(t *testing.T) {
+	var tap Tap
+
+	// Data isn't formatted while nobody is subscribed.
+	tap.Publish(func() string {
+		require.FailNow(t, "format called without subscribers")
+		return ""
+	})
+	require.False(t, tap.Active())
+
+	sub, err := tap.Subscribe(Options{})
+	require.NoError(t, err)
+	require.True(t, tap.Active())
+
+	tap.Publish(func() string { return "hello" })
+	ev := <-sub.Events()
+	require.Equal(t, "hello", ev.Data)
+
+	sub.Close()
+	sub.Close() // Closing twice is a no-op.
+	require.False(t, tap.Active())
+
+	_, open := <-sub.Events()
+	require.False(t, open, "events channel should be closed")
+}
+
+func TestTap_RateLimit(t *testing.T) {
+	var tap Tap
+
+	sub, err := tap.Subscribe(Options{Rate: 5})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i < 50; i++ {
+		i := i
+		tap.Publish(func() string { return fmt.Sprint(i) })
+	}
+
+	// The burst of the limiter allows one event more than the rate.
+	require.Len(t, sub.Events(), 6)
+	require.Equal(t, uint64(44), sub.Dropped())
+}
+
+func TestTap_MaxRate(t *testing.T) {
+	var tap Tap
+
+	sub, err := tap.Subscribe(Options{Rate: 10 * MaxRate})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i < 10*MaxRate; i++ {
+		tap.Publish(func() string { return "" })
+	}
+	require.Len(t, sub.Events(), bufferSize)
+}
+
+func TestTap_SampleRatio(t *testing.T) {
+	var tap Tap
+
+	sub, err := tap.Subscribe(Options{Rate: MaxRate, SampleRatio: 0.1})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i < 100; i++ {
+		tap.Publish(func() string { return "" })
+	}
+
+	// Sampled out events aren't counted as dropped.
+	require.Less(t, len(sub.Events()), 50)
+	require.Zero(t, sub.Dropped())
+}
+
+func TestTap_MaxSubscribers(t *testing.T) {
+	var tap Tap
+
+	var subs []*Subscription
+	for i := 0; i < MaxSubscribers; i++ {
+		sub, err := tap.Subscribe(Options{})
+		require.NoError(t, err)
+		subs = append(subs, sub)
+	}
+
+	_, err := tap.Subscribe(Options{})
+	require.ErrorIs(t, err, ErrTooManySubscribers)
+
+	// Closing a subscription makes room for a new one.
+	subs[0].Close()
+	sub, err := tap.Subscribe(Options{})
+	require.NoError(t, err)
+	sub.Close()
+
+	for _, sub := range subs[1:] {
+		sub.Close()
+	}
+	require.False(t, tap.Active())
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile is an io.Writer which writes to a file, rotating it once it
+// grows past a maximum size. Rotated files are renamed by appending an
+// increasing number to the path, where path.1 is the most recently rotated
+// file.
+type rotatingFile struct {
+	path string
+
+	mut        sync.Mutex
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+// openRotatingFile opens the file described by o for appending, creating it
+// and its parent directory if needed.
+func openRotatingFile(o FileOptions) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:       o.Path,
+		maxSize:    int64(o.MaxSize),
+		maxBackups: o.MaxBackups,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// SetLimits changes the rotation limits of rf. The new limits are applied on
+// the next write.
+func (rf *rotatingFile) SetLimits(o FileOptions) {
+	rf.mut.Lock()
+	defer rf.mut.Unlock()
+
+	rf.maxSize = int64(o.MaxSize)
+	rf.maxBackups = o.MaxBackups
+}
+
+func (rf *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("opening log file: %w", err)
+	}
+
+	rf.f = f
+	rf.size = fi.Size()
+	return nil
+}
+
+// Write implements io.Writer. Write rotates the file before writing p if p
+// doesn't fit in the remaining space of the current file.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mut.Lock()
+	defer rf.mut.Unlock()
+
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, shifts existing backups, and opens a new
+// file. rf.mut must be held when calling rotate.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	rf.f = nil
+
+	if rf.maxBackups == 0 {
+		if err := os.Remove(rf.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return rf.open()
+	}
+
+	// Drop the oldest backup and shift the remaining ones by one.
+	_ = os.Remove(rf.backupPath(rf.maxBackups))
+	for i := rf.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(rf.backupPath(i), rf.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(rf.path, rf.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", rf.path, n)
+}
+
+// Close closes the file. Writes after Close fail.
+func (rf *rotatingFile) Close() error {
+	rf.mut.Lock()
+	defer rf.mut.Unlock()
+
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
@@ -3,29 +3,36 @@ package logging
 import (
 	"fmt"
 	"io"
+	"path"
 	"sync"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 )
 
+// componentKey is the key used by the Flow controller to annotate logs with
+// the ID of the component which emitted them.
+const componentKey = "component"
+
 // Logger implements the github.com/go-kit/log.Logger interface. It supports
 // being dynamically updated at runtime.
 type Logger struct {
-	w io.Writer
+	w      io.Writer
+	loki   *lokiSink
+	recent *recentLogs
 
-	mut sync.RWMutex
-	l   log.Logger
+	mut  sync.RWMutex
+	l    log.Logger
+	file *rotatingFile // Current file sink; nil if logs aren't written to a file.
 }
 
 // New creates a New logger with the default log level and format.
 func New(w io.Writer, o Options) (*Logger, error) {
-	inner, err := buildLogger(w, o)
-	if err != nil {
+	l := &Logger{w: w, loki: newLokiSink(), recent: newRecentLogs(recentLines)}
+	if err := l.Update(o); err != nil {
 		return nil, err
 	}
-
-	return &Logger{w: w, l: inner}, nil
+	return l, nil
 }
 
 // Log implements log.Logger.
@@ -37,31 +44,163 @@ func (l *Logger) Log(kvps ...interface{}) error {
 
 // Update re-configures the options used for the logger.
 func (l *Logger) Update(o Options) error {
-	newLogger, err := buildLogger(l.w, o)
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	// Reuse the open file if the path didn't change, so that log lines aren't
+	// lost or reordered between reloads.
+	var (
+		file    = l.file
+		oldFile *rotatingFile
+	)
+	switch {
+	case o.File == nil:
+		file, oldFile = nil, l.file
+	case l.file != nil && l.file.path == o.File.Path:
+		l.file.SetLimits(*o.File)
+	default:
+		newFile, err := openRotatingFile(*o.File)
+		if err != nil {
+			return err
+		}
+		file, oldFile = newFile, l.file
+	}
+
+	newLogger, err := buildLogger(l.w, file, l.recent, l.loki, o)
 	if err != nil {
+		if file != l.file {
+			_ = file.Close()
+		}
 		return err
 	}
 
-	l.mut.Lock()
-	defer l.mut.Unlock()
+	l.loki.UpdateReceivers(o.WriteTo)
 	l.l = newLogger
+	l.file = file
+
+	if oldFile != nil {
+		_ = oldFile.Close()
+	}
 	return nil
 }
 
-func buildLogger(w io.Writer, o Options) (log.Logger, error) {
-	var l log.Logger
+// Close stops forwarding log lines to the receivers configured by the
+// WriteTo option and closes the log file, if any. Log lines written after
+// Close are discarded.
+func (l *Logger) Close() error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
 
-	switch o.Format {
-	case FormatLogfmt:
-		l = log.NewLogfmtLogger(log.NewSyncWriter(w))
-	case FormatJSON:
-		l = log.NewJSONLogger(log.NewSyncWriter(w))
-	default:
-		return nil, fmt.Errorf("unrecognized log format %q", o.Format)
+	l.loki.Close()
+	l.l = log.NewNopLogger()
+
+	var err error
+	if l.file != nil {
+		err = l.file.Close()
+		l.file = nil
+	}
+	return err
+}
+
+// RecentLogs returns the most recent log lines written by l, oldest first.
+// Only log lines which pass the configured level and component filters are
+// kept.
+func (l *Logger) RecentLogs() []byte {
+	return l.recent.Bytes()
+}
+
+func buildLogger(w io.Writer, file *rotatingFile, recent *recentLogs, sink *lokiSink, o Options) (log.Logger, error) {
+	if file != nil {
+		w = io.MultiWriter(w, file)
+	}
+	w = io.MultiWriter(w, recent)
+
+	l, err := newFormatLogger(w, o.Format)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.WriteTo) > 0 {
+		l = multiLogger{l, &lokiLogger{sink: sink, format: o.Format}}
 	}
 
 	l = level.NewFilter(l, o.Level.Filter())
 
+	if len(o.IncludeComponents) > 0 || len(o.ExcludeComponents) > 0 {
+		l = &componentFilter{
+			next:    l,
+			include: o.IncludeComponents,
+			exclude: o.ExcludeComponents,
+		}
+	}
+
 	l = log.With(l, "ts", log.DefaultTimestampUTC)
 	return l, nil
 }
+
+func newFormatLogger(w io.Writer, format Format) (log.Logger, error) {
+	switch format {
+	case FormatLogfmt:
+		return log.NewLogfmtLogger(log.NewSyncWriter(w)), nil
+	case FormatJSON:
+		return log.NewJSONLogger(log.NewSyncWriter(w)), nil
+	default:
+		return nil, fmt.Errorf("unrecognized log format %q", format)
+	}
+}
+
+// multiLogger sends log lines to every logger in the slice.
+type multiLogger []log.Logger
+
+func (ml multiLogger) Log(kvps ...interface{}) error {
+	var firstErr error
+	for _, l := range ml {
+		if err := l.Log(kvps...); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// componentFilter drops log lines of components which don't match the
+// include list or which match the exclude list. Log lines which weren't
+// emitted by a component are always passed through.
+type componentFilter struct {
+	next             log.Logger
+	include, exclude []string
+}
+
+func (f *componentFilter) Log(kvps ...interface{}) error {
+	id, ok := componentID(kvps)
+	if !ok {
+		return f.next.Log(kvps...)
+	}
+
+	if len(f.include) > 0 && !matchAny(f.include, id) {
+		return nil
+	}
+	if matchAny(f.exclude, id) {
+		return nil
+	}
+	return f.next.Log(kvps...)
+}
+
+// componentID returns the ID of the component which emitted a log line.
+func componentID(kvps []interface{}) (string, bool) {
+	for i := 0; i+1 < len(kvps); i += 2 {
+		if k, ok := kvps[i].(string); ok && k == componentKey {
+			id, ok := kvps[i+1].(string)
+			return id, ok
+		}
+	}
+	return "", false
+}
+
+func matchAny(patterns []string, id string) bool {
+	for _, pattern := range patterns {
+		// Patterns are validated when decoding Options, so errors are ignored.
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestLogger_ComponentFilter(t *testing.T) {
+	var buf bytes.Buffer
+
+	opts := DefaultOptions
+	opts.IncludeComponents = []string{"prometheus.*"}
+	opts.ExcludeComponents = []string{"prometheus.scrape.noisy"}
+
+	l, err := New(&buf, opts)
+	require.NoError(t, err)
+
+	level.Info(l).Log("msg", "controller message")
+	level.Info(log.With(l, "component", "prometheus.scrape.default")).Log("msg", "included message")
+	level.Info(log.With(l, "component", "prometheus.scrape.noisy")).Log("msg", "excluded message")
+	level.Info(log.With(l, "component", "loki.write.default")).Log("msg", "not included message")
+
+	out := buf.String()
+	require.Contains(t, out, "controller message")
+	require.Contains(t, out, "included message")
+	require.NotContains(t, out, "excluded message")
+	require.NotContains(t, out, "not included message")
+
+	// Removing the filters at runtime lets all messages through.
+	buf.Reset()
+	require.NoError(t, l.Update(DefaultOptions))
+	level.Info(log.With(l, "component", "prometheus.scrape.noisy")).Log("msg", "excluded message")
+	require.Contains(t, buf.String(), "excluded message")
+}
+
+func TestLogger_File(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "agent.log")
+	)
+
+	opts := DefaultOptions
+	opts.File = &FileOptions{Path: path, MaxSize: 100, MaxBackups: 2}
+
+	l, err := New(&bytes.Buffer{}, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		level.Info(l).Log("msg", strings.Repeat("x", 40))
+	}
+
+	// Each line is larger than half of MaxSize, so every line rotates the
+	// file. Only MaxBackups old files are kept.
+	matches, err := filepath.Glob(path + "*")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{path, path + ".1", path + ".2"}, matches)
+
+	for _, p := range matches {
+		fi, err := os.Stat(p)
+		require.NoError(t, err)
+		require.LessOrEqual(t, fi.Size(), int64(100))
+	}
+
+	// Removing the file block stops writing to the file.
+	require.NoError(t, l.Update(DefaultOptions))
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	level.Info(l).Log("msg", "not in file")
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, bb, after)
+}
+
+func TestLogger_WriteTo(t *testing.T) {
+	receiver := testLogsReceiver(make(chan lokiEntry))
+
+	opts := DefaultOptions
+	opts.WriteTo = []LogsReceiver{receiver}
+
+	l, err := New(&bytes.Buffer{}, opts)
+	require.NoError(t, err)
+
+	level.Info(log.With(l, "component", "prometheus.scrape.default")).Log("msg", "hello")
+
+	select {
+	case e := <-receiver:
+		require.Equal(t, "grafana-agent", string(e.labels["job"]))
+		require.Equal(t, "prometheus.scrape.default", string(e.labels["component"]))
+		require.Contains(t, e.line, "msg=hello")
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for log entry")
+	}
+}
+
+func TestLogger_WriteTo_Stop(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	opts := DefaultOptions
+	opts.WriteTo = []LogsReceiver{testLogsReceiver(make(chan lokiEntry))}
+
+	l, err := New(&bytes.Buffer{}, opts)
+	require.NoError(t, err)
+
+	// Reloading with the same receivers keeps a single goroutine running,
+	// and removing the receivers stops it.
+	require.NoError(t, l.Update(opts))
+	require.NoError(t, l.Update(DefaultOptions))
+
+	// Closing the logger stops the goroutine too.
+	require.NoError(t, l.Update(opts))
+	require.NoError(t, l.Close())
+}
+
+// testLogsReceiver is a LogsReceiver which sends log lines to a channel.
+type testLogsReceiver chan lokiEntry
+
+func (r testLogsReceiver) SendLogLine(ctx context.Context, labels model.LabelSet, ts time.Time, line string) error {
+	select {
+	case r <- lokiEntry{labels: labels, ts: ts, line: line}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestLogger_RecentLogs(t *testing.T) {
+	opts := DefaultOptions
+	opts.Level = LevelInfo
+
+	l, err := New(&bytes.Buffer{}, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < recentLines+10; i++ {
+		level.Info(l).Log("msg", "line", "i", i)
+	}
+	level.Debug(l).Log("msg", "filtered")
+
+	lines := strings.Split(strings.TrimSuffix(string(l.RecentLogs()), "\n"), "\n")
+	require.Len(t, lines, recentLines)
+	require.Contains(t, lines[0], "i=10")
+	require.Contains(t, lines[len(lines)-1], fmt.Sprintf("i=%d", recentLines+9))
+}
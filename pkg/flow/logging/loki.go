@@ -0,0 +1,142 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// lokiQueueSize is the number of log entries which can be buffered before
+	// entries for loki receivers are dropped.
+	lokiQueueSize = 1024
+
+	// lokiSendTimeout is how long to wait for a receiver to accept an entry
+	// before dropping it for that receiver.
+	lokiSendTimeout = 5 * time.Second
+)
+
+// LogsReceiver receives log lines written by a Logger. The receivers exported
+// by loki components, such as loki.write, implement LogsReceiver.
+type LogsReceiver interface {
+	// SendLogLine sends a log line to the receiver, blocking until the
+	// receiver accepts it or ctx is canceled.
+	SendLogLine(ctx context.Context, labels model.LabelSet, ts time.Time, line string) error
+}
+
+// lokiEntry is a log line queued by lokiSink.
+type lokiEntry struct {
+	labels model.LabelSet
+	ts     time.Time
+	line   string
+}
+
+// lokiSink forwards log lines to a set of loki receivers. Lines are queued
+// and forwarded in the background so that logging never blocks on a
+// receiver; lines are dropped when the queue is full. The background
+// goroutine only runs while there are receivers.
+type lokiSink struct {
+	entries chan lokiEntry
+
+	mut       sync.RWMutex
+	receivers []LogsReceiver
+	stop      chan struct{} // Closed to stop the running goroutine; nil if it isn't running
+}
+
+func newLokiSink() *lokiSink {
+	return &lokiSink{entries: make(chan lokiEntry, lokiQueueSize)}
+}
+
+// UpdateReceivers changes the receivers which lines are sent to. The
+// background goroutine is started when the first receiver is added, and
+// stopped when the last receiver is removed.
+func (s *lokiSink) UpdateReceivers(receivers []LogsReceiver) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.receivers = receivers
+
+	switch {
+	case len(receivers) > 0 && s.stop == nil:
+		s.stop = make(chan struct{})
+		go s.run(s.stop)
+	case len(receivers) == 0 && s.stop != nil:
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Close stops the background goroutine of the sink.
+func (s *lokiSink) Close() {
+	s.UpdateReceivers(nil)
+}
+
+// Enqueue queues an entry to be sent to receivers. Enqueue never blocks.
+func (s *lokiSink) Enqueue(e lokiEntry) {
+	select {
+	case s.entries <- e:
+	default:
+		// Queue is full; drop the entry.
+	}
+}
+
+// run sends queued entries to the receivers until stop is closed.
+func (s *lokiSink) run(stop <-chan struct{}) {
+	for {
+		var e lokiEntry
+		select {
+		case <-stop:
+			return
+		case e = <-s.entries:
+		}
+
+		s.mut.RLock()
+		receivers := s.receivers
+		s.mut.RUnlock()
+
+		for _, r := range receivers {
+			// If the receiver isn't accepting entries in time, the entry is
+			// dropped for it.
+			ctx, cancel := context.WithTimeout(context.Background(), lokiSendTimeout)
+			_ = r.SendLogLine(ctx, e.labels.Clone(), e.ts, e.line)
+			cancel()
+		}
+	}
+}
+
+// lokiLogger is a log.Logger which formats log lines and sends them to a
+// lokiSink. Lines emitted by components are labeled with the ID of the
+// component.
+type lokiLogger struct {
+	sink   *lokiSink
+	format Format
+}
+
+var _ log.Logger = (*lokiLogger)(nil)
+
+func (l *lokiLogger) Log(kvps ...interface{}) error {
+	var buf bytes.Buffer
+	inner, err := newFormatLogger(&buf, l.format)
+	if err != nil {
+		return err
+	}
+	if err := inner.Log(kvps...); err != nil {
+		return err
+	}
+
+	labels := model.LabelSet{"job": "grafana-agent"}
+	if id, ok := componentID(kvps); ok {
+		labels["component"] = model.LabelValue(id)
+	}
+
+	l.sink.Enqueue(lokiEntry{
+		labels: labels,
+		ts:     time.Now(),
+		line:   strings.TrimSuffix(buf.String(), "\n"),
+	})
+	return nil
+}
@@ -3,7 +3,9 @@ package logging
 import (
 	"encoding"
 	"fmt"
+	"path"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/pkg/river"
 )
@@ -13,6 +15,26 @@ type Options struct {
 	Level  Level  `river:"level,attr,optional"`
 	Format Format `river:"format,attr,optional"`
 
+	// File optionally writes logs to a rotating file in addition to the
+	// writer passed to New.
+	File *FileOptions `river:"file,block,optional"`
+
+	// WriteTo holds a set of loki receivers where logs should be sent.
+	//
+	// Components which receive log lines through WriteTo may log about the
+	// lines they receive, which are then sent back to them. Lines are dropped
+	// once the queue of lines for WriteTo is full, so this doesn't grow
+	// without bound, but lines of such components should be excluded with
+	// ExcludeComponents.
+	WriteTo []LogsReceiver `river:"write_to,attr,optional"`
+
+	// IncludeComponents and ExcludeComponents filter logs emitted by
+	// components by their ID. Each entry is a pattern matched with
+	// path.Match. Logs which weren't emitted by a component are never
+	// filtered.
+	IncludeComponents []string `river:"include_components,attr,optional"`
+	ExcludeComponents []string `river:"exclude_components,attr,optional"`
+
 	// TODO: log sink parameter (e.g., to use the Windows Event logger)
 }
 
@@ -22,13 +44,64 @@ var DefaultOptions = Options{
 	Format: FormatDefault,
 }
 
-var _ river.Defaulter = (*Options)(nil)
+var (
+	_ river.Defaulter = (*Options)(nil)
+	_ river.Validator = (*Options)(nil)
+)
 
 // SetToDefault implements river.Defaulter.
 func (o *Options) SetToDefault() {
 	*o = DefaultOptions
 }
 
+// Validate implements river.Validator.
+func (o *Options) Validate() error {
+	for _, pattern := range append(o.IncludeComponents, o.ExcludeComponents...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid component pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// FileOptions configure writing logs to a file. The file is rotated once it
+// reaches MaxSize, keeping up to MaxBackups old files next to it.
+type FileOptions struct {
+	Path       string           `river:"path,attr"`
+	MaxSize    units.Base2Bytes `river:"max_size,attr,optional"`
+	MaxBackups int              `river:"max_backups,attr,optional"`
+}
+
+// DefaultFileOptions holds defaults for FileOptions.
+var DefaultFileOptions = FileOptions{
+	MaxSize:    100 * units.MiB,
+	MaxBackups: 5,
+}
+
+var (
+	_ river.Defaulter = (*FileOptions)(nil)
+	_ river.Validator = (*FileOptions)(nil)
+)
+
+// SetToDefault implements river.Defaulter.
+func (o *FileOptions) SetToDefault() {
+	*o = DefaultFileOptions
+}
+
+// Validate implements river.Validator.
+func (o *FileOptions) Validate() error {
+	if o.Path == "" {
+		return fmt.Errorf("path must not be empty")
+	}
+	if o.MaxSize <= 0 {
+		return fmt.Errorf("max_size must be greater than zero")
+	}
+	if o.MaxBackups < 0 {
+		return fmt.Errorf("max_backups must not be negative")
+	}
+	return nil
+}
+
 // Level represents how verbose logging should be.
 type Level string
 
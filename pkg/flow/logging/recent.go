@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+)
+
+// recentLines is the number of log lines kept by recentLogs.
+const recentLines = 1000
+
+// recentLogs is an io.Writer which keeps the most recently written log lines
+// in a ring buffer. Loggers write each log line with a single call to Write.
+type recentLogs struct {
+	mut   sync.Mutex
+	lines [][]byte
+	next  int // Index in lines to write the next line to.
+}
+
+func newRecentLogs(size int) *recentLogs {
+	return &recentLogs{lines: make([][]byte, 0, size)}
+}
+
+func (rl *recentLogs) Write(p []byte) (int, error) {
+	// p may be reused by the caller after Write returns.
+	line := append([]byte(nil), p...)
+
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+
+	if len(rl.lines) < cap(rl.lines) {
+		rl.lines = append(rl.lines, line)
+	} else {
+		rl.lines[rl.next] = line
+	}
+	rl.next = (rl.next + 1) % cap(rl.lines)
+	return len(p), nil
+}
+
+// Bytes returns the kept log lines, oldest first.
+func (rl *recentLogs) Bytes() []byte {
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+
+	var buf bytes.Buffer
+	if len(rl.lines) == cap(rl.lines) {
+		// The buffer is full, so the oldest line is the next to be
+		// overwritten.
+		for _, line := range rl.lines[rl.next:] {
+			buf.Write(line)
+		}
+		for _, line := range rl.lines[:rl.next] {
+			buf.Write(line)
+		}
+		return buf.Bytes()
+	}
+
+	for _, line := range rl.lines {
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,180 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/internal/importsource"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// Kinds of top-level blocks.
+const (
+	kindComponent         = "component"
+	kindDeclaredComponent = "declared component"
+	kindConfigBlock       = "config block"
+)
+
+// blockInfo describes a block which may be used at the top level of a file.
+type blockInfo struct {
+	Name      string
+	Kind      string
+	Labeled   bool
+	Arguments schema.Body
+	Exports   schema.Body
+}
+
+// Types of config blocks which are not backed by a Go type elsewhere.
+type (
+	exportBlock struct {
+		Value any `river:"value,attr"`
+	}
+
+	functionBlock struct {
+		Params []string `river:"params,attr,optional"`
+		Result any      `river:"result,attr"`
+	}
+)
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// metaArguments are the attributes supported by every component block in
+// addition to its arguments.
+var metaArguments = []schema.Attr{
+	{Name: "for_each", Optional: true, Type: anyType},
+	{Name: "enabled", Optional: true, Type: reflect.TypeOf(true)},
+}
+
+// builtinConfigBlocks returns the config blocks supported by every Flow
+// file.
+func builtinConfigBlocks() []blockInfo {
+	return []blockInfo{
+		{Name: "argument", Kind: kindConfigBlock, Labeled: true, Arguments: schema.For(reflect.TypeOf(flow.Argument{}))},
+		{Name: "declare", Kind: kindConfigBlock, Labeled: true},
+		{Name: "export", Kind: kindConfigBlock, Labeled: true, Arguments: schema.For(reflect.TypeOf(exportBlock{}))},
+		{Name: "function", Kind: kindConfigBlock, Labeled: true, Arguments: schema.For(reflect.TypeOf(functionBlock{}))},
+		{Name: "import.file", Kind: kindConfigBlock, Labeled: true, Arguments: schema.For(reflect.TypeOf(importsource.FileArguments{}))},
+		{Name: "import.git", Kind: kindConfigBlock, Labeled: true, Arguments: schema.For(reflect.TypeOf(importsource.GitArguments{}))},
+		{Name: "import.http", Kind: kindConfigBlock, Labeled: true, Arguments: schema.For(reflect.TypeOf(importsource.HTTPArguments{}))},
+		{Name: "logging", Kind: kindConfigBlock, Arguments: schema.For(reflect.TypeOf(logging.Options{}))},
+		{Name: "tracing", Kind: kindConfigBlock, Arguments: schema.For(reflect.TypeOf(tracing.Options{}))},
+	}
+}
+
+// componentBlock returns the blockInfo for a registered component.
+func componentBlock(name string) (blockInfo, bool) {
+	reg, ok := component.Get(name)
+	if !ok {
+		return blockInfo{}, false
+	}
+
+	info := blockInfo{
+		Name:    name,
+		Kind:    kindComponent,
+		Labeled: !reg.Singleton,
+	}
+	if reg.Args != nil {
+		info.Arguments = schema.For(reflect.TypeOf(reg.Args))
+	}
+	info.Arguments.Attrs = append(info.Arguments.Attrs, metaArguments...)
+	if reg.Exports != nil {
+		info.Exports = schema.For(reflect.TypeOf(reg.Exports))
+	}
+	return info, true
+}
+
+// declaredBlock returns the blockInfo for a component defined by a declare
+// block with the given header.
+func declaredBlock(decl *blockHeader) blockInfo {
+	info := blockInfo{
+		Name:    decl.Label,
+		Kind:    kindDeclaredComponent,
+		Labeled: true,
+	}
+	for _, child := range decl.Children {
+		switch child.Name {
+		case "argument":
+			info.Arguments.Attrs = append(info.Arguments.Attrs, schema.Attr{
+				Name:     child.Label,
+				Optional: child.Attrs["optional"],
+				Type:     anyType,
+			})
+		case "export":
+			info.Exports.Attrs = append(info.Exports.Attrs, schema.Attr{
+				Name:     child.Label,
+				Optional: true,
+				Type:     anyType,
+			})
+		}
+	}
+	info.Arguments.Attrs = append(info.Arguments.Attrs, metaArguments...)
+	return info
+}
+
+// typeName returns a description of the River type of attr.
+func typeName(attr schema.Attr) string {
+	switch attr.Type {
+	case anyType:
+		return "any"
+	case reflect.TypeOf(rivertypes.Secret("")):
+		return "secret"
+	case reflect.TypeOf(rivertypes.OptionalSecret{}):
+		return "string or secret"
+	}
+	return attr.RiverType()
+}
+
+// describeBlock returns a markdown description of a top-level block.
+func describeBlock(info blockInfo) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** (%s)\n", info.Name, info.Kind)
+
+	if args := info.Arguments; len(args.Attrs) > 0 || len(args.Blocks) > 0 {
+		sb.WriteString("\nArguments:\n\n")
+		writeBody(&sb, args)
+	}
+	if exports := info.Exports; len(exports.Attrs) > 0 {
+		sb.WriteString("\nExports:\n\n")
+		for _, attr := range exports.Attrs {
+			fmt.Fprintf(&sb, "* `%s` (%s)\n", attr.Name, typeName(attr))
+		}
+	}
+	return sb.String()
+}
+
+func writeBody(sb *strings.Builder, body schema.Body) {
+	for _, attr := range body.Attrs {
+		fmt.Fprintf(sb, "* %s\n", describeAttr(attr))
+	}
+	for _, block := range body.Blocks {
+		fmt.Fprintf(sb, "* %s\n", describeNestedBlock(block))
+	}
+}
+
+func describeAttr(attr schema.Attr) string {
+	required := ", required"
+	if attr.Optional {
+		required = ""
+	}
+	return fmt.Sprintf("`%s` (%s%s)", attr.Name, typeName(attr), required)
+}
+
+func describeNestedBlock(block schema.Block) string {
+	var props []string
+	if !block.Optional {
+		props = append(props, "required")
+	}
+	if block.Repeated {
+		props = append(props, "repeatable")
+	}
+	if len(props) == 0 {
+		return fmt.Sprintf("`%s` block", block.Name)
+	}
+	return fmt.Sprintf("`%s` block (%s)", block.Name, strings.Join(props, ", "))
+}
@@ -0,0 +1,161 @@
+package lsp
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// document is an open River file.
+type document struct {
+	uri      string
+	filename string
+	version  int
+	text     []byte
+	lines    []int // Offset of the start of each line.
+
+	// Parsed file. If the file has syntax errors, file holds the statements
+	// which could be parsed.
+	file  *ast.File
+	diags diag.Diagnostics // Syntax errors of the file.
+
+	// parser reuses the unchanged blocks of the document between edits.
+	parser parser.Incremental
+}
+
+func newDocument(uri string, version int, text []byte) *document {
+	doc := &document{
+		uri:      uri,
+		filename: uriFilename(uri),
+		version:  version,
+	}
+	doc.setText(text)
+	return doc
+}
+
+// setText updates the content of the document and parses it.
+func (d *document) setText(text []byte) {
+	d.text = text
+	d.lines = []int{0}
+	for i, b := range text {
+		if b == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	d.diags = nil
+	f, err := d.parser.ParseFile(d.filename, text)
+	if err != nil {
+		if diags, ok := err.(diag.Diagnostics); ok {
+			d.diags = diags
+		} else {
+			d.diags = diag.Diagnostics{{Severity: diag.SeverityLevelError, Message: err.Error()}}
+		}
+	}
+	d.file = f
+}
+
+// applyChange applies a change sent by the client to the document.
+func (d *document) applyChange(change textDocumentContentChangeEvent) {
+	if change.Range == nil {
+		d.setText([]byte(change.Text))
+		return
+	}
+
+	start, end := d.offset(change.Range.Start), d.offset(change.Range.End)
+	text := make([]byte, 0, len(d.text)-(end-start)+len(change.Text))
+	text = append(text, d.text[:start]...)
+	text = append(text, change.Text...)
+	text = append(text, d.text[end:]...)
+	d.setText(text)
+}
+
+// offset converts pos into a byte offset within the document. Positions past
+// the end of a line are clamped to the end of the line.
+func (d *document) offset(pos position) int {
+	if pos.Line < 0 {
+		return 0
+	} else if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	off := d.lines[pos.Line]
+	for units := 0; units < pos.Character && off < len(d.text); {
+		r, size := utf8.DecodeRune(d.text[off:])
+		if r == '\n' {
+			break
+		}
+		units += utf16Len(r)
+		off += size
+	}
+	return off
+}
+
+// position converts a byte offset into a position within the document.
+func (d *document) position(off int) position {
+	if off > len(d.text) {
+		off = len(d.text)
+	} else if off < 0 {
+		off = 0
+	}
+
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > off }) - 1
+
+	var character int
+	for _, r := range string(d.text[d.lines[line]:off]) {
+		character += utf16Len(r)
+	}
+	return position{Line: line, Character: character}
+}
+
+// nodeRange returns the range of the text from start to end, where end is the
+// position of the final character of the range.
+func (d *document) nodeRange(start, end token.Pos) textRange {
+	endOff := start.Offset()
+	if end.Valid() {
+		endOff = end.Offset()
+	}
+	return textRange{
+		Start: d.position(start.Offset()),
+		End:   d.position(endOff + 1),
+	}
+}
+
+// diagnosticRange returns the range covered by a River diagnostic.
+func (d *document) diagnosticRange(dd diag.Diagnostic) textRange {
+	start := dd.StartPos.Offset
+	end := start + 1
+	if dd.EndPos.Valid() && dd.EndPos.Offset >= start {
+		end = dd.EndPos.Offset + 1
+	}
+	return textRange{Start: d.position(start), End: d.position(end)}
+}
+
+// contains returns true if off is within the range of a node from start to
+// end, where end is the position of the final character of the node.
+func contains(start, end token.Pos, off int) bool {
+	return start.Valid() && off >= start.Offset() && off <= end.Offset()+1
+}
+
+func utf16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// uriFilename returns the filename used in diagnostics for a document URI.
+// File URIs are converted into paths; other URIs are used as is.
+func uriFilename(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return strings.TrimPrefix(uri, "file://")
+}
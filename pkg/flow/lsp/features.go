@@ -0,0 +1,331 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// completion returns completion items for the cursor at pos.
+func (s *Server) completion(doc *document, pos position) []completionItem {
+	off := doc.offset(pos)
+	_, ctx := scanDocument(doc.text, off)
+
+	edit := textRange{Start: doc.position(ctx.PrefixStart), End: doc.position(off)}
+
+	var items []completionItem
+	switch {
+	case ctx.InHeader:
+		return []completionItem{}
+	case ctx.InExpr:
+		items = s.referenceCompletions(ctx, edit)
+	case ctx.Block.isFileLevel():
+		items = s.blockCompletions(ctx, edit)
+	default:
+		items = s.bodyCompletions(ctx, edit)
+	}
+
+	filtered := make([]completionItem, 0, len(items))
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, ctx.Prefix) {
+			filtered = append(filtered, item)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].Label < filtered[j].Label })
+	return filtered
+}
+
+// blockCompletions returns completions for top-level blocks.
+func (s *Server) blockCompletions(ctx cursorContext, edit textRange) []completionItem {
+	var infos []blockInfo
+	infos = append(infos, s.configBlocks...)
+	for _, name := range component.AllNames() {
+		if info, ok := componentBlock(name); ok {
+			infos = append(infos, info)
+		}
+	}
+	for _, decl := range ctx.Block.declares() {
+		infos = append(infos, declaredBlock(decl))
+	}
+
+	items := make([]completionItem, 0, len(infos))
+	for _, info := range infos {
+		items = append(items, completionItem{
+			Label:            info.Name,
+			Kind:             completionKindModule,
+			Detail:           info.Kind,
+			InsertTextFormat: insertTextFormatSnippet,
+			TextEdit:         &textEdit{Range: edit, NewText: blockSnippet(info.Name, info.Labeled)},
+		})
+	}
+	return items
+}
+
+// bodyCompletions returns completions for the attributes and blocks of the
+// block holding the cursor.
+func (s *Server) bodyCompletions(ctx cursorContext, edit textRange) []completionItem {
+	body, ok := s.resolveBody(ctx.Block)
+	if !ok {
+		return nil
+	}
+
+	var items []completionItem
+	for _, attr := range body.Attrs {
+		if ctx.Block.Attrs[attr.Name] {
+			continue
+		}
+		items = append(items, completionItem{
+			Label:            attr.Name,
+			Kind:             completionKindField,
+			Detail:           describeAttr(attr),
+			InsertTextFormat: insertTextFormatSnippet,
+			TextEdit:         &textEdit{Range: edit, NewText: attr.Name + " = $0"},
+		})
+	}
+	for _, block := range body.Blocks {
+		items = append(items, completionItem{
+			Label:            block.Name,
+			Kind:             completionKindProperty,
+			Detail:           describeNestedBlock(block),
+			InsertTextFormat: insertTextFormatSnippet,
+			TextEdit:         &textEdit{Range: edit, NewText: blockSnippet(block.Name, block.Labeled)},
+		})
+	}
+	return items
+}
+
+// referenceCompletions returns completions for the expression holding the
+// cursor: the exports of components in the same scope and module arguments.
+func (s *Server) referenceCompletions(ctx cursorContext, edit textRange) []completionItem {
+	var (
+		items []completionItem
+		add   = func(label, detail string) {
+			items = append(items, completionItem{
+				Label:    label,
+				Kind:     completionKindReference,
+				Detail:   detail,
+				TextEdit: &textEdit{Range: edit, NewText: label},
+			})
+		}
+	)
+
+	if top := ctx.Block.topLevel(); top != nil && top.Attrs["for_each"] {
+		add("each.key", "string")
+		add("each.value", "any")
+	}
+
+	scope := ctx.Block.fileLevel()
+	for _, child := range scope.Children {
+		if child.Name == "argument" {
+			add("argument."+child.Label+".value", "module argument")
+			continue
+		}
+
+		info, ok := s.lookupBlock(scope, child.Name)
+		if !ok || info.Kind == kindConfigBlock {
+			continue
+		}
+		id := child.Name
+		if child.Label != "" {
+			id += "." + child.Label
+		}
+		for _, attr := range info.Exports.Attrs {
+			add(id+"."+attr.Name, fmt.Sprintf("%s (export of %s)", typeName(attr), info.Name))
+		}
+	}
+	return items
+}
+
+// resolveBody returns the schema of the body of a block which isn't
+// file-level.
+func (s *Server) resolveBody(h *blockHeader) (schema.Body, bool) {
+	if h.Parent.isFileLevel() {
+		info, ok := s.lookupBlock(h.Parent, h.Name)
+		return info.Arguments, ok
+	}
+
+	parent, ok := s.resolveBody(h.Parent)
+	if !ok {
+		return schema.Body{}, false
+	}
+	block, ok := parent.Block(h.Name)
+	return block.Body, ok
+}
+
+// blockSnippet returns a snippet to insert a block with the given name.
+func blockSnippet(name string, labeled bool) string {
+	if labeled {
+		return name + " \"${1:label}\" {\n\t$0\n}"
+	}
+	return name + " {\n\t$0\n}"
+}
+
+// hover returns hover information for pos.
+func (s *Server) hover(doc *document, pos position) *hoverResult {
+	if doc.file == nil {
+		return nil
+	}
+	off := doc.offset(pos)
+	sc := findScope(doc.file, off)
+
+	// Hovering over the name of a top-level block.
+	if block, ok := blockHeaderAt(sc.Body, off); ok {
+		info, ok := s.lookupBlock(sc.Header, strings.Join(block.Name, "."))
+		if !ok {
+			return nil
+		}
+		return s.hoverResult(doc, describeBlock(info), block.NamePos, block.LCurlyPos)
+	}
+
+	// Hovering over a reference to another block.
+	if t, ok := traversalAt(sc.Body, off); ok {
+		return s.hoverTraversal(doc, sc, t)
+	}
+
+	// Hovering over an attribute or nested block in a top-level block.
+	for _, stmt := range sc.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || isDeclare(block) || !contains(block.LCurlyPos, block.RCurlyPos, off) {
+			continue
+		}
+		info, ok := s.lookupBlock(sc.Header, strings.Join(block.Name, "."))
+		if !ok {
+			return nil
+		}
+		return s.hoverBody(doc, info.Arguments, block.Body, off)
+	}
+	return nil
+}
+
+// hoverBody returns hover information for an attribute or block of body,
+// whose schema is described by sch.
+func (s *Server) hoverBody(doc *document, sch schema.Body, body ast.Body, off int) *hoverResult {
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if !contains(stmt.Name.NamePos, ast.EndPos(stmt.Name), off) {
+				continue
+			}
+			attr, ok := sch.Attr(stmt.Name.Name)
+			if !ok {
+				return nil
+			}
+			return s.hoverResult(doc, describeAttr(attr), stmt.Name.NamePos, ast.EndPos(stmt.Name))
+
+		case *ast.BlockStmt:
+			if !contains(stmt.NamePos, stmt.RCurlyPos, off) {
+				continue
+			}
+			block, ok := sch.Block(strings.Join(stmt.Name, "."))
+			if !ok {
+				return nil
+			}
+			if contains(stmt.NamePos, stmt.LCurlyPos, off) {
+				var sb strings.Builder
+				sb.WriteString(describeNestedBlock(block) + "\n\n")
+				writeBody(&sb, block.Body)
+				return s.hoverResult(doc, sb.String(), stmt.NamePos, stmt.LCurlyPos)
+			}
+			return s.hoverBody(doc, block.Body, stmt.Body, off)
+		}
+	}
+	return nil
+}
+
+// hoverTraversal returns hover information for a reference to another block.
+func (s *Server) hoverTraversal(doc *document, sc scope, t traversal) *hoverResult {
+	target, ok := resolveTraversal(sc.Body, t.Names)
+	if !ok {
+		return nil
+	}
+	info, ok := s.lookupBlock(sc.Header, strings.Join(target.Name, "."))
+	if !ok {
+		return nil
+	}
+
+	id := blockID(target)
+	contents := fmt.Sprintf("**%s** (%s)", strings.Join(id, "."), info.Name)
+	if len(t.Names) > len(id) {
+		if attr, ok := info.Exports.Attr(t.Names[len(id)]); ok {
+			contents += fmt.Sprintf("\n\nExport `%s` (%s)", attr.Name, typeName(attr))
+		}
+	}
+	return s.hoverResult(doc, contents, t.Start, t.End)
+}
+
+func (s *Server) hoverResult(doc *document, contents string, start, end token.Pos) *hoverResult {
+	r := doc.nodeRange(start, end)
+	return &hoverResult{
+		Contents: markupContent{Kind: "markdown", Value: contents},
+		Range:    &r,
+	}
+}
+
+// definition returns the location of the block referenced at pos.
+func (s *Server) definition(doc *document, pos position) []location {
+	if doc.file == nil {
+		return []location{}
+	}
+	off := doc.offset(pos)
+	sc := findScope(doc.file, off)
+
+	if t, ok := traversalAt(sc.Body, off); ok {
+		if target, ok := resolveTraversal(sc.Body, t.Names); ok {
+			return []location{blockLocation(doc, target)}
+		}
+		return []location{}
+	}
+
+	// Blocks using a declared component link to the declare block.
+	if block, ok := blockHeaderAt(sc.Body, off); ok {
+		if decl, ok := findDeclare(sc, strings.Join(block.Name, ".")); ok {
+			return []location{blockLocation(doc, decl)}
+		}
+	}
+	return []location{}
+}
+
+// references returns the locations of expressions which reference the block
+// at pos. pos may be on the header of the block or on a reference to it.
+func (s *Server) references(doc *document, pos position, includeDeclaration bool) []location {
+	if doc.file == nil {
+		return []location{}
+	}
+	off := doc.offset(pos)
+	sc := findScope(doc.file, off)
+
+	target, ok := blockHeaderAt(sc.Body, off)
+	if !ok {
+		t, found := traversalAt(sc.Body, off)
+		if !found {
+			return []location{}
+		}
+		if target, ok = resolveTraversal(sc.Body, t.Names); !ok {
+			return []location{}
+		}
+	}
+
+	locs := []location{}
+	if includeDeclaration {
+		locs = append(locs, blockLocation(doc, target))
+	}
+	walkTraversals(sc.Body, func(t traversal) {
+		if resolved, ok := resolveTraversal(sc.Body, t.Names); ok && resolved == target {
+			locs = append(locs, location{URI: doc.uri, Range: doc.nodeRange(t.Start, t.End)})
+		}
+	})
+	return locs
+}
+
+// blockLocation returns the location of the header of block.
+func blockLocation(doc *document, block *ast.BlockStmt) location {
+	return location{
+		URI:   doc.uri,
+		Range: doc.nodeRange(block.NamePos, block.LCurlyPos),
+	}
+}
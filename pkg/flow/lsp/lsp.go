@@ -0,0 +1,423 @@
+// Package lsp implements a language server for Grafana Agent Flow
+// configuration files.
+//
+// The server speaks the Language Server Protocol over a stream, such as the
+// standard input and output of a process, and supports diagnostics,
+// completion, hover documentation, go-to-definition and references for
+// component references, and formatting. Information about components is taken
+// from the component registry, so only components compiled into the binary
+// are known to the server.
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/grafana/agent/service"
+)
+
+// Options configures a Server.
+type Options struct {
+	// Logger to write logs to. Logs must not be written to the stream used by
+	// the server.
+	Logger log.Logger
+
+	// Validate, if set, returns diagnostics for a file which has no syntax
+	// errors, such as unknown components or invalid arguments. name is the
+	// path of the file. Validate is called from a background goroutine each
+	// time an open file changes, so it must not have side effects such as
+	// fetching or writing files.
+	Validate func(name string, bb []byte) diag.Diagnostics
+
+	// ValidateDelay is how long to wait after a file last changed before
+	// validating it, so that files aren't validated on every keystroke.
+	// Defaults to DefaultValidateDelay.
+	ValidateDelay time.Duration
+
+	// Services whose config blocks may be used in files.
+	Services []service.Definition
+}
+
+// DefaultValidateDelay is the default value of Options.ValidateDelay.
+const DefaultValidateDelay = 250 * time.Millisecond
+
+// Server is a language server for Flow configuration files. Requests are
+// handled one at a time in the order they are received. Files are validated
+// in the background, so requests are answered while files are validated.
+type Server struct {
+	opts Options
+	conn *conn
+
+	configBlocks []blockInfo
+	docs         map[string]*document
+	shutdown     bool
+
+	validateMut sync.Mutex
+	validations map[string]*time.Timer // Pending validations keyed by document URI
+}
+
+// NewServer creates a new Server.
+func NewServer(opts Options) *Server {
+	if opts.Logger == nil {
+		opts.Logger = log.NewNopLogger()
+	}
+	if opts.ValidateDelay == 0 {
+		opts.ValidateDelay = DefaultValidateDelay
+	}
+
+	configBlocks := builtinConfigBlocks()
+	for _, def := range opts.Services {
+		if def.ConfigType == nil {
+			continue
+		}
+		configBlocks = append(configBlocks, blockInfo{
+			Name:      def.Name,
+			Kind:      kindConfigBlock,
+			Arguments: schema.For(reflect.TypeOf(def.ConfigType)),
+		})
+	}
+
+	return &Server{
+		opts:         opts,
+		configBlocks: configBlocks,
+		docs:         make(map[string]*document),
+		validations:  make(map[string]*time.Timer),
+	}
+}
+
+// errExit is returned by handlers when the client asks the server to exit.
+var errExit = errors.New("exit")
+
+// Serve handles requests read from r, writing responses to w. Serve returns
+// nil when the client sends an exit notification after shutting down the
+// server, or when r is closed.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	defer s.cancelValidations()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if rerr := (*responseError)(nil); errors.As(err, &rerr) {
+			_ = s.conn.reply(nil, nil, rerr)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		result, err := s.handle(req)
+		if errors.Is(err, errExit) {
+			if !s.shutdown {
+				return fmt.Errorf("exit requested before shutdown")
+			}
+			return nil
+		}
+		if req.isNotification() {
+			if err != nil {
+				level.Warn(s.opts.Logger).Log("msg", "failed to handle notification", "method", req.Method, "err", err)
+			}
+			continue
+		}
+		if err := s.conn.reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request to its handler.
+func (s *Server) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:           textDocumentSyncFull,
+				CompletionProvider:         &completionOptions{TriggerCharacters: []string{"."}},
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				ReferencesProvider:         true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "grafana-agent"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		return nil, errExit
+
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc := newDocument(params.TextDocument.URI, params.TextDocument.Version, []byte(params.TextDocument.Text))
+		s.docs[doc.uri] = doc
+		return nil, s.updateDiagnostics(doc)
+
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range params.ContentChanges {
+			doc.applyChange(change)
+		}
+		doc.version = params.TextDocument.Version
+		return nil, s.updateDiagnostics(doc)
+
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.cancelValidation(params.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return completionList{Items: s.completion(doc, params.Position)}, nil
+
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		if h := s.hover(doc, params.Position); h != nil {
+			return h, nil
+		}
+		return nil, nil
+
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.definition(doc, params.Position), nil
+
+	case "textDocument/references":
+		var params referenceParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.references(doc, params.Position, params.Context.IncludeDeclaration), nil
+
+	case "textDocument/formatting":
+		var params documentFormattingParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.format(doc)
+
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not supported", req.Method)}
+	}
+}
+
+func decodeParams(req *request, v interface{}) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document %q is not open", uri)
+	}
+	return doc, nil
+}
+
+// updateDiagnostics publishes the diagnostics of doc after it was opened or
+// changed. Syntax errors are published immediately. Otherwise, if the
+// Validate option is set, doc is validated in the background once it hasn't
+// changed for ValidateDelay, and the diagnostics are published afterwards.
+func (s *Server) updateDiagnostics(doc *document) error {
+	s.cancelValidation(doc.uri)
+	if len(doc.diags) > 0 || s.opts.Validate == nil {
+		return s.publishDiagnostics(doc, doc.diags)
+	}
+	s.scheduleValidation(doc)
+	return nil
+}
+
+// scheduleValidation validates doc after ValidateDelay in a background
+// goroutine. The diagnostics are dropped if doc changes or is closed before
+// validation finishes.
+func (s *Server) scheduleValidation(doc *document) {
+	// Later changes replace the text of doc, so validate a copy of its current
+	// content.
+	snapshot := &document{
+		uri:      doc.uri,
+		filename: doc.filename,
+		version:  doc.version,
+		text:     doc.text,
+		lines:    doc.lines,
+	}
+
+	s.validateMut.Lock()
+	defer s.validateMut.Unlock()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(s.opts.ValidateDelay, func() {
+		diags := s.opts.Validate(snapshot.filename, snapshot.text)
+
+		s.validateMut.Lock()
+		current := s.validations[snapshot.uri] == timer
+		if current {
+			delete(s.validations, snapshot.uri)
+		}
+		s.validateMut.Unlock()
+
+		if !current {
+			return
+		}
+		if err := s.publishDiagnostics(snapshot, diags); err != nil {
+			level.Warn(s.opts.Logger).Log("msg", "failed to publish diagnostics", "uri", snapshot.uri, "err", err)
+		}
+	})
+	s.validations[doc.uri] = timer
+}
+
+// cancelValidation cancels the pending validation of the document with the
+// given URI, if any. Validations which already started finish, but their
+// diagnostics aren't published.
+func (s *Server) cancelValidation(uri string) {
+	s.validateMut.Lock()
+	defer s.validateMut.Unlock()
+
+	if timer, ok := s.validations[uri]; ok {
+		timer.Stop()
+		delete(s.validations, uri)
+	}
+}
+
+// cancelValidations cancels the pending validations of all documents.
+func (s *Server) cancelValidations() {
+	s.validateMut.Lock()
+	defer s.validateMut.Unlock()
+
+	for uri, timer := range s.validations {
+		timer.Stop()
+		delete(s.validations, uri)
+	}
+}
+
+// publishDiagnostics sends diags to the client as the diagnostics of doc.
+func (s *Server) publishDiagnostics(doc *document, diags diag.Diagnostics) error {
+	out := []diagnostic{}
+	for _, d := range diags {
+		// Diagnostics may refer to other files, such as files loaded by
+		// modules; those can't be shown in this document.
+		if name := d.StartPos.Filename; name != "" && name != doc.filename {
+			continue
+		}
+
+		severity := severityError
+		if d.Severity == diag.SeverityLevelWarn {
+			severity = severityWarning
+		}
+		out = append(out, diagnostic{
+			Range:    doc.diagnosticRange(d),
+			Severity: severity,
+			Source:   "grafana-agent",
+			Message:  d.Message,
+		})
+	}
+
+	return s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: out,
+	})
+}
+
+// format returns the edits to format doc.
+func (s *Server) format(doc *document) ([]textEdit, error) {
+	if len(doc.diags) > 0 {
+		return nil, fmt.Errorf("cannot format a file with syntax errors")
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, doc.file); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+
+	if bytes.Equal(buf.Bytes(), doc.text) {
+		return []textEdit{}, nil
+	}
+	return []textEdit{{
+		Range:   textRange{Start: position{}, End: doc.position(len(doc.text))},
+		NewText: buf.String(),
+	}}, nil
+}
+
+// lookupBlock returns information about the top-level block with the given
+// name, as seen from the file-level block scope.
+func (s *Server) lookupBlock(scope *blockHeader, name string) (blockInfo, bool) {
+	for _, info := range s.configBlocks {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	if info, ok := componentBlock(name); ok {
+		return info, true
+	}
+	for _, decl := range scope.declares() {
+		if decl.Label == name {
+			return declaredBlock(decl), true
+		}
+	}
+	return blockInfo{}, false
+}
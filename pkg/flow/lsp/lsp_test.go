@@ -0,0 +1,501 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testURI = "file:///config.river"
+
+const testFile = `testcomponents.passthrough "a" {
+	input = "hello"
+}
+
+testcomponents.passthrough "b" {
+	input = testcomponents.passthrough.a.output
+}
+`
+
+func TestServer_Initialize(t *testing.T) {
+	c := newTestClient(t, Options{})
+
+	var res initializeResult
+	c.call("initialize", map[string]any{}, &res)
+	require.Equal(t, textDocumentSyncFull, res.Capabilities.TextDocumentSync)
+	require.True(t, res.Capabilities.HoverProvider)
+	require.True(t, res.Capabilities.DefinitionProvider)
+	require.True(t, res.Capabilities.ReferencesProvider)
+	require.True(t, res.Capabilities.DocumentFormattingProvider)
+
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	require.NoError(t, c.wait())
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	t.Run("syntax errors", func(t *testing.T) {
+		c := newTestClient(t, Options{})
+
+		diags := c.open("testcomponents.passthrough \"a\" {\n\tinput = \n}\n")
+		require.Len(t, diags.Diagnostics, 1)
+		require.Equal(t, severityError, diags.Diagnostics[0].Severity)
+		require.Equal(t, 2, diags.Diagnostics[0].Range.Start.Line)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		c := newTestClient(t, Options{
+			Validate: func(name string, bb []byte) diag.Diagnostics {
+				assert.Equal(t, "/config.river", name)
+				return diag.Diagnostics{{
+					Severity: diag.SeverityLevelWarn,
+					Message:  "something is wrong",
+				}}
+			},
+		})
+
+		diags := c.open(testFile)
+		require.Equal(t, []diagnostic{{
+			Range:    textRange{End: position{Character: 1}},
+			Severity: severityWarning,
+			Source:   "grafana-agent",
+			Message:  "something is wrong",
+		}}, diags.Diagnostics)
+	})
+
+	t.Run("debounced validation", func(t *testing.T) {
+		var (
+			mut       sync.Mutex
+			validated []string
+		)
+		c := newTestClient(t, Options{
+			Validate: func(name string, bb []byte) diag.Diagnostics {
+				mut.Lock()
+				defer mut.Unlock()
+				validated = append(validated, string(bb))
+				return nil
+			},
+			ValidateDelay: time.Second,
+		})
+
+		c.notify("textDocument/didOpen", didOpenTextDocumentParams{
+			TextDocument: textDocumentItem{URI: testURI, Version: 1, Text: ""},
+		})
+		c.notify("textDocument/didChange", didChangeTextDocumentParams{
+			TextDocument:   versionedTextDocumentIdentifier{URI: testURI, Version: 2},
+			ContentChanges: []textDocumentContentChangeEvent{{Text: testFile}},
+		})
+
+		// Only the most recent version of the file is validated.
+		diags := c.diagnostics()
+		require.Equal(t, 2, diags.Version)
+
+		mut.Lock()
+		defer mut.Unlock()
+		require.Equal(t, []string{testFile}, validated)
+	})
+
+	t.Run("requests during validation", func(t *testing.T) {
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+		c := newTestClient(t, Options{
+			Validate: func(name string, bb []byte) diag.Diagnostics {
+				close(started)
+				<-release
+				return nil
+			},
+			ValidateDelay: time.Millisecond,
+		})
+
+		c.notify("textDocument/didOpen", didOpenTextDocumentParams{
+			TextDocument: textDocumentItem{URI: testURI, Version: 1, Text: testFile},
+		})
+		<-started
+
+		// Requests are answered while the file is being validated.
+		var res []textEdit
+		c.call("textDocument/formatting", documentFormattingParams{
+			TextDocument: textDocumentIdentifier{URI: testURI},
+		}, &res)
+
+		close(release)
+		require.Equal(t, 1, c.diagnostics().Version)
+	})
+
+	t.Run("fixed errors", func(t *testing.T) {
+		c := newTestClient(t, Options{})
+
+		diags := c.open("testcomponents.passthrough \"a\" {\n")
+		require.NotEmpty(t, diags.Diagnostics)
+
+		c.notify("textDocument/didChange", didChangeTextDocumentParams{
+			TextDocument:   versionedTextDocumentIdentifier{URI: testURI, Version: 2},
+			ContentChanges: []textDocumentContentChangeEvent{{Text: testFile}},
+		})
+		diags = c.diagnostics()
+		require.Equal(t, 2, diags.Version)
+		require.Empty(t, diags.Diagnostics)
+	})
+}
+
+func TestServer_Completion(t *testing.T) {
+	tt := []struct {
+		name   string
+		text   string // | marks the cursor.
+		expect []string
+		reject []string
+	}{
+		{
+			name:   "component names",
+			text:   "testcomponents.pa|",
+			expect: []string{"testcomponents.passthrough"},
+			reject: []string{"testcomponents.tick", "logging"},
+		},
+		{
+			name:   "config blocks",
+			text:   "lo|",
+			expect: []string{"logging"},
+		},
+		{
+			name:   "component arguments",
+			text:   "testcomponents.passthrough \"a\" {\n\t|\n}\n",
+			expect: []string{"input", "for_each", "enabled"},
+		},
+		{
+			name:   "arguments already set",
+			text:   "testcomponents.passthrough \"a\" {\n\tinput = \"\"\n\t|\n}\n",
+			expect: []string{"for_each", "enabled"},
+			reject: []string{"input"},
+		},
+		{
+			name: "component exports",
+			text: strings.Join([]string{
+				`testcomponents.passthrough "a" {`,
+				`	input = "hello"`,
+				`}`,
+				``,
+				`testcomponents.passthrough "b" {`,
+				`	input = testcomponents.|`,
+				`}`,
+			}, "\n"),
+			expect: []string{"testcomponents.passthrough.a.output", "testcomponents.passthrough.b.output"},
+		},
+		{
+			name: "module arguments",
+			text: strings.Join([]string{
+				`argument "in" { }`,
+				``,
+				`testcomponents.passthrough "a" {`,
+				`	input = |`,
+				`}`,
+			}, "\n"),
+			expect: []string{"argument.in.value", "testcomponents.passthrough.a.output"},
+		},
+		{
+			name: "declared components",
+			text: strings.Join([]string{
+				`declare "example" {`,
+				`	argument "in" { }`,
+				`}`,
+				``,
+				`exa|`,
+			}, "\n"),
+			expect: []string{"example"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, Options{})
+
+			text, pos := cursorPosition(t, tc.text)
+			c.open(text)
+
+			var res completionList
+			c.call("textDocument/completion", textDocumentPositionParams{
+				TextDocument: textDocumentIdentifier{URI: testURI},
+				Position:     pos,
+			}, &res)
+
+			labels := make([]string, 0, len(res.Items))
+			for _, item := range res.Items {
+				labels = append(labels, item.Label)
+			}
+			for _, expect := range tc.expect {
+				require.Contains(t, labels, expect)
+			}
+			for _, reject := range tc.reject {
+				require.NotContains(t, labels, reject)
+			}
+		})
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	c := newTestClient(t, Options{})
+	c.open(testFile)
+
+	// Hover over the name of a component.
+	var res hoverResult
+	c.call("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     position{Line: 0, Character: 3},
+	}, &res)
+	require.Contains(t, res.Contents.Value, "**testcomponents.passthrough** (component)")
+	require.Contains(t, res.Contents.Value, "`input` (string, required)")
+	require.Contains(t, res.Contents.Value, "`output` (string)")
+
+	// Hover over a reference to an export.
+	c.call("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     position{Line: 5, Character: 12},
+	}, &res)
+	require.Contains(t, res.Contents.Value, "**testcomponents.passthrough.a** (testcomponents.passthrough)")
+	require.Contains(t, res.Contents.Value, "Export `output` (string)")
+	require.Equal(t, &textRange{
+		Start: position{Line: 5, Character: 9},
+		End:   position{Line: 5, Character: 44},
+	}, res.Range)
+}
+
+func TestServer_Hover_SyntaxErrors(t *testing.T) {
+	c := newTestClient(t, Options{})
+	diags := c.open(testFile + "\ntestcomponents.passthrough \"c\" {\n\tinput = \n}\n")
+	require.NotEmpty(t, diags.Diagnostics)
+
+	// Blocks without errors can still be used while the file has syntax
+	// errors.
+	var res hoverResult
+	c.call("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     position{Line: 5, Character: 12},
+	}, &res)
+	require.Contains(t, res.Contents.Value, "**testcomponents.passthrough.a** (testcomponents.passthrough)")
+}
+
+func TestServer_Definition(t *testing.T) {
+	c := newTestClient(t, Options{})
+	c.open(testFile)
+
+	var res []location
+	c.call("textDocument/definition", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     position{Line: 5, Character: 12},
+	}, &res)
+	require.Equal(t, []location{{
+		URI: testURI,
+		Range: textRange{
+			Start: position{Line: 0, Character: 0},
+			End:   position{Line: 0, Character: 32},
+		},
+	}}, res)
+}
+
+func TestServer_References(t *testing.T) {
+	c := newTestClient(t, Options{})
+	c.open(testFile)
+
+	var res []location
+	c.call("textDocument/references", map[string]any{
+		"textDocument": textDocumentIdentifier{URI: testURI},
+		"position":     position{Line: 0, Character: 3},
+		"context":      map[string]any{"includeDeclaration": false},
+	}, &res)
+	require.Equal(t, []location{{
+		URI: testURI,
+		Range: textRange{
+			Start: position{Line: 5, Character: 9},
+			End:   position{Line: 5, Character: 44},
+		},
+	}}, res)
+}
+
+func TestServer_Formatting(t *testing.T) {
+	c := newTestClient(t, Options{})
+	c.open("testcomponents.passthrough \"a\" {\ninput = \"hello\"\n}")
+
+	var res []textEdit
+	c.call("textDocument/formatting", documentFormattingParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+	}, &res)
+	require.Equal(t, []textEdit{{
+		Range: textRange{
+			Start: position{Line: 0, Character: 0},
+			End:   position{Line: 2, Character: 1},
+		},
+		NewText: "testcomponents.passthrough \"a\" {\n\tinput = \"hello\"\n}\n",
+	}}, res)
+}
+
+func TestServer_UnknownMethod(t *testing.T) {
+	c := newTestClient(t, Options{})
+
+	err := c.callErr("textDocument/unknown", nil, nil)
+	require.NotNil(t, err)
+	require.Equal(t, codeMethodNotFound, err.Code)
+}
+
+// cursorPosition removes the | cursor marker from text and returns the
+// position of the cursor.
+func cursorPosition(t *testing.T, text string) (string, position) {
+	t.Helper()
+
+	idx := strings.Index(text, "|")
+	require.NotEqual(t, -1, idx, "text has no cursor")
+
+	before := text[:idx]
+	pos := position{
+		Line:      strings.Count(before, "\n"),
+		Character: idx - (strings.LastIndex(before, "\n") + 1),
+	}
+	return before + text[idx+1:], pos
+}
+
+// testClient is a client communicating with a Server over in-memory pipes.
+type testClient struct {
+	t *testing.T
+
+	w      io.Writer
+	r      *textproto.Reader
+	nextID int
+	errCh  chan error
+
+	// Notifications received while waiting for a response.
+	pending []json.RawMessage
+}
+
+func newTestClient(t *testing.T, opts Options) *testClient {
+	t.Helper()
+
+	var (
+		clientReader, serverWriter = io.Pipe()
+		serverReader, clientWriter = io.Pipe()
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		err := NewServer(opts).Serve(context.Background(), serverReader, serverWriter)
+		_ = serverWriter.Close()
+		errCh <- err
+	}()
+	t.Cleanup(func() { _ = clientWriter.Close() })
+
+	return &testClient{
+		t:     t,
+		w:     clientWriter,
+		r:     textproto.NewReader(bufio.NewReader(clientReader)),
+		errCh: errCh,
+	}
+}
+
+// open opens testURI with the given text and returns the diagnostics
+// published for it.
+func (c *testClient) open(text string) publishDiagnosticsParams {
+	c.notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{URI: testURI, Version: 1, Text: text},
+	})
+	return c.diagnostics()
+}
+
+// diagnostics waits for the next publishDiagnostics notification.
+func (c *testClient) diagnostics() publishDiagnosticsParams {
+	c.t.Helper()
+
+	var msg struct {
+		Method string                   `json:"method"`
+		Params publishDiagnosticsParams `json:"params"`
+	}
+	if len(c.pending) > 0 {
+		require.NoError(c.t, json.Unmarshal(c.pending[0], &msg))
+		c.pending = c.pending[1:]
+	} else {
+		require.NoError(c.t, json.Unmarshal(c.readMessage(), &msg))
+	}
+	require.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+	return msg.Params
+}
+
+// call sends a request and decodes its result into result, failing the test
+// if the request fails.
+func (c *testClient) call(method string, params, result any) {
+	c.t.Helper()
+	err := c.callErr(method, params, result)
+	require.Nil(c.t, err, "request %s failed", method)
+}
+
+// callErr sends a request and decodes its result into result, returning the
+// error sent by the server.
+func (c *testClient) callErr(method string, params, result any) *responseError {
+	c.t.Helper()
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	c.write(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+
+	for {
+		bb := c.readMessage()
+
+		var resp response
+		require.NoError(c.t, json.Unmarshal(bb, &resp))
+		if len(resp.ID) == 0 || string(resp.ID) != id {
+			c.pending = append(c.pending, bb)
+			continue
+		}
+
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(resp.Result, result))
+		}
+		return nil
+	}
+}
+
+// notify sends a notification.
+func (c *testClient) notify(method string, params any) {
+	c.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// wait waits for the server to stop.
+func (c *testClient) wait() error {
+	return <-c.errCh
+}
+
+func (c *testClient) write(msg any) {
+	c.t.Helper()
+
+	bb, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(bb), bb)
+	require.NoError(c.t, err)
+}
+
+func (c *testClient) readMessage() json.RawMessage {
+	c.t.Helper()
+
+	header, err := c.r.ReadMIMEHeader()
+	require.NoError(c.t, err)
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	require.NoError(c.t, err)
+
+	bb := make([]byte, length)
+	_, err = io.ReadFull(c.r.R, bb)
+	require.NoError(c.t, err)
+	return bb
+}
@@ -0,0 +1,227 @@
+package lsp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/scanner"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// blockHeader describes a block in a document. Block headers are built by
+// scanning the tokens of a document, so that they're available while a
+// document being edited has syntax errors.
+type blockHeader struct {
+	Name  string
+	Label string
+
+	Attrs    map[string]bool // Attributes set directly within the block.
+	Children []*blockHeader  // Blocks set directly within the block.
+	Parent   *blockHeader    // Parent block; nil for the root of the document.
+}
+
+// isFileLevel returns true if h holds top-level statements of a file or
+// module: the root of the document or a declare block.
+func (h *blockHeader) isFileLevel() bool {
+	return h.Parent == nil || (h.Name == "declare" && h.Parent.isFileLevel())
+}
+
+// fileLevel returns the closest block to h, including h itself, which holds
+// top-level statements.
+func (h *blockHeader) fileLevel() *blockHeader {
+	for !h.isFileLevel() {
+		h = h.Parent
+	}
+	return h
+}
+
+// topLevel returns the ancestor of h, including h itself, which is a direct
+// child of a file-level block. It returns nil if h is file-level.
+func (h *blockHeader) topLevel() *blockHeader {
+	if h.isFileLevel() {
+		return nil
+	}
+	for !h.Parent.isFileLevel() {
+		h = h.Parent
+	}
+	return h
+}
+
+// declares returns the declare blocks visible from the file-level block h,
+// innermost first.
+func (h *blockHeader) declares() []*blockHeader {
+	var decls []*blockHeader
+	for scope := h; scope != nil; scope = scope.Parent {
+		if !scope.isFileLevel() {
+			continue
+		}
+		for _, child := range scope.Children {
+			if child.Name == "declare" {
+				decls = append(decls, child)
+			}
+		}
+	}
+	return decls
+}
+
+// cursorContext describes the location of the cursor within a document.
+type cursorContext struct {
+	Block    *blockHeader // Innermost block holding the cursor.
+	InExpr   bool         // True if the cursor is within an attribute value.
+	InHeader bool         // True if the cursor is within a block header, such as its label.
+
+	// Prefix is the partial name before the cursor, which may include dots.
+	// PrefixStart is the offset of the start of Prefix.
+	Prefix      string
+	PrefixStart int
+}
+
+// scanDocument scans the blocks of text. If cursor is not negative, the
+// context of the cursor at that offset is also returned.
+func scanDocument(text []byte, cursor int) (*blockHeader, cursorContext) {
+	var (
+		root = &blockHeader{Attrs: make(map[string]bool)}
+		cur  = root
+
+		ctx       cursorContext
+		captured  = cursor < 0
+		inExpr    bool
+		exprDepth int
+
+		// Header of the statement being scanned.
+		header  []string
+		label   string
+		lastDot bool
+	)
+
+	if !captured {
+		ctx.PrefixStart = prefixStart(text, cursor)
+		ctx.Prefix = string(text[ctx.PrefixStart:cursor])
+	}
+	capture := func() {
+		ctx.Block = cur
+		ctx.InExpr = inExpr
+		ctx.InHeader = !inExpr && len(header) > 0 && !lastDot
+		captured = true
+	}
+	resetHeader := func() {
+		header, label, lastDot = nil, "", false
+	}
+
+	s := scanner.New(token.NewFile(""), text, func(token.Pos, string) {}, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if !captured && pos.Offset() >= ctx.PrefixStart {
+			capture()
+		}
+
+		if inExpr {
+			switch tok {
+			case token.LPAREN, token.LBRACK, token.LCURLY:
+				exprDepth++
+			case token.RPAREN, token.RBRACK:
+				if exprDepth > 0 {
+					exprDepth--
+				}
+			case token.RCURLY:
+				if exprDepth > 0 {
+					exprDepth--
+				} else {
+					// The block was closed right after the attribute value.
+					inExpr = false
+					if cur.Parent != nil {
+						cur = cur.Parent
+					}
+				}
+			case token.TERMINATOR:
+				if exprDepth == 0 {
+					inExpr = false
+				}
+			}
+			continue
+		}
+
+		switch tok {
+		case token.IDENT:
+			if len(header) > 0 && !lastDot {
+				resetHeader()
+			}
+			header, lastDot = append(header, lit), false
+		case token.DOT:
+			lastDot = len(header) > 0
+		case token.STRING:
+			if len(header) > 0 {
+				label, _ = strconv.Unquote(lit)
+			}
+		case token.ASSIGN:
+			if len(header) == 1 {
+				cur.Attrs[header[0]] = true
+			}
+			inExpr, exprDepth = true, 0
+			resetHeader()
+		case token.LCURLY:
+			child := &blockHeader{
+				Name:   strings.Join(header, "."),
+				Label:  label,
+				Attrs:  make(map[string]bool),
+				Parent: cur,
+			}
+			cur.Children = append(cur.Children, child)
+			cur = child
+			resetHeader()
+		case token.RCURLY:
+			if cur.Parent != nil {
+				cur = cur.Parent
+			}
+			resetHeader()
+		default:
+			resetHeader()
+		}
+	}
+
+	if !captured {
+		capture()
+	}
+	return root, ctx
+}
+
+// prefixStart returns the offset of the start of the partial name which ends
+// at cursor.
+func prefixStart(text []byte, cursor int) int {
+	start := cursor
+	for start > 0 {
+		switch ch := text[start-1]; {
+		case ch == '_' || ch == '.',
+			ch >= 'a' && ch <= 'z',
+			ch >= 'A' && ch <= 'Z',
+			ch >= '0' && ch <= '9':
+			start--
+		default:
+			return start
+		}
+	}
+	return start
+}
+
+// headerFromBlock builds a blockHeader from a parsed block.
+func headerFromBlock(block *ast.BlockStmt, parent *blockHeader) *blockHeader {
+	h := &blockHeader{
+		Name:   strings.Join(block.Name, "."),
+		Label:  block.Label,
+		Attrs:  make(map[string]bool),
+		Parent: parent,
+	}
+	for _, stmt := range block.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			h.Attrs[stmt.Name.Name] = true
+		case *ast.BlockStmt:
+			h.Children = append(h.Children, headerFromBlock(stmt, h))
+		}
+	}
+	return h
+}
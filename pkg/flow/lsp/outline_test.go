@@ -0,0 +1,107 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanDocument(t *testing.T) {
+	// The document is incomplete, as if it's being edited.
+	text := `logging {
+	level = "debug"
+}
+
+declare "example" {
+	argument "in" { }
+
+	prometheus.scrape "default" {
+		targets = [{ "__address__" = "localhost:9090" }]
+		clustering {
+			enabled = 
+`
+
+	root, _ := scanDocument([]byte(text), -1)
+	require.Len(t, root.Children, 2)
+
+	logging := root.Children[0]
+	require.Equal(t, "logging", logging.Name)
+	require.True(t, logging.Attrs["level"])
+
+	decl := root.Children[1]
+	require.Equal(t, "declare", decl.Name)
+	require.Equal(t, "example", decl.Label)
+	require.True(t, decl.isFileLevel())
+	require.Equal(t, []*blockHeader{decl}, root.declares())
+	require.Len(t, decl.Children, 2)
+
+	scrape := decl.Children[1]
+	require.Equal(t, "prometheus.scrape", scrape.Name)
+	require.Equal(t, "default", scrape.Label)
+	require.True(t, scrape.Attrs["targets"])
+	require.False(t, scrape.isFileLevel())
+
+	clustering := scrape.Children[0]
+	require.Equal(t, "clustering", clustering.Name)
+	require.Equal(t, scrape, clustering.topLevel())
+	require.Equal(t, decl, clustering.fileLevel())
+}
+
+func TestScanDocument_Cursor(t *testing.T) {
+	tt := []struct {
+		name      string
+		text      string // | marks the cursor.
+		block     string
+		prefix    string
+		inExpr    bool
+		inHeader  bool
+		fileLevel bool
+	}{
+		{
+			name:      "top level",
+			text:      "prometheus.sc|",
+			prefix:    "prometheus.sc",
+			fileLevel: true,
+		},
+		{
+			name:   "block body",
+			text:   "prometheus.scrape \"default\" {\n\tta|\n}",
+			block:  "prometheus.scrape",
+			prefix: "ta",
+		},
+		{
+			name:   "attribute value",
+			text:   "prometheus.scrape \"default\" {\n\ttargets = discovery.|\n}",
+			block:  "prometheus.scrape",
+			prefix: "discovery.",
+			inExpr: true,
+		},
+		{
+			name:   "after attribute value",
+			text:   "prometheus.scrape \"default\" {\n\ttargets = []\n\t|\n}",
+			block:  "prometheus.scrape",
+			prefix: "",
+		},
+		{
+			name:      "block label",
+			text:      "prometheus.scrape \"def|\" {\n}",
+			prefix:    "def",
+			inHeader:  true,
+			fileLevel: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			text, pos := cursorPosition(t, tc.text)
+			doc := newDocument(testURI, 1, []byte(text))
+
+			_, ctx := scanDocument(doc.text, doc.offset(pos))
+			require.Equal(t, tc.block, ctx.Block.Name)
+			require.Equal(t, tc.prefix, ctx.Prefix)
+			require.Equal(t, tc.inExpr, ctx.InExpr)
+			require.Equal(t, tc.inHeader, ctx.InHeader)
+			require.Equal(t, tc.fileLevel, ctx.Block.isFileLevel())
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package lsp
+
+// This file holds the subset of the Language Server Protocol types used by
+// the server. See
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+// for the full specification.
+
+// position is a zero-based line and character offset within a document.
+// Character offsets are measured in UTF-16 code units.
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// textRange is a range within a document. End is exclusive.
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// location is a range within a specific document.
+type location struct {
+	URI   string    `json:"uri"`
+	Range textRange `json:"range"`
+}
+
+// Diagnostic severities.
+const (
+	severityError   = 1
+	severityWarning = 2
+)
+
+// diagnostic is a problem found within a document.
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+// textEdit replaces a range of a document with new text.
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+// markupContent is formatted text shown to the user.
+type markupContent struct {
+	Kind  string `json:"kind"` // Always "markdown".
+	Value string `json:"value"`
+}
+
+type (
+	initializeResult struct {
+		Capabilities serverCapabilities `json:"capabilities"`
+		ServerInfo   serverInfo         `json:"serverInfo"`
+	}
+
+	serverCapabilities struct {
+		TextDocumentSync           int                `json:"textDocumentSync"`
+		CompletionProvider         *completionOptions `json:"completionProvider,omitempty"`
+		HoverProvider              bool               `json:"hoverProvider"`
+		DefinitionProvider         bool               `json:"definitionProvider"`
+		ReferencesProvider         bool               `json:"referencesProvider"`
+		DocumentFormattingProvider bool               `json:"documentFormattingProvider"`
+	}
+
+	completionOptions struct {
+		TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+	}
+
+	serverInfo struct {
+		Name string `json:"name"`
+	}
+)
+
+// textDocumentSyncFull indicates that documents are synced by sending their
+// full content on every change.
+const textDocumentSyncFull = 1
+
+type (
+	textDocumentIdentifier struct {
+		URI string `json:"uri"`
+	}
+
+	textDocumentItem struct {
+		URI     string `json:"uri"`
+		Version int    `json:"version"`
+		Text    string `json:"text"`
+	}
+
+	versionedTextDocumentIdentifier struct {
+		URI     string `json:"uri"`
+		Version int    `json:"version"`
+	}
+
+	textDocumentContentChangeEvent struct {
+		Range *textRange `json:"range,omitempty"`
+		Text  string     `json:"text"`
+	}
+
+	didOpenTextDocumentParams struct {
+		TextDocument textDocumentItem `json:"textDocument"`
+	}
+
+	didChangeTextDocumentParams struct {
+		TextDocument   versionedTextDocumentIdentifier  `json:"textDocument"`
+		ContentChanges []textDocumentContentChangeEvent `json:"contentChanges"`
+	}
+
+	didCloseTextDocumentParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+	}
+
+	textDocumentPositionParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+		Position     position               `json:"position"`
+	}
+
+	referenceParams struct {
+		textDocumentPositionParams
+		Context struct {
+			IncludeDeclaration bool `json:"includeDeclaration"`
+		} `json:"context"`
+	}
+
+	documentFormattingParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+	}
+
+	publishDiagnosticsParams struct {
+		URI         string       `json:"uri"`
+		Version     int          `json:"version,omitempty"`
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}
+)
+
+// Completion item kinds.
+const (
+	completionKindField     = 5
+	completionKindVariable  = 6
+	completionKindModule    = 9
+	completionKindProperty  = 10
+	completionKindReference = 18
+)
+
+// insertTextFormatSnippet indicates that the text of a completion item is a
+// snippet with placeholders.
+const insertTextFormatSnippet = 2
+
+// completionItem is a single completion suggestion.
+type completionItem struct {
+	Label            string    `json:"label"`
+	Kind             int       `json:"kind,omitempty"`
+	Detail           string    `json:"detail,omitempty"`
+	InsertTextFormat int       `json:"insertTextFormat,omitempty"`
+	TextEdit         *textEdit `json:"textEdit,omitempty"`
+}
+
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []completionItem `json:"items"`
+}
+
+// hoverResult is information shown when hovering over a range of a document.
+type hoverResult struct {
+	Contents markupContent `json:"contents"`
+	Range    *textRange    `json:"range,omitempty"`
+}
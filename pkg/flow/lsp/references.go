@@ -0,0 +1,229 @@
+package lsp
+
+import (
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// scope is a body of top-level statements in a parsed file: either the file
+// itself or the body of a declare block. Expressions can only reference
+// blocks in their own scope.
+type scope struct {
+	Body   ast.Body
+	Header *blockHeader // Header for the scope, used to look up declared components.
+	Outer  []ast.Body   // Bodies of the enclosing scopes, outermost first.
+}
+
+// findScope returns the innermost scope of file which holds off.
+func findScope(file *ast.File, off int) scope {
+	sc := scope{
+		Body:   file.Body,
+		Header: &blockHeader{Attrs: make(map[string]bool)},
+	}
+	for _, stmt := range file.Body {
+		if block, ok := stmt.(*ast.BlockStmt); ok {
+			sc.Header.Children = append(sc.Header.Children, headerFromBlock(block, sc.Header))
+		}
+	}
+
+	for {
+		next, ok := innerScope(sc, off)
+		if !ok {
+			return sc
+		}
+		sc = next
+	}
+}
+
+// innerScope returns the scope of the declare block within sc which holds
+// off.
+func innerScope(sc scope, off int) (scope, bool) {
+	for i, stmt := range sc.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || !isDeclare(block) || !contains(block.LCurlyPos, block.RCurlyPos, off) {
+			continue
+		}
+		// Children of the scope header are in the same order as the blocks of
+		// the body, but skip non-block statements.
+		return scope{
+			Body:   block.Body,
+			Header: headerForStmt(sc, i),
+			Outer:  append(append([]ast.Body{}, sc.Outer...), sc.Body),
+		}, true
+	}
+	return scope{}, false
+}
+
+// headerForStmt returns the header of the block statement at index i of the
+// body of sc.
+func headerForStmt(sc scope, i int) *blockHeader {
+	var blockIndex int
+	for _, stmt := range sc.Body[:i] {
+		if _, ok := stmt.(*ast.BlockStmt); ok {
+			blockIndex++
+		}
+	}
+	return sc.Header.Children[blockIndex]
+}
+
+func isDeclare(block *ast.BlockStmt) bool {
+	return len(block.Name) == 1 && block.Name[0] == "declare"
+}
+
+// blockID returns the parts of the ID expressions use to reference block.
+func blockID(block *ast.BlockStmt) []string {
+	id := append([]string{}, block.Name...)
+	if block.Label != "" {
+		id = append(id, block.Label)
+	}
+	return id
+}
+
+// traversal is a chain of names accessed by an expression, such as
+// `discovery.kubernetes.pods.targets`.
+type traversal struct {
+	Names      []string
+	Start, End token.Pos
+}
+
+// walkTraversals calls fn for each traversal in the attributes of body,
+// including attributes of nested blocks. Declare blocks are skipped, since
+// their bodies are separate scopes.
+func walkTraversals(body ast.Body, fn func(traversal)) {
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			exprTraversals(stmt.Value, fn)
+		case *ast.BlockStmt:
+			if !isDeclare(stmt) {
+				walkTraversals(stmt.Body, fn)
+			}
+		}
+	}
+}
+
+func exprTraversals(expr ast.Expr, fn func(traversal)) {
+	switch expr := expr.(type) {
+	case *ast.IdentifierExpr, *ast.AccessExpr:
+		if names, ok := flattenTraversal(expr); ok {
+			fn(traversal{Names: names, Start: ast.StartPos(expr), End: ast.EndPos(expr)})
+		} else if access, ok := expr.(*ast.AccessExpr); ok {
+			exprTraversals(access.Value, fn)
+		}
+	case *ast.InterpolationExpr:
+		for _, e := range expr.Exprs {
+			exprTraversals(e, fn)
+		}
+	case *ast.ArrayExpr:
+		for _, elem := range expr.Elements {
+			exprTraversals(elem, fn)
+		}
+	case *ast.ObjectExpr:
+		for _, field := range expr.Fields {
+			exprTraversals(field.Value, fn)
+		}
+	case *ast.IndexExpr:
+		exprTraversals(expr.Value, fn)
+		exprTraversals(expr.Index, fn)
+	case *ast.CallExpr:
+		exprTraversals(expr.Value, fn)
+		for _, arg := range expr.Args {
+			exprTraversals(arg, fn)
+		}
+	case *ast.UnaryExpr:
+		exprTraversals(expr.Value, fn)
+	case *ast.BinaryExpr:
+		exprTraversals(expr.Left, fn)
+		exprTraversals(expr.Right, fn)
+	case *ast.ParenExpr:
+		exprTraversals(expr.Inner, fn)
+	}
+}
+
+// flattenTraversal returns the names accessed by a chain of AccessExprs
+// starting from an IdentifierExpr.
+func flattenTraversal(expr ast.Expr) ([]string, bool) {
+	switch expr := expr.(type) {
+	case *ast.IdentifierExpr:
+		return []string{expr.Ident.Name}, true
+	case *ast.AccessExpr:
+		names, ok := flattenTraversal(expr.Value)
+		if !ok {
+			return nil, false
+		}
+		return append(names, expr.Name.Name), true
+	default:
+		return nil, false
+	}
+}
+
+// traversalAt returns the traversal in body which holds off.
+func traversalAt(body ast.Body, off int) (traversal, bool) {
+	var (
+		found traversal
+		ok    bool
+	)
+	walkTraversals(body, func(t traversal) {
+		if !ok && contains(t.Start, t.End, off) {
+			found, ok = t, true
+		}
+	})
+	return found, ok
+}
+
+// resolveTraversal returns the block of body referenced by names. The block
+// with the longest matching ID is returned.
+func resolveTraversal(body ast.Body, names []string) (*ast.BlockStmt, bool) {
+	var (
+		best    *ast.BlockStmt
+		bestLen int
+	)
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || isDeclare(block) {
+			continue
+		}
+		if id := blockID(block); len(id) > bestLen && hasPrefix(names, id) {
+			best, bestLen = block, len(id)
+		}
+	}
+	return best, best != nil
+}
+
+func hasPrefix(names, prefix []string) bool {
+	if len(prefix) > len(names) {
+		return false
+	}
+	for i := range prefix {
+		if names[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// blockHeaderAt returns the block in body whose header holds off.
+func blockHeaderAt(body ast.Body, off int) (*ast.BlockStmt, bool) {
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if ok && contains(block.NamePos, block.LCurlyPos, off) {
+			return block, true
+		}
+	}
+	return nil, false
+}
+
+// findDeclare returns the declare block with the given label which is
+// visible from sc.
+func findDeclare(sc scope, label string) (*ast.BlockStmt, bool) {
+	bodies := append(append([]ast.Body{}, sc.Outer...), sc.Body)
+	for i := len(bodies) - 1; i >= 0; i-- {
+		for _, stmt := range bodies[i] {
+			block, ok := stmt.(*ast.BlockStmt)
+			if ok && isDeclare(block) && block.Label == label {
+				return block, true
+			}
+		}
+	}
+	return nil, false
+}
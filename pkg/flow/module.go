@@ -5,14 +5,16 @@ import (
 	"fmt"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/grafana/agent/component"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/scanner"
-	"github.com/grafana/agent/pkg/river/token"
+	"github.com/grafana/agent/pkg/river/typeexpr"
+	"github.com/grafana/agent/service"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/exp/maps"
 )
@@ -37,7 +39,7 @@ func newModuleController(o *moduleControllerOptions) controller.ModuleController
 
 // NewModule creates a new, unstarted Module.
 func (m *moduleController) NewModule(id string, export component.ExportFunc) (component.Module, error) {
-	if id != "" && !isValidIdentifier(id) {
+	if id != "" && !scanner.IsValidIdentifier(id) {
 		return nil, fmt.Errorf("module ID %q is not a valid River identifier", id)
 	}
 
@@ -66,12 +68,6 @@ func (m *moduleController) NewModule(id string, export component.ExportFunc) (co
 	return mod, nil
 }
 
-func isValidIdentifier(in string) bool {
-	s := scanner.New(nil, []byte(in), nil, 0)
-	_, tok, lit := s.Scan()
-	return tok == token.IDENT && lit == in
-}
-
 func (m *moduleController) removeID(id string) {
 	m.mut.Lock()
 	defer m.mut.Unlock()
@@ -100,7 +96,8 @@ type moduleOptions struct {
 }
 
 var (
-	_ component.Module = (*module)(nil)
+	_ component.Module            = (*module)(nil)
+	_ controller.ModuleBodyLoader = (*module)(nil)
 )
 
 // newModule creates a module instance for a specific component.
@@ -110,7 +107,6 @@ func newModule(o *moduleOptions) *module {
 		f: newController(o.ModuleRegistry, Options{
 			ControllerID:   o.ID,
 			Tracer:         o.Tracer,
-			Clusterer:      o.Clusterer,
 			Reg:            o.Reg,
 			Logger:         o.Logger,
 			DataPath:       o.DataPath,
@@ -119,14 +115,28 @@ func newModule(o *moduleOptions) *module {
 			OnExportsChange: func(exports map[string]any) {
 				o.export(exports)
 			},
-			DialFunc: o.DialFunc,
+			OnComponentsRemoved: o.OnComponentsRemoved,
+			DialFunc:            o.DialFunc,
+			Services:            o.Services,
+			StuckTimeout:        o.StuckTimeout,
+			Capsules:            o.Capsules,
 		}),
 	}
 }
 
-// LoadConfig parses River config and loads it.
+// LoadConfig parses River config, or its JSON representation, and loads it.
 func (c *module) LoadConfig(config []byte, args map[string]any) error {
-	ff, err := ReadFile(c.o.ID, config)
+	ff, err := readModuleFile(c.o.ID, config)
+	if err != nil {
+		return err
+	}
+	return c.f.LoadFile(ff, args)
+}
+
+// LoadBody loads an already parsed River body. It is used by declared
+// components, whose body comes from a declare block of the parent controller.
+func (c *module) LoadBody(body ast.Body, args map[string]any) error {
+	ff, err := newFile(c.o.ID, &ast.File{Name: c.o.ID, Body: body})
 	if err != nil {
 		return err
 	}
@@ -152,10 +162,6 @@ type moduleControllerOptions struct {
 	// nil.
 	Tracer *tracing.Tracer
 
-	// Clusterer for implementing distributed behavior among components running
-	// on different nodes.
-	Clusterer *cluster.Clusterer
-
 	// Reg is the prometheus register to use
 	Reg prometheus.Registerer
 
@@ -185,4 +191,20 @@ type moduleControllerOptions struct {
 	// ModuleRegistry is a shared registry of running modules from the same root
 	// controller.
 	ModuleRegistry *moduleRegistry
+
+	// Services of the root controller. Modules don't run services, but their
+	// components may depend on them.
+	Services []service.Service
+
+	// StuckTimeout is how long components may take to respond before they're
+	// reported as stuck.
+	StuckTimeout time.Duration
+
+	// Capsules are the Go types which capsule type expressions of arguments
+	// may refer to.
+	Capsules typeexpr.Capsules
+
+	// OnComponentsRemoved is called with the global IDs of components removed
+	// from modules. May be nil.
+	OnComponentsRemoved func(globalIDs []string)
 }
@@ -3,6 +3,8 @@ package flow
 import (
 	"fmt"
 	"sync"
+
+	"golang.org/x/exp/maps"
 )
 
 type moduleRegistry struct {
@@ -47,3 +49,11 @@ func (reg *moduleRegistry) Unregister(id string) {
 
 	delete(reg.modules, id)
 }
+
+// List returns all registered modules.
+func (reg *moduleRegistry) List() []*module {
+	reg.mut.RLock()
+	defer reg.mut.RUnlock()
+
+	return maps.Values(reg.modules)
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/grafana/agent/component"
-	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
@@ -92,6 +91,15 @@ func TestModule(t *testing.T) {
 			exportModuleContent:   exportStringConfig + exportStringConfig,
 			expectedErrorContains: "\"export.username\" block already declared",
 		},
+		{
+			name: "JSON module",
+			exportModuleContent: `[
+				{ "name": "export", "type": "block", "label": "username", "body": [
+					{ "name": "value", "type": "attr", "value": { "type": "string", "value": "bob" } }
+				] }
+			]`,
+			expectedExports: []string{"username"},
+		},
 		{
 			name:                "Multiple exports but none are used but still exported",
 			exportModuleContent: exportStringConfig + exportDummy,
@@ -205,13 +213,10 @@ func testModuleControllerOptions(t *testing.T) *moduleControllerOptions {
 	s, err := logging.New(os.Stderr, logging.DefaultOptions)
 	require.NoError(t, err)
 
-	c := &cluster.Clusterer{Node: cluster.NewLocalNode("")}
-
 	return &moduleControllerOptions{
 		Logger:         s,
 		DataPath:       t.TempDir(),
 		Reg:            prometheus.NewRegistry(),
-		Clusterer:      c,
 		ModuleRegistry: newModuleRegistry(),
 	}
 }
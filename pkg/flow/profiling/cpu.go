@@ -0,0 +1,185 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/pprof/profile"
+)
+
+// CPUAccountant continuously records CPU profiles to attribute CPU time to
+// components. Profiles are recorded in consecutive windows; the profile of
+// the most recent window is retained so it can be downloaded.
+//
+// Only one CPU profile can be recorded by a process at a time, so other CPU
+// profiles, such as the ones from /debug/pprof/profile, can't be recorded
+// while a CPUAccountant is running.
+type CPUAccountant struct {
+	log    log.Logger
+	window time.Duration
+
+	mut     sync.RWMutex
+	totals  map[string]float64  // Total CPU seconds per component ID.
+	removed map[string]struct{} // Components removed during the current window.
+	last    *profile.Profile    // Profile of the last window.
+}
+
+// NewCPUAccountant creates a new CPUAccountant which records profiles in
+// windows of the given duration. Call Run to start recording.
+func NewCPUAccountant(l log.Logger, window time.Duration) *CPUAccountant {
+	return &CPUAccountant{
+		log:     l,
+		window:  window,
+		totals:  make(map[string]float64),
+		removed: make(map[string]struct{}),
+	}
+}
+
+// Run records CPU profiles until ctx is canceled.
+func (a *CPUAccountant) Run(ctx context.Context) error {
+	for {
+		var buf bytes.Buffer
+		started := true
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			// Another CPU profile is being recorded; try again in the next window.
+			level.Warn(a.log).Log("msg", "failed to start recording CPU profile", "err", err)
+			started = false
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(a.window):
+		}
+
+		if started {
+			pprof.StopCPUProfile()
+			if err := a.record(&buf); err != nil {
+				level.Warn(a.log).Log("msg", "failed to record CPU profile", "err", err)
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// RecordCPU records a CPU profile for the duration d or until ctx is
+// canceled.
+func RecordCPU(ctx context.Context, d time.Duration) (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, fmt.Errorf("starting CPU profile: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+	pprof.StopCPUProfile()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return profile.Parse(&buf)
+}
+
+// record adds the CPU time of samples in the CPU profile from r to the
+// totals of their components.
+func (a *CPUAccountant) record(r io.Reader) error {
+	p, err := profile.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	valueIndex := -1
+	for i, st := range p.SampleType {
+		if st.Type == "cpu" && st.Unit == "nanoseconds" {
+			valueIndex = i
+			break
+		}
+	}
+	if valueIndex == -1 {
+		return fmt.Errorf("profile doesn't contain CPU samples")
+	}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for _, s := range p.Sample {
+		id := sampleComponent(s)
+		if id == "" || a.isRemoved(id) {
+			continue
+		}
+		a.totals[id] += time.Duration(s.Value[valueIndex]).Seconds()
+	}
+	a.removed = make(map[string]struct{})
+	a.last = p
+	return nil
+}
+
+// Remove drops the totals of the components with the given IDs and of the
+// components running inside them. Samples of these components in the
+// window which is being recorded are ignored. Remove is called when
+// components are removed from the graph so that totals don't grow with
+// every component that was ever run.
+func (a *CPUAccountant) Remove(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for _, id := range ids {
+		a.removed[id] = struct{}{}
+	}
+	for id := range a.totals {
+		if a.isRemoved(id) {
+			delete(a.totals, id)
+		}
+	}
+}
+
+// isRemoved reports whether the component id, or a module it runs in, was
+// removed during the current window. a.mut must be held when calling
+// isRemoved.
+func (a *CPUAccountant) isRemoved(id string) bool {
+	for removed := range a.removed {
+		if MatchComponent(id, removed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Totals returns the total CPU time in seconds recorded for each component.
+func (a *CPUAccountant) Totals() map[string]float64 {
+	a.mut.RLock()
+	defer a.mut.RUnlock()
+
+	res := make(map[string]float64, len(a.totals))
+	for id, total := range a.totals {
+		res[id] = total
+	}
+	return res
+}
+
+// LastProfile returns the CPU profile of the most recent window. LastProfile
+// returns nil if no window has completed yet.
+func (a *CPUAccountant) LastProfile() *profile.Profile {
+	a.mut.RLock()
+	defer a.mut.RUnlock()
+
+	if a.last == nil {
+		return nil
+	}
+	return a.last.Copy()
+}
@@ -0,0 +1,61 @@
+package profiling
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPUAccountant_Remove(t *testing.T) {
+	a := NewCPUAccountant(log.NewNopLogger(), time.Minute)
+
+	record := func(t *testing.T, ids ...string) {
+		t.Helper()
+
+		var (
+			fn  = &profile.Function{ID: 1, Name: "main.run"}
+			loc = &profile.Location{ID: 1, Line: []profile.Line{{Function: fn}}}
+		)
+		p := &profile.Profile{
+			SampleType: []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}},
+			Function:   []*profile.Function{fn},
+			Location:   []*profile.Location{loc},
+		}
+		for _, id := range ids {
+			p.Sample = append(p.Sample, &profile.Sample{
+				Value:    []int64{int64(time.Second)},
+				Location: []*profile.Location{loc},
+				Label:    map[string][]string{LabelComponentID: {id}},
+			})
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, p.Write(&buf))
+		require.NoError(t, a.record(&buf))
+	}
+
+	record(t, "local.file.a", "module.file.b", "module.file.b/local.file.c")
+	require.Equal(t, map[string]float64{
+		"local.file.a":               1,
+		"module.file.b":              1,
+		"module.file.b/local.file.c": 1,
+	}, a.Totals())
+
+	// Removing a module component also removes the components running inside
+	// of it.
+	a.Remove([]string{"module.file.b"})
+	require.Equal(t, map[string]float64{"local.file.a": 1}, a.Totals())
+
+	// Samples of removed components in the window which was being recorded are
+	// ignored.
+	record(t, "local.file.a", "module.file.b/local.file.c")
+	require.Equal(t, map[string]float64{"local.file.a": 2}, a.Totals())
+
+	// Components with the same ID are accounted again in later windows.
+	record(t, "module.file.b")
+	require.Equal(t, map[string]float64{"local.file.a": 2, "module.file.b": 1}, a.Totals())
+}
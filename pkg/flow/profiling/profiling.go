@@ -0,0 +1,177 @@
+// Package profiling attributes the resource usage of Flow components to the
+// IDs of the components.
+//
+// Goroutines and CPU samples are attributed using pprof labels: the Flow
+// controller runs components with the ID of the component set as the
+// LabelComponentID label, which is inherited by every goroutine the
+// component starts.
+//
+// The Go runtime doesn't record pprof labels for heap allocations, so heap
+// profiles are attributed to the Go package which implements a component
+// instead.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// LabelComponentID is the pprof label holding the global ID of the component
+// which started a goroutine.
+const LabelComponentID = "component_id"
+
+// Do calls f with the calling goroutine labeled with the component ID id.
+// Goroutines started by f inherit the label.
+func Do(ctx context.Context, id string, f func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(LabelComponentID, id), f)
+}
+
+// Goroutines returns the current number of goroutines for each component.
+// Goroutines which don't belong to a component aren't counted.
+func Goroutines() (map[string]int, error) {
+	p, err := Lookup("goroutine")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]int)
+	for _, s := range p.Sample {
+		id := sampleComponent(s)
+		if id == "" {
+			continue
+		}
+		res[id] += int(s.Value[0])
+	}
+	return res, nil
+}
+
+// Lookup returns the current state of the runtime profile with the given
+// name, such as "goroutine" or "heap".
+func Lookup(name string) (*profile.Profile, error) {
+	rp := pprof.Lookup(name)
+	if rp == nil {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+
+	var buf bytes.Buffer
+	if err := rp.WriteTo(&buf, 0); err != nil {
+		return nil, fmt.Errorf("writing %s profile: %w", name, err)
+	}
+	return profile.Parse(&buf)
+}
+
+// sampleComponent returns the ID of the component which recorded s, or an
+// empty string if s wasn't recorded by a component.
+func sampleComponent(s *profile.Sample) string {
+	if ids := s.Label[LabelComponentID]; len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// MatchComponent reports whether a sample labeled with the component ID
+// label belongs to the component id. Samples of components running inside a
+// module belong to the module component too.
+func MatchComponent(label, id string) bool {
+	return label == id || strings.HasPrefix(label, id+"/")
+}
+
+// FilterComponent returns a copy of p which only retains samples belonging to
+// the component id.
+func FilterComponent(p *profile.Profile, id string) *profile.Profile {
+	return filterSamples(p, func(s *profile.Sample) bool {
+		return MatchComponent(sampleComponent(s), id)
+	})
+}
+
+// FilterPackage returns a copy of p which only retains samples whose stack
+// includes a function of the Go package pkg or one of its subpackages.
+func FilterPackage(p *profile.Profile, pkg string) *profile.Profile {
+	return filterSamples(p, func(s *profile.Sample) bool {
+		for _, loc := range s.Location {
+			for _, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				name := line.Function.Name
+				if strings.HasPrefix(name, pkg+".") || strings.HasPrefix(name, pkg+"/") {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+func filterSamples(p *profile.Profile, keep func(s *profile.Sample) bool) *profile.Profile {
+	res := p.Copy()
+	samples := res.Sample[:0]
+	for _, s := range res.Sample {
+		if keep(s) {
+			samples = append(samples, s)
+		}
+	}
+	res.Sample = samples
+	return res
+}
+
+// FuncPackage returns the import path of the Go package which defines the
+// function fn. FuncPackage returns an empty string if fn isn't a function.
+func FuncPackage(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+
+	// Function names are the import path of the package followed by a
+	// period-delimited symbol name, such as
+	// github.com/grafana/agent/component/local/file.init.0.func1, so the
+	// package ends at the first period after the last slash. Packages whose
+	// last import path element contains a period aren't supported.
+	name := f.Name()
+	lastSlash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[lastSlash+1:], '.')
+	if dot == -1 {
+		return name
+	}
+	return name[:lastSlash+1+dot]
+}
+
+// Stacks returns a text dump of the stacks of the goroutines currently
+// running for the component id. Goroutines with identical stacks are grouped
+// together.
+func Stacks(id string) (string, error) {
+	p, err := Lookup("goroutine")
+	if err != nil {
+		return "", err
+	}
+	p = FilterComponent(p, id)
+
+	var sb strings.Builder
+	for i, s := range p.Sample {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%d goroutine(s) of %s:\n", s.Value[0], sampleComponent(s))
+		for _, loc := range s.Location {
+			for _, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				fmt.Fprintf(&sb, "\t%s\n\t\t%s:%d\n", line.Function.Name, line.Function.Filename, line.Line)
+			}
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,80 @@
+package profiling
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutines(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		done    = make(chan struct{})
+	)
+	defer close(done)
+
+	Do(context.Background(), "module.file/local.file.a", func(context.Context) {
+		for i := 0; i < 3; i++ {
+			go func() {
+				started <- struct{}{}
+				<-done
+			}()
+		}
+	})
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	goroutines, err := Goroutines()
+	require.NoError(t, err)
+	require.Equal(t, 3, goroutines["module.file/local.file.a"])
+}
+
+func TestFilterComponent(t *testing.T) {
+	p := &profile.Profile{
+		Sample: []*profile.Sample{
+			{Value: []int64{1}, Label: map[string][]string{LabelComponentID: {"module.file.a"}}},
+			{Value: []int64{2}, Label: map[string][]string{LabelComponentID: {"module.file.a/local.file.b"}}},
+			{Value: []int64{4}, Label: map[string][]string{LabelComponentID: {"module.file.ab"}}},
+			{Value: []int64{8}},
+		},
+	}
+
+	filtered := FilterComponent(p, "module.file.a")
+	require.Len(t, filtered.Sample, 2)
+	require.Equal(t, []int64{1}, filtered.Sample[0].Value)
+	require.Equal(t, []int64{2}, filtered.Sample[1].Value)
+
+	// The original profile is left unmodified.
+	require.Len(t, p.Sample, 4)
+}
+
+func TestFilterPackage(t *testing.T) {
+	var (
+		scrapeFn = &profile.Function{ID: 1, Name: "github.com/grafana/agent/component/prometheus/scrape.(*Component).Run"}
+		otherFn  = &profile.Function{ID: 2, Name: "github.com/grafana/agent/component/prometheus/scraper.New"}
+		scrape   = &profile.Location{ID: 1, Line: []profile.Line{{Function: scrapeFn}}}
+		other    = &profile.Location{ID: 2, Line: []profile.Line{{Function: otherFn}}}
+	)
+	p := &profile.Profile{
+		Function: []*profile.Function{scrapeFn, otherFn},
+		Location: []*profile.Location{scrape, other},
+		Sample: []*profile.Sample{
+			{Value: []int64{1}, Location: []*profile.Location{other, scrape}},
+			{Value: []int64{2}, Location: []*profile.Location{other}},
+		},
+	}
+
+	filtered := FilterPackage(p, "github.com/grafana/agent/component/prometheus/scrape")
+	require.Len(t, filtered.Sample, 1)
+	require.Equal(t, []int64{1}, filtered.Sample[0].Value)
+}
+
+func TestFuncPackage(t *testing.T) {
+	require.Equal(t, "github.com/grafana/agent/pkg/flow/profiling", FuncPackage(TestFuncPackage))
+	require.Equal(t, "github.com/grafana/agent/pkg/flow/profiling", FuncPackage(func() {}))
+	require.Equal(t, "context", FuncPackage(context.Background))
+	require.Equal(t, "", FuncPackage("not a function"))
+}
@@ -0,0 +1,204 @@
+package flow
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/importsource"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// RedactedConfig returns the file from the most recent successful load,
+// printed as River. Values of attributes of type secret or optional secret
+// which are written in the file are replaced with the string "(secret)",
+// while expressions which only refer to other values are kept, so that the
+// result is still valid River. RedactedConfig returns nil if no file was
+// loaded successfully.
+//
+// Attributes of custom components aren't redacted, since their types aren't
+// known. The files loaded by import blocks and modules aren't included.
+func (f *Flow) RedactedConfig() ([]byte, error) {
+	f.loadMut.RLock()
+	file := f.lastFile
+	f.loadMut.RUnlock()
+
+	if file == nil {
+		return nil, nil
+	}
+
+	redacted := &ast.File{
+		Name:     file.Node.Name,
+		Body:     redactStatements(file.Node.Body, f.blockSchema),
+		Comments: file.Node.Comments,
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, redacted); err != nil {
+		return nil, err
+	}
+
+	// Add a newline at the end of the file.
+	_, _ = buf.Write([]byte{'\n'})
+	return buf.Bytes(), nil
+}
+
+// blockSchema returns the schema of the body of the top-level block with the
+// given name. blockSchema returns false for blocks which don't have a Go
+// type, such as declare and argument blocks and custom components.
+func (f *Flow) blockSchema(name string) (schema.Body, bool) {
+	switch name {
+	case "logging":
+		return schema.For(reflect.TypeOf(logging.Options{})), true
+	case "tracing":
+		return schema.For(reflect.TypeOf(tracing.Options{})), true
+	case importsource.File.BlockName():
+		return schema.For(reflect.TypeOf(importsource.FileArguments{})), true
+	case importsource.Git.BlockName():
+		return schema.For(reflect.TypeOf(importsource.GitArguments{})), true
+	case importsource.HTTP.BlockName():
+		return schema.For(reflect.TypeOf(importsource.HTTPArguments{})), true
+	}
+
+	for _, svc := range f.opts.Services {
+		if def := svc.Definition(); def.Name == name && def.ConfigType != nil {
+			return schema.For(reflect.TypeOf(def.ConfigType)), true
+		}
+	}
+
+	if reg, ok := component.Get(name); ok && reg.Args != nil {
+		return schema.For(reflect.TypeOf(reg.Args)), true
+	}
+	return schema.Body{}, false
+}
+
+// redactStatements returns a copy of the top-level statements of a file, or
+// of a declare block, with secrets redacted. lookup returns the schema of a
+// top-level block by name. body isn't modified.
+func redactStatements(body ast.Body, lookup func(name string) (schema.Body, bool)) ast.Body {
+	out := make(ast.Body, len(body))
+	for i, stmt := range body {
+		out[i] = stmt
+
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+
+		name := strings.Join(block.Name, ".")
+		if name == "declare" {
+			copied := *block
+			copied.Body = redactStatements(block.Body, lookup)
+			out[i] = &copied
+		} else if s, ok := lookup(name); ok {
+			copied := *block
+			copied.Body = redactBody(block.Body, s)
+			out[i] = &copied
+		}
+	}
+	return out
+}
+
+// redactBody returns a copy of the body of a block described by s, where the
+// values of secret attributes which contain string literals are replaced
+// with "(secret)".
+func redactBody(body ast.Body, s schema.Body) ast.Body {
+	out := make(ast.Body, len(body))
+	for i, stmt := range body {
+		out[i] = stmt
+
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			attr, ok := s.Attr(stmt.Name.Name)
+			if !ok || !isSecretType(attr.Type) || !containsLiteral(stmt.Value) {
+				continue
+			}
+			out[i] = &ast.AttributeStmt{
+				Name:  stmt.Name,
+				Value: redactedValue(stmt),
+			}
+
+		case *ast.BlockStmt:
+			inner, ok := s.Block(strings.Join(stmt.Name, "."))
+			if !ok {
+				continue
+			}
+			copied := *stmt
+			copied.Body = redactBody(stmt.Body, inner.Body)
+			out[i] = &copied
+		}
+	}
+	return out
+}
+
+// redactedPlaceholder replaces the values of secret attributes.
+const redactedPlaceholder = `"(secret)"`
+
+// redactedValue returns the placeholder which replaces the value of stmt.
+// The placeholder ends where the value ended, so that the printer keeps the
+// statement on the same lines and aligned with its neighbors.
+func redactedValue(stmt *ast.AttributeStmt) *ast.LiteralExpr {
+	pos := ast.EndPos(stmt.Value).Add(1 - len(redactedPlaceholder))
+	if start := ast.StartPos(stmt); pos.Offset() < start.Offset() {
+		pos = start
+	}
+	return &ast.LiteralExpr{
+		Kind:     token.STRING,
+		ValuePos: pos,
+		Value:    redactedPlaceholder,
+	}
+}
+
+var (
+	secretType         = reflect.TypeOf(rivertypes.Secret(""))
+	optionalSecretType = reflect.TypeOf(rivertypes.OptionalSecret{})
+)
+
+// isSecretType returns true if values of t are secrets or optional secrets,
+// or are collections of them. Optional secrets are redacted even though they
+// may hold non-sensitive values, since the file doesn't tell them apart.
+func isSecretType(t reflect.Type) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t == secretType || t == optionalSecretType
+		}
+	}
+}
+
+// containsLiteral returns true if expr contains a string literal, including
+// the text of an interpolated string.
+func containsLiteral(expr ast.Expr) bool {
+	var v literalVisitor
+	ast.Walk(&v, expr)
+	return v.found
+}
+
+type literalVisitor struct{ found bool }
+
+func (v *literalVisitor) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.LiteralExpr:
+		v.found = node.Kind == token.STRING
+	case *ast.InterpolationExpr:
+		// Walk only visits the embedded expressions of interpolated strings.
+		for _, text := range node.Strings {
+			if text != "" {
+				v.found = true
+			}
+		}
+	}
+	if v.found {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package flow
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/stretchr/testify/require"
+)
+
+type redactArguments struct {
+	URL      string                       `river:"url,attr"`
+	Password rivertypes.Secret            `river:"password,attr,optional"`
+	Headers  map[string]rivertypes.Secret `river:"headers,attr,optional"`
+	Auth     *redactAuth                  `river:"auth,block,optional"`
+}
+
+type redactAuth struct {
+	Username rivertypes.OptionalSecret `river:"username,attr,optional"`
+	Token    rivertypes.Secret         `river:"token,attr,optional"`
+	APIKey   rivertypes.Secret         `river:"api_key,attr,optional"`
+	Header   rivertypes.Secret         `river:"header,attr,optional"`
+}
+
+func TestRedactStatements(t *testing.T) {
+	input := `// Comments are kept.
+logging {
+	level = "debug"
+}
+
+remote.http "default" {
+	url      = "http://example.com"
+	password = "hunter2" // Trailing comment.
+	headers  = {
+		"X-Token" = "hunter2",
+	}
+
+	auth {
+		username = "admin"
+		token    = local.file.token.content
+		api_key  = "s3cr3t\(env("X"))"
+		header   = "\(local.file.token.content)"
+	}
+}
+
+declare "example" {
+	argument "password" { }
+
+	remote.http "nested" {
+		url      = "http://example.com"
+		password = "prefix-" + argument.password.value
+	}
+}
+
+custom "example" {
+	password = "hunter2"
+}`
+
+	expect := `// Comments are kept.
+logging {
+	level = "debug"
+}
+
+remote.http "default" {
+	url      = "http://example.com"
+	password = "(secret)" // Trailing comment.
+	headers  = "(secret)"
+
+	auth {
+		username = "(secret)"
+		token    = local.file.token.content
+		api_key  = "(secret)"
+		header   = "\(local.file.token.content)"
+	}
+}
+
+declare "example" {
+	argument "password" { }
+
+	remote.http "nested" {
+		url      = "http://example.com"
+		password = "(secret)"
+	}
+}
+
+custom "example" {
+	password = "hunter2"
+}`
+
+	file, err := parser.ParseFile(t.Name(), []byte(input))
+	require.NoError(t, err)
+
+	lookup := func(name string) (schema.Body, bool) {
+		if name == "remote.http" {
+			return schema.For(reflect.TypeOf(redactArguments{})), true
+		}
+		return schema.Body{}, false
+	}
+	redacted := &ast.File{
+		Name:     file.Name,
+		Body:     redactStatements(file.Body, lookup),
+		Comments: file.Comments,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, redacted))
+	require.Equal(t, expect, buf.String())
+
+	// The redacted file must still be valid River.
+	_, err = parser.ParseFile(t.Name(), buf.Bytes())
+	require.NoError(t, err)
+
+	// The parsed file must not be modified.
+	buf.Reset()
+	require.NoError(t, printer.Fprint(&buf, file))
+	require.Equal(t, input, buf.String())
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// componentSampler is a sampler which uses a different sampler for spans
+// started by specific components. Spans are attributed to a component through
+// the component ID attribute injected by WrapTracer.
+type componentSampler struct {
+	base      tracesdk.Sampler
+	overrides []componentSamplerOverride
+}
+
+type componentSamplerOverride struct {
+	patterns []string
+	sampler  tracesdk.Sampler
+}
+
+var _ tracesdk.Sampler = (*componentSampler)(nil)
+
+func newComponentSampler(base tracesdk.Sampler, opts []ComponentSamplingOptions) *componentSampler {
+	cs := &componentSampler{base: base}
+	for _, o := range opts {
+		cs.overrides = append(cs.overrides, componentSamplerOverride{
+			patterns: o.Components,
+			sampler:  tracesdk.TraceIDRatioBased(o.SamplingFraction),
+		})
+	}
+	return cs
+}
+
+// ShouldSample implements tracesdk.Sampler. The first override whose
+// patterns match the component ID of the span makes the sampling decision.
+func (cs *componentSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	return cs.samplerFor(p).ShouldSample(p)
+}
+
+func (cs *componentSampler) samplerFor(p tracesdk.SamplingParameters) tracesdk.Sampler {
+	var id string
+	for _, attr := range p.Attributes {
+		if string(attr.Key) == componentIDAttributeKey {
+			id = attr.Value.AsString()
+			break
+		}
+	}
+	if id == "" {
+		return cs.base
+	}
+
+	for _, o := range cs.overrides {
+		for _, pattern := range o.patterns {
+			// Patterns are validated when decoding options, so errors are
+			// ignored.
+			if ok, _ := path.Match(pattern, id); ok {
+				return o.sampler
+			}
+		}
+	}
+	return cs.base
+}
+
+// Description implements tracesdk.Sampler.
+func (cs *componentSampler) Description() string {
+	overrides := make([]string, 0, len(cs.overrides))
+	for _, o := range cs.overrides {
+		overrides = append(overrides, fmt.Sprintf("%s:%s", strings.Join(o.patterns, ","), o.sampler.Description()))
+	}
+	return fmt.Sprintf("ComponentSampler{base:%s,overrides:[%s]}", cs.base.Description(), strings.Join(overrides, ";"))
+}
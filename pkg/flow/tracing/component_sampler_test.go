@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestComponentSampler(t *testing.T) {
+	cs := newComponentSampler(tracesdk.NeverSample(), []ComponentSamplingOptions{
+		{Components: []string{"prometheus.remote_write.*"}, SamplingFraction: 1},
+		{Components: []string{"prometheus.*"}, SamplingFraction: 0},
+	})
+
+	sample := func(id string) tracesdk.SamplingDecision {
+		var attrs []attribute.KeyValue
+		if id != "" {
+			attrs = append(attrs, attribute.String(componentIDAttributeKey, id))
+		}
+		res := cs.ShouldSample(tracesdk.SamplingParameters{
+			TraceID:    trace.TraceID{1},
+			Name:       "test",
+			Attributes: attrs,
+		})
+		return res.Decision
+	}
+
+	// The first matching override wins.
+	require.Equal(t, tracesdk.RecordAndSample, sample("prometheus.remote_write.default"))
+	require.Equal(t, tracesdk.Drop, sample("prometheus.scrape.default"))
+
+	// Spans of other components and spans without a component use the base
+	// sampler.
+	require.Equal(t, tracesdk.Drop, sample("loki.write.default"))
+	require.Equal(t, tracesdk.Drop, sample(""))
+}
+
+func TestComponentSamplingOptions_Validate(t *testing.T) {
+	var opts Options
+	err := river.Unmarshal([]byte(`
+		component_sampling {
+			components        = ["prometheus.*"]
+			sampling_fraction = 1.5
+		}
+	`), &opts)
+	require.ErrorContains(t, err, "sampling_fraction must be between 0 and 1, got 1.5")
+}
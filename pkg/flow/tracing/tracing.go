@@ -5,6 +5,8 @@ package tracing
 
 import (
 	"context"
+	"fmt"
+	"path"
 	"sync"
 	"time"
 
@@ -44,11 +46,39 @@ type Options struct {
 	// fraction.
 	Sampler SamplerOptions `river:"sampler,block,optional"`
 
+	// ComponentSampling overrides SamplingFraction for spans started by
+	// specific components.
+	ComponentSampling []ComponentSamplingOptions `river:"component_sampling,block,optional"`
+
 	// WriteTo holds a set of OpenTelemetry Collector consumers where internal
 	// traces should be sent.
 	WriteTo []otelcol.Consumer `river:"write_to,attr,optional"`
 }
 
+// ComponentSamplingOptions sets the sampling fraction of traces started by a
+// set of components. Components are matched by their ID using patterns
+// supported by path.Match.
+type ComponentSamplingOptions struct {
+	Components       []string `river:"components,attr"`
+	SamplingFraction float64  `river:"sampling_fraction,attr"`
+}
+
+// Validate implements river.Validator.
+func (opts *ComponentSamplingOptions) Validate() error {
+	if len(opts.Components) == 0 {
+		return fmt.Errorf("components must not be empty")
+	}
+	for _, pattern := range opts.Components {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid component pattern %q: %w", pattern, err)
+		}
+	}
+	if opts.SamplingFraction < 0 || opts.SamplingFraction > 1 {
+		return fmt.Errorf("sampling_fraction must be between 0 and 1, got %v", opts.SamplingFraction)
+	}
+	return nil
+}
+
 type SamplerOptions struct {
 	JaegerRemote *JaegerRemoteSamplerOptions `river:"jaeger_remote,block,optional"`
 
@@ -148,7 +178,11 @@ func (t *Tracer) Update(opts Options) error {
 	// Remote samplers accept a "seed" sampler to use before the remote is
 	// available. Get the current sampler from the previous iteration.
 	lastSampler := t.sampler.Sampler()
+	if cs, ok := lastSampler.(*componentSampler); ok {
+		lastSampler = cs.base
+	}
 
+	var sampler tracesdk.Sampler
 	switch {
 	case opts.Sampler.JaegerRemote != nil:
 		t.jaegerRemoteSampler = jaegerremote.New(
@@ -159,11 +193,16 @@ func (t *Tracer) Update(opts Options) error {
 			jaegerremote.WithInitialSampler(lastSampler),
 		)
 
-		t.sampler.SetSampler(t.jaegerRemoteSampler)
+		sampler = t.jaegerRemoteSampler
 
 	default:
-		t.sampler.SetSampler(tracesdk.TraceIDRatioBased(opts.SamplingFraction))
+		sampler = tracesdk.TraceIDRatioBased(opts.SamplingFraction)
+	}
+
+	if len(opts.ComponentSampling) > 0 {
+		sampler = newComponentSampler(sampler, opts.ComponentSampling)
 	}
+	t.sampler.SetSampler(sampler)
 
 	return nil
 }
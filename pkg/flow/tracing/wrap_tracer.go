@@ -58,12 +58,12 @@ type wrappedTracer struct {
 var _ trace.Tracer = (*wrappedTracer)(nil)
 
 func (tp *wrappedTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
-	ctx, span := tp.inner.Start(ctx, spanName, opts...)
+	// The ID is passed as a start option rather than set on the started span
+	// so that samplers can make decisions based on it.
 	if tp.id != "" {
-		span.SetAttributes(
+		opts = append(opts, trace.WithAttributes(
 			attribute.String(tp.spanName, tp.id),
-		)
+		))
 	}
-
-	return ctx, span
+	return tp.inner.Start(ctx, spanName, opts...)
 }
@@ -94,6 +94,18 @@ type LiteralExpr struct {
 	Value string
 }
 
+// InterpolationExpr is a string literal which embeds the values of
+// expressions, such as "http://\(host):9090".
+type InterpolationExpr struct {
+	// Strings holds the text around each embedded expression as written in the
+	// source, without surrounding quotes and with escape sequences left intact.
+	// Strings always has one more element than Exprs.
+	Strings []string
+	Exprs   []Expr
+
+	LQuotePos, RQuotePos token.Pos
+}
+
 // ArrayExpr is an array of values.
 type ArrayExpr struct {
 	Elements             []Expr
@@ -164,6 +176,7 @@ var (
 	_ Node = (*Ident)(nil)
 	_ Node = (*IdentifierExpr)(nil)
 	_ Node = (*LiteralExpr)(nil)
+	_ Node = (*InterpolationExpr)(nil)
 	_ Node = (*ArrayExpr)(nil)
 	_ Node = (*ObjectExpr)(nil)
 	_ Node = (*AccessExpr)(nil)
@@ -178,6 +191,7 @@ var (
 
 	_ Expr = (*IdentifierExpr)(nil)
 	_ Expr = (*LiteralExpr)(nil)
+	_ Expr = (*InterpolationExpr)(nil)
 	_ Expr = (*ArrayExpr)(nil)
 	_ Expr = (*ObjectExpr)(nil)
 	_ Expr = (*AccessExpr)(nil)
@@ -188,37 +202,39 @@ var (
 	_ Expr = (*ParenExpr)(nil)
 )
 
-func (n *File) astNode()           {}
-func (n Body) astNode()            {}
-func (n CommentGroup) astNode()    {}
-func (n *Comment) astNode()        {}
-func (n *AttributeStmt) astNode()  {}
-func (n *BlockStmt) astNode()      {}
-func (n *Ident) astNode()          {}
-func (n *IdentifierExpr) astNode() {}
-func (n *LiteralExpr) astNode()    {}
-func (n *ArrayExpr) astNode()      {}
-func (n *ObjectExpr) astNode()     {}
-func (n *AccessExpr) astNode()     {}
-func (n *IndexExpr) astNode()      {}
-func (n *CallExpr) astNode()       {}
-func (n *UnaryExpr) astNode()      {}
-func (n *BinaryExpr) astNode()     {}
-func (n *ParenExpr) astNode()      {}
+func (n *File) astNode()              {}
+func (n Body) astNode()               {}
+func (n CommentGroup) astNode()       {}
+func (n *Comment) astNode()           {}
+func (n *AttributeStmt) astNode()     {}
+func (n *BlockStmt) astNode()         {}
+func (n *Ident) astNode()             {}
+func (n *IdentifierExpr) astNode()    {}
+func (n *LiteralExpr) astNode()       {}
+func (n *InterpolationExpr) astNode() {}
+func (n *ArrayExpr) astNode()         {}
+func (n *ObjectExpr) astNode()        {}
+func (n *AccessExpr) astNode()        {}
+func (n *IndexExpr) astNode()         {}
+func (n *CallExpr) astNode()          {}
+func (n *UnaryExpr) astNode()         {}
+func (n *BinaryExpr) astNode()        {}
+func (n *ParenExpr) astNode()         {}
 
 func (n *AttributeStmt) astStmt() {}
 func (n *BlockStmt) astStmt()     {}
 
-func (n *IdentifierExpr) astExpr() {}
-func (n *LiteralExpr) astExpr()    {}
-func (n *ArrayExpr) astExpr()      {}
-func (n *ObjectExpr) astExpr()     {}
-func (n *AccessExpr) astExpr()     {}
-func (n *IndexExpr) astExpr()      {}
-func (n *CallExpr) astExpr()       {}
-func (n *UnaryExpr) astExpr()      {}
-func (n *BinaryExpr) astExpr()     {}
-func (n *ParenExpr) astExpr()      {}
+func (n *IdentifierExpr) astExpr()    {}
+func (n *LiteralExpr) astExpr()       {}
+func (n *InterpolationExpr) astExpr() {}
+func (n *ArrayExpr) astExpr()         {}
+func (n *ObjectExpr) astExpr()        {}
+func (n *AccessExpr) astExpr()        {}
+func (n *IndexExpr) astExpr()         {}
+func (n *CallExpr) astExpr()          {}
+func (n *UnaryExpr) astExpr()         {}
+func (n *BinaryExpr) astExpr()        {}
+func (n *ParenExpr) astExpr()         {}
 
 // StartPos returns the position of the first character belonging to a Node.
 func StartPos(n Node) token.Pos {
@@ -250,6 +266,8 @@ func StartPos(n Node) token.Pos {
 		return StartPos(n.Ident)
 	case *LiteralExpr:
 		return n.ValuePos
+	case *InterpolationExpr:
+		return n.LQuotePos
 	case *ArrayExpr:
 		return n.LBrackPos
 	case *ObjectExpr:
@@ -301,6 +319,8 @@ func EndPos(n Node) token.Pos {
 		return EndPos(n.Ident)
 	case *LiteralExpr:
 		return n.ValuePos.Add(len(n.Value) - 1)
+	case *InterpolationExpr:
+		return n.RQuotePos
 	case *ArrayExpr:
 		return n.RBrackPos
 	case *ObjectExpr:
@@ -0,0 +1,148 @@
+package ast
+
+// Equal reports whether two nodes are structurally equal. Positions of nodes
+// and comments are ignored, so two nodes which only differ in whitespace or
+// comments are considered equal.
+func Equal(a, b Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	// The order of the cases matches the declared order of nodes in ast.go.
+	switch a := a.(type) {
+	case *File:
+		b, ok := b.(*File)
+		return ok && equalPtr(a, b, func() bool { return Equal(a.Body, b.Body) })
+	case Body:
+		b, ok := b.(Body)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case *AttributeStmt:
+		b, ok := b.(*AttributeStmt)
+		return ok && equalPtr(a, b, func() bool {
+			return Equal(a.Name, b.Name) && equalExpr(a.Value, b.Value)
+		})
+	case *BlockStmt:
+		b, ok := b.(*BlockStmt)
+		return ok && equalPtr(a, b, func() bool {
+			return equalStrings(a.Name, b.Name) && a.Label == b.Label && Equal(a.Body, b.Body)
+		})
+	case *Ident:
+		b, ok := b.(*Ident)
+		return ok && equalPtr(a, b, func() bool { return a.Name == b.Name })
+	case *IdentifierExpr:
+		b, ok := b.(*IdentifierExpr)
+		return ok && equalPtr(a, b, func() bool { return Equal(a.Ident, b.Ident) })
+	case *LiteralExpr:
+		b, ok := b.(*LiteralExpr)
+		return ok && equalPtr(a, b, func() bool { return a.Kind == b.Kind && a.Value == b.Value })
+	case *InterpolationExpr:
+		b, ok := b.(*InterpolationExpr)
+		return ok && equalPtr(a, b, func() bool {
+			if len(a.Strings) != len(b.Strings) {
+				return false
+			}
+			for i := range a.Strings {
+				if a.Strings[i] != b.Strings[i] {
+					return false
+				}
+			}
+			return equalExprs(a.Exprs, b.Exprs)
+		})
+	case *ArrayExpr:
+		b, ok := b.(*ArrayExpr)
+		return ok && equalPtr(a, b, func() bool { return equalExprs(a.Elements, b.Elements) })
+	case *ObjectExpr:
+		b, ok := b.(*ObjectExpr)
+		return ok && equalPtr(a, b, func() bool {
+			if len(a.Fields) != len(b.Fields) {
+				return false
+			}
+			for i := range a.Fields {
+				af, bf := a.Fields[i], b.Fields[i]
+				if af.Quoted != bf.Quoted || !Equal(af.Name, bf.Name) || !equalExpr(af.Value, bf.Value) {
+					return false
+				}
+			}
+			return true
+		})
+	case *AccessExpr:
+		b, ok := b.(*AccessExpr)
+		return ok && equalPtr(a, b, func() bool {
+			return equalExpr(a.Value, b.Value) && Equal(a.Name, b.Name)
+		})
+	case *IndexExpr:
+		b, ok := b.(*IndexExpr)
+		return ok && equalPtr(a, b, func() bool {
+			return equalExpr(a.Value, b.Value) && equalExpr(a.Index, b.Index)
+		})
+	case *CallExpr:
+		b, ok := b.(*CallExpr)
+		return ok && equalPtr(a, b, func() bool {
+			return equalExpr(a.Value, b.Value) && equalExprs(a.Args, b.Args)
+		})
+	case *UnaryExpr:
+		b, ok := b.(*UnaryExpr)
+		return ok && equalPtr(a, b, func() bool {
+			return a.Kind == b.Kind && equalExpr(a.Value, b.Value)
+		})
+	case *BinaryExpr:
+		b, ok := b.(*BinaryExpr)
+		return ok && equalPtr(a, b, func() bool {
+			return a.Kind == b.Kind && equalExpr(a.Left, b.Left) && equalExpr(a.Right, b.Right)
+		})
+	case *ParenExpr:
+		b, ok := b.(*ParenExpr)
+		return ok && equalPtr(a, b, func() bool { return equalExpr(a.Inner, b.Inner) })
+	default:
+		return false
+	}
+}
+
+// equalPtr compares two pointers of the same type. If both pointers are
+// non-nil, fn is invoked to compare the values they point to.
+func equalPtr[T any](a, b *T, fn func() bool) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return fn()
+}
+
+// equalExpr compares two expressions, which may be nil.
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return Equal(a, b)
+}
+
+func equalExprs(a, b []Expr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equalExpr(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	tt := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{
+			name:  "identical",
+			a:     `foo "bar" { value = 5 }`,
+			b:     `foo "bar" { value = 5 }`,
+			equal: true,
+		},
+		{
+			name: "whitespace and comments",
+			a:    `foo "bar" { value = [1, 2, {a = "b"}] }`,
+			b: `
+				// Comment
+				foo "bar" {
+					value = [
+						1, // One
+						2,
+						{ a = "b" },
+					]
+				}
+			`,
+			equal: true,
+		},
+		{
+			name: "different attribute value",
+			a:    `foo "bar" { value = 5 }`,
+			b:    `foo "bar" { value = 6 }`,
+		},
+		{
+			name: "different label",
+			a:    `foo "bar" { value = 5 }`,
+			b:    `foo "baz" { value = 5 }`,
+		},
+		{
+			name: "different statement order",
+			a:    "foo \"bar\" {\n a = 1\n b = 2\n}",
+			b:    "foo \"bar\" {\n b = 2\n a = 1\n}",
+		},
+		{
+			name: "different expression type",
+			a:    `foo "bar" { value = a.b }`,
+			b:    `foo "bar" { value = a["b"] }`,
+		},
+		{
+			name: "different operator",
+			a:    `foo "bar" { value = 1 + 2 }`,
+			b:    `foo "bar" { value = 1 - 2 }`,
+		},
+		{
+			name: "quoted object key",
+			a:    `foo "bar" { value = { a = 1 } }`,
+			b:    `foo "bar" { value = { "a" = 1 } }`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := parser.ParseFile(t.Name(), []byte(tc.a))
+			require.NoError(t, err)
+			b, err := parser.ParseFile(t.Name(), []byte(tc.b))
+			require.NoError(t, err)
+
+			require.Equal(t, tc.equal, ast.Equal(a, b))
+			require.Equal(t, tc.equal, ast.Equal(b, a))
+		})
+	}
+}
+
+func TestEqual_Nil(t *testing.T) {
+	require.True(t, ast.Equal(nil, nil))
+	require.False(t, ast.Equal(nil, &ast.Ident{Name: "a"}))
+	require.False(t, ast.Equal(&ast.Ident{Name: "a"}, (*ast.IdentifierExpr)(nil)))
+}
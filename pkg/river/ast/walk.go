@@ -38,13 +38,20 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Ident)
 	case *LiteralExpr:
 		// Nothing to do
+	case *InterpolationExpr:
+		for _, e := range n.Exprs {
+			Walk(v, e)
+		}
 	case *ArrayExpr:
 		for _, e := range n.Elements {
 			Walk(v, e)
 		}
 	case *ObjectExpr:
 		for _, f := range n.Fields {
-			Walk(v, f.Name)
+			// The name of a field is nil if it couldn't be parsed.
+			if f.Name != nil {
+				Walk(v, f.Name)
+			}
 			Walk(v, f.Value)
 		}
 	case *AccessExpr:
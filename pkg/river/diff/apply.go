@@ -0,0 +1,283 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+)
+
+// Apply applies the changes in p to f and returns the resulting file. f isn't
+// modified.
+//
+// Comments in f are preserved: comments of removed statements are removed,
+// and comments of moved statements are moved along with them. The returned
+// file is formatted the same way as the printer package formats files.
+//
+// Apply returns an error if a change refers to a statement which doesn't
+// exist, or if the statement doesn't match the old value recorded in the
+// change.
+func (p Patch) Apply(f *ast.File) (*ast.File, error) {
+	src, err := formatFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range p {
+		file, err := parser.ParseFile(f.Name, src)
+		if err != nil {
+			return nil, err
+		}
+		if src, err = c.apply(file, src); err != nil {
+			return nil, fmt.Errorf("applying change to %s: %w", formatPath(c.Path), err)
+		}
+	}
+
+	// Parse and format the result so that the positions of the returned file
+	// match its formatted text.
+	file, err := parser.ParseFile(f.Name, src)
+	if err != nil {
+		return nil, err
+	}
+	if src, err = formatFile(file); err != nil {
+		return nil, err
+	}
+	return parser.ParseFile(f.Name, src)
+}
+
+func (c Change) apply(f *ast.File, src []byte) ([]byte, error) {
+	if len(c.Path) == 0 {
+		return nil, errors.New("empty path")
+	}
+	parent, body, err := findBody(f.Body, c.Path[:len(c.Path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := c.Path[len(c.Path)-1]
+
+	switch c.Kind {
+	case KindAdd:
+		stmt, err := parseStmt(c.New)
+		if err != nil {
+			return nil, err
+		}
+		if stmtName(stmt) == key && indexOf(body, key) >= 0 {
+			return nil, fmt.Errorf("%s already exists", key)
+		}
+		return insertStmt(f, src, parent, body, c.After, c.New)
+
+	case KindRemove:
+		i := indexOf(body, key)
+		if i < 0 {
+			return nil, fmt.Errorf("%s not found", key)
+		}
+		if c.Old != "" {
+			old, err := parseStmt(c.Old)
+			if err != nil {
+				return nil, err
+			}
+			if !ast.Equal(old, body[i]) {
+				return nil, fmt.Errorf("%s doesn't match the removed statement", key)
+			}
+		}
+		start, end := stmtExtent(f, src, body, i)
+		return splice(src, start, end, ""), nil
+
+	case KindMove:
+		i := indexOf(body, key)
+		if i < 0 {
+			return nil, fmt.Errorf("%s not found", key)
+		}
+		start, end := stmtExtent(f, src, body, i)
+		text := string(bytes.TrimRight(src[start:end], "\n"))
+
+		// Remove the statement and find where to place it in the resulting file.
+		src = splice(src, start, end, "")
+		f, err := parser.ParseFile(f.Name, src)
+		if err != nil {
+			return nil, err
+		}
+		parent, body, err := findBody(f.Body, c.Path[:len(c.Path)-1])
+		if err != nil {
+			return nil, err
+		}
+		return insertStmt(f, src, parent, body, c.After, text)
+
+	case KindChange:
+		i := indexOf(body, key)
+		if i < 0 {
+			return nil, fmt.Errorf("%s not found", key)
+		}
+		attr, ok := body[i].(*ast.AttributeStmt)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an attribute", key)
+		}
+		if c.Old != "" {
+			old, err := parser.ParseExpression(c.Old)
+			if err != nil {
+				return nil, err
+			}
+			if !ast.Equal(old, attr.Value) {
+				return nil, fmt.Errorf("value of %s doesn't match the old value", key)
+			}
+		}
+		if _, err := parser.ParseExpression(c.New); err != nil {
+			return nil, err
+		}
+		start, end := ast.StartPos(attr.Value).Offset(), ast.EndPos(attr.Value).Offset()+1
+		return splice(src, start, end, c.New), nil
+
+	default:
+		return nil, fmt.Errorf("unknown change kind %q", c.Kind)
+	}
+}
+
+// findBody returns the body at path, along with the block which holds it. The
+// block is nil for the body of the file.
+func findBody(body ast.Body, path []string) (*ast.BlockStmt, ast.Body, error) {
+	var parent *ast.BlockStmt
+	for _, key := range path {
+		i := indexOf(body, key)
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%s not found", key)
+		}
+		block, ok := body[i].(*ast.BlockStmt)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s is not a block", key)
+		}
+		parent, body = block, block.Body
+	}
+	return parent, body, nil
+}
+
+func indexOf(body ast.Body, key string) int {
+	for i, k := range bodyKeys(body) {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertStmt inserts the statement text into body after the statement with
+// the key after. parent is the block holding body, or nil for the body of f.
+// Blocks are separated from other statements by a blank line.
+func insertStmt(f *ast.File, src []byte, parent *ast.BlockStmt, body ast.Body, after string, text string) ([]byte, error) {
+	stmt, err := parseStmt(text)
+	if err != nil {
+		return nil, err
+	}
+	_, isBlock := stmt.(*ast.BlockStmt)
+
+	if after != "" {
+		i := indexOf(body, after)
+		if i < 0 {
+			return nil, fmt.Errorf("%s not found", after)
+		}
+		_, end := stmtExtent(f, src, body, i)
+		if end == len(src) && !bytes.HasSuffix(src, []byte("\n")) {
+			text = "\n" + text
+		}
+		if _, afterBlock := body[i].(*ast.BlockStmt); isBlock || afterBlock {
+			text = "\n" + text
+		}
+		return splice(src, end, end, text+"\n"), nil
+	}
+
+	if isBlock && len(body) > 0 {
+		text += "\n"
+	}
+	if parent == nil {
+		return splice(src, 0, 0, text+"\n"), nil
+	}
+	pos := parent.LCurlyPos.Offset() + 1
+	return splice(src, pos, pos, "\n"+text+"\n"), nil
+}
+
+// stmtExtent returns the range of src holding the statement at index i of
+// body. The range covers the full lines of the statement, including comments
+// on its last line and comments directly before it.
+func stmtExtent(f *ast.File, src []byte, body ast.Body, i int) (start, end int) {
+	var (
+		startPos = ast.StartPos(body[i]).Position()
+		endPos   = ast.EndPos(body[i]).Position()
+	)
+
+	prevEndLine := 0
+	if i > 0 {
+		prevEndLine = ast.EndPos(body[i-1]).Position().Line
+	}
+
+	start = lineStart(src, startPos.Offset)
+	for _, cg := range f.Comments {
+		if len(cg) == 0 {
+			continue
+		}
+		var (
+			cgStart = ast.StartPos(cg).Position()
+			cgEnd   = ast.EndPos(cg).Position()
+		)
+		if cgEnd.Line == startPos.Line-1 && cgStart.Line > prevEndLine && startsLine(src, cgStart.Offset) {
+			start = lineStart(src, cgStart.Offset)
+		}
+	}
+
+	end = endPos.Offset + 1
+	if nl := bytes.IndexByte(src[end:], '\n'); nl >= 0 {
+		end += nl + 1
+	} else {
+		end = len(src)
+	}
+	return start, end
+}
+
+// lineStart returns the offset of the line holding offset if only whitespace
+// precedes offset on that line. Otherwise, offset is returned.
+func lineStart(src []byte, offset int) int {
+	i := offset
+	for i > 0 && (src[i-1] == ' ' || src[i-1] == '\t') {
+		i--
+	}
+	if i == 0 || src[i-1] == '\n' {
+		return i
+	}
+	return offset
+}
+
+// startsLine reports whether only whitespace precedes offset on its line.
+func startsLine(src []byte, offset int) bool {
+	i := lineStart(src, offset)
+	return i == 0 || src[i-1] == '\n'
+}
+
+// splice replaces src[start:end] with text.
+func splice(src []byte, start, end int, text string) []byte {
+	res := make([]byte, 0, len(src)-(end-start)+len(text))
+	res = append(res, src[:start]...)
+	res = append(res, text...)
+	return append(res, src[end:]...)
+}
+
+// parseStmt parses text holding a single statement.
+func parseStmt(text string) (ast.Stmt, error) {
+	f, err := parser.ParseFile("", []byte(text))
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Body) != 1 {
+		return nil, fmt.Errorf("expected a single statement, got %d", len(f.Body))
+	}
+	return f.Body[0], nil
+}
+
+func formatFile(f *ast.File) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		return nil, err
+	}
+	_ = buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
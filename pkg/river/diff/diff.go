@@ -0,0 +1,392 @@
+// Package diff computes structural differences between River files.
+//
+// Unlike a line-based diff, changes are described in terms of statements:
+// blocks and attributes which were added, removed, or moved, and attributes
+// whose value changed. Reformatting a file or changing its comments doesn't
+// produce any changes.
+//
+// The changes between two files form a Patch, which can be encoded as JSON
+// and applied onto another file with the same structure.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/internal/stmtkey"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// Kind is the kind of a Change.
+type Kind string
+
+// Supported kinds of changes.
+const (
+	KindAdd    Kind = "add"    // A statement was added.
+	KindRemove Kind = "remove" // A statement was removed.
+	KindMove   Kind = "move"   // A statement was moved within its body.
+	KindChange Kind = "change" // The value of an attribute changed.
+)
+
+// Change is a single change to a River file.
+//
+// Statements are identified by a path of keys from the root of the file. The
+// key of an attribute is its name, and the key of a block is its name
+// followed by its quoted label, if any. If a body has more than one statement
+// with the same key, such as unlabeled blocks, the Nth repetition (starting
+// at 1) has "#N" appended to its key.
+type Change struct {
+	Kind Kind `json:"kind"`
+
+	// Path to the changed statement. For additions, Path is the path the added
+	// statement has once it's added.
+	Path []string `json:"path"`
+
+	// After holds the key of the statement which an added or moved statement is
+	// placed after. If After is empty, the statement is placed first in its
+	// body. For moves, After refers to the body once the moved statement has
+	// been removed from it.
+	After string `json:"after,omitempty"`
+
+	// Old holds the River text of a removed statement or of the previous value
+	// of a changed attribute.
+	Old string `json:"old,omitempty"`
+
+	// New holds the River text of an added statement or of the new value of a
+	// changed attribute.
+	New string `json:"new,omitempty"`
+}
+
+// Patch is an ordered list of changes which transforms one River file into
+// another. Changes must be applied in order, since the keys in the path of a
+// change refer to the file after all previous changes have been applied.
+type Patch []Change
+
+// Diff returns the changes needed to transform a into b.
+func Diff(a, b *ast.File) Patch {
+	d := differ{comments: b.Comments}
+	d.diffBody(nil, a.Body, b.Body)
+	return d.patch
+}
+
+type differ struct {
+	comments []ast.CommentGroup // Comments of the new file.
+	patch    Patch
+}
+
+// entry is a statement in a body while changes to the body are being
+// computed. aIndex and bIndex are the indices of the statement in the old and
+// new bodies, or -1 if the statement doesn't exist in that body.
+type entry struct {
+	name           string
+	aIndex, bIndex int
+}
+
+// workingBody tracks the state of a body as changes are applied to it, so
+// that the keys of statements always reflect the state the body is in when a
+// change is applied.
+type workingBody []entry
+
+func (wb workingBody) find(fn func(e entry) bool) int {
+	for i, e := range wb {
+		if fn(e) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (wb workingBody) key(i int) string {
+	var n int
+	for _, e := range wb[:i] {
+		if e.name == wb[i].name {
+			n++
+		}
+	}
+	return stmtkey.Repeated(wb[i].name, n)
+}
+
+func (wb *workingBody) remove(i int) entry {
+	e := (*wb)[i]
+	*wb = append((*wb)[:i:i], (*wb)[i+1:]...)
+	return e
+}
+
+// insertAfter inserts e after the statement at index i, or at the start of
+// the body if i is -1.
+func (wb *workingBody) insertAfter(i int, e entry) {
+	*wb = append(*wb, entry{})
+	copy((*wb)[i+2:], (*wb)[i+1:])
+	(*wb)[i+1] = e
+}
+
+func (d *differ) diffBody(path []string, a, b ast.Body) {
+	var (
+		aKeys, bKeys = bodyKeys(a), bodyKeys(b)
+		bIndices     = make(map[string]int, len(b))
+		matchA       = make([]int, len(a)) // Index in b of each statement in a.
+		matchB       = make([]int, len(b)) // Index in a of each statement in b.
+	)
+	for j, key := range bKeys {
+		bIndices[key] = j
+		matchB[j] = -1
+	}
+	for i, key := range aKeys {
+		matchA[i] = -1
+		if j, ok := bIndices[key]; ok && sameKind(a[i], b[j]) {
+			matchA[i], matchB[j] = j, i
+		}
+	}
+
+	work := make(workingBody, len(a))
+	for i, s := range a {
+		work[i] = entry{name: stmtName(s), aIndex: i, bIndex: matchA[i]}
+	}
+	byA := func(i int) func(entry) bool { return func(e entry) bool { return e.aIndex == i } }
+	byB := func(j int) func(entry) bool { return func(e entry) bool { return e.bIndex == j } }
+
+	// Remove statements which don't exist in b.
+	for i := len(a) - 1; i >= 0; i-- {
+		if matchA[i] >= 0 {
+			continue
+		}
+		idx := work.find(byA(i))
+		d.add(Change{Kind: KindRemove, Path: appendPath(path, work.key(idx)), Old: format(a[i])})
+		work.remove(idx)
+	}
+
+	// Update statements which exist in both bodies.
+	for j, i := range matchB {
+		if i < 0 {
+			continue
+		}
+		key := work.key(work.find(byA(i)))
+
+		switch as := a[i].(type) {
+		case *ast.AttributeStmt:
+			bs := b[j].(*ast.AttributeStmt)
+			if !ast.Equal(as.Value, bs.Value) {
+				d.add(Change{Kind: KindChange, Path: appendPath(path, key), Old: format(as.Value), New: format(bs.Value)})
+			}
+		case *ast.BlockStmt:
+			d.diffBody(appendPath(path, key), as.Body, b[j].(*ast.BlockStmt).Body)
+		}
+	}
+
+	// Add new statements and move existing statements so the order of the body
+	// matches b. Statements in the longest common subsequence of both bodies
+	// keep their place.
+	stable := stableStatements(matchA, matchB)
+	for j := range b {
+		if matchB[j] >= 0 && stable[j] {
+			continue
+		}
+
+		var moved entry
+		if matchB[j] >= 0 {
+			idx := work.find(byB(j))
+			moved = work.remove(idx)
+			d.add(Change{Kind: KindMove, Path: appendPath(path, work.keyOf(moved, idx))})
+		} else {
+			moved = entry{name: stmtName(b[j]), aIndex: -1, bIndex: j}
+			d.add(Change{Kind: KindAdd, New: d.stmtText(b, j)})
+		}
+
+		after := -1
+		if j > 0 {
+			after = work.find(byB(j - 1))
+		}
+		change := &d.patch[len(d.patch)-1]
+		if after >= 0 {
+			change.After = work.key(after)
+		}
+		work.insertAfter(after, moved)
+		if change.Kind == KindAdd {
+			change.Path = appendPath(path, work.key(after+1))
+		}
+	}
+}
+
+// keyOf returns the key e had when it was at index i, before being removed
+// from wb.
+func (wb workingBody) keyOf(e entry, i int) string {
+	var n int
+	for _, other := range wb[:i] {
+		if other.name == e.name {
+			n++
+		}
+	}
+	return stmtkey.Repeated(e.name, n)
+}
+
+func (d *differ) add(c Change) { d.patch = append(d.patch, c) }
+
+// stmtText returns the River text for the statement at index i of body,
+// including comments within and directly before it.
+func (d *differ) stmtText(body ast.Body, i int) string {
+	var (
+		stmt     = body[i]
+		start    = ast.StartPos(stmt)
+		end      = ast.EndPos(stmt)
+		comments []ast.CommentGroup
+	)
+
+	var prevEnd token.Position
+	if i > 0 {
+		prevEnd = ast.EndPos(body[i-1]).Position()
+	}
+
+	for _, cg := range d.comments {
+		if len(cg) == 0 {
+			continue
+		}
+		var (
+			cgStart = ast.StartPos(cg).Position()
+			cgEnd   = ast.EndPos(cg).Position()
+		)
+
+		switch {
+		case cgStart.Offset > start.Offset() && cgEnd.Offset < end.Offset():
+			// Comments inside the statement.
+		case cgStart.Line == end.Position().Line && cgStart.Offset > end.Offset():
+			// Comments after the statement on its last line.
+		case cgEnd.Line == start.Position().Line-1 && cgStart.Line > prevEnd.Line:
+			// Comments directly before the statement.
+		default:
+			continue
+		}
+		comments = append(comments, cg)
+	}
+
+	return format(&ast.File{Body: ast.Body{stmt}, Comments: comments})
+}
+
+// stableStatements returns the statements of b which don't need to be moved:
+// the longest subsequence of matching statements which has the same order in
+// both bodies.
+func stableStatements(matchA, matchB []int) map[int]bool {
+	// seq holds the indices in b of the matching statements, in the order they
+	// appear in a. The longest increasing subsequence of seq is the longest
+	// common subsequence of both bodies.
+	var seq []int
+	for _, j := range matchA {
+		if j >= 0 {
+			seq = append(seq, j)
+		}
+	}
+
+	var (
+		lengths = make([]int, len(seq))
+		prev    = make([]int, len(seq))
+		best    = -1
+	)
+	for x := range seq {
+		lengths[x], prev[x] = 1, -1
+		for y := 0; y < x; y++ {
+			if seq[y] < seq[x] && lengths[y]+1 > lengths[x] {
+				lengths[x], prev[x] = lengths[y]+1, y
+			}
+		}
+		if best < 0 || lengths[x] > lengths[best] {
+			best = x
+		}
+	}
+
+	stable := make(map[int]bool, len(seq))
+	for x := best; x >= 0; x = prev[x] {
+		stable[seq[x]] = true
+	}
+	return stable
+}
+
+// String returns a human-readable description of the changes in p.
+func (p Patch) String() string {
+	var sb strings.Builder
+	for _, c := range p {
+		sb.WriteString(c.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// String returns a human-readable description of c.
+func (c Change) String() string {
+	path := formatPath(c.Path)
+
+	switch c.Kind {
+	case KindAdd:
+		return fmt.Sprintf("+ %s\n%s", path, indentLines(c.New, "    + "))
+	case KindRemove:
+		return fmt.Sprintf("- %s\n%s", path, indentLines(c.Old, "    - "))
+	case KindMove:
+		if c.After == "" {
+			return fmt.Sprintf("> %s (moved to the start)", path)
+		}
+		return fmt.Sprintf("> %s (moved after %s)", path, c.After)
+	case KindChange:
+		return fmt.Sprintf("~ %s\n%s\n%s", path, indentLines(c.Old, "    - "), indentLines(c.New, "    + "))
+	default:
+		return fmt.Sprintf("? %s", path)
+	}
+}
+
+func formatPath(path []string) string { return strings.Join(path, " / ") }
+
+func indentLines(text, prefix string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stmtName returns the name of a statement, which is its key if the
+// statement isn't repeated within its body.
+func stmtName(s ast.Stmt) string {
+	switch s := s.(type) {
+	case *ast.AttributeStmt:
+		return s.Name.Name
+	case *ast.BlockStmt:
+		return stmtkey.Block(s.Name, s.Label)
+	default:
+		panic(fmt.Sprintf("diff: unexpected statement type %T", s))
+	}
+}
+
+// bodyKeys returns the keys of the statements in body.
+func bodyKeys(body ast.Body) []string {
+	var (
+		keys = make([]string, len(body))
+		seen = make(map[string]int, len(body))
+	)
+	for i, s := range body {
+		name := stmtName(s)
+		keys[i] = stmtkey.Repeated(name, seen[name])
+		seen[name]++
+	}
+	return keys
+}
+
+func sameKind(a, b ast.Stmt) bool {
+	_, aBlock := a.(*ast.BlockStmt)
+	_, bBlock := b.(*ast.BlockStmt)
+	return aBlock == bBlock
+}
+
+func appendPath(path []string, key string) []string {
+	res := make([]string, 0, len(path)+1)
+	res = append(res, path...)
+	return append(res, key)
+}
+
+// format returns the River text for n.
+func format(n ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, n); err != nil {
+		panic(fmt.Sprintf("diff: printing node: %s", err))
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
@@ -0,0 +1,287 @@
+package diff_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diff"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	tt := []struct {
+		name   string
+		a, b   string
+		expect diff.Patch
+	}{
+		{
+			name: "formatting and comments",
+			a:    `foo "bar" { value = [1, 2] }`,
+			b: `
+				// Comment
+				foo "bar" {
+					value = [
+						1,
+						2, // Two
+					]
+				}
+			`,
+			expect: nil,
+		},
+		{
+			name: "changed attribute",
+			a:    `foo "bar" { inner { value = 5 } }`,
+			b:    `foo "bar" { inner { value = 6 } }`,
+			expect: diff.Patch{
+				{Kind: diff.KindChange, Path: []string{`foo "bar"`, "inner", "value"}, Old: "5", New: "6"},
+			},
+		},
+		{
+			name: "added and removed blocks",
+			a: `
+				foo "a" {}
+				foo "b" {}
+			`,
+			b: `
+				foo "a" {}
+				foo "c" { value = 1 }
+			`,
+			expect: diff.Patch{
+				{Kind: diff.KindRemove, Path: []string{`foo "b"`}, Old: `foo "b" { }`},
+				{Kind: diff.KindAdd, Path: []string{`foo "c"`}, After: `foo "a"`, New: "foo \"c\" {\n\tvalue = 1\n}"},
+			},
+		},
+		{
+			name: "moved block",
+			a: `
+				foo "a" {}
+				foo "b" {}
+				foo "c" {}
+			`,
+			b: `
+				foo "c" {}
+				foo "a" {}
+				foo "b" {}
+			`,
+			expect: diff.Patch{
+				{Kind: diff.KindMove, Path: []string{`foo "c"`}},
+			},
+		},
+		{
+			name: "repeated blocks",
+			a: `
+				rule { action = "keep" }
+				rule { action = "drop" }
+			`,
+			b: `
+				rule { action = "keep" }
+				rule { action = "replace" }
+				rule { action = "drop" }
+			`,
+			expect: diff.Patch{
+				{Kind: diff.KindChange, Path: []string{"rule#1", "action"}, Old: `"drop"`, New: `"replace"`},
+				{Kind: diff.KindAdd, Path: []string{"rule#2"}, After: "rule#1", New: "rule {\n\taction = \"drop\"\n}"},
+			},
+		},
+		{
+			name: "attribute replaced with block",
+			a:    `foo { bar = 5 }`,
+			b:    `foo { bar { } }`,
+			expect: diff.Patch{
+				{Kind: diff.KindRemove, Path: []string{"foo", "bar"}, Old: "bar = 5"},
+				{Kind: diff.KindAdd, Path: []string{"foo", "bar"}, New: "bar { }"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := parseFile(t, tc.a), parseFile(t, tc.b)
+			require.Equal(t, tc.expect, diff.Diff(a, b))
+		})
+	}
+}
+
+func TestPatch_Apply(t *testing.T) {
+	a := parseFile(t, `// Scrape the agent itself.
+prometheus.scrape "agent" {
+	targets    = [{"__address__" = "localhost:12345"}]
+	forward_to = [prometheus.remote_write.default.receiver]
+
+	// Scrape every 15s.
+	scrape_interval = "15s"
+}
+
+// Where to send metrics.
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://localhost:9009/api/prom/push" // Local Mimir.
+	}
+}
+
+discovery.kubernetes "pods" {
+	role = "pod"
+}
+`)
+	b := parseFile(t, `prometheus.remote_write "default" {
+	endpoint {
+		url = "http://mimir:9009/api/prom/push"
+	}
+}
+
+prometheus.scrape "agent" {
+	targets         = [{"__address__" = "localhost:12345"}]
+	forward_to      = [prometheus.remote_write.default.receiver]
+	scrape_interval = "30s"
+}
+
+// Scrape node_exporter.
+prometheus.scrape "node" {
+	targets    = [{"__address__" = "localhost:9100"}] // node_exporter
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+`)
+
+	patch := diff.Diff(a, b)
+	res, err := patch.Apply(a)
+	require.NoError(t, err)
+	require.True(t, ast.Equal(b, res))
+
+	expect := `// Where to send metrics.
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://mimir:9009/api/prom/push" // Local Mimir.
+	}
+}
+
+// Scrape the agent itself.
+prometheus.scrape "agent" {
+	targets    = [{"__address__" = "localhost:12345"}]
+	forward_to = [prometheus.remote_write.default.receiver]
+
+	// Scrape every 15s.
+	scrape_interval = "30s"
+}
+
+// Scrape node_exporter.
+prometheus.scrape "node" {
+	targets    = [{"__address__" = "localhost:9100"}] // node_exporter
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+`
+	require.Equal(t, expect, formatFile(t, res))
+}
+
+func TestPatch_Apply_Permutations(t *testing.T) {
+	// Every ordering of a body, including added and removed statements, can be
+	// reached by applying the diff.
+	stmts := []string{`a "one" {}`, `a "two" {}`, "x = 1", `rule { v = 1 }`, `rule { v = 2 }`}
+
+	var bodies [][]string
+	var permute func(prefix, rest []string)
+	permute = func(prefix, rest []string) {
+		bodies = append(bodies, prefix)
+		for i := range rest {
+			next := append(append([]string{}, rest[:i]...), rest[i+1:]...)
+			permute(append(append([]string{}, prefix...), rest[i]), next)
+		}
+	}
+	permute(nil, stmts)
+
+	for _, aStmts := range bodies[:40] {
+		for _, bStmts := range bodies {
+			a := parseFile(t, strings.Join(aStmts, "\n"))
+			b := parseFile(t, strings.Join(bStmts, "\n"))
+
+			res, err := diff.Diff(a, b).Apply(a)
+			require.NoError(t, err)
+			require.True(t, ast.Equal(b, res), "applying diff from %v to %v gave:\n%s", aStmts, bStmts, formatFile(t, res))
+		}
+	}
+}
+
+func TestPatch_Apply_Conflicts(t *testing.T) {
+	f := parseFile(t, `foo "a" { value = 1 }`)
+
+	tt := []struct {
+		change diff.Change
+		expect string
+	}{
+		{
+			change: diff.Change{Kind: diff.KindChange, Path: []string{`foo "a"`, "value"}, Old: "2", New: "3"},
+			expect: `applying change to foo "a" / value: value of value doesn't match the old value`,
+		},
+		{
+			change: diff.Change{Kind: diff.KindRemove, Path: []string{`foo "b"`}},
+			expect: `applying change to foo "b": foo "b" not found`,
+		},
+		{
+			change: diff.Change{Kind: diff.KindAdd, Path: []string{`foo "a"`}, New: `foo "a" {}`},
+			expect: `applying change to foo "a": foo "a" already exists`,
+		},
+		{
+			change: diff.Change{Kind: diff.KindMove, Path: []string{`foo "a"`}, After: `foo "b"`},
+			expect: `applying change to foo "a": foo "b" not found`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(string(tc.change.Kind), func(t *testing.T) {
+			_, err := diff.Patch{tc.change}.Apply(f)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
+
+func TestPatch_JSON(t *testing.T) {
+	a := parseFile(t, `foo "a" { value = 1 }`)
+	b := parseFile(t, `foo "a" { value = 2 }`)
+
+	bb, err := json.Marshal(diff.Diff(a, b))
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"kind": "change", "path": ["foo \"a\"", "value"], "old": "1", "new": "2"}]`, string(bb))
+
+	var patch diff.Patch
+	require.NoError(t, json.Unmarshal(bb, &patch))
+	res, err := patch.Apply(a)
+	require.NoError(t, err)
+	require.True(t, ast.Equal(b, res))
+}
+
+func TestPatch_String(t *testing.T) {
+	patch := diff.Patch{
+		{Kind: diff.KindChange, Path: []string{`foo "a"`, "value"}, Old: "1", New: "2"},
+		{Kind: diff.KindMove, Path: []string{`foo "b"`}, After: `foo "c"`},
+		{Kind: diff.KindAdd, Path: []string{`foo "d"`}, New: "foo \"d\" {\n\tvalue = 1\n}"},
+	}
+
+	expect := `~ foo "a" / value
+    - 1
+    + 2
+> foo "b" (moved after foo "c")
++ foo "d"
+    + foo "d" {
+    + 	value = 1
+    + }
+`
+	require.Equal(t, expect, patch.String())
+}
+
+func parseFile(t *testing.T, src string) *ast.File {
+	t.Helper()
+	f, err := parser.ParseFile(t.Name(), []byte(src))
+	require.NoError(t, err)
+	return f
+}
+
+func formatFile(t *testing.T, f *ast.File) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, f))
+	return buf.String() + "\n"
+}
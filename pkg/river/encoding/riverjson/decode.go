@@ -0,0 +1,652 @@
+package riverjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/scanner"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// ParseFile parses the JSON representation of a River file, as produced by
+// MarshalBody, into an AST. The data parameter should hold the file contents
+// to parse, while the filename parameter is used for reporting errors.
+//
+// In addition to the value types produced by MarshalValue, ParseFile accepts
+// values of type "expr", whose value is a string holding a River expression.
+// Expressions allow JSON files to reference other components and call
+// functions:
+//
+//	{ "type": "expr", "value": "prometheus.remote_write.default.receiver" }
+//
+// Positions in the returned AST refer to the JSON input. If an error was
+// encountered during parsing, the returned AST will be nil and err will be a
+// diag.Diagnostics with all the errors encountered during parsing. The
+// message of each diagnostic starts with the JSON path of the invalid value.
+func ParseFile(filename string, data []byte) (*ast.File, error) {
+	d := newDecoder(filename, data)
+
+	root, err := d.readRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	body := d.convertBody(root, "$")
+	if len(d.diags) > 0 {
+		return nil, d.diags
+	}
+	return &ast.File{Name: filename, Body: body}, nil
+}
+
+// ParseExpression parses the JSON representation of a single River value, as
+// produced by MarshalValue, into an expression. Values of type "expr" are
+// supported as described by ParseFile.
+//
+// If an error was encountered during parsing, the returned expression will be
+// nil and err will be a diag.Diagnostics with all the errors encountered
+// during parsing.
+func ParseExpression(data []byte) (ast.Expr, error) {
+	d := newDecoder("", data)
+
+	root, err := d.readRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	expr := d.convertValue(root, "$")
+	if len(d.diags) > 0 {
+		return nil, d.diags
+	}
+	return expr, nil
+}
+
+// jsonNode is a JSON value along with the range of the input it was read
+// from. Value is one of nil, bool, json.Number, string, []*jsonNode, or
+// []jsonMember.
+type jsonNode struct {
+	Value    interface{}
+	Start    int // Offset of the first byte of the value.
+	End      int // Offset of the last byte of the value.
+	Position token.Pos
+}
+
+// jsonMember is a key-value pair within a JSON object.
+type jsonMember struct {
+	Key    string
+	KeyPos token.Pos
+	Value  *jsonNode
+}
+
+type decoder struct {
+	data  []byte
+	file  *token.File
+	dec   *json.Decoder
+	diags diag.Diagnostics
+}
+
+func newDecoder(filename string, data []byte) *decoder {
+	file := token.NewFile(filename)
+	for i, b := range data {
+		if b == '\n' {
+			file.AddLine(i + 1)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return &decoder{
+		data: data,
+		file: file,
+		dec:  dec,
+	}
+}
+
+// readRoot reads the single top-level JSON value from the input.
+func (d *decoder) readRoot() (*jsonNode, error) {
+	root, err := d.readNode()
+	if err != nil {
+		return nil, err
+	}
+
+	off := d.nextOffset()
+	if _, err := d.dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, d.syntaxError(off, "unexpected data after top-level value")
+	}
+	return root, nil
+}
+
+// nextOffset returns the offset of the next token in the input. Separators
+// between tokens are consumed by the json.Decoder as part of reading the next
+// token, so they are skipped along with whitespace.
+func (d *decoder) nextOffset() int {
+	off := int(d.dec.InputOffset())
+	for off < len(d.data) {
+		switch d.data[off] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			off++
+		default:
+			return off
+		}
+	}
+	return off
+}
+
+func (d *decoder) readNode() (*jsonNode, error) {
+	start := d.nextOffset()
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, d.tokenError(start, err)
+	}
+
+	node := &jsonNode{Start: start, Position: d.file.Pos(start)}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			elems := []*jsonNode{}
+			for d.dec.More() {
+				elem, err := d.readNode()
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, elem)
+			}
+			node.Value = elems
+
+		case '{':
+			members := []jsonMember{}
+			for d.dec.More() {
+				keyStart := d.nextOffset()
+				key, err := d.dec.Token()
+				if err != nil {
+					return nil, d.tokenError(keyStart, err)
+				}
+				val, err := d.readNode()
+				if err != nil {
+					return nil, err
+				}
+				members = append(members, jsonMember{
+					Key:    key.(string),
+					KeyPos: d.file.Pos(keyStart),
+					Value:  val,
+				})
+			}
+			node.Value = members
+		}
+
+		// Consume the closing delimiter.
+		node.End = d.nextOffset()
+		if _, err := d.dec.Token(); err != nil {
+			return nil, d.tokenError(node.End, err)
+		}
+
+	default:
+		node.Value = tok
+		node.End = int(d.dec.InputOffset()) - 1
+	}
+
+	return node, nil
+}
+
+// tokenError converts an error from the json.Decoder into diagnostics.
+func (d *decoder) tokenError(off int, err error) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr) && syntaxErr.Offset < int64(len(d.data)):
+		// SyntaxError.Offset is the offset after the invalid byte.
+		return d.syntaxError(int(syntaxErr.Offset)-1, syntaxErr.Error())
+	case syntaxErr != nil, errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return d.syntaxError(len(d.data), "unexpected end of JSON input")
+	default:
+		return d.syntaxError(off, err.Error())
+	}
+}
+
+func (d *decoder) syntaxError(off int, msg string) error {
+	if off < 0 {
+		off = 0
+	}
+	return diag.Diagnostics{{
+		Severity: diag.SeverityLevelError,
+		StartPos: d.file.Pos(off).Position(),
+		Message:  msg,
+	}}
+}
+
+// errorf adds a diagnostic for n, prefixing the message with path.
+func (d *decoder) errorf(n *jsonNode, path string, format string, args ...interface{}) {
+	d.diags.Add(diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: n.Position.Position(),
+		EndPos:   d.file.Pos(n.End).Position(),
+		Message:  path + ": " + fmt.Sprintf(format, args...),
+	})
+}
+
+// convertBody converts a JSON array of statements into an ast.Body. A null
+// body is treated as an empty body.
+func (d *decoder) convertBody(n *jsonNode, path string) ast.Body {
+	if n.Value == nil {
+		return nil
+	}
+
+	elems, ok := n.Value.([]*jsonNode)
+	if !ok {
+		d.errorf(n, path, "expected array of statements, got %s", describeNode(n))
+		return nil
+	}
+
+	body := make(ast.Body, 0, len(elems))
+	for i, elem := range elems {
+		if stmt := d.convertStmt(elem, fmt.Sprintf("%s[%d]", path, i)); stmt != nil {
+			body = append(body, stmt)
+		}
+	}
+	return body
+}
+
+func (d *decoder) convertStmt(n *jsonNode, path string) ast.Stmt {
+	fields, ok := d.objectFields(n, path, "name", "type", "label", "body", "value")
+	if !ok {
+		return nil
+	}
+
+	typ, ok := d.requiredString(n, fields, path, "type")
+	if !ok {
+		return nil
+	}
+	name, ok := d.requiredString(n, fields, path, "name")
+	if !ok {
+		return nil
+	}
+	nameNode := fields["name"]
+
+	switch typ {
+	case "attr":
+		if !d.allowFields(fields, path, "name", "type", "value") {
+			return nil
+		}
+		if !scanner.IsValidIdentifier(name) {
+			d.errorf(nameNode, path+".name", "attribute name %q must be a valid identifier", name)
+			return nil
+		}
+
+		valueNode, ok := fields["value"]
+		if !ok {
+			d.errorf(n, path, "missing required field %q", "value")
+			return nil
+		}
+		value := d.convertValue(valueNode, path+".value")
+		if value == nil {
+			return nil
+		}
+
+		return &ast.AttributeStmt{
+			Name:  &ast.Ident{Name: name, NamePos: nameNode.Position},
+			Value: value,
+		}
+
+	case "block":
+		if !d.allowFields(fields, path, "name", "type", "label", "body") {
+			return nil
+		}
+
+		nameParts := strings.Split(name, ".")
+		for _, part := range nameParts {
+			if !scanner.IsValidIdentifier(part) {
+				d.errorf(nameNode, path+".name", "block name %q must be a valid identifier or a sequence of identifiers separated by dots", name)
+				return nil
+			}
+		}
+
+		block := &ast.BlockStmt{
+			Name:      nameParts,
+			NamePos:   nameNode.Position,
+			LCurlyPos: n.Position,
+			RCurlyPos: d.file.Pos(n.End),
+		}
+
+		if labelNode, ok := fields["label"]; ok {
+			label, ok := labelNode.Value.(string)
+			if !ok {
+				d.errorf(labelNode, path+".label", "expected string, got %s", describeNode(labelNode))
+				return nil
+			}
+			block.Label = label
+			block.LabelPos = labelNode.Position
+		}
+
+		if bodyNode, ok := fields["body"]; ok {
+			block.Body = d.convertBody(bodyNode, path+".body")
+		}
+		return block
+
+	default:
+		d.errorf(fields["type"], path+".type", `unrecognized statement type %q; expected "attr" or "block"`, typ)
+		return nil
+	}
+}
+
+// convertValue converts a JSON value in the form of {"type": ..., "value":
+// ...} into an expression.
+func (d *decoder) convertValue(n *jsonNode, path string) ast.Expr {
+	fields, ok := d.objectFields(n, path, "type", "value")
+	if !ok {
+		return nil
+	}
+
+	typ, ok := d.requiredString(n, fields, path, "type")
+	if !ok {
+		return nil
+	}
+
+	valueNode, ok := fields["value"]
+	if !ok {
+		if typ != "null" {
+			d.errorf(n, path, "missing required field %q", "value")
+			return nil
+		}
+		valueNode = &jsonNode{Start: n.Start, End: n.End, Position: n.Position}
+	}
+	valuePath := path + ".value"
+
+	switch typ {
+	case "null":
+		if valueNode.Value != nil {
+			d.errorf(valueNode, valuePath, "expected null, got %s", describeNode(valueNode))
+			return nil
+		}
+		return &ast.LiteralExpr{Kind: token.NULL, ValuePos: valueNode.Position, Value: "null"}
+
+	case "bool":
+		val, ok := valueNode.Value.(bool)
+		if !ok {
+			d.errorf(valueNode, valuePath, "expected bool, got %s", describeNode(valueNode))
+			return nil
+		}
+		return &ast.LiteralExpr{Kind: token.BOOL, ValuePos: valueNode.Position, Value: strconv.FormatBool(val)}
+
+	case "number":
+		val, ok := valueNode.Value.(json.Number)
+		if !ok {
+			d.errorf(valueNode, valuePath, "expected number, got %s", describeNode(valueNode))
+			return nil
+		}
+		return convertNumber(string(val), valueNode.Position)
+
+	case "string":
+		val, ok := valueNode.Value.(string)
+		if !ok {
+			d.errorf(valueNode, valuePath, "expected string, got %s", describeNode(valueNode))
+			return nil
+		}
+		return &ast.LiteralExpr{Kind: token.STRING, ValuePos: valueNode.Position, Value: strconv.Quote(val)}
+
+	case "array":
+		elems, ok := valueNode.Value.([]*jsonNode)
+		if !ok {
+			d.errorf(valueNode, valuePath, "expected array, got %s", describeNode(valueNode))
+			return nil
+		}
+
+		arr := &ast.ArrayExpr{
+			Elements:  make([]ast.Expr, 0, len(elems)),
+			LBrackPos: valueNode.Position,
+			RBrackPos: d.file.Pos(valueNode.End),
+		}
+		for i, elem := range elems {
+			if expr := d.convertValue(elem, fmt.Sprintf("%s[%d]", valuePath, i)); expr != nil {
+				arr.Elements = append(arr.Elements, expr)
+			}
+		}
+		return arr
+
+	case "object":
+		elems, ok := valueNode.Value.([]*jsonNode)
+		if !ok {
+			d.errorf(valueNode, valuePath, "expected array of object fields, got %s", describeNode(valueNode))
+			return nil
+		}
+
+		obj := &ast.ObjectExpr{
+			Fields:    make([]*ast.ObjectField, 0, len(elems)),
+			LCurlyPos: valueNode.Position,
+			RCurlyPos: d.file.Pos(valueNode.End),
+		}
+		for i, elem := range elems {
+			if field := d.convertObjectField(elem, fmt.Sprintf("%s[%d]", valuePath, i)); field != nil {
+				obj.Fields = append(obj.Fields, field)
+			}
+		}
+		return obj
+
+	case "expr":
+		val, ok := valueNode.Value.(string)
+		if !ok {
+			d.errorf(valueNode, valuePath, "expected string, got %s", describeNode(valueNode))
+			return nil
+		}
+		return d.convertExpr(val, valueNode, valuePath)
+
+	case "capsule", "function":
+		d.errorf(fields["type"], path+".type", "%s values cannot be decoded", typ)
+		return nil
+
+	default:
+		d.errorf(fields["type"], path+".type", "unrecognized value type %q", typ)
+		return nil
+	}
+}
+
+func (d *decoder) convertObjectField(n *jsonNode, path string) *ast.ObjectField {
+	fields, ok := d.objectFields(n, path, "key", "value")
+	if !ok {
+		return nil
+	}
+
+	key, ok := d.requiredString(n, fields, path, "key")
+	if !ok {
+		return nil
+	}
+	valueNode, ok := fields["value"]
+	if !ok {
+		d.errorf(n, path, "missing required field %q", "value")
+		return nil
+	}
+	value := d.convertValue(valueNode, path+".value")
+	if value == nil {
+		return nil
+	}
+
+	return &ast.ObjectField{
+		Name:   &ast.Ident{Name: key, NamePos: fields["key"].Position},
+		Quoted: !scanner.IsValidIdentifier(key),
+		Value:  value,
+	}
+}
+
+// convertExpr parses src as a River expression. The positions of the parsed
+// expression are moved to point at the JSON string n which held src. If the
+// JSON string contained escape sequences, positions after the first escape
+// sequence are approximate.
+func (d *decoder) convertExpr(src string, n *jsonNode, path string) ast.Expr {
+	// Offset of the first character after the opening quote.
+	base := n.Start + 1
+
+	expr, err := parser.ParseExpression(src)
+	if err != nil {
+		var diags diag.Diagnostics
+		if !errors.As(err, &diags) {
+			d.errorf(n, path, "%s", err)
+			return nil
+		}
+		for _, parseDiag := range diags {
+			pos := d.file.Pos(d.clampOffset(base+parseDiag.StartPos.Offset, n))
+			d.diags.Add(diag.Diagnostic{
+				Severity: parseDiag.Severity,
+				StartPos: pos.Position(),
+				Message:  path + ": " + parseDiag.Message,
+			})
+		}
+		return nil
+	}
+
+	ast.Walk(&rebaser{d: d, base: base, n: n}, expr)
+	return expr
+}
+
+// clampOffset ensures that off falls within the range of n.
+func (d *decoder) clampOffset(off int, n *jsonNode) int {
+	if off > n.End {
+		return n.End
+	}
+	return off
+}
+
+// objectFields returns the members of the JSON object n by key. Only keys in
+// known are permitted, and each key may only be specified once.
+func (d *decoder) objectFields(n *jsonNode, path string, known ...string) (map[string]*jsonNode, bool) {
+	members, ok := n.Value.([]jsonMember)
+	if !ok {
+		d.errorf(n, path, "expected object, got %s", describeNode(n))
+		return nil, false
+	}
+
+	fields := make(map[string]*jsonNode, len(members))
+	for _, m := range members {
+		if !containsString(known, m.Key) {
+			d.errorf(m.Value, path, "unrecognized field %q", m.Key)
+			return nil, false
+		} else if _, exists := fields[m.Key]; exists {
+			d.errorf(m.Value, path, "field %q may only be specified once", m.Key)
+			return nil, false
+		}
+		fields[m.Key] = m.Value
+	}
+	return fields, true
+}
+
+// allowFields reports an error if fields contains a key not in allowed.
+func (d *decoder) allowFields(fields map[string]*jsonNode, path string, allowed ...string) bool {
+	for key, n := range fields {
+		if !containsString(allowed, key) {
+			d.errorf(n, path, "unrecognized field %q", key)
+			return false
+		}
+	}
+	return true
+}
+
+// requiredString returns the string value of the field key within the object
+// n.
+func (d *decoder) requiredString(n *jsonNode, fields map[string]*jsonNode, path, key string) (string, bool) {
+	field, ok := fields[key]
+	if !ok {
+		d.errorf(n, path, "missing required field %q", key)
+		return "", false
+	}
+	s, ok := field.Value.(string)
+	if !ok {
+		d.errorf(field, path+"."+key, "expected string, got %s", describeNode(field))
+		return "", false
+	}
+	return s, true
+}
+
+// convertNumber converts the text of a JSON number into a literal. Negative
+// numbers are converted into a negation of a literal since River literals are
+// always positive.
+func convertNumber(text string, pos token.Pos) ast.Expr {
+	if strings.HasPrefix(text, "-") {
+		return &ast.UnaryExpr{
+			Kind:    token.SUB,
+			KindPos: pos,
+			Value:   convertNumber(text[1:], pos.Add(1)),
+		}
+	}
+
+	kind := token.NUMBER
+	if strings.ContainsAny(text, ".eE") {
+		kind = token.FLOAT
+	}
+	return &ast.LiteralExpr{Kind: kind, ValuePos: pos, Value: text}
+}
+
+// rebaser moves the positions of a parsed expression into the JSON file.
+type rebaser struct {
+	d    *decoder
+	base int
+	n    *jsonNode
+}
+
+func (r *rebaser) pos(p token.Pos) token.Pos {
+	if !p.Valid() {
+		return p
+	}
+	return r.d.file.Pos(r.d.clampOffset(r.base+p.Offset(), r.n))
+}
+
+func (r *rebaser) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.Ident:
+		node.NamePos = r.pos(node.NamePos)
+	case *ast.LiteralExpr:
+		node.ValuePos = r.pos(node.ValuePos)
+	case *ast.InterpolationExpr:
+		node.LQuotePos, node.RQuotePos = r.pos(node.LQuotePos), r.pos(node.RQuotePos)
+	case *ast.ArrayExpr:
+		node.LBrackPos, node.RBrackPos = r.pos(node.LBrackPos), r.pos(node.RBrackPos)
+	case *ast.ObjectExpr:
+		node.LCurlyPos, node.RCurlyPos = r.pos(node.LCurlyPos), r.pos(node.RCurlyPos)
+	case *ast.IndexExpr:
+		node.LBrackPos, node.RBrackPos = r.pos(node.LBrackPos), r.pos(node.RBrackPos)
+	case *ast.CallExpr:
+		node.LParenPos, node.RParenPos = r.pos(node.LParenPos), r.pos(node.RParenPos)
+	case *ast.UnaryExpr:
+		node.KindPos = r.pos(node.KindPos)
+	case *ast.BinaryExpr:
+		node.KindPos = r.pos(node.KindPos)
+	case *ast.ParenExpr:
+		node.LParenPos, node.RParenPos = r.pos(node.LParenPos), r.pos(node.RParenPos)
+	}
+	return r
+}
+
+// describeNode returns the JSON type of n for use in error messages.
+func describeNode(n *jsonNode) string {
+	switch n.Value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []*jsonNode:
+		return "array"
+	case []jsonMember:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", n.Value)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
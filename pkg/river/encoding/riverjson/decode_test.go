@@ -0,0 +1,252 @@
+package riverjson_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/encoding/riverjson"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFile(t *testing.T) {
+	input := `[
+		{ "name": "log_level", "type": "attr", "value": { "type": "string", "value": "debug" } },
+		{
+			"name": "local.file",
+			"type": "block",
+			"label": "token",
+			"body": [
+				{ "name": "filename", "type": "attr", "value": { "type": "expr", "value": "env(\"TOKEN_PATH\")" } },
+				{ "name": "is_secret", "type": "attr", "value": { "type": "bool", "value": true } }
+			]
+		},
+		{
+			"name": "example",
+			"type": "block",
+			"body": [
+				{ "name": "count", "type": "attr", "value": { "type": "number", "value": -5 } },
+				{ "name": "ratio", "type": "attr", "value": { "type": "number", "value": 0.25 } },
+				{ "name": "nothing", "type": "attr", "value": { "type": "null" } },
+				{ "name": "list", "type": "attr", "value": {
+					"type": "array",
+					"value": [
+						{ "type": "number", "value": 1 },
+						{ "type": "string", "value": "two" }
+					]
+				} },
+				{ "name": "labels", "type": "attr", "value": {
+					"type": "object",
+					"value": [
+						{ "key": "job", "value": { "type": "string", "value": "example" } },
+						{ "key": "not-an-ident", "value": { "type": "expr", "value": "local.file.token.content" } }
+					]
+				} },
+				{ "name": "nested", "type": "block", "body": null }
+			]
+		}
+	]`
+
+	expect := `
+		log_level = "debug"
+
+		local.file "token" {
+			filename  = env("TOKEN_PATH")
+			is_secret = true
+		}
+
+		example {
+			count   = -5
+			ratio   = 0.25
+			nothing = null
+			list    = [
+				1,
+				"two",
+			]
+
+			labels = {
+				job            = "example",
+				"not-an-ident" = local.file.token.content,
+			}
+
+			nested { }
+		}
+	`
+
+	f, err := riverjson.ParseFile("config.json", []byte(input))
+	require.NoError(t, err)
+
+	expectFile, err := parser.ParseFile("expect.river", []byte(expect))
+	require.NoError(t, err)
+
+	require.Equal(t, printFile(t, expectFile), printFile(t, f))
+
+	// Positions must point into the JSON input.
+	block := f.Body[1].(*ast.BlockStmt)
+	require.Equal(t, "config.json:4:12", block.NamePos.Position().String())
+	require.Equal(t, "config.json:6:13", block.LabelPos.Position().String())
+}
+
+func TestParseFile_RoundTrip(t *testing.T) {
+	type nested struct {
+		Name string `river:"name,attr"`
+	}
+	type body struct {
+		Field  string            `river:"field,attr"`
+		Number float64           `river:"number,attr"`
+		Labels map[string]string `river:"labels,attr"`
+		Blocks []nested          `river:"nested,block"`
+	}
+
+	in := body{
+		Field:  "value",
+		Number: 1.5,
+		Labels: map[string]string{"a": "b"},
+		Blocks: []nested{{Name: "first"}, {Name: "second"}},
+	}
+
+	bb, err := riverjson.MarshalBody(in)
+	require.NoError(t, err)
+
+	f, err := riverjson.ParseFile("config.json", bb)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, f))
+
+	var out body
+	require.NoError(t, river.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, in, out)
+}
+
+func TestParseFile_Errors(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "invalid JSON",
+			input:  `[ { "name": "a", } ]`,
+			expect: `config.json:1:16: invalid character ',' looking for beginning of value`,
+		},
+		{
+			name:   "truncated JSON",
+			input:  `[`,
+			expect: `config.json:1:2: unexpected end of JSON input`,
+		},
+		{
+			name:   "trailing data",
+			input:  `[] []`,
+			expect: `config.json:1:4: unexpected data after top-level value`,
+		},
+		{
+			name:   "body not an array",
+			input:  `{}`,
+			expect: `config.json:1:1: $: expected array of statements, got object`,
+		},
+		{
+			name:   "unknown statement type",
+			input:  `[{ "name": "a", "type": "thing" }]`,
+			expect: `config.json:1:25: $[0].type: unrecognized statement type "thing"; expected "attr" or "block"`,
+		},
+		{
+			name: "invalid attribute name",
+			input: `[
+				{ "name": "a b", "type": "attr", "value": { "type": "null" } }
+			]`,
+			expect: `config.json:2:15: $[0].name: attribute name "a b" must be a valid identifier`,
+		},
+		{
+			name: "invalid block name",
+			input: `[
+				{ "name": "a..b", "type": "block" }
+			]`,
+			expect: `config.json:2:15: $[0].name: block name "a..b" must be a valid identifier or a sequence of identifiers separated by dots`,
+		},
+		{
+			name: "unknown field",
+			input: `[
+				{ "name": "a", "type": "block", "value": { "type": "null" } }
+			]`,
+			expect: `$[0]: unrecognized field "value"`,
+		},
+		{
+			name: "missing value",
+			input: `[
+				{ "name": "a", "type": "attr" }
+			]`,
+			expect: `config.json:2:5: $[0]: missing required field "value"`,
+		},
+		{
+			name: "mismatched value type",
+			input: `[
+				{ "name": "a", "type": "block", "body": [
+					{ "name": "b", "type": "attr", "value": { "type": "number", "value": "5" } }
+				] }
+			]`,
+			expect: `config.json:3:75: $[0].body[0].value.value: expected number, got string`,
+		},
+		{
+			name: "nested value error",
+			input: `[
+				{ "name": "a", "type": "attr", "value": { "type": "array", "value": [
+					{ "type": "object", "value": [ { "key": "k", "value": { "type": "bogus", "value": 1 } } ] }
+				] } }
+			]`,
+			expect: `$[0].value.value[0].value[0].value.type: unrecognized value type "bogus"`,
+		},
+		{
+			name: "capsule",
+			input: `[
+				{ "name": "a", "type": "attr", "value": { "type": "capsule", "value": "(secret)" } }
+			]`,
+			expect: `$[0].value.type: capsule values cannot be decoded`,
+		},
+		{
+			name: "invalid expression",
+			input: `[
+				{ "name": "a", "type": "attr", "value": { "type": "expr", "value": "1 +" } }
+			]`,
+			expect: `config.json:2:76: $[0].value.value: expected expression, got EOF`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := riverjson.ParseFile("config.json", []byte(tc.input))
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	expr, err := riverjson.ParseExpression([]byte(`{
+		"type": "array",
+		"value": [
+			{ "type": "number", "value": 1e3 },
+			{ "type": "expr", "value": "concat([1], [2])" }
+		]
+	}`))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, expr))
+	require.Equal(t, "[\n\t1e3,\n\tconcat([1], [2]),\n]", buf.String())
+
+	// Positions of expressions point into the JSON string holding them.
+	pos := expr.(*ast.ArrayExpr).Elements[1].(*ast.CallExpr).LParenPos.Position()
+	require.Equal(t, "5:38", pos.String())
+}
+
+func printFile(t *testing.T, f *ast.File) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, f))
+	return strings.TrimSpace(buf.String())
+}
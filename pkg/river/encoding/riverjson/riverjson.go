@@ -17,7 +17,8 @@ import (
 var goRiverDefaulter = reflect.TypeOf((*value.Defaulter)(nil)).Elem()
 
 // MarshalBody marshals the provided Go value to a JSON representation of
-// River. MarshalBody panics if not given a struct with River tags.
+// River. MarshalBody panics if not given a struct with River tags or a map
+// with string keys.
 func MarshalBody(val interface{}) ([]byte, error) {
 	rv := reflect.ValueOf(val)
 	return json.Marshal(encodeStructAsBody(rv))
@@ -33,6 +34,8 @@ func encodeStructAsBody(rv reflect.Value) jsonBody {
 
 	if rv.Kind() == reflect.Invalid {
 		return []jsonStatement{}
+	} else if rv.Kind() == reflect.Map {
+		return encodeMapAsBody(rv)
 	} else if rv.Kind() != reflect.Struct {
 		panic(fmt.Sprintf("river/encoding/riverjson: can only encode struct values to bodies, got %s", rv.Kind()))
 	}
@@ -62,6 +65,27 @@ func encodeStructAsBody(rv reflect.Value) jsonBody {
 	return body
 }
 
+// encodeMapAsBody encodes a map with string keys as a body where each key is
+// an attribute. Attributes are sorted by key.
+func encodeMapAsBody(rv reflect.Value) jsonBody {
+	if rv.Type().Key().Kind() != reflect.String {
+		panic("river/encoding/riverjson: unsupported map type for body; expected map[string]T, got " + rv.Type().String())
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	body := []jsonStatement{}
+	for _, key := range keys {
+		body = append(body, jsonAttr{
+			Name:  key.String(),
+			Type:  "attr",
+			Value: buildJSONValue(value.FromRaw(rv.MapIndex(key))),
+		})
+	}
+	return body
+}
+
 // encodeFieldAsStatements encodes an individual field from a struct as a set
 // of statements. One field may map to multiple statements in the case of a
 // slice of blocks.
@@ -309,3 +309,21 @@ func TestMapBlocks(t *testing.T) {
 	require.NoError(t, err)
 	require.JSONEq(t, expect, string(bb))
 }
+
+func TestMapBody(t *testing.T) {
+	val := map[string]any{"year": 2023, "field": "value"}
+
+	expect := `[{
+		"name": "field",
+		"type": "attr",
+		"value": { "type": "string", "value": "value" }
+	}, {
+		"name": "year",
+		"type": "attr",
+		"value": { "type": "number", "value": 2023 }
+	}]`
+
+	bb, err := riverjson.MarshalBody(val)
+	require.NoError(t, err)
+	require.JSONEq(t, expect, string(bb))
+}
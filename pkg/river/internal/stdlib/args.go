@@ -0,0 +1,82 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// checkArgCount returns an error if args doesn't have exactly n elements.
+func checkArgCount(funcValue value.Value, args []value.Value, n int) error {
+	if len(args) != n {
+		return value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected %d args, got %d", n, len(args)),
+		}
+	}
+	return nil
+}
+
+// checkArgType returns an error if the argument at index i of args isn't of
+// type t.
+func checkArgType(funcValue value.Value, args []value.Value, i int, t value.Type) error {
+	if arg := args[i]; arg.Type() != t {
+		return value.ArgError{
+			Function: funcValue,
+			Argument: arg,
+			Index:    i,
+			Inner:    value.TypeError{Value: arg, Expected: t},
+		}
+	}
+	return nil
+}
+
+// argError returns an error for the argument at index i of args.
+func argError(funcValue value.Value, args []value.Value, i int, err error) error {
+	return value.ArgError{
+		Function: funcValue,
+		Argument: args[i],
+		Index:    i,
+		Inner:    err,
+	}
+}
+
+// stringArg returns the string held by the argument at index i of args.
+func stringArg(funcValue value.Value, args []value.Value, i int) (string, error) {
+	if err := checkArgType(funcValue, args, i, value.TypeString); err != nil {
+		return "", err
+	}
+	return args[i].Text(), nil
+}
+
+// secretArg returns the string held by the argument at index i of args, which
+// may be a string, a secret, or an optional secret. isSecret reports whether
+// the argument was a secret.
+func secretArg(funcValue value.Value, args []value.Value, i int) (s string, isSecret bool, err error) {
+	arg := args[i]
+
+	switch arg.Type() {
+	case value.TypeString:
+		return arg.Text(), false, nil
+	case value.TypeCapsule:
+		switch v := arg.Interface().(type) {
+		case rivertypes.Secret:
+			return string(v), true, nil
+		case rivertypes.OptionalSecret:
+			return v.Value, v.IsSecret, nil
+		}
+	}
+
+	return "", false, argError(funcValue, args, i, fmt.Errorf("expected string or secret, got %s", arg.Describe()))
+}
+
+// secretValue returns s as a secret if isSecret is true, and as a string
+// otherwise. Functions which transform secrets use secretValue so that the
+// result of transforming a secret is never exposed as a plain string.
+func secretValue(s string, isSecret bool) value.Value {
+	if isSecret {
+		return value.Encapsulate(rivertypes.Secret(s))
+	}
+	return value.String(s)
+}
@@ -0,0 +1,152 @@
+package stdlib
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+)
+
+// keys returns the keys of an object in sorted order.
+func keys(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeObject); err != nil {
+		return value.Null, err
+	}
+
+	names := sortedKeys(args[0])
+	res := make([]value.Value, len(names))
+	for i, name := range names {
+		res[i] = value.String(name)
+	}
+	return value.Array(res...), nil
+}
+
+// values returns the values of an object, ordered by their keys.
+func values(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeObject); err != nil {
+		return value.Null, err
+	}
+
+	names := sortedKeys(args[0])
+	res := make([]value.Value, len(names))
+	for i, name := range names {
+		res[i], _ = args[0].Key(name)
+	}
+	return value.Array(res...), nil
+}
+
+func sortedKeys(obj value.Value) []string {
+	names := obj.Keys()
+	sort.Strings(names)
+	return names
+}
+
+// merge combines objects into a single object. Keys set in later objects
+// override the same keys in earlier objects. Objects aren't merged
+// recursively.
+func merge(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	res := make(map[string]value.Value)
+	for i, arg := range args {
+		if err := checkArgType(funcValue, args, i, value.TypeObject); err != nil {
+			return value.Null, err
+		}
+		for _, key := range arg.Keys() {
+			res[key], _ = arg.Key(key)
+		}
+	}
+	return value.Object(res), nil
+}
+
+// distinct returns the elements of an array with duplicates removed. The
+// first occurrence of each element is kept.
+func distinct(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	arr := args[0]
+	res := make([]value.Value, 0, arr.Len())
+	for i := 0; i < arr.Len(); i++ {
+		elem := arr.Index(i)
+		if !containsValue(res, elem) {
+			res = append(res, elem)
+		}
+	}
+	return value.Array(res...), nil
+}
+
+func containsValue(vv []value.Value, v value.Value) bool {
+	for _, elem := range vv {
+		if value.Equal(elem, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the elements of an array for which a function returns true.
+// The function is called with each element of the array and must return a
+// bool.
+func filter(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 2); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	arr, fn := args[0], args[1]
+	res := make([]value.Value, 0, arr.Len())
+	for i := 0; i < arr.Len(); i++ {
+		elem := arr.Index(i)
+
+		keep, err := fn.Call(elem)
+		if err != nil {
+			return value.Null, err
+		}
+		if keep.Type() != value.TypeBool {
+			return value.Null, argError(funcValue, args, 1, fmt.Errorf("function must return a bool, got %s", keep.Describe()))
+		}
+		if keep.Bool() {
+			res = append(res, elem)
+		}
+	}
+	return value.Array(res...), nil
+}
+
+// mapArray returns the results of calling a function with each element of an
+// array.
+func mapArray(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 2); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	arr, fn := args[0], args[1]
+	res := make([]value.Value, arr.Len())
+	for i := range res {
+		out, err := fn.Call(arr.Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		res[i] = out
+	}
+	return value.Array(res...), nil
+}
@@ -0,0 +1,76 @@
+package stdlib
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+)
+
+// toNumber converts a string into a number. Numbers are returned unchanged.
+// Strings holding integers are converted into integers; all other strings are
+// parsed as floating-point numbers.
+func toNumber(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	if args[0].Type() == value.TypeNumber {
+		return args[0], nil
+	}
+	in, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	in = strings.TrimSpace(in)
+	if i, err := strconv.ParseInt(in, 10, 64); err == nil {
+		return value.Int(i), nil
+	}
+	if u, err := strconv.ParseUint(in, 10, 64); err == nil {
+		return value.Uint(u), nil
+	}
+	f, err := strconv.ParseFloat(in, 64)
+	if err != nil {
+		return value.Null, argError(funcValue, args, 0, errors.New("is not a number"))
+	}
+	return value.Float(f), nil
+}
+
+// parseDuration parses a duration string, such as "1h30m", and returns the
+// number of seconds in the duration.
+func parseDuration(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	in, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	d, err := time.ParseDuration(in)
+	if err != nil {
+		return value.Null, argError(funcValue, args, 0, err)
+	}
+	return value.Float(d.Seconds()), nil
+}
+
+// file returns the contents of a file. Relative paths are relative to the
+// working directory of the process.
+func file(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	path, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return value.Null, argError(funcValue, args, 0, err)
+	}
+	return value.String(string(bb)), nil
+}
@@ -0,0 +1,103 @@
+package stdlib
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDecode decodes a YAML document into a River value. Mappings are decoded
+// into objects, so their keys are converted into strings.
+func yamlDecode(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	in, err := stringArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	var res interface{}
+	if err := yaml.Unmarshal([]byte(in), &res); err != nil {
+		return value.Null, argError(funcValue, args, 0, fmt.Errorf("invalid YAML: %w", err))
+	}
+	return value.Encode(normalizeYAML(res)), nil
+}
+
+// normalizeYAML converts values decoded from YAML into values with a River
+// equivalent.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = normalizeYAML(elem)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[fmt.Sprint(key)] = normalizeYAML(elem)
+		}
+		return out
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeYAML(elem)
+		}
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// base64Encode encodes a string or secret with standard base64 encoding.
+// Encoding a secret returns a secret.
+func base64Encode(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	in, isSecret, err := secretArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	return secretValue(base64.StdEncoding.EncodeToString([]byte(in)), isSecret), nil
+}
+
+// base64Decode decodes a string or secret encoded with standard base64
+// encoding. Decoding a secret returns a secret.
+func base64Decode(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	in, isSecret, err := secretArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	out, err := base64.StdEncoding.DecodeString(in)
+	if err != nil {
+		return value.Null, argError(funcValue, args, 0, fmt.Errorf("invalid base64: %w", err))
+	}
+	return secretValue(string(out), isSecret), nil
+}
+
+// sha256Sum returns the hex-encoded SHA-256 hash of a string or secret.
+// Hashing a secret returns a secret.
+func sha256Sum(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 1); err != nil {
+		return value.Null, err
+	}
+	in, isSecret, err := secretArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	sum := sha256.Sum256([]byte(in))
+	return secretValue(hex.EncodeToString(sum[:]), isSecret), nil
+}
@@ -0,0 +1,62 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/regexp"
+)
+
+// regexMatch reports whether a string contains a match of a regular
+// expression. The expression isn't anchored; use ^ and $ to match the entire
+// string.
+func regexMatch(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 2); err != nil {
+		return value.Null, err
+	}
+	re, err := regexArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	in, err := stringArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+	return value.Bool(re.MatchString(in)), nil
+}
+
+// regexReplace replaces every match of a regular expression in a string or
+// secret. The replacement may refer to capture groups, such as $1 or ${name}.
+// Replacing within a secret returns a secret.
+func regexReplace(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if err := checkArgCount(funcValue, args, 3); err != nil {
+		return value.Null, err
+	}
+	in, isSecret, err := secretArg(funcValue, args, 0)
+	if err != nil {
+		return value.Null, err
+	}
+	re, err := regexArg(funcValue, args, 1)
+	if err != nil {
+		return value.Null, err
+	}
+	replacement, err := stringArg(funcValue, args, 2)
+	if err != nil {
+		return value.Null, err
+	}
+	return secretValue(re.ReplaceAllString(in, replacement), isSecret), nil
+}
+
+// regexArg compiles the regular expression held by the argument at index i
+// of args.
+func regexArg(funcValue value.Value, args []value.Value, i int) (*regexp.Regexp, error) {
+	pattern, err := stringArg(funcValue, args, i)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, argError(funcValue, args, i, fmt.Errorf("invalid regular expression: %w", err))
+	}
+	return re, nil
+}
@@ -0,0 +1,124 @@
+package stdlib
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// secretAware wraps fn so that it accepts secrets wherever it accepts
+// strings. Secrets passed to the returned function, including secrets inside
+// arrays and objects, are given to fn as strings.
+//
+// If any argument held a secret, every string, number, and bool in the result
+// of fn is converted into a secret, so that values derived from secrets are
+// never exposed.
+func secretAware(fn interface{}) value.RawFunction {
+	inner := value.Encode(fn)
+
+	return func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		var (
+			revealed = make([]value.Value, len(args))
+			isSecret bool
+		)
+		for i, arg := range args {
+			var argSecret bool
+			revealed[i], argSecret = revealSecrets(arg)
+			isSecret = isSecret || argSecret
+		}
+
+		res, err := inner.Call(revealed...)
+		if err != nil && isSecret {
+			// Errors refer to the values given to fn. Replace them with the
+			// original arguments so revealed secrets aren't shown to the user.
+			if argErr, ok := err.(value.ArgError); ok && argErr.Index < len(args) {
+				return value.Null, value.ArgError{
+					Function: funcValue,
+					Argument: args[argErr.Index],
+					Index:    argErr.Index,
+					Inner:    errors.New(argErr.Inner.Error()),
+				}
+			}
+			return value.Null, value.Error{Value: funcValue, Inner: errors.New(err.Error())}
+		} else if err != nil {
+			return value.Null, err
+		}
+
+		if isSecret {
+			return hideValues(res), nil
+		}
+		return res, nil
+	}
+}
+
+// revealSecrets returns v with every secret it holds converted into a string.
+// isSecret reports whether v held a secret.
+func revealSecrets(v value.Value) (res value.Value, isSecret bool) {
+	switch v.Type() {
+	case value.TypeCapsule:
+		switch s := v.Interface().(type) {
+		case rivertypes.Secret:
+			return value.String(string(s)), true
+		case rivertypes.OptionalSecret:
+			return value.String(s.Value), s.IsSecret
+		}
+
+	case value.TypeArray:
+		elems := make([]value.Value, v.Len())
+		for i := range elems {
+			var elemSecret bool
+			elems[i], elemSecret = revealSecrets(v.Index(i))
+			isSecret = isSecret || elemSecret
+		}
+		if isSecret {
+			return value.Array(elems...), true
+		}
+
+	case value.TypeObject:
+		fields := make(map[string]value.Value, v.Len())
+		for _, key := range v.Keys() {
+			field, _ := v.Key(key)
+
+			var fieldSecret bool
+			fields[key], fieldSecret = revealSecrets(field)
+			isSecret = isSecret || fieldSecret
+		}
+		if isSecret {
+			return value.Object(fields), true
+		}
+	}
+
+	return v, false
+}
+
+// hideValues returns v with every string, number, and bool it holds
+// converted into a secret.
+func hideValues(v value.Value) value.Value {
+	switch v.Type() {
+	case value.TypeString:
+		return value.Encapsulate(rivertypes.Secret(v.Text()))
+	case value.TypeNumber:
+		return value.Encapsulate(rivertypes.Secret(v.Number().ToString()))
+	case value.TypeBool:
+		return value.Encapsulate(rivertypes.Secret(strconv.FormatBool(v.Bool())))
+
+	case value.TypeArray:
+		elems := make([]value.Value, v.Len())
+		for i := range elems {
+			elems[i] = hideValues(v.Index(i))
+		}
+		return value.Array(elems...)
+
+	case value.TypeObject:
+		fields := make(map[string]value.Value, v.Len())
+		for _, key := range v.Keys() {
+			field, _ := v.Key(key)
+			fields[key] = hideValues(field)
+		}
+		return value.Object(fields)
+	}
+
+	return v
+}
@@ -23,7 +23,8 @@ var Identifiers = map[string]interface{}{
 	// See constants.go for the definition.
 	"constants": constants,
 
-	"env": os.Getenv,
+	"env":  os.Getenv,
+	"file": value.RawFunction(file),
 
 	"nonsensitive": func(secret rivertypes.Secret) string {
 		return string(secret)
@@ -74,16 +75,18 @@ var Identifiers = map[string]interface{}{
 		return value.Array(raw...), nil
 	}),
 
-	"json_decode": func(in string) (interface{}, error) {
+	// Decoding functions accept secrets. Every string, number, and bool decoded
+	// from a secret is a secret.
+	"json_decode": secretAware(func(in string) (interface{}, error) {
 		var res interface{}
 		err := json.Unmarshal([]byte(in), &res)
 		if err != nil {
 			return nil, err
 		}
 		return res, nil
-	},
+	}),
 
-	"json_path": func(jsonString string, path string) (interface{}, error) {
+	"json_path": secretAware(func(jsonString string, path string) (interface{}, error) {
 		jsonPathExpr, err := jp.ParseString(path)
 		if err != nil {
 			return nil, err
@@ -95,7 +98,15 @@ var Identifiers = map[string]interface{}{
 		}
 
 		return jsonPathExpr.Get(jsonExpr), nil
-	},
+	}),
+
+	"yaml_decode": secretAware(value.RawFunction(yamlDecode)),
+
+	// Encoding and hashing functions accept secrets, returning a secret when
+	// given one.
+	"base64_encode": value.RawFunction(base64Encode),
+	"base64_decode": value.RawFunction(base64Decode),
+	"sha256":        value.RawFunction(sha256Sum),
 
 	"coalesce": value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
 		if len(args) == 0 {
@@ -119,14 +130,28 @@ var Identifiers = map[string]interface{}{
 		return args[len(args)-1], nil
 	}),
 
-	"format":      fmt.Sprintf,
-	"join":        strings.Join,
-	"replace":     strings.ReplaceAll,
-	"split":       strings.Split,
-	"to_lower":    strings.ToLower,
-	"to_upper":    strings.ToUpper,
-	"trim":        strings.Trim,
-	"trim_prefix": strings.TrimPrefix,
-	"trim_suffix": strings.TrimSuffix,
-	"trim_space":  strings.TrimSpace,
+	"keys":     value.RawFunction(keys),
+	"values":   value.RawFunction(values),
+	"merge":    value.RawFunction(merge),
+	"distinct": value.RawFunction(distinct),
+	"filter":   value.RawFunction(filter),
+	"map":      value.RawFunction(mapArray),
+
+	"to_number":      value.RawFunction(toNumber),
+	"parse_duration": value.RawFunction(parseDuration),
+
+	// String functions accept secrets, returning secrets when given one.
+	"format":      secretAware(fmt.Sprintf),
+	"join":        secretAware(strings.Join),
+	"replace":     secretAware(strings.ReplaceAll),
+	"split":       secretAware(strings.Split),
+	"to_lower":    secretAware(strings.ToLower),
+	"to_upper":    secretAware(strings.ToUpper),
+	"trim":        secretAware(strings.Trim),
+	"trim_prefix": secretAware(strings.TrimPrefix),
+	"trim_suffix": secretAware(strings.TrimSuffix),
+	"trim_space":  secretAware(strings.TrimSpace),
+
+	"regex_match":   value.RawFunction(regexMatch),
+	"regex_replace": value.RawFunction(regexReplace),
 }
@@ -0,0 +1,29 @@
+// Package stmtkey builds the keys which identify statements inside a River
+// body, shared by packages which address blocks by path.
+package stmtkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Block returns the key of a block: its name followed by its quoted label,
+// if it has one, such as `prometheus.remote_write "default"`.
+func Block(name []string, label string) string {
+	key := strings.Join(name, ".")
+	if label != "" {
+		key += " " + strconv.Quote(label)
+	}
+	return key
+}
+
+// Repeated returns the key for the nth repetition of key in the same body,
+// counting from zero. The first occurrence keeps key unchanged, and later
+// ones have "#n" appended.
+func Repeated(key string, n int) string {
+	if n == 0 {
+		return key
+	}
+	return fmt.Sprintf("%s#%d", key, n)
+}
@@ -0,0 +1,81 @@
+package value
+
+import "reflect"
+
+// Equal returns true if two River Values are equal. Numbers are compared by
+// value regardless of their Go type, so 3 is equal to 3.0. Functions are never
+// equal, and capsules are equal if their underlying Go values are deeply
+// equal.
+func Equal(lhs Value, rhs Value) bool {
+	if lhs.Type() != rhs.Type() {
+		// Two values with different types are never equal.
+		return false
+	}
+
+	switch lhs.Type() {
+	case TypeNull:
+		// Nothing to compare here: both lhs and rhs have the null type,
+		// so they're equal.
+		return true
+
+	case TypeNumber:
+		// Two numbers are equal if they have equal values. However, we have to
+		// determine what comparison we want to do and upcast the values to a
+		// different Go type as needed (so that 3 == 3.0 is true).
+		lhsNum, rhsNum := lhs.Number(), rhs.Number()
+		switch FitNumberKinds(lhsNum.Kind(), rhsNum.Kind()) {
+		case NumberKindUint:
+			return lhsNum.Uint() == rhsNum.Uint()
+		case NumberKindInt:
+			return lhsNum.Int() == rhsNum.Int()
+		case NumberKindFloat:
+			return lhsNum.Float() == rhsNum.Float()
+		}
+
+	case TypeString:
+		return lhs.Text() == rhs.Text()
+
+	case TypeBool:
+		return lhs.Bool() == rhs.Bool()
+
+	case TypeArray:
+		// Two arrays are equal if they have equal elements.
+		if lhs.Len() != rhs.Len() {
+			return false
+		}
+		for i := 0; i < lhs.Len(); i++ {
+			if !Equal(lhs.Index(i), rhs.Index(i)) {
+				return false
+			}
+		}
+		return true
+
+	case TypeObject:
+		// Two objects are equal if they have equal elements.
+		if lhs.Len() != rhs.Len() {
+			return false
+		}
+		for _, key := range lhs.Keys() {
+			lhsElement, _ := lhs.Key(key)
+			rhsElement, inRHS := rhs.Key(key)
+			if !inRHS {
+				return false
+			}
+			if !Equal(lhsElement, rhsElement) {
+				return false
+			}
+		}
+		return true
+
+	case TypeFunction:
+		// Two functions are never equal. We can't compare functions in Go, so
+		// there's no way to compare them in River right now.
+		return false
+
+	case TypeCapsule:
+		// Two capsules are only equal if the underlying values are deeply equal.
+		return reflect.DeepEqual(lhs.Interface(), rhs.Interface())
+	}
+
+	panic("river/value: unreachable")
+}
@@ -37,6 +37,22 @@ func makeNumberKind(k reflect.Kind) NumberKind {
 	}
 }
 
+// FitNumberKinds returns the NumberKind which can represent numbers of both
+// kind a and kind b with the least loss of precision.
+func FitNumberKinds(a, b NumberKind) NumberKind {
+	aPrec, bPrec := numberKindPrec[a], numberKindPrec[b]
+	if aPrec > bPrec {
+		return a
+	}
+	return b
+}
+
+var numberKindPrec = map[NumberKind]int{
+	NumberKindUint:  0,
+	NumberKindInt:   1,
+	NumberKindFloat: 2,
+}
+
 // Number is a generic representation of Go numbers. It is intended to be
 // created on the fly for numerical operations when the real number type is not
 // known.
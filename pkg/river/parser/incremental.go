@@ -0,0 +1,356 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// Incremental parses successive versions of River files. When a new version
+// of a file is parsed, the top-level statements outside of the range of
+// source which changed since the previous version are reused rather than
+// parsed again. This makes parsing a large file after a small change much
+// cheaper than parsing it in full.
+//
+// Versions of a file are tracked by file name. Only versions which parsed
+// without errors are used as the base for later versions.
+//
+// The ASTs returned by Incremental share nodes with one another, so they must
+// not be modified. Positions of reused statements may refer to the
+// token.File of an earlier version, which holds the same line information
+// for them.
+//
+// The zero value is ready for use. Incremental is safe for concurrent use.
+type Incremental struct {
+	mut   sync.Mutex
+	files map[string]*parsedFile
+}
+
+// parsedFile is a version of a file which parsed without errors.
+type parsedFile struct {
+	src  []byte
+	node *ast.File
+}
+
+// ParseFile parses a version of the River file filename. The result is the
+// same as the result of ParseFilePartial: if errors were encountered, the
+// returned AST holds the statements which could be parsed, and err is a
+// diag.Diagnostics with all the errors encountered during parsing.
+func (inc *Incremental) ParseFile(filename string, data []byte) (*ast.File, error) {
+	inc.mut.Lock()
+	prev := inc.files[filename]
+	inc.mut.Unlock()
+
+	var f *ast.File
+	if prev != nil {
+		f = reparseFile(prev, filename, data)
+	}
+	if f == nil {
+		var err error
+		if f, err = ParseFilePartial(filename, data); err != nil {
+			return f, err
+		}
+	}
+
+	inc.mut.Lock()
+	defer inc.mut.Unlock()
+
+	if inc.files == nil {
+		inc.files = make(map[string]*parsedFile)
+	}
+	inc.files[filename] = &parsedFile{src: bytes.Clone(data), node: f}
+	return f, nil
+}
+
+// reparseFile parses src, reusing the statements of prev which are outside
+// of the range of source which changed. reparseFile returns nil if the
+// changed range can't be parsed on its own without errors; the caller must
+// then parse src in full.
+func reparseFile(prev *parsedFile, filename string, src []byte) *ast.File {
+	var (
+		old  = prev.src
+		body = prev.node.Body
+	)
+
+	// The changed range is old[prefix:len(old)-suffix], which was replaced with
+	// src[prefix:len(src)-suffix].
+	prefix := commonPrefix(old, src)
+	suffix := commonSuffix(old[prefix:], src[prefix:])
+	delta := len(src) - len(old)
+
+	// Statements whose last line ends before the changed range are reused as
+	// they are. Text after a statement on its last line is parsed again, since
+	// changing it can change how the statement is parsed.
+	head := 0
+	for head < len(body) && endsLineBefore(old, body[head], prefix) {
+		head++
+	}
+
+	// Statements which start on a line after the changed range are reused with
+	// their positions moved by delta. If the change didn't move any lines, they
+	// can be reused as they are.
+	tail := len(body)
+	for tail > head && startsLineAfter(old, body[tail-1], len(old)-suffix) {
+		tail--
+	}
+	moved := !sameLines(old, src, prefix, len(old)-suffix)
+
+	// Parse the source between the reused statements. Since parsing a body
+	// starting after the end of a statement or the start of a line is the same
+	// as parsing a new file, the result can be combined with the reused
+	// statements as long as the source parses without errors.
+	start, oldEnd := 0, len(old)
+	if head > 0 {
+		start = ast.EndPos(body[head-1]).Offset() + 1
+	}
+	if tail < len(body) {
+		oldEnd = ast.StartPos(body[tail]).Offset()
+	}
+	end := oldEnd + delta
+
+	p := newParser(filename, src[start:end])
+	changed := p.ParseFile()
+	if len(p.diags) > 0 {
+		return nil
+	}
+
+	file := token.NewFile(filename)
+	for i, b := range src {
+		if b == '\n' {
+			file.AddLine(i + 1)
+		}
+	}
+
+	res := &ast.File{
+		Name: filename,
+		Body: make(ast.Body, 0, head+len(changed.Body)+len(body)-tail),
+	}
+
+	r := &rebaser{file: file, base: start}
+	s := &shifter{file: file, delta: delta}
+
+	res.Body = append(res.Body, body[:head]...)
+	for _, stmt := range changed.Body {
+		ast.Walk(r, stmt)
+		res.Body = append(res.Body, stmt)
+	}
+	for _, stmt := range body[tail:] {
+		if moved {
+			stmt = s.stmt(stmt)
+		}
+		res.Body = append(res.Body, stmt)
+	}
+
+	for _, cg := range prev.node.Comments {
+		if len(cg) > 0 && cg[0].StartPos.Offset() < start {
+			res.Comments = append(res.Comments, cg)
+		}
+	}
+	for _, cg := range changed.Comments {
+		for _, c := range cg {
+			c.StartPos = r.pos(c.StartPos)
+		}
+		res.Comments = append(res.Comments, cg)
+	}
+	for _, cg := range prev.node.Comments {
+		if len(cg) > 0 && cg[0].StartPos.Offset() >= oldEnd {
+			if moved {
+				cg = s.commentGroup(cg)
+			}
+			res.Comments = append(res.Comments, cg)
+		}
+	}
+
+	return res
+}
+
+// commonPrefix returns the length of the longest common prefix of a and b.
+func commonPrefix(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// commonSuffix returns the length of the longest common suffix of a and b.
+func commonSuffix(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[len(a)-1-i] != b[len(b)-1-i] {
+			return i
+		}
+	}
+	return n
+}
+
+// sameLines reports whether replacing old[start:end] with src[start:end]
+// keeps the offsets of all lines the same.
+func sameLines(old, src []byte, start, end int) bool {
+	if len(old) != len(src) {
+		return false
+	}
+	for i := start; i < end; i++ {
+		if (old[i] == '\n') != (src[i] == '\n') {
+			return false
+		}
+	}
+	return true
+}
+
+// endsLineBefore reports whether the newline ending the last line of stmt is
+// before the offset limit of src.
+func endsLineBefore(src []byte, stmt ast.Stmt, limit int) bool {
+	end := ast.EndPos(stmt).Offset() + 1
+	nl := bytes.IndexByte(src[end:], '\n')
+	return nl >= 0 && end+nl < limit
+}
+
+// startsLineAfter reports whether the newline before the first line of stmt
+// is at or after the offset limit of src.
+func startsLineAfter(src []byte, stmt ast.Stmt, limit int) bool {
+	nl := bytes.LastIndexByte(src[:ast.StartPos(stmt).Offset()], '\n')
+	return nl >= 0 && nl >= limit
+}
+
+// shifter copies nodes, moving their positions into a file by delta bytes.
+// Nodes are copied so that ASTs sharing the original nodes aren't changed.
+type shifter struct {
+	file  *token.File
+	delta int
+}
+
+func (s *shifter) pos(p token.Pos) token.Pos {
+	if !p.Valid() {
+		return p
+	}
+	return s.file.Pos(p.Offset() + s.delta)
+}
+
+func (s *shifter) commentGroup(cg ast.CommentGroup) ast.CommentGroup {
+	res := make(ast.CommentGroup, len(cg))
+	for i, c := range cg {
+		res[i] = &ast.Comment{StartPos: s.pos(c.StartPos), Text: c.Text}
+	}
+	return res
+}
+
+func (s *shifter) stmt(stmt ast.Stmt) ast.Stmt {
+	switch stmt := stmt.(type) {
+	case *ast.AttributeStmt:
+		return &ast.AttributeStmt{Name: s.ident(stmt.Name), Value: s.expr(stmt.Value)}
+
+	case *ast.BlockStmt:
+		res := *stmt
+		res.NamePos, res.LabelPos = s.pos(stmt.NamePos), s.pos(stmt.LabelPos)
+		res.LCurlyPos, res.RCurlyPos = s.pos(stmt.LCurlyPos), s.pos(stmt.RCurlyPos)
+		res.Body = make(ast.Body, len(stmt.Body))
+		for i, inner := range stmt.Body {
+			res.Body[i] = s.stmt(inner)
+		}
+		return &res
+
+	default:
+		panic(fmt.Sprintf("parser: unexpected statement type %T", stmt))
+	}
+}
+
+func (s *shifter) ident(ident *ast.Ident) *ast.Ident {
+	if ident == nil {
+		return nil
+	}
+	return &ast.Ident{Name: ident.Name, NamePos: s.pos(ident.NamePos)}
+}
+
+func (s *shifter) exprs(exprs []ast.Expr) []ast.Expr {
+	if exprs == nil {
+		return nil
+	}
+	res := make([]ast.Expr, len(exprs))
+	for i, e := range exprs {
+		res[i] = s.expr(e)
+	}
+	return res
+}
+
+func (s *shifter) expr(expr ast.Expr) ast.Expr {
+	switch expr := expr.(type) {
+	case *ast.IdentifierExpr:
+		return &ast.IdentifierExpr{Ident: s.ident(expr.Ident)}
+
+	case *ast.LiteralExpr:
+		res := *expr
+		res.ValuePos = s.pos(expr.ValuePos)
+		return &res
+
+	case *ast.InterpolationExpr:
+		res := *expr
+		res.Exprs = s.exprs(expr.Exprs)
+		res.LQuotePos, res.RQuotePos = s.pos(expr.LQuotePos), s.pos(expr.RQuotePos)
+		return &res
+
+	case *ast.ArrayExpr:
+		res := *expr
+		res.Elements = s.exprs(expr.Elements)
+		res.LBrackPos, res.RBrackPos = s.pos(expr.LBrackPos), s.pos(expr.RBrackPos)
+		return &res
+
+	case *ast.ObjectExpr:
+		res := *expr
+		if expr.Fields != nil {
+			res.Fields = make([]*ast.ObjectField, len(expr.Fields))
+			for i, f := range expr.Fields {
+				res.Fields[i] = &ast.ObjectField{Name: s.ident(f.Name), Quoted: f.Quoted, Value: s.expr(f.Value)}
+			}
+		}
+		res.LCurlyPos, res.RCurlyPos = s.pos(expr.LCurlyPos), s.pos(expr.RCurlyPos)
+		return &res
+
+	case *ast.AccessExpr:
+		return &ast.AccessExpr{Value: s.expr(expr.Value), Name: s.ident(expr.Name)}
+
+	case *ast.IndexExpr:
+		res := *expr
+		res.Value, res.Index = s.expr(expr.Value), s.expr(expr.Index)
+		res.LBrackPos, res.RBrackPos = s.pos(expr.LBrackPos), s.pos(expr.RBrackPos)
+		return &res
+
+	case *ast.CallExpr:
+		res := *expr
+		res.Value, res.Args = s.expr(expr.Value), s.exprs(expr.Args)
+		res.LParenPos, res.RParenPos = s.pos(expr.LParenPos), s.pos(expr.RParenPos)
+		return &res
+
+	case *ast.UnaryExpr:
+		res := *expr
+		res.Value = s.expr(expr.Value)
+		res.KindPos = s.pos(expr.KindPos)
+		return &res
+
+	case *ast.BinaryExpr:
+		res := *expr
+		res.Left, res.Right = s.expr(expr.Left), s.expr(expr.Right)
+		res.KindPos = s.pos(expr.KindPos)
+		return &res
+
+	case *ast.ParenExpr:
+		res := *expr
+		res.Inner = s.expr(expr.Inner)
+		res.LParenPos, res.RParenPos = s.pos(expr.LParenPos), s.pos(expr.RParenPos)
+		return &res
+
+	default:
+		panic(fmt.Sprintf("parser: unexpected expression type %T", expr))
+	}
+}
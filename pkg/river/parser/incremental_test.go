@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/stretchr/testify/require"
+)
+
+const incrementalBase = `// Scrape the agent itself.
+prometheus.scrape "agent" {
+	targets    = [{"__address__" = "localhost:12345"}]
+	forward_to = [prometheus.remote_write.default.receiver]
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://\(env("HOST")):9009/api/prom/push" // Local Mimir.
+	}
+}
+
+/* Discover pods. */
+discovery.kubernetes "pods" {
+	role = "pod"
+}
+
+logging {
+	level = "debug"
+}
+`
+
+func TestIncremental(t *testing.T) {
+	tt := []struct {
+		name     string
+		old, new string
+	}{
+		{"unchanged", "", ""},
+		{"change attribute", `role = "pod"`, `role = "node"`},
+		{"change attribute on last line", `level = "debug"`, `level = "info"`},
+		{"change attribute keeping lines", `role = "pod"`, `role = "svc"`},
+		{"join lines", "\trole = \"pod\"\n}", "\trole = \"pod\" }"},
+		{"add block", "logging {", "foo {}\n\nlogging {"},
+		{"remove block", "/* Discover pods. */\ndiscovery.kubernetes \"pods\" {\n\trole = \"pod\"\n}\n", ""},
+		{"add blank lines", "\n\nlogging", "\n\n\n\n\nlogging"},
+		{"change comment", "// Local Mimir.", "// Remote Mimir."},
+		{"change first line", "// Scrape the agent itself.", "// Scrape the agent."},
+		{"append statement", "level = \"debug\"\n}\n", "level = \"debug\"\n}\n\nfoo {}\n"},
+		{"extend expression onto next line", "\"localhost:12345\"}]", "\"localhost:12345\"}] +\n [\"a\"]"},
+		{"open array", `role = "pod"`, `role = ["pod"`},
+		{"open comment", "/* Discover pods. */", "/* Discover pods."},
+		{"syntax error", `role = "pod"`, `role = = "pod"`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var inc Incremental
+			_, err := inc.ParseFile("test.river", []byte(incrementalBase))
+			require.NoError(t, err)
+
+			src := strings.Replace(incrementalBase, tc.old, tc.new, 1)
+			require.True(t, tc.old == tc.new || src != incrementalBase, "test case doesn't change the source")
+
+			expect, expectErr := ParseFilePartial("test.river", []byte(src))
+			actual, actualErr := inc.ParseFile("test.river", []byte(src))
+			require.Equal(t, expectErr, actualErr)
+			requireSameFile(t, expect, actual)
+		})
+	}
+}
+
+func TestIncremental_Reuse(t *testing.T) {
+	var inc Incremental
+	prev, err := inc.ParseFile("test.river", []byte(incrementalBase))
+	require.NoError(t, err)
+
+	// Only the changed block is parsed again. Statements before it are reused
+	// and statements after it are copied with their positions moved.
+	src := strings.Replace(incrementalBase, `role = "pod"`, `role = "endpoints"`, 1)
+	f, err := inc.ParseFile("test.river", []byte(src))
+	require.NoError(t, err)
+	require.Len(t, f.Body, 4)
+	require.Same(t, prev.Body[0], f.Body[0])
+	require.Same(t, prev.Body[1], f.Body[1])
+	require.NotSame(t, prev.Body[2], f.Body[2])
+	require.True(t, ast.Equal(prev.Body[3], f.Body[3]))
+	require.Equal(t, 18, ast.StartPos(prev.Body[3]).Position().Line)
+	require.Equal(t, 18, ast.StartPos(f.Body[3]).Position().Line)
+	require.Equal(t, ast.StartPos(prev.Body[3]).Offset()+len("endpoints")-len("pod"), ast.StartPos(f.Body[3]).Offset())
+
+	// Statements after a change which doesn't move any lines are reused as
+	// they are.
+	src = strings.Replace(src, ":9009/", ":9010/", 1)
+	next, err := inc.ParseFile("test.river", []byte(src))
+	require.NoError(t, err)
+	require.Len(t, next.Body, 4)
+	require.Same(t, f.Body[0], next.Body[0])
+	require.NotSame(t, f.Body[1], next.Body[1])
+	require.Same(t, f.Body[2], next.Body[2])
+	require.Same(t, f.Body[3], next.Body[3])
+	f = next
+
+	// Versions with errors aren't used as the base for later versions.
+	_, err = inc.ParseFile("test.river", []byte(src+"}"))
+	require.Error(t, err)
+	next, err = inc.ParseFile("test.river", []byte(src+"foo {}\n"))
+	require.NoError(t, err)
+	require.Same(t, f.Body[0], next.Body[0])
+	require.Len(t, next.Body, 5)
+}
+
+// requireSameFile asserts that expect and actual have the same statements and
+// comments at the same positions.
+func requireSameFile(t *testing.T, expect, actual *ast.File) {
+	t.Helper()
+	require.True(t, ast.Equal(expect, actual), "files are not equal")
+	require.Equal(t, nodePositions(expect), nodePositions(actual))
+	require.Equal(t, commentPositions(expect), commentPositions(actual))
+}
+
+func nodePositions(f *ast.File) []string {
+	var res []string
+	ast.Walk(visitorFunc(func(n ast.Node) {
+		res = append(res, fmt.Sprintf("%T %s-%s", n, ast.StartPos(n).Position(), ast.EndPos(n).Position()))
+	}), f)
+	return res
+}
+
+func commentPositions(f *ast.File) []string {
+	var res []string
+	for _, cg := range f.Comments {
+		for _, c := range cg {
+			res = append(res, fmt.Sprintf("%s %s", c.StartPos.Position(), c.Text))
+		}
+		res = append(res, "")
+	}
+	return res
+}
+
+type visitorFunc func(n ast.Node)
+
+func (f visitorFunc) Visit(n ast.Node) ast.Visitor {
+	if n != nil {
+		f(n)
+	}
+	return f
+}
+
+func BenchmarkParseFile(b *testing.B) {
+	src := []byte(generateConfig(20000))
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseFile("bench.river", src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIncremental_ParseFile(b *testing.B) {
+	src := generateConfig(20000)
+
+	tt := []struct {
+		name     string
+		old, new string
+	}{
+		{"change keeping lines", `"host-10000:9100"`, `"host-10000:9200"`},
+		{"change moving lines", `"host-10000:9100"`, `"host-10000.example.com:9100"`},
+		{"add line", `"job"         = "job-10000",`, "\"job\"         = \"job-10000\",\n\t\t\"env\"         = \"prod\","},
+	}
+
+	for _, tc := range tt {
+		b.Run(tc.name, func(b *testing.B) {
+			// Alternate between two versions of the file, so that every iteration
+			// parses a change.
+			versions := [][]byte{[]byte(src), []byte(strings.Replace(src, tc.old, tc.new, 1))}
+
+			var inc Incremental
+			if _, err := inc.ParseFile("bench.river", versions[0]); err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := inc.ParseFile("bench.river", versions[(i+1)%2]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// generateConfig returns a River file with n components.
+func generateConfig(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `// Scrape host %[1]d.
+prometheus.scrape "host_%[1]d" {
+	targets = [{
+		"__address__" = "host-%[1]d:9100",
+		"job"         = "job-%[1]d",
+	}]
+	forward_to      = [prometheus.remote_write.default.receiver]
+	scrape_interval = "15s"
+}
+
+`, i)
+	}
+	return sb.String()
+}
@@ -20,8 +20,9 @@ import (
 // should directly represent the code.
 //
 // The parser will continue on encountering errors to allow a more complete
-// list of errors to be returned to the user. The resulting AST should be
-// discarded if errors were encountered during parsing.
+// list of errors to be returned to the user. After an error, the parser skips
+// to the end of the statement holding the error, so that the resulting AST
+// still holds every other statement of the file.
 type parser struct {
 	file     *token.File
 	diags    diag.Diagnostics
@@ -190,6 +191,10 @@ func (p *parser) parseBody(until token.Token) ast.Body {
 		if p.tok != token.TERMINATOR {
 			p.addErrorf("expected %s, got %s", token.TERMINATOR, p.tok)
 			p.consumeStatement()
+
+			if p.tok == until {
+				break
+			}
 		}
 		p.next()
 	}
@@ -201,6 +206,9 @@ func (p *parser) parseBody(until token.Token) ast.Body {
 // to but not including a terminator). consumeStatement will keep track of the
 // number of {}, [], and () pairs, only returning after the count of pairs is
 // <= 0.
+//
+// consumeStatement also returns before a "}" which closes the block
+// surrounding the statement, so that the block can still be parsed.
 func (p *parser) consumeStatement() {
 	var curlyPairs, brackPairs, parenPairs int
 
@@ -209,6 +217,9 @@ func (p *parser) consumeStatement() {
 		case token.LCURLY:
 			curlyPairs++
 		case token.RCURLY:
+			if curlyPairs == 0 {
+				return
+			}
 			curlyPairs--
 		case token.LBRACK:
 			brackPairs++
@@ -240,9 +251,8 @@ func (p *parser) consumeStatement() {
 func (p *parser) parseStatement() ast.Stmt {
 	blockName := p.parseBlockName()
 	if blockName == nil {
-		// parseBlockName failed; skip to the next identifier which would start a
-		// new Statement.
-		p.advance(token.IDENT)
+		// parseBlockName failed; skip the rest of the statement.
+		p.consumeStatement()
 		return nil
 	}
 
@@ -301,8 +311,8 @@ func (p *parser) parseStatement() ast.Stmt {
 			p.addErrorf("expected block body, got %s", p.tok)
 		}
 
-		// Give up on this statement and skip to the next identifier.
-		p.advance(token.IDENT)
+		// Give up on this statement and skip the rest of it.
+		p.consumeStatement()
 		return nil
 	}
 }
@@ -344,7 +354,7 @@ func (p *parser) parseBlockName() *blockName {
 			// label to be a valid identifier.
 			if len(p.lit) > 2 {
 				bn.Label = p.lit[1 : len(p.lit)-1]
-				if !isValidIdentifier(bn.Label) {
+				if !scanner.IsValidIdentifier(bn.Label) {
 					p.addErrorf("expected block label to be a valid identifier")
 				}
 			}
@@ -583,7 +593,15 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 		p.next()
 		return res
 
-	case token.STRING, token.NUMBER, token.FLOAT, token.BOOL, token.NULL:
+	case token.STRING:
+		if strings.Contains(p.lit, `\(`) {
+			res := p.parseInterpolation(p.pos, p.lit)
+			p.next()
+			return res
+		}
+		fallthrough
+
+	case token.NUMBER, token.FLOAT, token.BOOL, token.NULL:
 		res := &ast.LiteralExpr{
 			Kind:     p.tok,
 			Value:    p.lit,
@@ -630,6 +648,119 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 	return res
 }
 
+// parseInterpolation parses a string literal which may embed expressions. If
+// lit doesn't embed any expressions, a LiteralExpr is returned.
+//
+//	InterpolationExpr = '"' { string_character | escape_sequence | Interpolation } '"'
+//	Interpolation     = "\(" Expression ")"
+func (p *parser) parseInterpolation(pos token.Pos, lit string) ast.Expr {
+	// The scanner reports unterminated strings, but still returns them as
+	// STRING tokens.
+	end := len(lit)
+	if len(lit) >= 2 && strings.HasSuffix(lit, `"`) {
+		end = len(lit) - 1
+	}
+
+	res := &ast.InterpolationExpr{LQuotePos: pos, RQuotePos: pos.Add(len(lit) - 1)}
+
+	start := 1 // Start of the text before the next embedded expression.
+	for i := 1; i < end; {
+		switch {
+		case lit[i] != '\\':
+			i++
+		case i+1 < end && lit[i+1] == '(':
+			exprStart := i + 2
+			expr, length := p.parseEmbeddedExpr(pos.Add(exprStart), lit[exprStart:end])
+
+			res.Strings = append(res.Strings, lit[start:i])
+			res.Exprs = append(res.Exprs, expr)
+
+			i = exprStart + length + 1
+			start = i
+		default:
+			i += 2 // Skip over the escaped character.
+		}
+	}
+
+	if len(res.Exprs) == 0 {
+		return &ast.LiteralExpr{Kind: token.STRING, Value: lit, ValuePos: pos}
+	}
+	if start > end {
+		// The last embedded expression wasn't terminated.
+		start = end
+	}
+	res.Strings = append(res.Strings, lit[start:end])
+	return res
+}
+
+// parseEmbeddedExpr parses the expression at the start of text, which is
+// embedded in a string literal at pos. It returns the expression along with
+// the length of text before the parenthesis which closes the expression.
+func (p *parser) parseEmbeddedExpr(pos token.Pos, text string) (ast.Expr, int) {
+	sub := newParser(p.file.Name(), []byte(text))
+
+	expr := sub.ParseExpression()
+	length := sub.pos.Offset()
+	if sub.tok != token.RPAREN {
+		sub.addErrorf("expected ) to end embedded expression, got %s", sub.tok)
+		length = len(text)
+	}
+
+	// Move the positions of the expression and its errors into p's file.
+	ast.Walk(&rebaser{file: p.file, base: pos.Offset()}, expr)
+	for _, d := range sub.diags {
+		d.StartPos = p.file.PositionFor(p.file.Pos(pos.Offset() + d.StartPos.Offset))
+		if d.EndPos.Valid() {
+			d.EndPos = p.file.PositionFor(p.file.Pos(pos.Offset() + d.EndPos.Offset))
+		}
+		p.diags.Add(d)
+	}
+	return expr, length
+}
+
+// rebaser moves the positions of a node parsed from part of a file, such as
+// an expression embedded in a string literal, into the file holding it.
+type rebaser struct {
+	file *token.File
+	base int
+}
+
+func (r *rebaser) pos(p token.Pos) token.Pos {
+	if !p.Valid() {
+		return p
+	}
+	return r.file.Pos(r.base + p.Offset())
+}
+
+func (r *rebaser) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.BlockStmt:
+		node.NamePos, node.LabelPos = r.pos(node.NamePos), r.pos(node.LabelPos)
+		node.LCurlyPos, node.RCurlyPos = r.pos(node.LCurlyPos), r.pos(node.RCurlyPos)
+	case *ast.Ident:
+		node.NamePos = r.pos(node.NamePos)
+	case *ast.LiteralExpr:
+		node.ValuePos = r.pos(node.ValuePos)
+	case *ast.InterpolationExpr:
+		node.LQuotePos, node.RQuotePos = r.pos(node.LQuotePos), r.pos(node.RQuotePos)
+	case *ast.ArrayExpr:
+		node.LBrackPos, node.RBrackPos = r.pos(node.LBrackPos), r.pos(node.RBrackPos)
+	case *ast.ObjectExpr:
+		node.LCurlyPos, node.RCurlyPos = r.pos(node.LCurlyPos), r.pos(node.RCurlyPos)
+	case *ast.IndexExpr:
+		node.LBrackPos, node.RBrackPos = r.pos(node.LBrackPos), r.pos(node.RBrackPos)
+	case *ast.CallExpr:
+		node.LParenPos, node.RParenPos = r.pos(node.LParenPos), r.pos(node.RParenPos)
+	case *ast.UnaryExpr:
+		node.KindPos = r.pos(node.KindPos)
+	case *ast.BinaryExpr:
+		node.KindPos = r.pos(node.KindPos)
+	case *ast.ParenExpr:
+		node.LParenPos, node.RParenPos = r.pos(node.LParenPos), r.pos(node.RParenPos)
+	}
+	return r
+}
+
 var statementEnd = map[token.Token]struct{}{
 	token.TERMINATOR: {},
 	token.RPAREN:     {},
@@ -707,9 +838,3 @@ func (p *parser) parseField() *ast.ObjectField {
 	field.Value = p.ParseExpression()
 	return &field
 }
-
-func isValidIdentifier(in string) bool {
-	s := scanner.New(nil, []byte(in), nil, 0)
-	_, tok, lit := s.Scan()
-	return tok == token.IDENT && lit == in
-}
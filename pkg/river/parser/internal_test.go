@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestObjectFieldName(t *testing.T) {
@@ -20,3 +23,38 @@ func TestObjectFieldName(t *testing.T) {
 		assert.Equal(t, "field_a", res.Name.Name)
 	}
 }
+
+func TestInterpolation(t *testing.T) {
+	p := newParser(t.Name(), []byte(`"http://\(host):\(port)/"`))
+
+	res := p.ParseExpression()
+	require.Len(t, p.diags, 0)
+
+	expr, ok := res.(*ast.InterpolationExpr)
+	require.True(t, ok, "expected interpolation, got %T", res)
+	require.Equal(t, []string{"http://", ":", "/"}, expr.Strings)
+	require.Len(t, expr.Exprs, 2)
+
+	// Positions of embedded expressions are relative to the file.
+	require.Equal(t, 11, ast.StartPos(expr.Exprs[0]).Position().Column)
+	require.Equal(t, 19, ast.StartPos(expr.Exprs[1]).Position().Column)
+	require.Equal(t, 25, ast.EndPos(expr).Position().Column)
+}
+
+func TestInterpolation_Errors(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect string
+	}{
+		{`"\(1 +)"`, "1:7: expected expression, got )"},
+		{`"a \(b c)"`, "1:8: expected ) to end embedded expression, got IDENT"},
+	}
+
+	for _, tc := range tt {
+		p := newParser(t.Name(), []byte(tc.input))
+		_ = p.ParseExpression()
+
+		require.Len(t, p.diags, 1)
+		require.Equal(t, tc.expect, fmt.Sprintf("%d:%d: %s", p.diags[0].StartPos.Line, p.diags[0].StartPos.Column, p.diags[0].Message))
+	}
+}
@@ -22,6 +22,23 @@ func ParseFile(filename string, data []byte) (*ast.File, error) {
 	return f, nil
 }
 
+// ParseFilePartial parses an entire River configuration file like ParseFile,
+// but returns the AST even if errors were encountered during parsing, so that
+// tools such as editors can keep working with files being edited.
+//
+// Statements which couldn't be parsed are left out of the returned AST, and
+// invalid expressions are replaced with null literals. err is a
+// diag.Diagnostics with all the errors encountered during parsing.
+func ParseFilePartial(filename string, data []byte) (*ast.File, error) {
+	p := newParser(filename, data)
+
+	f := p.ParseFile()
+	if len(p.diags) > 0 {
+		return f, p.diags
+	}
+	return f, nil
+}
+
 // ParseExpression parses a single River expression from expr.
 //
 // If an error was encountered during parsing, the returned expression will be
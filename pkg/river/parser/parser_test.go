@@ -4,8 +4,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/stretchr/testify/require"
 )
 
@@ -108,6 +110,9 @@ func TestParseExpressions(t *testing.T) {
 
 		"parens": `(1 + 5) * 100`,
 
+		"interpolation":        `"http://\(host):\(port + 1)/"`,
+		"nested interpolation": `"\("\(a)" + b)"`,
+
 		"mixed expression": `(a.b.c)(1, 3 * some_list[magic_index * 2]).resulting_field`,
 	}
 
@@ -121,3 +126,66 @@ func TestParseExpressions(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilePartial(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect []string // Names of the top-level statements which should be parsed.
+	}{
+		{
+			name: "invalid statement in block",
+			input: `
+				foo {
+					= 5
+				}
+				bar {}
+			`,
+			expect: []string{"foo", "bar"},
+		},
+		{
+			name: "invalid block header",
+			input: `
+				foo "a" 5 {
+					value = 1
+				}
+				bar {}
+			`,
+			expect: []string{"bar"},
+		},
+		{
+			name: "invalid expression",
+			input: `
+				foo {
+					value = 1 + + 2
+					other = 3
+				}
+				bar {}
+			`,
+			expect: []string{"foo", "bar"},
+		},
+		{
+			name: "stray closing brace",
+			input: `
+				foo {}
+				}
+				bar {}
+			`,
+			expect: []string{"foo", "bar"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := ParseFilePartial(t.Name(), []byte(tc.input))
+			require.Error(t, err)
+			require.NotNil(t, f)
+
+			var names []string
+			for _, stmt := range f.Body {
+				names = append(names, strings.Join(stmt.(*ast.BlockStmt).Name, "."))
+			}
+			require.Equal(t, tc.expect, names)
+		})
+	}
+}
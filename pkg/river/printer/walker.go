@@ -138,6 +138,9 @@ func (w *walker) walkExpr(e ast.Expr) {
 	case *ast.LiteralExpr:
 		w.p.Write(e.ValuePos, e)
 
+	case *ast.InterpolationExpr:
+		w.walkInterpolationExpr(e)
+
 	case *ast.ArrayExpr:
 		w.walkArrayExpr(e)
 
@@ -182,6 +185,25 @@ func (w *walker) walkExpr(e ast.Expr) {
 	}
 }
 
+func (w *walker) walkInterpolationExpr(e *ast.InterpolationExpr) {
+	// The text around each embedded expression is written as a string literal
+	// so that the printer doesn't insert whitespace within the string.
+	text := func(s string) *ast.LiteralExpr {
+		return &ast.LiteralExpr{Kind: token.STRING, Value: s}
+	}
+
+	w.p.Write(e.LQuotePos, text(`"`+e.Strings[0]+`\(`))
+	for i, expr := range e.Exprs {
+		w.walkExpr(expr)
+
+		if i+1 < len(e.Exprs) {
+			w.p.Write(text(`)` + e.Strings[i+1] + `\(`))
+		} else {
+			w.p.Write(text(`)` + e.Strings[i+1] + `"`))
+		}
+	}
+}
+
 func (w *walker) walkArrayExpr(e *ast.ArrayExpr) {
 	w.p.Write(e.LBrackPos, token.LBRACK)
 	prevPos := e.LBrackPos
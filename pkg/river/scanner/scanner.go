@@ -56,6 +56,10 @@ import (
 // details. The escape sequences supported by River are the same as the escape
 // sequences supported by Go, except that it is always valid to use \' in
 // strings (which in Go, is only valid to use in character literals).
+//
+// Strings may also embed expressions with the \( escape sequence, which is
+// terminated by the matching ")". The embedded expression is scanned as part
+// of the STRING token and may itself contain strings.
 
 // ErrorHandler is invoked whenever there is an error.
 type ErrorHandler func(pos token.Pos, msg string)
@@ -357,6 +361,13 @@ func (s *Scanner) skipWhitespace() {
 	}
 }
 
+// IsValidIdentifier returns true if in is a valid River identifier.
+func IsValidIdentifier(in string) bool {
+	s := New(nil, []byte(in), nil, 0)
+	_, tok, lit := s.Scan()
+	return tok == token.IDENT && lit == in
+}
+
 func isLetter(ch rune) bool {
 	// We check for ASCII first as an optimization, and leave checking unicode
 	// (the slowest) to the very end.
@@ -506,6 +517,10 @@ func (s *Scanner) scanEscape() {
 	case 'a', 'b', 'f', 'n', 'r', 't', 'v', '\\', '"':
 		s.next()
 		return
+	case '(':
+		s.next()
+		s.scanInterpolation()
+		return
 	case '0', '1', '2', '3', '4', '5', '6', '7':
 		n, base, max = 3, 8, 255
 	case 'x':
@@ -547,6 +562,34 @@ func (s *Scanner) scanEscape() {
 	}
 }
 
+// scanInterpolation scans the expression embedded in a string by a \(
+// escape sequence, up to and including its closing parenthesis. If the
+// expression isn't terminated, scanInterpolation stops at the end of the line
+// and the enclosing string reports the error.
+func (s *Scanner) scanInterpolation() {
+	var depth int
+
+	for {
+		ch := s.ch
+		if ch == '\n' || ch == eof {
+			return
+		}
+		s.next()
+
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return
+			}
+			depth--
+		case '"':
+			s.scanString('"')
+		}
+	}
+}
+
 func digitVal(ch rune) int {
 	switch {
 	case ch >= '0' && ch <= '9':
@@ -41,6 +41,8 @@ var tokens = []tokenExample{
 	{token.FLOAT, "1e-100"},
 	{token.FLOAT, "2.71828e-1000"},
 	{token.STRING, `"Hello, world!"`},
+	{token.STRING, `"http://\(host):\(port + (1 * 2))/"`},
+	{token.STRING, `"\(env("HOST")):\("\(port)")"`},
 
 	// Operators and delimiters
 	{token.ADD, "+"},
@@ -227,6 +229,7 @@ var errorTests = []struct {
 	{"abc\x00def", token.IDENT, 3, "abc", "illegal character NUL"},
 	{"abc\x00", token.IDENT, 3, "abc", "illegal character NUL"},
 	{"10E", token.FLOAT, 0, "10E", "exponent has no digits"},
+	{`"\(foo`, token.STRING, 0, `"\(foo`, "string literal not terminated"},
 }
 
 func TestScanner_Scan_Errors(t *testing.T) {
@@ -269,3 +272,26 @@ func checkError(t *testing.T, src string, tok token.Token, pos int, lit, err str
 	assert.Equal(t, err, latestError, "Unexpected error message in src %q", src)
 	assert.Equal(t, pos, latestPos.Offset(), "Unexpected offset in src %q", src)
 }
+
+func TestIsValidIdentifier(t *testing.T) {
+	tt := []struct {
+		in    string
+		valid bool
+	}{
+		{"foobar", true},
+		{"_foo_bar9", true},
+		{"ŝ", true},
+		{"", false},
+		{"9foo", false},
+		{"foo bar", false},
+		{"foo.bar", false},
+		{"foo-bar", false},
+		{" foo", false},
+		{"true", false},
+		{"null", false},
+	}
+
+	for _, tc := range tt {
+		assert.Equal(t, tc.valid, IsValidIdentifier(tc.in), "IsValidIdentifier(%q)", tc.in)
+	}
+}
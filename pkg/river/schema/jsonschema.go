@@ -0,0 +1,199 @@
+package schema
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect used by schemas returned by
+// Body.JSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a JSON Schema describing a River body or value. A body is
+// described as an object where each attribute and block is a property, with
+// repeated blocks described as arrays.
+//
+// Fields prefixed with River hold extension keywords describing how a
+// property is written in River, since not all River values can be expressed
+// in JSON.
+type JSONSchema struct {
+	Schema string `json:"$schema,omitempty"`
+
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"` // false or a *JSONSchema.
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	WriteOnly            bool                   `json:"writeOnly,omitempty"`
+
+	// RiverType is the River type of an attribute which can't be described
+	// with JSON types: "secret", "capsule", or "function".
+	RiverType string `json:"x-river-type,omitempty"`
+
+	// RiverBlock is true if the property is a block rather than an attribute.
+	// RiverLabeled is true if the block requires a label.
+	RiverBlock   bool `json:"x-river-block,omitempty"`
+	RiverLabeled bool `json:"x-river-labeled,omitempty"`
+
+	// RiverEnum is the name of the enum the block belongs to. Blocks in an
+	// enum may be written in any order relative to each other, and their
+	// order is preserved.
+	RiverEnum string `json:"x-river-enum,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema describing b. The returned schema sets
+// $schema to JSONSchemaDialect.
+func (b Body) JSONSchema() *JSONSchema {
+	s := bodySchema(b)
+	s.Schema = JSONSchemaDialect
+	return s
+}
+
+func bodySchema(b Body) *JSONSchema {
+	s := &JSONSchema{Type: "object"}
+	if b.Dynamic {
+		return s
+	}
+
+	s.Properties = make(map[string]*JSONSchema, len(b.Attrs)+len(b.Blocks))
+	s.AdditionalProperties = false
+
+	for _, attr := range b.Attrs {
+		prop := typeSchema(attr.Type)
+		if attr.Default != nil {
+			prop.Default, _ = jsonValue(value.Encode(attr.Default))
+		}
+		s.Properties[attr.Name] = prop
+		if !attr.Optional {
+			s.Required = append(s.Required, attr.Name)
+		}
+	}
+
+	for _, block := range b.Blocks {
+		prop := bodySchema(block.Body)
+		if block.Repeated {
+			prop = &JSONSchema{Type: "array", Items: prop}
+		}
+		prop.RiverBlock = true
+		prop.RiverLabeled = block.Labeled
+		prop.RiverEnum = block.Enum
+
+		s.Properties[block.Name] = prop
+		if !block.Optional {
+			s.Required = append(s.Required, block.Name)
+		}
+	}
+	return s
+}
+
+var (
+	goAny            = reflect.TypeOf((*interface{})(nil)).Elem()
+	goDuration       = reflect.TypeOf(time.Duration(0))
+	goSecret         = reflect.TypeOf(rivertypes.Secret(""))
+	goOptionalSecret = reflect.TypeOf(rivertypes.OptionalSecret{})
+)
+
+// typeSchema returns the schema of values of Go type t.
+func typeSchema(t reflect.Type) *JSONSchema {
+	t = deref(t)
+
+	switch t {
+	case goAny:
+		return &JSONSchema{}
+	case goDuration:
+		return &JSONSchema{Type: "string", Format: "duration"}
+	case goSecret:
+		return &JSONSchema{Type: "string", WriteOnly: true, RiverType: "secret"}
+	case goOptionalSecret:
+		// Optional secrets may be either strings or secrets.
+		return &JSONSchema{Type: "string", RiverType: "secret"}
+	}
+
+	switch value.RiverType(t) {
+	case value.TypeNull:
+		return &JSONSchema{Type: "null"}
+	case value.TypeBool:
+		return &JSONSchema{Type: "boolean"}
+	case value.TypeString:
+		return &JSONSchema{Type: "string"}
+	case value.TypeNumber:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return &JSONSchema{Type: "number"}
+		default:
+			return &JSONSchema{Type: "integer"}
+		}
+
+	case value.TypeArray:
+		return &JSONSchema{Type: "array", Items: typeSchema(t.Elem())}
+
+	case value.TypeObject:
+		switch t.Kind() {
+		case reflect.Map:
+			return &JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+		case reflect.Struct:
+			return bodySchema(For(t))
+		default:
+			// Slices of labeled blocks are objects keyed by label.
+			return &JSONSchema{Type: "object", AdditionalProperties: bodySchema(For(t.Elem()))}
+		}
+
+	case value.TypeFunction:
+		return &JSONSchema{RiverType: "function"}
+	default:
+		return &JSONSchema{RiverType: "capsule"}
+	}
+}
+
+// jsonValue converts v into a value which can be encoded as JSON. It returns
+// false if v holds a capsule or function.
+func jsonValue(v value.Value) (interface{}, bool) {
+	switch v.Type() {
+	case value.TypeNull:
+		return nil, true
+	case value.TypeBool:
+		return v.Bool(), true
+	case value.TypeString:
+		return v.Text(), true
+	case value.TypeNumber:
+		switch num := v.Number(); num.Kind() {
+		case value.NumberKindInt:
+			return num.Int(), true
+		case value.NumberKindUint:
+			return num.Uint(), true
+		default:
+			return num.Float(), true
+		}
+
+	case value.TypeArray:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			elem, ok := jsonValue(v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			out[i] = elem
+		}
+		return out, true
+
+	case value.TypeObject:
+		out := make(map[string]interface{})
+		for _, key := range v.Keys() {
+			field, _ := v.Key(key)
+			elem, ok := jsonValue(field)
+			if !ok {
+				return nil, false
+			}
+			out[key] = elem
+		}
+		return out, true
+
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,191 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/stretchr/testify/require"
+)
+
+type exampleArguments struct {
+	URL      string                    `river:"url,attr"`
+	Timeout  time.Duration             `river:"timeout,attr,optional"`
+	Password rivertypes.Secret         `river:"password,attr,optional"`
+	Username rivertypes.OptionalSecret `river:"username,attr,optional"`
+	Labels   map[string]string         `river:"labels,attr,optional"`
+	Targets  []map[string]string       `river:"targets,attr,optional"`
+	Retries  int                       `river:"retries,attr,optional"`
+	Ratio    float64                   `river:"ratio,attr,optional"`
+	Extra    any                       `river:"extra,attr,optional"`
+
+	TLS    tlsBlock      `river:"tls,block,optional"`
+	Header []headerBlock `river:"header,block,optional"`
+	Stages []stageEnum   `river:"stage,enum,optional"`
+}
+
+func (args *exampleArguments) SetToDefault() {
+	*args = exampleArguments{
+		Timeout: 10 * time.Second,
+		Retries: 3,
+		TLS:     tlsBlock{ServerName: "localhost"},
+	}
+}
+
+type tlsBlock struct {
+	ServerName string `river:"server_name,attr,optional"`
+	Insecure   bool   `river:"insecure,attr,optional"`
+}
+
+type headerBlock struct {
+	Name  string `river:",label"`
+	Value string `river:"value,attr"`
+}
+
+func (h *headerBlock) SetToDefault() { *h = headerBlock{Value: "none"} }
+
+type stageEnum struct {
+	Match *matchBlock `river:"match,block"`
+}
+
+type matchBlock struct {
+	Selector string `river:"selector,attr"`
+}
+
+func TestBody_JSONSchema(t *testing.T) {
+	body := schema.For(reflect.TypeOf(exampleArguments{}))
+
+	bb, err := json.MarshalIndent(body.JSONSchema(), "", "  ")
+	require.NoError(t, err)
+
+	expect := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "extra": {},
+    "header": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "value": {
+            "type": "string",
+            "default": "none"
+          }
+        },
+        "required": [
+          "value"
+        ],
+        "additionalProperties": false
+      },
+      "x-river-block": true,
+      "x-river-labeled": true
+    },
+    "labels": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    },
+    "password": {
+      "type": "string",
+      "writeOnly": true,
+      "x-river-type": "secret"
+    },
+    "ratio": {
+      "type": "number"
+    },
+    "retries": {
+      "type": "integer",
+      "default": 3
+    },
+    "stage.match": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "selector": {
+            "type": "string"
+          }
+        },
+        "required": [
+          "selector"
+        ],
+        "additionalProperties": false
+      },
+      "x-river-block": true,
+      "x-river-enum": "stage"
+    },
+    "targets": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": {
+          "type": "string"
+        }
+      }
+    },
+    "timeout": {
+      "type": "string",
+      "format": "duration",
+      "default": "10s"
+    },
+    "tls": {
+      "type": "object",
+      "properties": {
+        "insecure": {
+          "type": "boolean"
+        },
+        "server_name": {
+          "type": "string",
+          "default": "localhost"
+        }
+      },
+      "additionalProperties": false,
+      "x-river-block": true
+    },
+    "url": {
+      "type": "string"
+    },
+    "username": {
+      "type": "string",
+      "x-river-type": "secret"
+    }
+  },
+  "required": [
+    "url"
+  ],
+  "additionalProperties": false
+}`
+	require.Equal(t, expect, string(bb))
+}
+
+func TestFor_Defaults(t *testing.T) {
+	body := schema.For(reflect.TypeOf(&exampleArguments{}))
+
+	timeout, ok := body.Attr("timeout")
+	require.True(t, ok)
+	require.Equal(t, 10*time.Second, timeout.Default)
+
+	url, ok := body.Attr("url")
+	require.True(t, ok)
+	require.Nil(t, url.Default)
+
+	// Defaults of blocks which appear once come from the defaults of the
+	// enclosing type.
+	tls, ok := body.Block("tls")
+	require.True(t, ok)
+	serverName, ok := tls.Body.Attr("server_name")
+	require.True(t, ok)
+	require.Equal(t, "localhost", serverName.Default)
+
+	// Defaults of repeated blocks come from the block type.
+	header, ok := body.Block("header")
+	require.True(t, ok)
+	value, ok := header.Body.Attr("value")
+	require.True(t, ok)
+	require.Equal(t, "none", value.Default)
+}
@@ -0,0 +1,207 @@
+// Package schema describes the River attributes and blocks supported by Go
+// types which use river struct tags.
+//
+// Schemas are used by tools which need to know the structure of a River body
+// without decoding it, such as editors and documentation generators.
+package schema
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/internal/reflectutil"
+	"github.com/grafana/agent/pkg/river/internal/rivertags"
+	"github.com/grafana/agent/pkg/river/internal/value"
+)
+
+// Body describes the attributes and blocks supported within a River body.
+type Body struct {
+	Attrs  []Attr  // Attributes supported by the body, in declaration order.
+	Blocks []Block // Blocks supported by the body, in declaration order.
+
+	// Dynamic is true if the body accepts attributes with any name, such as
+	// when the body is decoded into a map.
+	Dynamic bool
+}
+
+// Attr returns the attribute in b with the given name.
+func (b Body) Attr(name string) (Attr, bool) {
+	for _, a := range b.Attrs {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Attr{}, false
+}
+
+// Block returns the block in b with the given name. Names of nested blocks are
+// separated by dots.
+func (b Body) Block(name string) (Block, bool) {
+	for _, blk := range b.Blocks {
+		if blk.Name == name {
+			return blk, true
+		}
+	}
+	return Block{}, false
+}
+
+// Attr describes a River attribute.
+type Attr struct {
+	Name     string
+	Optional bool
+	Type     reflect.Type // Go type the attribute is decoded into.
+
+	// Default is the value of the attribute when it isn't set, taken from the
+	// SetToDefault method of the type holding the attribute. Default is nil if
+	// the attribute defaults to the zero value of its type.
+	Default interface{}
+}
+
+// RiverType returns the name of the River type of the attribute, such as
+// "string" or "array".
+func (a Attr) RiverType() string {
+	return value.RiverType(a.Type).String()
+}
+
+// Block describes a River block.
+type Block struct {
+	Name     string // Name of the block. Multiple identifiers are separated by dots.
+	Optional bool
+	Repeated bool         // True if the block may be specified more than once.
+	Labeled  bool         // True if the block requires a label.
+	Enum     string       // Name of the enum holding the block, if any.
+	Type     reflect.Type // Go type the block is decoded into.
+	Body     Body
+}
+
+// For returns the schema of the body of Go type t. The fields of t are
+// inspected through their river struct tags. For returns an empty Body if t
+// isn't a struct or a map, after dereferencing pointers.
+//
+// Default values of attributes are found by calling the SetToDefault method
+// of t and of the types of its blocks, if they implement river.Defaulter.
+func For(t reflect.Type) Body {
+	return bodyFor(t, reflect.Value{}, make(map[reflect.Type]struct{}))
+}
+
+// bodyFor returns the schema of t. rv, if valid, is the value of t before
+// defaults are applied. Types in seen are currently being inspected;
+// recursive types yield an empty Body the second time they are encountered.
+func bodyFor(t reflect.Type, rv reflect.Value, seen map[reflect.Type]struct{}) Body {
+	t = deref(t)
+
+	switch t.Kind() {
+	case reflect.Map:
+		return Body{Dynamic: true}
+	case reflect.Struct:
+		// Handled below.
+	default:
+		return Body{}
+	}
+
+	if _, ok := seen[t]; ok {
+		return Body{}
+	}
+	seen[t] = struct{}{}
+	defer delete(seen, t)
+
+	defaults := withDefaults(t, rv)
+
+	var body Body
+	for _, field := range rivertags.Get(t) {
+		body.add(nil, defaults, field, seen)
+	}
+	return body
+}
+
+// withDefaults returns an addressable copy of rv with defaults applied. A
+// zero value of t is used if rv isn't valid.
+func withDefaults(t reflect.Type, rv reflect.Value) reflect.Value {
+	out := reflect.New(t).Elem()
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.IsValid() && rv.Type() == t {
+		out.Set(rv)
+	}
+
+	if d, ok := out.Addr().Interface().(value.Defaulter); ok {
+		d.SetToDefault()
+	}
+	return out
+}
+
+// add adds field of struct value rv into b. prefix is prepended to the names
+// of blocks, and is set for blocks within an enum.
+func (b *Body) add(prefix []string, rv reflect.Value, field rivertags.Field, seen map[reflect.Type]struct{}) {
+	fieldValue := reflectutil.Get(rv, field)
+	fieldType := fieldValue.Type()
+
+	switch {
+	case field.IsAttr():
+		attr := Attr{
+			Name:     strings.Join(field.Name, "."),
+			Optional: field.IsOptional(),
+			Type:     fieldType,
+		}
+		if !fieldValue.IsZero() && fieldValue.CanInterface() {
+			attr.Default = fieldValue.Interface()
+		}
+		b.Attrs = append(b.Attrs, attr)
+
+	case field.IsBlock():
+		blockType, repeated := deref(fieldType), false
+		if kind := blockType.Kind(); kind == reflect.Slice || kind == reflect.Array {
+			blockType, repeated = deref(blockType.Elem()), true
+		}
+
+		// Blocks which appear once are decoded on top of the default value of
+		// their field; repeated blocks start from their zero value.
+		var blockValue reflect.Value
+		if !repeated && prefix == nil {
+			blockValue = fieldValue
+		}
+
+		b.Blocks = append(b.Blocks, Block{
+			Name:     strings.Join(append(append([]string{}, prefix...), field.Name...), "."),
+			Optional: field.IsOptional() || prefix != nil,
+			Repeated: repeated || prefix != nil,
+			Labeled:  hasLabel(blockType),
+			Enum:     strings.Join(prefix, "."),
+			Type:     blockType,
+			Body:     bodyFor(blockType, blockValue, seen),
+		})
+
+	case field.IsEnum():
+		// Enums are a list of structs where each field is a block. Each block
+		// may be specified any number of times.
+		elemType := deref(fieldType.Elem())
+		if elemType.Kind() != reflect.Struct {
+			return
+		}
+		newPrefix := append(append([]string{}, prefix...), field.Name...)
+		elemValue := reflect.New(elemType).Elem()
+		for _, inner := range rivertags.Get(elemType) {
+			b.add(newPrefix, elemValue, inner, seen)
+		}
+	}
+}
+
+func hasLabel(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, field := range rivertags.Get(t) {
+		if field.IsLabel() {
+			return true
+		}
+	}
+	return false
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,75 @@
+package schema_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFor(t *testing.T) {
+	type Inner struct {
+		Label string `river:",label"`
+		Value int    `river:"value,attr,optional"`
+	}
+
+	type EnumElement struct {
+		First  *Inner `river:"first,block"`
+		Second *Inner `river:"second,block"`
+	}
+
+	type Squashed struct {
+		Extra string `river:"extra,attr,optional"`
+	}
+
+	type Example struct {
+		Name     string            `river:"name,attr"`
+		Labels   map[string]string `river:"labels,attr,optional"`
+		Single   Inner             `river:"single,block"`
+		Multi    []Inner           `river:"multi,block,optional"`
+		Headers  map[string]string `river:"headers,block,optional"`
+		Stages   []EnumElement     `river:"stage,enum"`
+		Squashed Squashed          `river:",squash"`
+	}
+
+	innerBody := schema.Body{
+		Attrs: []schema.Attr{{Name: "value", Optional: true, Type: reflect.TypeOf(0)}},
+	}
+	innerType := reflect.TypeOf(Inner{})
+
+	expect := schema.Body{
+		Attrs: []schema.Attr{
+			{Name: "name", Type: reflect.TypeOf("")},
+			{Name: "labels", Optional: true, Type: reflect.TypeOf(map[string]string{})},
+			{Name: "extra", Optional: true, Type: reflect.TypeOf("")},
+		},
+		Blocks: []schema.Block{
+			{Name: "single", Labeled: true, Type: innerType, Body: innerBody},
+			{Name: "multi", Optional: true, Repeated: true, Labeled: true, Type: innerType, Body: innerBody},
+			{Name: "headers", Optional: true, Type: reflect.TypeOf(map[string]string{}), Body: schema.Body{Dynamic: true}},
+			{Name: "stage.first", Optional: true, Repeated: true, Labeled: true, Enum: "stage", Type: innerType, Body: innerBody},
+			{Name: "stage.second", Optional: true, Repeated: true, Labeled: true, Enum: "stage", Type: innerType, Body: innerBody},
+		},
+	}
+
+	actual := schema.For(reflect.TypeOf(&Example{}))
+	require.Equal(t, expect, actual)
+
+	attr, ok := actual.Attr("labels")
+	require.True(t, ok)
+	require.Equal(t, "object", attr.RiverType())
+
+	_, ok = actual.Block("stage.first")
+	require.True(t, ok)
+}
+
+func TestFor_Recursive(t *testing.T) {
+	type Node struct {
+		Children []*Node `river:"child,block,optional"`
+	}
+
+	body := schema.For(reflect.TypeOf(Node{}))
+	require.Len(t, body.Blocks, 1)
+	require.Empty(t, body.Blocks[0].Body.Blocks)
+}
@@ -1,9 +1,11 @@
 // Package builder exposes an API to create a River configuration file by
-// constructing a set of tokens.
+// constructing a set of tokens. Existing files can be loaded with ParseFile,
+// modified, and written back out without losing their comments.
 package builder
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/grafana/agent/pkg/river/internal/reflectutil"
 	"github.com/grafana/agent/pkg/river/internal/rivertags"
+	"github.com/grafana/agent/pkg/river/internal/stmtkey"
 	"github.com/grafana/agent/pkg/river/internal/value"
 	"github.com/grafana/agent/pkg/river/token"
 )
@@ -81,6 +84,10 @@ func (f *File) Bytes() []byte {
 type Body struct {
 	nodes             []tokenNode
 	valueOverrideHook ValueOverrideHook
+
+	// trailing holds comments after the last statement of a Body loaded by
+	// ParseFile.
+	trailing []Token
 }
 
 type ValueOverrideHook = func(val interface{}) interface{}
@@ -115,11 +122,41 @@ func (b *Body) Tokens() []Token {
 				Tok: token.LITERAL,
 				Lit: "\n",
 			})
+
+			if needsBlankLine(node, b.nodes[i+1]) {
+				rawToks = append(rawToks, Token{Tok: token.LITERAL, Lit: "\n"})
+			}
+		}
+	}
+	if len(b.trailing) > 0 {
+		if len(b.nodes) > 0 {
+			rawToks = append(rawToks, Token{Tok: token.LITERAL, Lit: "\n"})
 		}
+		rawToks = append(rawToks, b.trailing...)
 	}
 	return rawToks
 }
 
+// needsBlankLine reports whether a blank line must be written between the
+// statements prev and next. Blocks are separated from other statements by a
+// blank line, which the printer only adds when no comments are between them.
+func needsBlankLine(prev, next tokenNode) bool {
+	var leading []Token
+	switch next := next.(type) {
+	case *attribute:
+		leading = next.comments.leading
+	case *Block:
+		leading = next.comments.leading
+	}
+	if len(leading) == 0 || leading[0].Tok != token.COMMENT {
+		return false
+	}
+
+	_, prevBlock := prev.(*Block)
+	_, nextBlock := next.(*Block)
+	return prevBlock || nextBlock
+}
+
 // AppendTokens appends raw tokens to the Body.
 func (b *Body) AppendTokens(tokens []Token) {
 	b.nodes = append(b.nodes, tokensSlice(tokens))
@@ -130,6 +167,149 @@ func (b *Body) AppendBlock(block *Block) {
 	b.nodes = append(b.nodes, block)
 }
 
+// InsertBlockAfter inserts block into the Body directly after the block after.
+// If after is nil, block is inserted at the start of the Body.
+// InsertBlockAfter returns false without inserting block if after isn't in
+// the Body.
+func (b *Body) InsertBlockAfter(after, block *Block) bool {
+	idx := 0
+	if after != nil {
+		idx = b.indexOf(after) + 1
+		if idx == 0 {
+			return false
+		}
+	}
+
+	b.nodes = append(b.nodes, nil)
+	copy(b.nodes[idx+1:], b.nodes[idx:])
+	b.nodes[idx] = block
+	return true
+}
+
+// RemoveBlock removes block from the Body, along with the comments directly
+// before it and on its last line. RemoveBlock returns false if block isn't in
+// the Body.
+func (b *Body) RemoveBlock(block *Block) bool {
+	return b.removeNode(b.indexOf(block))
+}
+
+// RemoveAttribute removes the attribute name from the Body, along with the
+// comments directly before it and on its last line. RemoveAttribute returns
+// false if the attribute isn't set.
+func (b *Body) RemoveAttribute(name string) bool {
+	for i, n := range b.nodes {
+		if attr, ok := n.(*attribute); ok && attr.Name == name {
+			return b.removeNode(i)
+		}
+	}
+	return false
+}
+
+func (b *Body) indexOf(n tokenNode) int {
+	for i, other := range b.nodes {
+		if other == n {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *Body) removeNode(i int) bool {
+	if i < 0 {
+		return false
+	}
+	b.nodes = append(b.nodes[:i], b.nodes[i+1:]...)
+	return true
+}
+
+// Blocks returns the blocks inside of the Body, in the order they are written
+// out.
+func (b *Body) Blocks() []*Block {
+	var blocks []*Block
+	for _, n := range b.nodes {
+		if block, ok := n.(*Block); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// FindBlock returns the block found by following path from the Body, or nil
+// if there is no such block.
+//
+// Each element of path identifies a block inside the previous block. A block
+// is identified by its name followed by its quoted label, if it has one, such
+// as `prometheus.remote_write "default"`. If a body has more than one block
+// with the same name and label, such as unlabeled blocks, the Nth repetition
+// (starting at 1) is identified by appending "#N".
+func (b *Body) FindBlock(path ...string) *Block {
+	var block *Block
+	for _, key := range path {
+		block = b.findBlock(key)
+		if block == nil {
+			return nil
+		}
+		b = block.body
+	}
+	return block
+}
+
+func (b *Body) findBlock(key string) *Block {
+	seen := make(map[string]int)
+	for _, block := range b.Blocks() {
+		name := stmtkey.Block(block.Name, block.Label)
+		if stmtkey.Repeated(name, seen[name]) == key {
+			return block
+		}
+		seen[name]++
+	}
+	return nil
+}
+
+// SetAttributeValueAt sets the attribute identified by path to a River value
+// converted from a Go value, following the same rules as SetAttributeValue.
+// All elements of path except the last identify the block holding the
+// attribute, using the same format as FindBlock. The last element of path is
+// the name of the attribute.
+//
+// SetAttributeValueAt returns an error if the block holding the attribute
+// doesn't exist.
+func (b *Body) SetAttributeValueAt(path []string, goValue interface{}) error {
+	body, name, err := b.attributeBody(path)
+	if err != nil {
+		return err
+	}
+	body.SetAttributeValue(name, goValue)
+	return nil
+}
+
+// SetAttributeTokensAt sets the attribute identified by path to a set of raw
+// tokens. path is interpreted the same way as in SetAttributeValueAt.
+func (b *Body) SetAttributeTokensAt(path []string, tokens []Token) error {
+	body, name, err := b.attributeBody(path)
+	if err != nil {
+		return err
+	}
+	body.SetAttributeTokens(name, tokens)
+	return nil
+}
+
+func (b *Body) attributeBody(path []string) (*Body, string, error) {
+	if len(path) == 0 {
+		return nil, "", errors.New("empty attribute path")
+	}
+	blockPath, name := path[:len(path)-1], path[len(path)-1]
+	if len(blockPath) == 0 {
+		return b, name, nil
+	}
+
+	block := b.FindBlock(blockPath...)
+	if block == nil {
+		return nil, "", fmt.Errorf("block %s not found", strings.Join(blockPath, " / "))
+	}
+	return block.body, name, nil
+}
+
 // AppendFrom sets attributes and appends blocks defined by goValue into the
 // Body. If any value reachable from goValue implements Tokenizer, the printed
 // tokens will instead be retrieved by calling the RiverTokenize method.
@@ -353,18 +533,28 @@ func (b *Body) SetAttributeValue(name string, goValue interface{}) {
 type attribute struct {
 	Name      string
 	RawTokens []Token
+
+	comments stmtComments
 }
 
 func (attr *attribute) Tokens() []Token {
 	var toks []Token
 
+	toks = append(toks, attr.comments.leading...)
 	toks = append(toks, Token{Tok: token.IDENT, Lit: attr.Name})
 	toks = append(toks, Token{Tok: token.ASSIGN})
 	toks = append(toks, attr.RawTokens...)
+	toks = append(toks, attr.comments.trailing...)
 
 	return toks
 }
 
+// stmtComments holds the comments around a statement loaded by ParseFile.
+type stmtComments struct {
+	leading  []Token // Comments and blank lines before the statement.
+	trailing []Token // Comments after the statement on its last line.
+}
+
 // A Block encapsulates a body within a named and labeled River block. Blocks
 // must be created by calling NewBlock, but its public struct fields may be
 // safely modified by callers.
@@ -377,6 +567,11 @@ type Block struct {
 	// Private fields:
 
 	body *Body
+
+	comments stmtComments
+	// lcurlyComments holds comments after the opening curly brace of a Block
+	// loaded by ParseFile.
+	lcurlyComments []Token
 }
 
 // NewBlock returns a new Block with the given name and label. The name/label
@@ -394,6 +589,8 @@ func NewBlock(name []string, label string) *Block {
 func (b *Block) Tokens() []Token {
 	var toks []Token
 
+	toks = append(toks, b.comments.leading...)
+
 	for i, frag := range b.Name {
 		toks = append(toks, Token{Tok: token.IDENT, Lit: frag})
 		if i+1 < len(b.Name) {
@@ -407,9 +604,12 @@ func (b *Block) Tokens() []Token {
 		toks = append(toks, Token{Tok: token.STRING, Lit: fmt.Sprintf("%q", b.Label)})
 	}
 
-	toks = append(toks, Token{Tok: token.LCURLY}, Token{Tok: token.LITERAL, Lit: "\n"})
+	toks = append(toks, Token{Tok: token.LCURLY})
+	toks = append(toks, b.lcurlyComments...)
+	toks = append(toks, Token{Tok: token.LITERAL, Lit: "\n"})
 	toks = append(toks, b.body.Tokens()...)
 	toks = append(toks, Token{Tok: token.LITERAL, Lit: "\n"}, Token{Tok: token.RCURLY})
+	toks = append(toks, b.comments.trailing...)
 
 	return toks
 }
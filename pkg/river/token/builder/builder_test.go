@@ -409,3 +409,122 @@ func TestBuilder_MapBlocks(t *testing.T) {
 
 	require.Equal(t, expect, string(f.Bytes()))
 }
+
+func TestParseFile_RoundTrip(t *testing.T) {
+	src := format(t, `
+		// Header comment.
+
+		// Scrape the agent itself.
+		prometheus.scrape "agent" { // Inline comment.
+			targets    = [{"__address__" = "localhost:12345"}]
+			forward_to = [
+				prometheus.remote_write.default.receiver, // Inner comment.
+			]
+
+			// Scrape every 15s.
+			scrape_interval = "15s" // Trailing comment.
+			// Dangling comment.
+		}
+
+		discovery.kubernetes "pods" {
+			role = "pod"
+		} // Pods.
+
+		/* End of file. */
+	`)
+
+	f, err := builder.ParseFile(t.Name(), []byte(src))
+	require.NoError(t, err)
+	require.Equal(t, src, string(f.Bytes()))
+}
+
+func TestParseFile_Modify(t *testing.T) {
+	f, err := builder.ParseFile(t.Name(), []byte(`
+		// Where to send metrics.
+		prometheus.remote_write "default" {
+			endpoint {
+				url = "http://localhost:9009/api/prom/push" // Local Mimir.
+			}
+		}
+
+		// Unused.
+		discovery.kubernetes "pods" {
+			role = "pod"
+		}
+
+		prometheus.relabel "default" {
+			forward_to = [prometheus.remote_write.default.receiver]
+
+			// Keep all samples.
+			rule {
+				action = "keep"
+			}
+		}
+	`))
+	require.NoError(t, err)
+
+	err = f.Body().SetAttributeValueAt([]string{`prometheus.remote_write "default"`, "endpoint", "url"}, "http://mimir:9009/api/prom/push")
+	require.NoError(t, err)
+
+	require.True(t, f.Body().RemoveBlock(f.Body().FindBlock(`discovery.kubernetes "pods"`)))
+
+	rule := builder.NewBlock([]string{"rule"}, "")
+	rule.Body().SetAttributeValue("action", "drop")
+	f.Body().FindBlock(`prometheus.relabel "default"`).Body().AppendBlock(rule)
+
+	scrape := builder.NewBlock([]string{"prometheus", "scrape"}, "default")
+	scrape.Body().SetAttributeTokens("forward_to", []builder.Token{{Tok: token.LITERAL, Lit: "[prometheus.relabel.default.receiver]"}})
+	require.True(t, f.Body().InsertBlockAfter(nil, scrape))
+
+	expect := format(t, `
+		prometheus.scrape "default" {
+			forward_to = [prometheus.relabel.default.receiver]
+		}
+
+		// Where to send metrics.
+		prometheus.remote_write "default" {
+			endpoint {
+				url = "http://mimir:9009/api/prom/push" // Local Mimir.
+			}
+		}
+
+		prometheus.relabel "default" {
+			forward_to = [prometheus.remote_write.default.receiver]
+
+			// Keep all samples.
+			rule {
+				action = "keep"
+			}
+
+			rule {
+				action = "drop"
+			}
+		}
+	`)
+	require.Equal(t, expect, string(f.Bytes()))
+}
+
+func TestBody_FindBlock(t *testing.T) {
+	f, err := builder.ParseFile(t.Name(), []byte(`
+		attr = 1
+
+		prometheus.relabel "default" {
+			rule { action = "keep" }
+			rule { action = "drop" }
+		}
+	`))
+	require.NoError(t, err)
+
+	require.NotNil(t, f.Body().FindBlock(`prometheus.relabel "default"`))
+	require.Nil(t, f.Body().FindBlock("prometheus.relabel"))
+	require.Nil(t, f.Body().FindBlock("attr"))
+	require.Nil(t, f.Body().FindBlock(`prometheus.relabel "default"`, "rule#2"))
+
+	rule := f.Body().FindBlock(`prometheus.relabel "default"`, "rule#1")
+	require.NotNil(t, rule)
+	require.True(t, rule.Body().RemoveAttribute("action"))
+	require.False(t, rule.Body().RemoveAttribute("action"))
+
+	err = f.Body().SetAttributeValueAt([]string{`prometheus.relabel "other"`, "rule", "action"}, "keep")
+	require.EqualError(t, err, `block prometheus.relabel "other" / rule not found`)
+}
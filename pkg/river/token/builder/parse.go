@@ -0,0 +1,159 @@
+package builder
+
+import (
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// ParseFile parses the River file src into a File which can be modified and
+// written back out. Comments and blank lines in src are kept alongside the
+// statements they belong to: comments directly before a statement or on its
+// last line are removed when the statement is removed, and an attribute keeps
+// its comments when its value changes.
+//
+// The values of attributes are kept as written in src until they are changed
+// with SetAttributeValue or SetAttributeTokens.
+func ParseFile(filename string, src []byte) (*File, error) {
+	f, err := parser.ParseFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{src: src}
+	for _, cg := range f.Comments {
+		l.comments = append(l.comments, cg...)
+	}
+
+	file := NewFile()
+	l.loadBody(file.body, f.Body, 0, len(src))
+	return file, nil
+}
+
+// loader converts a parsed file into builder types. Comments are consumed in
+// source order as the statements around them are loaded.
+type loader struct {
+	src      []byte
+	comments []*ast.Comment
+}
+
+// loadBody loads stmts into body. prevLine is the line preceding the first
+// statement, used to detect blank lines, or 0 at the start of a file. Comments
+// before the offset end which don't belong to any statement are kept at the
+// end of body.
+func (l *loader) loadBody(body *Body, stmts ast.Body, prevLine int, end int) {
+	for _, stmt := range stmts {
+		var comments stmtComments
+		comments.leading, prevLine = l.commentTokens(ast.StartPos(stmt).Offset(), prevLine)
+
+		startLine := ast.StartPos(stmt).Position().Line
+		if len(comments.leading) > 0 {
+			comments.leading = append(comments.leading, Token{Tok: token.LITERAL, Lit: "\n"})
+		}
+		if prevLine > 0 && startLine > prevLine+1 {
+			comments.leading = append(comments.leading, Token{Tok: token.LITERAL, Lit: "\n"})
+		}
+
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			body.nodes = append(body.nodes, l.loadAttribute(stmt, comments))
+		case *ast.BlockStmt:
+			body.nodes = append(body.nodes, l.loadBlock(stmt, comments))
+		}
+
+		endLine := ast.EndPos(stmt).Position().Line
+		trailing, lastLine := l.lineComments(endLine, end)
+		setTrailing(body.nodes[len(body.nodes)-1], trailing)
+		prevLine = lastLine
+	}
+
+	body.trailing, _ = l.commentTokens(end, prevLine)
+}
+
+func (l *loader) loadAttribute(stmt *ast.AttributeStmt, comments stmtComments) *attribute {
+	// Comments between the name of the attribute and the end of its value are
+	// kept as part of the value.
+	start := ast.StartPos(stmt.Value).Offset()
+	if len(l.comments) > 0 && l.comments[0].StartPos.Offset() < start {
+		start = l.comments[0].StartPos.Offset()
+	}
+	end := ast.EndPos(stmt.Value).Offset() + 1
+	l.skipComments(end)
+
+	return &attribute{
+		Name:      stmt.Name.Name,
+		RawTokens: []Token{{Tok: token.LITERAL, Lit: string(l.src[start:end])}},
+		comments:  comments,
+	}
+}
+
+func (l *loader) loadBlock(stmt *ast.BlockStmt, comments stmtComments) *Block {
+	block := NewBlock(stmt.Name, stmt.Label)
+	block.comments = comments
+
+	// Comments inside the block header are moved after the opening curly
+	// brace.
+	lcurlyLine := stmt.LCurlyPos.Position().Line
+	bodyStart := stmt.RCurlyPos.Offset()
+	if len(stmt.Body) > 0 {
+		bodyStart = ast.StartPos(stmt.Body[0]).Offset()
+	}
+	for len(l.comments) > 0 && l.comments[0].StartPos.Offset() < bodyStart && l.comments[0].StartPos.Position().Line <= lcurlyLine {
+		block.lcurlyComments = append(block.lcurlyComments, Token{Tok: token.LITERAL, Lit: " "}, Token{Tok: token.COMMENT, Lit: l.comments[0].Text})
+		lcurlyLine = ast.EndPos(l.comments[0]).Position().Line
+		l.comments = l.comments[1:]
+	}
+
+	l.loadBody(block.body, stmt.Body, lcurlyLine, stmt.RCurlyPos.Offset())
+	return block
+}
+
+// commentTokens consumes the comments before the offset end and returns them
+// as tokens, along with the last line holding a comment. Comments are
+// separated by newlines, and blank lines between prevLine and the comments
+// are kept.
+func (l *loader) commentTokens(end int, prevLine int) ([]Token, int) {
+	var toks []Token
+	for len(l.comments) > 0 && l.comments[0].StartPos.Offset() < end {
+		c := l.comments[0]
+		l.comments = l.comments[1:]
+
+		if len(toks) > 0 {
+			toks = append(toks, Token{Tok: token.LITERAL, Lit: "\n"})
+		}
+		if line := c.StartPos.Position().Line; prevLine > 0 && line > prevLine+1 {
+			toks = append(toks, Token{Tok: token.LITERAL, Lit: "\n"})
+		}
+		toks = append(toks, Token{Tok: token.COMMENT, Lit: c.Text})
+		prevLine = ast.EndPos(c).Position().Line
+	}
+	return toks, prevLine
+}
+
+// lineComments consumes the comments before the offset end which start on
+// line and returns them as tokens, along with the last line holding a comment.
+func (l *loader) lineComments(line int, end int) ([]Token, int) {
+	var toks []Token
+	for len(l.comments) > 0 && l.comments[0].StartPos.Offset() < end && l.comments[0].StartPos.Position().Line == line {
+		toks = append(toks, Token{Tok: token.LITERAL, Lit: " "}, Token{Tok: token.COMMENT, Lit: l.comments[0].Text})
+		line = ast.EndPos(l.comments[0]).Position().Line
+		l.comments = l.comments[1:]
+	}
+	return toks, line
+}
+
+// skipComments consumes the comments before the offset end.
+func (l *loader) skipComments(end int) {
+	for len(l.comments) > 0 && l.comments[0].StartPos.Offset() < end {
+		l.comments = l.comments[1:]
+	}
+}
+
+func setTrailing(n tokenNode, toks []Token) {
+	switch n := n.(type) {
+	case *attribute:
+		n.comments.trailing = toks
+	case *Block:
+		n.comments.trailing = toks
+	}
+}
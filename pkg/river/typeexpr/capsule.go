@@ -0,0 +1,30 @@
+package typeexpr
+
+import "reflect"
+
+// Capsules maps the names used in capsule(name) type expressions to Go types.
+// If a type is an interface type, capsule(name) matches every value which
+// implements it.
+type Capsules map[string]reflect.Type
+
+// capsuleMatches reports whether a Go value of type t matches a capsule type
+// expression. If registered is nil, the capsule only matches if name is the
+// name of t, or the name of the type t points to.
+func capsuleMatches(name string, registered, t reflect.Type) bool {
+	if registered != nil {
+		if registered.Kind() == reflect.Interface {
+			return t.Implements(registered)
+		}
+		return t.AssignableTo(registered)
+	}
+
+	for {
+		if t.String() == name {
+			return true
+		}
+		if t.Kind() != reflect.Pointer {
+			return false
+		}
+		t = t.Elem()
+	}
+}
@@ -0,0 +1,140 @@
+package typeexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	"github.com/grafana/regexp"
+)
+
+// Constraints restrict the values accepted beyond their type. The zero value
+// accepts every value.
+type Constraints struct {
+	// Regex is a regular expression which strings and secrets must match. The
+	// expression is anchored to both ends of the string.
+	Regex string
+
+	// Min and Max bound numbers, the number of characters in strings, and the
+	// number of elements in lists and maps.
+	Min, Max *float64
+
+	// AllowedValues, if not empty, holds the only values which are accepted.
+	AllowedValues []interface{}
+}
+
+// Validate returns an error if c is invalid, such as when Regex doesn't
+// compile.
+func (c Constraints) Validate() error {
+	if _, err := c.compileRegex(); err != nil {
+		return err
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return fmt.Errorf("min (%v) must not be greater than max (%v)", *c.Min, *c.Max)
+	}
+	return nil
+}
+
+func (c Constraints) compileRegex() (*regexp.Regexp, error) {
+	if c.Regex == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + c.Regex + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return re, nil
+}
+
+// Check returns an error if the Go value v doesn't satisfy c. Errors never
+// include the contents of secrets.
+func (c Constraints) Check(v interface{}) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	val := value.Encode(v)
+
+	if len(c.AllowedValues) > 0 && !c.allowed(val) {
+		return fmt.Errorf("must be one of %s", describeValues(c.AllowedValues))
+	}
+
+	if re, _ := c.compileRegex(); re != nil {
+		text, ok := stringValue(val)
+		if !ok {
+			return fmt.Errorf("regex can only be used with strings and secrets, got %s", val.Describe())
+		}
+		if !re.MatchString(text) {
+			return fmt.Errorf("must match regex %q", c.Regex)
+		}
+	}
+
+	if c.Min != nil || c.Max != nil {
+		return c.checkBounds(val)
+	}
+	return nil
+}
+
+func (c Constraints) allowed(v value.Value) bool {
+	for _, allowed := range c.AllowedValues {
+		if value.Equal(value.Encode(allowed), v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraints) checkBounds(v value.Value) error {
+	var (
+		n    float64
+		what string
+	)
+	switch v.Type() {
+	case value.TypeNumber:
+		n, what = v.Float(), "value"
+	case value.TypeArray, value.TypeObject:
+		n, what = float64(v.Len()), "number of elements"
+	default:
+		text, ok := stringValue(v)
+		if !ok {
+			return fmt.Errorf("min and max can only be used with numbers, strings, secrets, lists, and maps, got %s", v.Describe())
+		}
+		n, what = float64(utf8.RuneCountInString(text)), "length"
+	}
+
+	if c.Min != nil && n < *c.Min {
+		return fmt.Errorf("%s must be at least %v", what, *c.Min)
+	}
+	if c.Max != nil && n > *c.Max {
+		return fmt.Errorf("%s must be at most %v", what, *c.Max)
+	}
+	return nil
+}
+
+// stringValue returns the text held by a string or secret.
+func stringValue(v value.Value) (string, bool) {
+	switch v.Type() {
+	case value.TypeString:
+		return v.Text(), true
+	case value.TypeCapsule:
+		switch s := v.Interface().(type) {
+		case rivertypes.Secret:
+			return string(s), true
+		case rivertypes.OptionalSecret:
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+func describeValues(vv []interface{}) string {
+	parts := make([]string, len(vv))
+	for i, v := range vv {
+		expr := builder.NewExpr()
+		expr.SetValue(v)
+		parts[i] = string(expr.Bytes())
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,230 @@
+// Package typeexpr implements type expressions which describe the expected
+// shape of a River value, such as list(map(string)).
+//
+// Type expressions are written using River syntax. The following types are
+// supported:
+//
+//   - any: any value, including null.
+//   - string, number, bool, function: values of the matching River type.
+//   - secret: a string or a secret.
+//   - list(T): an array where every element is of type T. list is shorthand
+//     for list(any).
+//   - map(T): an object where every value is of type T. map is shorthand for
+//     map(any).
+//   - capsule("NAME"): a capsule holding a Go value whose type is NAME, or
+//     which matches the type passed as NAME in the Capsules given to Parse.
+package typeexpr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+type kind int
+
+const (
+	kindAny kind = iota
+	kindString
+	kindNumber
+	kindBool
+	kindFunction
+	kindSecret
+	kindList
+	kindMap
+	kindCapsule
+)
+
+var simpleKinds = map[string]kind{
+	"any":      kindAny,
+	"string":   kindString,
+	"number":   kindNumber,
+	"bool":     kindBool,
+	"function": kindFunction,
+	"secret":   kindSecret,
+	"list":     kindList,
+	"map":      kindMap,
+}
+
+// Type is a parsed type expression. The zero value is the any type.
+type Type struct {
+	kind        kind
+	elem        *Type        // Element type for lists and maps.
+	capsule     string       // Name of the Go type for capsules.
+	capsuleType reflect.Type // Go type registered for the capsule name, if any.
+}
+
+// Any is the type which matches every value.
+var Any = Type{kind: kindAny}
+
+// Parse parses a type expression, such as list(map(string)). Capsule names
+// found in capsules match the Go type they're mapped to; capsules may be nil.
+func Parse(expr string, capsules Capsules) (Type, error) {
+	e, err := parser.ParseExpression(expr)
+	if err != nil {
+		return Any, err
+	}
+	return fromExpr(e, capsules)
+}
+
+func fromExpr(e ast.Expr, capsules Capsules) (Type, error) {
+	switch e := e.(type) {
+	case *ast.IdentifierExpr:
+		k, ok := simpleKinds[e.Ident.Name]
+		if !ok {
+			return Any, fmt.Errorf("unknown type %q", e.Ident.Name)
+		}
+		if k == kindList || k == kindMap {
+			return Type{kind: k, elem: &Any}, nil
+		}
+		return Type{kind: k}, nil
+
+	case *ast.CallExpr:
+		ident, ok := e.Value.(*ast.IdentifierExpr)
+		if !ok {
+			return Any, fmt.Errorf("unsupported type expression")
+		}
+		name := ident.Ident.Name
+		if len(e.Args) != 1 {
+			return Any, fmt.Errorf("%s expects 1 argument, got %d", name, len(e.Args))
+		}
+
+		switch name {
+		case "list", "map":
+			elem, err := fromExpr(e.Args[0], capsules)
+			if err != nil {
+				return Any, err
+			}
+			return Type{kind: simpleKinds[name], elem: &elem}, nil
+		case "capsule":
+			lit, ok := e.Args[0].(*ast.LiteralExpr)
+			if !ok || lit.Kind != token.STRING {
+				return Any, fmt.Errorf("capsule expects a string argument")
+			}
+			capsuleName, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return Any, err
+			}
+			return Type{kind: kindCapsule, capsule: capsuleName, capsuleType: capsules[capsuleName]}, nil
+		default:
+			return Any, fmt.Errorf("unknown type %q", name)
+		}
+
+	default:
+		return Any, fmt.Errorf("unsupported type expression")
+	}
+}
+
+// String returns the type expression for t.
+func (t Type) String() string {
+	switch t.kind {
+	case kindList:
+		return fmt.Sprintf("list(%s)", t.elem)
+	case kindMap:
+		return fmt.Sprintf("map(%s)", t.elem)
+	case kindCapsule:
+		return fmt.Sprintf("capsule(%q)", t.capsule)
+	}
+	for name, k := range simpleKinds {
+		if k == t.kind {
+			return name
+		}
+	}
+	return "any"
+}
+
+// Check returns an error if the Go value v doesn't match t. Values aren't
+// converted between types, so a number doesn't match the string type.
+func (t Type) Check(v interface{}) error {
+	return t.check(value.Encode(v), "")
+}
+
+func (t Type) check(v value.Value, path string) error {
+	switch t.kind {
+	case kindAny:
+		return nil
+	case kindString:
+		return t.checkType(v, path, value.TypeString)
+	case kindNumber:
+		return t.checkType(v, path, value.TypeNumber)
+	case kindBool:
+		return t.checkType(v, path, value.TypeBool)
+	case kindFunction:
+		return t.checkType(v, path, value.TypeFunction)
+
+	case kindSecret:
+		if v.Type() == value.TypeString {
+			return nil
+		}
+		if v.Type() == value.TypeCapsule {
+			switch v.Interface().(type) {
+			case rivertypes.Secret, rivertypes.OptionalSecret:
+				return nil
+			}
+		}
+		return t.mismatch(v, path)
+
+	case kindList:
+		if err := t.checkType(v, path, value.TypeArray); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := t.elem.check(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case kindMap:
+		if err := t.checkType(v, path, value.TypeObject); err != nil {
+			return err
+		}
+		keys := v.Keys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			elem, _ := v.Key(key)
+			if err := t.elem.check(elem, fmt.Sprintf("%s[%q]", path, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case kindCapsule:
+		if v.Type() != value.TypeCapsule {
+			return t.mismatch(v, path)
+		}
+		// Values are dereferenced when encoded; take the address again so that
+		// methods with pointer receivers are considered.
+		rv := v.Reflect()
+		if rv.CanAddr() {
+			rv = rv.Addr()
+		}
+		if !capsuleMatches(t.capsule, t.capsuleType, rv.Type()) {
+			return t.mismatch(v, path)
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func (t Type) checkType(v value.Value, path string, expect value.Type) error {
+	if v.Type() != expect {
+		return t.mismatch(v, path)
+	}
+	return nil
+}
+
+func (t Type) mismatch(v value.Value, path string) error {
+	if path == "" {
+		return fmt.Errorf("expected %s, got %s", t, v.Describe())
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, t, v.Describe())
+}
@@ -0,0 +1,207 @@
+package typeexpr_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/typeexpr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect string
+	}{
+		{"any", "any"},
+		{"string", "string"},
+		{"secret", "secret"},
+		{"list", "list(any)"},
+		{"map", "map(any)"},
+		{"list(map(string))", "list(map(string))"},
+		{`capsule("storage.Appendable")`, `capsule("storage.Appendable")`},
+		{`map(list(capsule("foo")))`, `map(list(capsule("foo")))`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			ty, err := typeexpr.Parse(tc.input, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, ty.String())
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect string
+	}{
+		{"strin", `unknown type "strin"`},
+		{"list(string, number)", "list expects 1 argument, got 2"},
+		{"capsule(foo)", "capsule expects a string argument"},
+		{"tuple(string)", `unknown type "tuple"`},
+		{`"string"`, "unsupported type expression"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := typeexpr.Parse(tc.input, nil)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
+
+type appender interface{ Append(string) }
+
+type testAppender struct{}
+
+func (*testAppender) Append(string) {}
+
+var testCapsules = typeexpr.Capsules{
+	"test.Appender": reflect.TypeOf((*appender)(nil)).Elem(),
+}
+
+func TestType_Check(t *testing.T) {
+	targets := []map[string]string{{"__address__": "localhost:12345"}}
+
+	tt := []struct {
+		ty     string
+		input  interface{}
+		expect string
+	}{
+		{"any", nil, ""},
+		{"string", "foo", ""},
+		{"string", 5, "expected string, got number"},
+		{"string", nil, "expected string, got null"},
+		{"number", 5.5, ""},
+		{"bool", true, ""},
+		{"secret", "foo", ""},
+		{"secret", rivertypes.Secret("foo"), ""},
+		{"secret", rivertypes.OptionalSecret{Value: "foo"}, ""},
+		{"string", rivertypes.Secret("foo"), `expected string, got capsule("rivertypes.Secret")`},
+		{"list(map(string))", targets, ""},
+		{"list(map(string))", []interface{}{map[string]interface{}{"a": 1}}, `[0]["a"]: expected string, got number`},
+		{"list(map(string))", "localhost:12345", "expected list(map(string)), got string"},
+		{"map(number)", map[string]interface{}{"a": 1, "b": "2"}, `["b"]: expected number, got string`},
+		{`capsule("test.Appender")`, &testAppender{}, ""},
+		{`list(capsule("test.Appender"))`, []interface{}{"foo"}, `[0]: expected capsule("test.Appender"), got string`},
+		{`capsule("typeexpr_test.testAppender")`, &testAppender{}, ""},
+		{`capsule("other.Type")`, &testAppender{}, `expected capsule("other.Type"), got capsule("typeexpr_test.testAppender")`},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%s/%v", tc.ty, tc.input), func(t *testing.T) {
+			ty, err := typeexpr.Parse(tc.ty, testCapsules)
+			require.NoError(t, err)
+
+			err = ty.Check(tc.input)
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expect)
+			}
+		})
+	}
+}
+
+func TestConstraints_Check(t *testing.T) {
+	floatPtr := func(f float64) *float64 { return &f }
+
+	tt := []struct {
+		name        string
+		constraints typeexpr.Constraints
+		input       interface{}
+		expect      string
+	}{
+		{
+			name:        "regex match",
+			constraints: typeexpr.Constraints{Regex: "https?://.+"},
+			input:       "http://localhost",
+		},
+		{
+			name:        "regex is anchored",
+			constraints: typeexpr.Constraints{Regex: "https?://.+"},
+			input:       "tcp://http://localhost",
+			expect:      `must match regex "https?://.+"`,
+		},
+		{
+			name:        "regex on secret",
+			constraints: typeexpr.Constraints{Regex: "[a-z]+"},
+			input:       rivertypes.Secret("Hunter2"),
+			expect:      `must match regex "[a-z]+"`,
+		},
+		{
+			name:        "regex on number",
+			constraints: typeexpr.Constraints{Regex: "[0-9]+"},
+			input:       5,
+			expect:      "regex can only be used with strings and secrets, got number",
+		},
+		{
+			name:        "min number",
+			constraints: typeexpr.Constraints{Min: floatPtr(1)},
+			input:       0.5,
+			expect:      "value must be at least 1",
+		},
+		{
+			name:        "max number",
+			constraints: typeexpr.Constraints{Max: floatPtr(10)},
+			input:       10,
+		},
+		{
+			name:        "min list",
+			constraints: typeexpr.Constraints{Min: floatPtr(1)},
+			input:       []string{},
+			expect:      "number of elements must be at least 1",
+		},
+		{
+			name:        "max string",
+			constraints: typeexpr.Constraints{Max: floatPtr(3)},
+			input:       "abcd",
+			expect:      "length must be at most 3",
+		},
+		{
+			name:        "bounds on bool",
+			constraints: typeexpr.Constraints{Max: floatPtr(3)},
+			input:       true,
+			expect:      "min and max can only be used with numbers, strings, secrets, lists, and maps, got bool",
+		},
+		{
+			name:        "allowed values",
+			constraints: typeexpr.Constraints{AllowedValues: []interface{}{"debug", "info"}},
+			input:       "info",
+		},
+		{
+			name:        "allowed numbers of different kinds",
+			constraints: typeexpr.Constraints{AllowedValues: []interface{}{1, 2}},
+			input:       2.0,
+		},
+		{
+			name:        "disallowed value",
+			constraints: typeexpr.Constraints{AllowedValues: []interface{}{"debug", "info"}},
+			input:       "trace",
+			expect:      `must be one of ["debug", "info"]`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.constraints.Check(tc.input)
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expect)
+			}
+		})
+	}
+}
+
+func TestConstraints_Validate(t *testing.T) {
+	one, two := 1.0, 2.0
+
+	require.NoError(t, typeexpr.Constraints{}.Validate())
+	require.EqualError(t, typeexpr.Constraints{Regex: "("}.Validate(), "invalid regex: error parsing regexp: missing closing ): `^(?:()$`")
+	require.EqualError(t, typeexpr.Constraints{Min: &two, Max: &one}.Validate(), "min (2) must not be greater than max (1)")
+}
@@ -0,0 +1,210 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/scanner"
+)
+
+// MaxCallDepth is the maximum number of nested calls to user-defined
+// functions permitted while evaluating an expression. Calls beyond this depth
+// fail, which stops unbounded recursion.
+const MaxCallDepth = 100
+
+// A Function is a pure function written in River. Functions are defined with
+// a function block, where the block label is the name of the function:
+//
+//	function "add" {
+//	  params = ["a", "b"]
+//	  result = a + b
+//	}
+//
+// The result expression is evaluated each time the function is called, with
+// each parameter bound to the argument in the same position.
+type Function struct {
+	Name   string   // Name of the function.
+	Params []string // Names of the parameters of the function.
+	Result ast.Expr // Expression which computes the result.
+}
+
+// NewFunction creates a Function from a function block. The block must have a
+// label, which is used as the name of the function, and may only contain the
+// params and result attributes. params must be an array of strings and is
+// evaluated without any variables in scope.
+func NewFunction(block *ast.BlockStmt) (*Function, diag.Diagnostics) {
+	var (
+		diags diag.Diagnostics
+		fn    = &Function{Name: block.Label}
+	)
+
+	if block.Label == "" {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  "function blocks must have a label",
+			StartPos: ast.StartPos(block).Position(),
+			EndPos:   block.NamePos.Add(len(block.GetBlockName()) - 1).Position(),
+		})
+	}
+
+	var paramsAttr *ast.AttributeStmt
+	for _, stmt := range block.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "function blocks only support the params and result attributes",
+				StartPos: ast.StartPos(stmt).Position(),
+				EndPos:   ast.EndPos(stmt).Position(),
+			})
+			continue
+		}
+
+		switch attr.Name.Name {
+		case "params":
+			paramsAttr = attr
+			if err := New(attr.Value).Evaluate(nil, &fn.Params); err != nil {
+				diags = append(diags, errorToDiagnostics(err, attr.Value)...)
+			}
+		case "result":
+			fn.Result = attr.Value
+		default:
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("unrecognized attribute name %q", attr.Name.Name),
+				StartPos: ast.StartPos(attr.Name).Position(),
+				EndPos:   ast.EndPos(attr.Name).Position(),
+			})
+		}
+	}
+
+	if fn.Result == nil {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("missing required attribute \"result\" in function %q", fn.Name),
+			StartPos: ast.StartPos(block).Position(),
+			EndPos:   ast.EndPos(block).Position(),
+		})
+	}
+
+	seen := make(map[string]struct{}, len(fn.Params))
+	for _, param := range fn.Params {
+		var message string
+		if _, dup := seen[param]; dup {
+			message = fmt.Sprintf("parameter %q is defined more than once", param)
+		} else if !scanner.IsValidIdentifier(param) {
+			message = fmt.Sprintf("parameter %q must be a valid identifier", param)
+		}
+		seen[param] = struct{}{}
+
+		if message != "" {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  message,
+				StartPos: ast.StartPos(paramsAttr.Value).Position(),
+				EndPos:   ast.EndPos(paramsAttr.Value).Position(),
+			})
+		}
+	}
+
+	return fn, diags
+}
+
+// errorToDiagnostics converts an error returned by Evaluate into diagnostics,
+// using the position of node for errors which don't have one.
+func errorToDiagnostics(err error, node ast.Node) diag.Diagnostics {
+	switch err := err.(type) {
+	case diag.Diagnostic:
+		return diag.Diagnostics{err}
+	case diag.Diagnostics:
+		return err
+	default:
+		return diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			Message:  err.Error(),
+			StartPos: ast.StartPos(node).Position(),
+			EndPos:   ast.EndPos(node).Position(),
+		}}
+	}
+}
+
+// NewFunctionScope returns a Scope which exposes each of fns as a variable
+// named after the function. parent is the parent of the returned Scope.
+//
+// The result expressions of fns are evaluated in a child of the returned
+// Scope, so functions may call themselves and any of the other functions in
+// fns. Callers which want functions to be pure should use a parent which
+// holds no variables.
+func NewFunctionScope(parent *Scope, fns []*Function) *Scope {
+	scope := &Scope{
+		Parent:    parent,
+		Variables: make(map[string]interface{}, len(fns)),
+	}
+	for _, fn := range fns {
+		scope.Variables[fn.Name] = &boundFunction{fn: fn, scope: scope}
+	}
+	return scope
+}
+
+// NewFunctionNamespace returns an object which exposes each of fns as a field
+// named after the function. The object can be used as the value of a
+// variable so that the functions are called as fields of that variable. As
+// with NewFunctionScope, the functions may call themselves and any of the
+// other functions in fns, but no other variables are in scope.
+func NewFunctionNamespace(fns []*Function) map[string]interface{} {
+	scope := NewFunctionScope(nil, fns)
+
+	ns := make(map[string]interface{}, len(fns))
+	for name, fn := range scope.Variables {
+		// Functions in the namespace can't call functions outside of it, so
+		// calls into the namespace can start counting their depth at zero
+		// without allowing unbounded recursion.
+		ns[name] = fn.(*boundFunction).Value(0)
+	}
+	return ns
+}
+
+// boundFunction is a Function bound to the Scope it was defined in.
+type boundFunction struct {
+	fn    *Function
+	scope *Scope
+}
+
+// Value returns a River function which calls bf. depth is the call depth of
+// the scope bf was looked up from.
+func (bf *boundFunction) Value(depth int) value.RawFunction {
+	return func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		if len(args) != len(bf.fn.Params) {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("expected %d args, got %d", len(bf.fn.Params), len(args)),
+			}
+		}
+		if depth >= MaxCallDepth {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("exceeded the maximum call depth of %d", MaxCallDepth),
+			}
+		}
+
+		callScope := &Scope{
+			Parent:    bf.scope,
+			Variables: make(map[string]interface{}, len(args)),
+			callDepth: depth + 1,
+		}
+		for i, param := range bf.fn.Params {
+			callScope.Variables[param] = args[i]
+		}
+
+		// The result is evaluated with its own set of associated nodes so that
+		// errors within the function point at the function body.
+		assoc := make(map[value.Value]ast.Node)
+		res, err := New(bf.fn.Result).evaluateExpr(callScope, assoc, bf.fn.Result)
+		if err != nil {
+			return value.Null, makeDiagnostic(err, assoc)
+		}
+		return res, nil
+	}
+}
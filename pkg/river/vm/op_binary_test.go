@@ -61,13 +61,24 @@ func TestVM_OptionalSecret_Conversion(t *testing.T) {
 			expect: bool(false),
 		},
 		{
-			name:        "secret + string",
-			input:       `secret_val + string_val`,
-			expectError: "secret_val should be one of [number string] for binop +",
+			name:   "secret + string",
+			input:  `secret_val + string_val`,
+			expect: rivertypes.Secret("secrethello"),
+		},
+		{
+			name:   "string + secret",
+			input:  `string_val + secret_val`,
+			expect: rivertypes.Secret("hellosecret"),
 		},
 		{
-			name:        "string + secret",
+			name:        "string + secret into string",
 			input:       `string_val + secret_val`,
+			expect:      string(""),
+			expectError: "secrets may not be converted into strings",
+		},
+		{
+			name:        "secret + number",
+			input:       `secret_val + 1`,
 			expectError: "secret_val should be one of [number string] for binop +",
 		},
 	}
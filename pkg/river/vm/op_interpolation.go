@@ -0,0 +1,71 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// evaluateInterpolation evaluates a string which embeds the values of
+// expressions. Strings, numbers, bools, and secrets may be embedded. If any
+// embedded value is a secret, the resulting string is also a secret.
+func (vm *Evaluator) evaluateInterpolation(scope *Scope, assoc map[value.Value]ast.Node, expr *ast.InterpolationExpr) (value.Value, error) {
+	var (
+		sb       strings.Builder
+		isSecret bool
+	)
+
+	for i, text := range expr.Strings {
+		s, err := strconv.Unquote(`"` + text + `"`)
+		if err != nil {
+			return value.Null, err
+		}
+		sb.WriteString(s)
+
+		if i >= len(expr.Exprs) {
+			break
+		}
+
+		val, err := vm.evaluateExpr(scope, assoc, expr.Exprs[i])
+		if err != nil {
+			return value.Null, err
+		}
+		s, secret, err := interpolatedText(val)
+		if err != nil {
+			return value.Null, err
+		}
+		sb.WriteString(s)
+		isSecret = isSecret || secret
+	}
+
+	if isSecret {
+		return value.Encapsulate(rivertypes.Secret(sb.String())), nil
+	}
+	return value.String(sb.String()), nil
+}
+
+// interpolatedText returns the text to embed in a string for val. isSecret
+// reports whether val was a secret.
+func interpolatedText(val value.Value) (text string, isSecret bool, err error) {
+	switch val.Type() {
+	case value.TypeString:
+		return val.Text(), false, nil
+	case value.TypeNumber:
+		return val.Number().ToString(), false, nil
+	case value.TypeBool:
+		return strconv.FormatBool(val.Bool()), false, nil
+	case value.TypeCapsule:
+		if text, isSecret, ok := secretText(val); ok {
+			return text, isSecret, nil
+		}
+	}
+
+	return "", false, value.Error{
+		Value: val,
+		Inner: fmt.Errorf("cannot embed %s in a string", val.Describe()),
+	}
+}
@@ -0,0 +1,68 @@
+package vm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVM_Interpolation(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]any{
+			"host":     "localhost",
+			"port":     12345,
+			"tls":      true,
+			"password": rivertypes.Secret("hunter2"),
+			"optional": rivertypes.OptionalSecret{Value: "plain"},
+		},
+	}
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"strings and numbers", `"http://\(host):\(port)/metrics"`, "http://localhost:12345/metrics"},
+		{"bools", `"tls=\(tls)"`, "tls=true"},
+		{"expressions", `"\(port + 1)"`, "12346"},
+		{"function calls", `"\(to_upper(host))"`, "LOCALHOST"},
+		{"nested strings", `"\("\(host)-\(port)")"`, "localhost-12345"},
+		{"escapes", `"\t\(host)\\(port)"`, "\tlocalhost\\(port)"},
+		{"optional secret holding a string", `"user:\(optional)"`, "user:plain"},
+		{"secrets", `"user:\(password)"`, rivertypes.Secret("user:hunter2")},
+		{"secrets into optional secrets", `"user:\(password)"`, rivertypes.OptionalSecret{Value: "user:hunter2", IsSecret: true}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, vm.New(expr).Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+
+	t.Run("secrets can't be used as strings", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`"user:\(password)"`)
+		require.NoError(t, err)
+
+		var out string
+		err = vm.New(expr).Evaluate(scope, &out)
+		require.EqualError(t, err, `1:1: "user:\(password)" secrets may not be converted into strings`)
+	})
+
+	t.Run("values which can't be embedded", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`"list: \([1, 2])"`)
+		require.NoError(t, err)
+
+		var out string
+		err = vm.New(expr).Evaluate(scope, &out)
+		require.EqualError(t, err, `1:10: [1, 2] cannot embed array in a string`)
+	})
+}
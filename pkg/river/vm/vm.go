@@ -304,6 +304,9 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 	case *ast.LiteralExpr:
 		return valueFromLiteral(expr.Value, expr.Kind)
 
+	case *ast.InterpolationExpr:
+		return vm.evaluateInterpolation(scope, assoc, expr)
+
 	case *ast.BinaryExpr:
 		lhs, err := vm.evaluateExpr(scope, assoc, expr.Left)
 		if err != nil {
@@ -347,6 +350,9 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 				Message:  fmt.Sprintf("identifier %q does not exist", expr.Ident.Name),
 			}
 		}
+		if fn, ok := val.(*boundFunction); ok {
+			return value.Encode(fn.Value(scope.depth())), nil
+		}
 		return value.Encode(val), nil
 
 	case *ast.AccessExpr:
@@ -471,6 +477,19 @@ type Scope struct {
 	// Evaluate; maps and slices will be copied by reference for performance
 	// optimizations.
 	Variables map[string]interface{}
+
+	// callDepth is the number of nested calls to user-defined functions which
+	// led to this scope. It is only set for scopes created by function calls.
+	callDepth int
+}
+
+// depth returns the number of nested calls to user-defined functions which
+// led to s.
+func (s *Scope) depth() int {
+	if s == nil {
+		return 0
+	}
+	return s.callDepth
 }
 
 // Lookup looks up a named identifier from the scope, all of the scope's
@@ -0,0 +1,242 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVM_Functions(t *testing.T) {
+	scope := parseFunctions(t, `
+		function "add" {
+			params = ["a", "b"]
+			result = a + b
+		}
+
+		function "double" {
+			params = ["n"]
+			result = add(n, n)
+		}
+
+		function "greeting" {
+			result = "Hello, " + env("TEST_NAME") + "!"
+		}
+
+		function "pick" {
+			params = ["obj", "key"]
+			result = obj[key]
+		}
+	`)
+	t.Setenv("TEST_NAME", "world")
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"call", `add(1, 2)`, 3},
+		{"nested calls", `double(double(3))`, 12},
+		{"no params", `greeting()`, "Hello, world!"},
+		{"object param", `pick({a = "x", b = "y"}, "b")`, "y"},
+		{"function as value", `[add][0](2, 3)`, 5},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var actual interface{}
+			require.NoError(t, vm.New(expr).Evaluate(scope, &actual))
+			require.EqualValues(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestVM_Functions_Scope(t *testing.T) {
+	parent := &vm.Scope{
+		Variables: map[string]interface{}{"offset": 10},
+	}
+	scope := vm.NewFunctionScope(parent, parseFunctionList(t, `
+		function "shift" {
+			params = ["n"]
+			result = n + offset
+		}
+	`))
+
+	// Parameters shadow variables of the same name in outer scopes.
+	child := &vm.Scope{
+		Parent:    scope,
+		Variables: map[string]interface{}{"n": 100},
+	}
+
+	expr, err := parser.ParseExpression(`shift(1) + n`)
+	require.NoError(t, err)
+
+	var actual int
+	require.NoError(t, vm.New(expr).Evaluate(child, &actual))
+	require.Equal(t, 111, actual)
+}
+
+func TestVM_Functions_Namespace(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]interface{}{
+			"offset": 10,
+			"lib": vm.NewFunctionNamespace(parseFunctionList(t, `
+				function "add" {
+					params = ["a", "b"]
+					result = a + b
+				}
+
+				function "double" {
+					params = ["n"]
+					result = add(n, n)
+				}
+
+				function "shift" {
+					params = ["n"]
+					result = n + offset
+				}
+			`)),
+		},
+	}
+
+	expr, err := parser.ParseExpression(`lib.double(lib.add(1, 2)) + offset`)
+	require.NoError(t, err)
+
+	var actual int
+	require.NoError(t, vm.New(expr).Evaluate(scope, &actual))
+	require.Equal(t, 16, actual)
+
+	// Functions in a namespace can't reference variables outside of it.
+	expr, err = parser.ParseExpression(`lib.shift(1)`)
+	require.NoError(t, err)
+	require.ErrorContains(t, vm.New(expr).Evaluate(scope, &actual), `identifier "offset" does not exist`)
+}
+
+func TestVM_Functions_Errors(t *testing.T) {
+	scope := parseFunctions(t, `
+		function "add" {
+			params = ["a", "b"]
+			result = a + b
+		}
+
+		function "forever" {
+			params = ["n"]
+			result = forever(n + 1)
+		}
+
+		function "ping" {
+			result = pong()
+		}
+
+		function "pong" {
+			result = ping()
+		}
+	`)
+
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"wrong arg count", `add(1)`, `add expected 2 args, got 1`},
+		{"error in body", `add(1, "two")`, `:4:17: b should be number, got string`},
+		{"recursion", `forever(0)`, `forever exceeded the maximum call depth of 100`},
+		{"mutual recursion", `ping()`, `exceeded the maximum call depth of 100`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var actual interface{}
+			err = vm.New(expr).Evaluate(scope, &actual)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestNewFunction_Errors(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "missing label",
+			input:  `function { result = 1 }`,
+			expect: "function blocks must have a label",
+		},
+		{
+			name:   "missing result",
+			input:  `function "f" { params = [] }`,
+			expect: `missing required attribute "result" in function "f"`,
+		},
+		{
+			name: "unknown attribute",
+			input: `function "f" { result = 1
+				value = 2 }`,
+			expect: `unrecognized attribute name "value"`,
+		},
+		{
+			name: "nested block",
+			input: `function "f" { result = 1
+				inner {} }`,
+			expect: "function blocks only support the params and result attributes",
+		},
+		{
+			name: "params not strings",
+			input: `function "f" { params = [true]
+				result = 1 }`,
+			expect: "should be string, got bool",
+		},
+		{
+			name: "invalid param name",
+			input: `function "f" { params = ["not valid"]
+				result = 1 }`,
+			expect: `parameter "not valid" must be a valid identifier`,
+		},
+		{
+			name: "duplicate param",
+			input: `function "f" { params = ["a", "a"]
+				result = a }`,
+			expect: `parameter "a" is defined more than once`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := parser.ParseFile(t.Name(), []byte(tc.input))
+			require.NoError(t, err)
+			require.Len(t, file.Body, 1)
+
+			_, diags := vm.NewFunction(file.Body[0].(*ast.BlockStmt))
+			require.ErrorContains(t, diags, tc.expect)
+		})
+	}
+}
+
+func parseFunctions(t *testing.T, input string) *vm.Scope {
+	t.Helper()
+	return vm.NewFunctionScope(nil, parseFunctionList(t, input))
+}
+
+func parseFunctionList(t *testing.T, input string) []*vm.Function {
+	t.Helper()
+
+	file, err := parser.ParseFile(t.Name(), []byte(input))
+	require.NoError(t, err)
+
+	var fns []*vm.Function
+	for _, stmt := range file.Body {
+		fn, diags := vm.NewFunction(stmt.(*ast.BlockStmt))
+		require.False(t, diags.HasErrors(), "unexpected diagnostics: %s", diags)
+		fns = append(fns, fn)
+	}
+	return fns
+}
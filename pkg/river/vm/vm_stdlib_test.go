@@ -2,6 +2,8 @@ package vm_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -179,6 +181,236 @@ func TestStdlib_StringFunc(t *testing.T) {
 	}
 }
 
+func TestStdlib_Encoding(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"yaml_decode object", `yaml_decode("foo: bar\nlist: [1, 2]")`, map[string]interface{}{"foo": "bar", "list": []interface{}{1, 2}}},
+		{"yaml_decode array", `yaml_decode("- a\n- b")`, []string{"a", "b"}},
+		{"yaml_decode non-string keys", `yaml_decode("1: one\ntrue: yes")`, map[string]string{"1": "one", "true": "yes"}},
+		{"yaml_decode null", `yaml_decode("")`, interface{}(nil)},
+		{"base64_encode", `base64_encode("hello")`, "aGVsbG8="},
+		{"base64_decode", `base64_decode("aGVsbG8=")`, "hello"},
+		{"sha256", `sha256("hello")`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(&tc.expect).Elem())
+			if tc.expect != nil {
+				rv = reflect.New(reflect.TypeOf(tc.expect))
+			}
+			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_Collections(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]any{
+			"targets": []map[string]string{
+				{"__address__": "a:80", "job": "web"},
+				{"__address__": "b:80", "job": "db"},
+				{"__address__": "a:80", "job": "web"},
+			},
+			"is_web": func(target map[string]string) bool { return target["job"] == "web" },
+			"address": func(target map[string]string) string {
+				return target["__address__"]
+			},
+		},
+	}
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"keys", `keys({ b = 1, a = 2, c = 3 })`, []string{"a", "b", "c"}},
+		{"keys empty", `keys({})`, []string{}},
+		{"values", `values({ b = 1, a = 2, c = 3 })`, []int{2, 1, 3}},
+		{"merge", `merge({ a = 1, b = 2 }, { b = 3 }, { c = 4 })`, map[string]int{"a": 1, "b": 3, "c": 4}},
+		{"merge nothing", `merge()`, map[string]int{}},
+		{"distinct", `distinct([1, 2, 1, 3.0, 3, "1"])`, []interface{}{1, 2, 3.0, "1"}},
+		{"distinct objects", `distinct(targets)`, []map[string]string{
+			{"__address__": "a:80", "job": "web"},
+			{"__address__": "b:80", "job": "db"},
+		}},
+		{"filter", `filter(targets, is_web)`, []map[string]string{
+			{"__address__": "a:80", "job": "web"},
+			{"__address__": "a:80", "job": "web"},
+		}},
+		{"map", `map(targets, address)`, []string{"a:80", "b:80", "a:80"}},
+		{"map with stdlib function", `map(["A", "B"], to_lower)`, []string{"a", "b"}},
+		{"distinct+map", `distinct(map(targets, address))`, []string{"a:80", "b:80"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_Conversion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hunter2"), 0644))
+
+	scope := &vm.Scope{
+		Variables: map[string]any{"path": path},
+	}
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"to_number int", `to_number("42")`, 42},
+		{"to_number negative", `to_number(" -42 ")`, -42},
+		{"to_number float", `to_number("1.5")`, 1.5},
+		{"to_number number", `to_number(7)`, 7},
+		{"to_number arithmetic", `to_number("40") + 2`, 42},
+		{"parse_duration", `parse_duration("1h30m")`, 5400.0},
+		{"parse_duration fraction", `parse_duration("1500ms")`, 1.5},
+		{"file", `file(path)`, "hunter2"},
+		{"regex_match", `regex_match("^web-[0-9]+$", "web-12")`, true},
+		{"regex_match unanchored", `regex_match("[0-9]+", "web-12")`, true},
+		{"regex_match no match", `regex_match("^db", "web-12")`, false},
+		{"regex_replace", `regex_replace("web-12:8080", "^(.*):[0-9]+$", "$1")`, "web-12"},
+		{"regex_replace named", `regex_replace("a-b", "(?P<first>[a-z])-(?P<second>[a-z])", "${second}-${first}")`, "b-a"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlib_Secrets(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]any{
+			"secret":         rivertypes.Secret("hello"),
+			"encodedSecret":  rivertypes.Secret("aGVsbG8="),
+			"optionalSecret": rivertypes.OptionalSecret{Value: "hello", IsSecret: true},
+			"optionalString": rivertypes.OptionalSecret{Value: "hello"},
+			"jsonSecret":     rivertypes.Secret(`{"user": "admin", "port": 8080, "tls": true}`),
+		},
+	}
+
+	tt := []struct {
+		name   string
+		input  string
+		expect interface{}
+	}{
+		{"base64_encode secret", `base64_encode(secret)`, rivertypes.Secret("aGVsbG8=")},
+		{"base64_decode secret", `base64_decode(encodedSecret)`, rivertypes.Secret("hello")},
+		{"sha256 secret", `sha256(secret)`, rivertypes.Secret("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")},
+		{"regex_replace secret", `regex_replace(secret, "l+", "L")`, rivertypes.Secret("heLo")},
+		{"optional secret", `base64_encode(optionalSecret)`, rivertypes.Secret("aGVsbG8=")},
+		{"optional secret holding a string", `base64_encode(optionalString)`, "aGVsbG8="},
+		{"nonsensitive", `nonsensitive(base64_decode(encodedSecret))`, "hello"},
+		{"format secret", `format("user:%s", secret)`, rivertypes.Secret("user:hello")},
+		{"to_upper secret", `to_upper(secret)`, rivertypes.Secret("HELLO")},
+		{"join secret", `join(["a", secret], ",")`, rivertypes.Secret("a,hello")},
+		{"split secret", `split(secret, "l")`, []rivertypes.Secret{"he", "", "o"}},
+		{"replace with secret", `replace("user:NAME", "NAME", secret)`, rivertypes.Secret("user:hello")},
+		{"string concat secret", `"user:" + secret`, rivertypes.Secret("user:hello")},
+		{"json_decode secret", `json_decode(jsonSecret)`, map[string]rivertypes.Secret{"user": "admin", "port": "8080", "tls": "true"}},
+		{"json_decode secret field", `json_decode(jsonSecret).user`, rivertypes.Secret("admin")},
+		{"format without secrets", `format("user:%s", optionalString)`, "user:hello"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+
+	t.Run("secrets can't be used as strings", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`base64_encode(secret)`)
+		require.NoError(t, err)
+
+		var out string
+		require.Error(t, vm.New(expr).Evaluate(scope, &out))
+	})
+
+	t.Run("errors don't reveal secrets", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`json_decode(secret)`)
+		require.NoError(t, err)
+
+		var out interface{}
+		err = vm.New(expr).Evaluate(scope, &out)
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), "hello")
+	})
+}
+
+func TestStdlib_Errors(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]any{
+			"count": func(s string) int { return len(s) },
+		},
+	}
+
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"wrong arg count", `keys({}, {})`, `1:1: keys expected 1 args, got 2`},
+		{"wrong arg type", `keys([])`, `1:6: [] should be object, got array`},
+		{"wrong arg type secret", `base64_encode(1)`, `1:15: 1 expected string or secret, got number`},
+		{"invalid yaml", `yaml_decode("foo: [")`, `1:13: "foo: [" invalid YAML: yaml: line 1: did not find expected node content`},
+		{"invalid base64", `base64_decode("!!")`, `1:15: "!!" invalid base64: illegal base64 data at input byte 0`},
+		{"invalid regex", `regex_match("(", "a")`, "1:13: \"(\" invalid regular expression: error parsing regexp: missing closing ): `(`"},
+		{"invalid number", `to_number("abc")`, `1:11: "abc" is not a number`},
+		{"invalid duration", `parse_duration("1x")`, `1:16: "1x" time: unknown unit "x" in duration "1x"`},
+		{"filter non-bool", `filter(["a"], to_upper)`, `1:15: to_upper function must return a bool, got string`},
+		{"map wrong function", `map([[1]], count)`, `1:6: [1] should be string, got array`},
+		{"missing file", `file("/does/not/exist")`, `1:6: "/does/not/exist" open /does/not/exist: no such file or directory`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var out interface{}
+			err = vm.New(expr).Evaluate(scope, &out)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
+
 func BenchmarkConcat(b *testing.B) {
 	// There's a bit of setup work to do here: we want to create a scope holding
 	// a slice of the Person type, which has a fair amount of data in it.
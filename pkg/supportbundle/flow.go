@@ -0,0 +1,252 @@
+package supportbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/profiling"
+	"github.com/mackerelio/go-osstat/uptime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"gopkg.in/yaml.v3"
+)
+
+// FlowOptions configure the data collected for a Flow mode support bundle.
+type FlowOptions struct {
+	Components component.Provider  // Source of the running components.
+	Node       cluster.Node        // Source of the clustering peers.
+	Gatherer   prometheus.Gatherer // Source of the Agent's own metrics.
+
+	// RecentLogs returns the most recent log lines of the Agent. May be nil.
+	RecentLogs func() []byte
+
+	// Config returns the loaded config file with its secrets redacted. May
+	// be nil.
+	Config func() ([]byte, error)
+
+	// CPUAccountant provides the CPU profile when set. Otherwise, a CPU
+	// profile is recorded for the duration of the export.
+	CPUAccountant *profiling.CPUAccountant
+
+	// Duration is how long block and mutex events, and the CPU profile, are
+	// recorded for.
+	Duration time.Duration
+}
+
+// FlowBundle collects all the data that is exposed as a Flow mode support
+// bundle.
+type FlowBundle struct {
+	meta         []byte
+	config       []byte
+	components   []byte
+	graph        []byte
+	peers        []byte
+	agentMetrics []byte
+	agentLogs    []byte
+	heapBuf      *bytes.Buffer
+	goroutineBuf *bytes.Buffer
+	blockBuf     *bytes.Buffer
+	mutexBuf     *bytes.Buffer
+	cpuBuf       *bytes.Buffer
+}
+
+// ExportFlow gathers the information required for the support bundle of a
+// Flow mode Agent. ExportFlow blocks for opts.Duration.
+func ExportFlow(ctx context.Context, opts FlowOptions) (*FlowBundle, error) {
+	mut.Lock()
+	defer mut.Unlock()
+	// The block profiler is disabled by default. Temporarily enable recording
+	// of all blocking events. Also, temporarily record all mutex contentions,
+	// and defer restoring of earlier mutex profiling fraction.
+	runtime.SetBlockProfileRate(1)
+	old := runtime.SetMutexProfileFraction(1)
+	defer func() {
+		runtime.SetBlockProfileRate(0)
+		runtime.SetMutexProfileFraction(old)
+	}()
+
+	// Gather runtime metadata.
+	ut, err := uptime.Get()
+	if err != nil {
+		return nil, err
+	}
+	m := Metadata{
+		BuildVersion: build.Version,
+		OS:           runtime.GOOS,
+		Architecture: runtime.GOARCH,
+		Uptime:       ut.Seconds(),
+	}
+	meta, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal support bundle metadata: %s", err)
+	}
+
+	var config []byte
+	if opts.Config != nil {
+		config, err = opts.Config()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the loaded config: %s", err)
+		}
+	}
+
+	// Gather the state of the components. Arguments are encoded with their
+	// secrets redacted.
+	infos := component.GetAllComponents(opts.Components, component.InfoOptions{
+		GetHealth:    true,
+		GetArguments: true,
+		GetExports:   true,
+		GetDebugInfo: true,
+	})
+	components, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal components: %s", err)
+	}
+	peers, err := json.MarshalIndent(opts.Node.Peers(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal clustering peers: %s", err)
+	}
+
+	// Gather Agent's own metrics.
+	mfs, err := opts.Gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get internal Agent metrics: %s", err)
+	}
+	var agentMetrics bytes.Buffer
+	enc := expfmt.NewEncoder(&agentMetrics, expfmt.FmtText)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return nil, fmt.Errorf("failed to encode internal Agent metrics: %s", err)
+		}
+	}
+
+	// Export pprof data.
+	var (
+		cpuBuf       bytes.Buffer
+		heapBuf      bytes.Buffer
+		goroutineBuf bytes.Buffer
+		blockBuf     bytes.Buffer
+		mutexBuf     bytes.Buffer
+	)
+	if opts.CPUAccountant != nil {
+		// Only one CPU profile can be recorded at a time, and the accountant
+		// is always recording one.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.Duration):
+		}
+		if p := opts.CPUAccountant.LastProfile(); p != nil {
+			if err := p.Write(&cpuBuf); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		p, err := profiling.RecordCPU(ctx, opts.Duration)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Write(&cpuBuf); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, buf := range map[string]*bytes.Buffer{
+		"heap":      &heapBuf,
+		"goroutine": &goroutineBuf,
+		"block":     &blockBuf,
+		"mutex":     &mutexBuf,
+	} {
+		if err := pprof.Lookup(name).WriteTo(buf, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// Logs are collected last so that they include the logs written while
+	// profiling.
+	var agentLogs []byte
+	if opts.RecentLogs != nil {
+		agentLogs = opts.RecentLogs()
+	}
+
+	bundle := &FlowBundle{
+		meta:         meta,
+		config:       config,
+		components:   components,
+		graph:        flowGraph(infos),
+		peers:        peers,
+		agentMetrics: agentMetrics.Bytes(),
+		agentLogs:    agentLogs,
+		heapBuf:      &heapBuf,
+		goroutineBuf: &goroutineBuf,
+		blockBuf:     &blockBuf,
+		mutexBuf:     &mutexBuf,
+		cpuBuf:       &cpuBuf,
+	}
+
+	return bundle, nil
+}
+
+// ServeFlow serves the collected data as a zip file over the given
+// http.ResponseWriter.
+func ServeFlow(rw http.ResponseWriter, b *FlowBundle) error {
+	zw := zip.NewWriter(rw)
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", "attachment; filename=\"agent-support-bundle.zip\"")
+
+	zipStructure := map[string][]byte{
+		"agent-metadata.yaml":   b.meta,
+		"agent-config.river":    b.config,
+		"agent-components.json": b.components,
+		"agent-graph.dot":       b.graph,
+		"agent-peers.json":      b.peers,
+		"agent-metrics.txt":     b.agentMetrics,
+		"agent-logs.txt":        b.agentLogs,
+		"pprof/cpu.pprof":       b.cpuBuf.Bytes(),
+		"pprof/heap.pprof":      b.heapBuf.Bytes(),
+		"pprof/goroutine.pprof": b.goroutineBuf.Bytes(),
+		"pprof/mutex.pprof":     b.mutexBuf.Bytes(),
+		"pprof/block.pprof":     b.blockBuf.Bytes(),
+	}
+
+	for fn, b := range zipStructure {
+		if b != nil {
+			path := append([]string{"agent-support-bundle"}, strings.Split(fn, "/")...)
+			if err := writeByteSlice(zw, b, path...); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to flush the zip writer: %v", err)
+	}
+	return nil
+}
+
+// flowGraph renders the dependencies between components in the DOT format.
+// Edges point from a component to the components it references.
+func flowGraph(infos []*component.Info) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph {\n")
+	for _, info := range infos {
+		fmt.Fprintf(&buf, "\t%q;\n", info.ID.String())
+		for _, ref := range info.References {
+			refID := component.ID{ModuleID: info.ID.ModuleID, LocalID: ref}
+			fmt.Fprintf(&buf, "\t%q -> %q;\n", info.ID.String(), refID.String())
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,34 @@
+package supportbundle
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowGraph(t *testing.T) {
+	infos := []*component.Info{
+		{
+			ID:         component.ID{LocalID: "prometheus.scrape.default"},
+			References: []string{"prometheus.remote_write.default"},
+		},
+		{
+			ID: component.ID{LocalID: "prometheus.remote_write.default"},
+		},
+		{
+			ID:         component.ID{ModuleID: "module.file.default", LocalID: "local.file.token"},
+			References: []string{"argument.path"},
+		},
+	}
+
+	expect := `digraph {
+	"prometheus.scrape.default";
+	"prometheus.scrape.default" -> "prometheus.remote_write.default";
+	"prometheus.remote_write.default";
+	"module.file.default/local.file.token";
+	"module.file.default/local.file.token" -> "module.file.default/argument.path";
+}
+`
+	require.Equal(t, expect, string(flowGraph(infos)))
+}
@@ -0,0 +1,444 @@
+// Package cluster implements the clustering service for Flow. The clustering
+// service allows a set of agents to form a cluster, distributing work between
+// them.
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/service"
+	"github.com/grafana/ckit"
+	"github.com/grafana/ckit/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/http2"
+)
+
+// ServiceName defines the name used for the clustering service.
+const ServiceName = "clustering"
+
+// Node is the cluster node exposed as the data of the clustering service.
+// Components which declare a dependency on the clustering service can
+// retrieve it through [component.ServiceData].
+type Node = cluster.Node
+
+// Component is a component which requires clustering and wants to be
+// informed when the state of the cluster changes.
+type Component interface {
+	component.Component
+
+	// NotifyClusterChange notifies the component that the set of peers in the
+	// cluster changed. NotifyClusterChange must not block.
+	NotifyClusterChange()
+}
+
+// Options are used to configure the clustering service. Options are constant
+// for the lifetime of the clustering service.
+type Options struct {
+	Log     log.Logger            // Where to send logs.
+	Metrics prometheus.Registerer // Where to send metrics.
+
+	EnableClustering bool     // Whether to form a cluster with other agents.
+	NodeName         string   // Name of the node; defaults to the hostname.
+	AdvertiseAddress string   // Default address to advertise to peers.
+	JoinPeers        []string // Default set of peers to join.
+
+	// HTTPListenAddr is the address the HTTP service listens on. Its port is
+	// used for advertise and peer addresses which don't have a port.
+	HTTPListenAddr string
+}
+
+// Arguments configure the clustering service at runtime through the
+// clustering block.
+type Arguments struct {
+	// JoinPeers overrides the peers given by Options.JoinPeers when set.
+	JoinPeers []string `river:"join_peers,attr,optional"`
+
+	// AdvertiseAddress overrides Options.AdvertiseAddress when set. The
+	// advertised address can't be changed once the node has been created.
+	AdvertiseAddress string `river:"advertise_address,attr,optional"`
+
+	// RejoinInterval is how often to rejoin the list of peers. Setting
+	// RejoinInterval to zero disables rejoining.
+	RejoinInterval time.Duration `river:"rejoin_interval,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	RejoinInterval: 60 * time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.RejoinInterval < 0 {
+		return fmt.Errorf("rejoin_interval must not be negative")
+	}
+	for _, p := range args.JoinPeers {
+		if p == "" {
+			return fmt.Errorf("join_peers must not contain empty addresses")
+		}
+	}
+	return nil
+}
+
+// Service is the clustering service.
+type Service struct {
+	log         log.Logger
+	opts        Options
+	defaultPort int
+	node        *sharedNode
+
+	mut     sync.Mutex
+	args    Arguments
+	gossip  *cluster.GossipNode // Created on the first update when clustering is enabled.
+	updated chan struct{}
+}
+
+var _ service.Service = (*Service)(nil)
+
+// New returns a new, unstarted instance of the clustering service.
+func New(opts Options) (*Service, error) {
+	l := opts.Log
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = prometheus.NewRegistry()
+	}
+
+	defaultPort := 80
+	if _, portStr, err := net.SplitHostPort(opts.HTTPListenAddr); err == nil {
+		defaultPort, err = strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in listen address %q: %w", opts.HTTPListenAddr, err)
+		}
+	}
+
+	return &Service{
+		log:         l,
+		opts:        opts,
+		defaultPort: defaultPort,
+		node:        &sharedNode{inner: cluster.NewLocalNode(opts.HTTPListenAddr)},
+
+		args:    DefaultArguments,
+		updated: make(chan struct{}, 1),
+	}, nil
+}
+
+// Definition returns the definition of the clustering service.
+func (s *Service) Definition() service.Definition {
+	return service.Definition{
+		Name:       ServiceName,
+		ConfigType: Arguments{},
+		DependsOn:  nil, // clustering has no dependencies.
+	}
+}
+
+// ServiceHandler implements the ServiceHandler interface of the HTTP
+// service, which depends on the clustering service, and serves the transport
+// used by nodes to gossip with each other.
+func (s *Service) ServiceHandler(host service.Host) (base string, handler http.Handler) {
+	return s.node.Handler()
+}
+
+// Update implements [service.Service]. Peers and the rejoin interval take
+// effect immediately, causing the node to rejoin the cluster.
+func (s *Service) Update(newConfig any) error {
+	args := newConfig.(Arguments)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.opts.EnableClustering {
+		advertiseAddr := s.advertiseAddress(args)
+
+		if s.gossip == nil {
+			gossip, err := s.newGossipNode(advertiseAddr)
+			if err != nil {
+				return err
+			}
+			s.gossip = gossip
+		} else if prevAddr := s.advertiseAddress(s.args); advertiseAddr != prevAddr {
+			return fmt.Errorf("advertise_address can't be changed from %q to %q while the agent is running", prevAddr, advertiseAddr)
+		}
+	}
+
+	s.args = args
+
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// advertiseAddress returns the address to advertise to peers for args.
+func (s *Service) advertiseAddress(args Arguments) string {
+	if args.AdvertiseAddress != "" {
+		return args.AdvertiseAddress
+	}
+	return s.opts.AdvertiseAddress
+}
+
+func (s *Service) newGossipNode(advertiseAddr string) (*cluster.GossipNode, error) {
+	cfg := cluster.DefaultGossipConfig
+	cfg.NodeName = s.opts.NodeName
+	cfg.AdvertiseAddr = advertiseAddr
+
+	if err := cfg.ApplyDefaults(s.defaultPort); err != nil {
+		return nil, err
+	}
+
+	cli := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				// Set a maximum timeout for establishing the connection. If our
+				// context has a deadline earlier than our timeout, we shrink the
+				// timeout to it.
+				//
+				// TODO(rfratto): consider making the max timeout configurable.
+				timeout := 30 * time.Second
+				if dur, ok := deadlineDuration(ctx); ok && dur < timeout {
+					timeout = dur
+				}
+
+				return net.DialTimeout(network, addr, timeout)
+			},
+		},
+	}
+
+	return cluster.NewGossipNode(s.log, s.opts.Metrics, cli, &cfg)
+}
+
+func deadlineDuration(ctx context.Context) (d time.Duration, ok bool) {
+	if t, ok := ctx.Deadline(); ok {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// Run implements [service.Service]. When clustering is enabled, Run joins the
+// configured peers, falling back to bootstrapping a new cluster if no peers
+// could be reached. Run periodically rejoins peers until ctx is canceled, at
+// which point the node leaves the cluster.
+func (s *Service) Run(ctx context.Context, host service.Host) error {
+	s.mut.Lock()
+	gossip := s.gossip
+	s.mut.Unlock()
+
+	if gossip == nil {
+		// Clustering is disabled; the local node owns all work.
+		<-ctx.Done()
+		return nil
+	}
+
+	if err := s.startNode(ctx, gossip); err != nil {
+		return err
+	}
+	defer s.stopNode(gossip)
+
+	gossip.Observe(ckit.FuncObserver(func(peers []peer.Peer) (reregister bool) {
+		names := make([]string, len(peers))
+		for i, p := range peers {
+			names[i] = p.Name
+		}
+		level.Info(s.log).Log("msg", "peers changed", "new_peers", strings.Join(names, ","))
+
+		notifyConsumers(host)
+		return ctx.Err() == nil
+	}))
+
+	// The node only becomes visible to consumers once it started, so that
+	// lookups against an unstarted node never fail.
+	s.node.Set(gossip)
+	notifyConsumers(host)
+
+	var (
+		rejoinTimer = time.NewTimer(0)
+		interval    = s.rejoinInterval()
+	)
+	resetTimer := func() {
+		if !rejoinTimer.Stop() {
+			select {
+			case <-rejoinTimer.C:
+			default:
+			}
+		}
+		if interval > 0 {
+			rejoinTimer.Reset(interval)
+		}
+	}
+	resetTimer()
+	defer rejoinTimer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-s.updated:
+			interval = s.rejoinInterval()
+			s.rejoin(gossip)
+			resetTimer()
+
+		case <-rejoinTimer.C:
+			s.rejoin(gossip)
+			resetTimer()
+		}
+	}
+}
+
+func (s *Service) rejoinInterval() time.Duration {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.args.RejoinInterval
+}
+
+// startNode starts gossip and moves it to the Participant state.
+func (s *Service) startNode(ctx context.Context, gossip *cluster.GossipNode) error {
+	if err := gossip.Start(); err != nil {
+		return fmt.Errorf("starting gossip node: %w", err)
+	}
+
+	// Join the configured peers. If none of the peers can be reached, the node
+	// forms a new cluster of its own until another node connects to it.
+	//
+	// TODO(@tpaschalis) Should we backoff and retry before moving on to the
+	// fallback here?
+	s.rejoin(gossip)
+
+	// We now have either joined or started a new cluster. Nodes initially join
+	// in the Viewer state. We can move to the Participant state to signal that
+	// we wish to participate in reading or writing data.
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := gossip.ChangeState(ctx, peer.StateParticipant); err != nil {
+		return fmt.Errorf("changing node state to Participant: %w", err)
+	}
+	return nil
+}
+
+// stopNode moves gossip to the Terminating state and leaves the cluster.
+func (s *Service) stopNode(gossip *cluster.GossipNode) {
+	// The node is going away. We move to the Terminating state to signal that
+	// we should not be owners for write hashing operations anymore.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// TODO(rfratto): should we enter terminating state earlier to allow for
+	// some kind of hand-off between components?
+	if err := gossip.ChangeState(ctx, peer.StateTerminating); err != nil {
+		level.Error(s.log).Log("msg", "failed to change state to Terminating before shutting down", "err", err)
+	}
+	if err := gossip.Stop(); err != nil {
+		level.Error(s.log).Log("msg", "failed to stop gossip node", "err", err)
+	}
+}
+
+// rejoin resolves the configured peers and joins them.
+func (s *Service) rejoin(gossip *cluster.GossipNode) {
+	peers, err := s.resolvePeers()
+	if err != nil {
+		level.Warn(s.log).Log("msg", "failed to resolve some peers", "err", err)
+	}
+	if len(peers) == 0 {
+		return
+	}
+
+	level.Debug(s.log).Log("msg", "joining peers", "peers", strings.Join(peers, ","))
+	if err := gossip.Rejoin(peers); err != nil {
+		level.Warn(s.log).Log("msg", "failed to join peers", "err", err)
+	}
+}
+
+// resolvePeers returns the list of peer addresses to join. Entries of the
+// configured peers which aren't a host:port pair or an IP address are treated
+// as DNS SRV records and resolved to the addresses they point to. Addresses
+// without a port default to the port of the HTTP listen address.
+//
+// An error is returned for entries which couldn't be resolved; the addresses
+// of the remaining entries are returned alongside the error.
+func (s *Service) resolvePeers() ([]string, error) {
+	s.mut.Lock()
+	peers := s.args.JoinPeers
+	if len(peers) == 0 {
+		peers = s.opts.JoinPeers
+	}
+	s.mut.Unlock()
+
+	return resolvePeers(peers, s.defaultPort)
+}
+
+// lookupSRV is used to resolve DNS SRV records. It can be overridden in
+// tests.
+var lookupSRV = net.LookupSRV
+
+func resolvePeers(peers []string, defaultPort int) ([]string, error) {
+	var (
+		addrs []string
+		errs  []string
+	)
+
+	for _, p := range peers {
+		if _, _, err := net.SplitHostPort(p); err == nil {
+			addrs = append(addrs, p)
+			continue
+		}
+
+		if ip := net.ParseIP(p); ip != nil {
+			addrs = append(addrs, net.JoinHostPort(ip.String(), strconv.Itoa(defaultPort)))
+			continue
+		}
+
+		_, srvs, err := lookupSRV("", "", p)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", p, err))
+			continue
+		}
+		for _, srv := range srvs {
+			port := int(srv.Port)
+			if port == 0 {
+				port = defaultPort
+			}
+			addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(port)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return addrs, fmt.Errorf("resolving peers: %s", strings.Join(errs, "; "))
+	}
+	return addrs, nil
+}
+
+// notifyConsumers informs components which depend on the clustering service
+// that the cluster changed.
+func notifyConsumers(host service.Host) {
+	for _, consumer := range host.GetServiceConsumers(ServiceName) {
+		if c, ok := consumer.(Component); ok {
+			c.NotifyClusterChange()
+		}
+	}
+}
+
+// Data returns a [Node]. The returned Node is a local node which owns all
+// work until clustering is enabled and the node has joined a cluster.
+func (s *Service) Data() any {
+	return Node(s.node)
+}
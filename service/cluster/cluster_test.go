@@ -0,0 +1,115 @@
+package cluster
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		var args Arguments
+		require.NoError(t, river.Unmarshal([]byte(``), &args))
+		require.Equal(t, DefaultArguments, args)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		var args Arguments
+		require.NoError(t, river.Unmarshal([]byte(`
+			join_peers        = ["10.0.0.1:12345", "_agent._tcp.example.com"]
+			advertise_address = "10.0.0.2:12345"
+			rejoin_interval   = "0s"
+		`), &args))
+
+		require.Equal(t, Arguments{
+			JoinPeers:        []string{"10.0.0.1:12345", "_agent._tcp.example.com"},
+			AdvertiseAddress: "10.0.0.2:12345",
+			RejoinInterval:   0,
+		}, args)
+	})
+
+	t.Run("Negative rejoin interval", func(t *testing.T) {
+		var args Arguments
+		err := river.Unmarshal([]byte(`rejoin_interval = "-1s"`), &args)
+		require.ErrorContains(t, err, "rejoin_interval must not be negative")
+	})
+}
+
+func TestResolvePeers(t *testing.T) {
+	prevLookupSRV := lookupSRV
+	defer func() { lookupSRV = prevLookupSRV }()
+
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		switch name {
+		case "_agent._tcp.example.com":
+			return "", []*net.SRV{
+				{Target: "agent-1.example.com.", Port: 8080},
+				{Target: "agent-2.example.com.", Port: 0},
+			}, nil
+		default:
+			return "", nil, errors.New("no such host")
+		}
+	}
+
+	addrs, err := resolvePeers([]string{
+		"10.0.0.1:9090",
+		"10.0.0.2",
+		"::1",
+		"_agent._tcp.example.com",
+	}, 12345)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"10.0.0.1:9090",
+		"10.0.0.2:12345",
+		"[::1]:12345",
+		"agent-1.example.com:8080",
+		"agent-2.example.com:12345",
+	}, addrs)
+
+	addrs, err = resolvePeers([]string{"10.0.0.1:9090", "missing.example.com"}, 12345)
+	require.EqualError(t, err, "resolving peers: missing.example.com: no such host")
+	require.Equal(t, []string{"10.0.0.1:9090"}, addrs)
+}
+
+func TestService_Disabled(t *testing.T) {
+	s, err := New(Options{HTTPListenAddr: "127.0.0.1:12345"})
+	require.NoError(t, err)
+
+	// Updating the service doesn't create a gossip node when clustering is
+	// disabled, and the local node owns all work.
+	require.NoError(t, s.Update(Arguments{
+		JoinPeers:      []string{"10.0.0.1"},
+		RejoinInterval: time.Second,
+	}))
+	require.Nil(t, s.gossip)
+
+	node := s.Data().(Node)
+	peers := node.Peers()
+	require.Len(t, peers, 1)
+	require.True(t, peers[0].Self)
+	require.Equal(t, "127.0.0.1:12345", peers[0].Addr)
+}
+
+func TestService_AdvertiseAddressChange(t *testing.T) {
+	s, err := New(Options{
+		EnableClustering: true,
+		NodeName:         "node-a",
+		HTTPListenAddr:   "127.0.0.1:12345",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Update(Arguments{AdvertiseAddress: "127.0.0.1:12345"}))
+	require.NotNil(t, s.gossip)
+
+	// Peers can change at runtime, but the advertised address can't.
+	require.NoError(t, s.Update(Arguments{
+		AdvertiseAddress: "127.0.0.1:12345",
+		JoinPeers:        []string{"127.0.0.2:12345"},
+	}))
+	err = s.Update(Arguments{AdvertiseAddress: "127.0.0.3:12345"})
+	require.EqualError(t, err, `advertise_address can't be changed from "127.0.0.1:12345" to "127.0.0.3:12345" while the agent is running`)
+}
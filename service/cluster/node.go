@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/ckit"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+)
+
+// sharedNode is a [cluster.Node] which forwards calls to an inner Node that
+// can be swapped at runtime. It allows the clustering service to hand out a
+// stable Node to consumers before the gossip node has started.
+//
+// Observers registered with sharedNode are carried over to every new inner
+// Node, and are notified of the peers of the new inner Node when it is set.
+type sharedNode struct {
+	mut       sync.RWMutex
+	inner     cluster.Node
+	gen       int // Incremented every time inner changes.
+	observers map[*sharedObserver]struct{}
+}
+
+var _ cluster.Node = (*sharedNode)(nil)
+
+// sharedObserver is an Observer registered with a sharedNode.
+type sharedObserver struct{ o ckit.Observer }
+
+// Set changes the Node which calls are forwarded to.
+func (n *sharedNode) Set(inner cluster.Node) {
+	n.mut.Lock()
+	n.inner = inner
+	n.gen++
+	gen := n.gen
+	observers := make([]*sharedObserver, 0, len(n.observers))
+	for o := range n.observers {
+		observers = append(observers, o)
+	}
+	n.mut.Unlock()
+
+	for _, o := range observers {
+		forward := n.forwarder(o, gen)
+		if forward.NotifyPeersChanged(inner.Peers()) {
+			inner.Observe(forward)
+		}
+	}
+}
+
+func (n *sharedNode) get() cluster.Node {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+	return n.inner
+}
+
+// Lookup implements cluster.Node.
+func (n *sharedNode) Lookup(key shard.Key, replicationFactor int, op shard.Op) ([]peer.Peer, error) {
+	return n.get().Lookup(key, replicationFactor, op)
+}
+
+// Observe implements cluster.Node. o is registered against the current inner
+// Node, and against every inner Node set afterwards until o asks not to be
+// reregistered.
+func (n *sharedNode) Observe(o ckit.Observer) {
+	so := &sharedObserver{o: o}
+
+	n.mut.Lock()
+	if n.observers == nil {
+		n.observers = make(map[*sharedObserver]struct{})
+	}
+	n.observers[so] = struct{}{}
+	inner, gen := n.inner, n.gen
+	n.mut.Unlock()
+
+	inner.Observe(n.forwarder(so, gen))
+}
+
+// forwarder returns an Observer to register against the inner Node of
+// generation gen which forwards notifications to o. The forwarder stops
+// forwarding and unregisters itself once the inner Node changes, since o is
+// registered against the new inner Node by Set.
+func (n *sharedNode) forwarder(o *sharedObserver, gen int) ckit.Observer {
+	return ckit.FuncObserver(func(peers []peer.Peer) (reregister bool) {
+		n.mut.RLock()
+		_, active := n.observers[o]
+		current := n.gen == gen
+		n.mut.RUnlock()
+
+		if !active || !current {
+			return false
+		}
+		if o.o.NotifyPeersChanged(peers) {
+			return true
+		}
+
+		n.mut.Lock()
+		delete(n.observers, o)
+		n.mut.Unlock()
+		return false
+	})
+}
+
+// Peers implements cluster.Node.
+func (n *sharedNode) Peers() []peer.Peer {
+	return n.get().Peers()
+}
+
+// Handler implements cluster.Node. The returned handler forwards requests to
+// the handler of the inner Node at the time of the request.
+func (n *sharedNode) Handler() (string, http.Handler) {
+	base, _ := n.get().Handler()
+	return base, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, h := n.get().Handler()
+		h.ServeHTTP(w, r)
+	})
+}
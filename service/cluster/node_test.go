@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/ckit"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedNode_Observe(t *testing.T) {
+	var (
+		first  = &fakeNode{peers: []peer.Peer{{Name: "first"}}}
+		second = &fakeNode{peers: []peer.Peer{{Name: "second"}}}
+		third  = &fakeNode{peers: []peer.Peer{{Name: "third"}}}
+
+		node = &sharedNode{inner: first}
+	)
+
+	var (
+		persistent []string
+		once       []string
+	)
+	node.Observe(ckit.FuncObserver(func(peers []peer.Peer) (reregister bool) {
+		persistent = append(persistent, peers[0].Name)
+		return true
+	}))
+	node.Observe(ckit.FuncObserver(func(peers []peer.Peer) (reregister bool) {
+		once = append(once, peers[0].Name)
+		return false
+	}))
+
+	first.notify()
+
+	// Observers are notified of the peers of the new node when it is set, and
+	// only the observer which asked to be reregistered is carried over.
+	node.Set(second)
+	second.notify()
+	require.Equal(t, []string{"first", "second", "second"}, persistent)
+	require.Equal(t, []string{"first"}, once)
+
+	// Notifications from a node which was replaced are dropped.
+	node.Set(third)
+	first.notify()
+	second.notify()
+	require.Equal(t, []string{"first", "second", "second", "third"}, persistent)
+	require.Equal(t, []string{"first"}, once)
+}
+
+// fakeNode is a cluster.Node which notifies its observers when notify is
+// called.
+type fakeNode struct {
+	mut       sync.Mutex
+	peers     []peer.Peer
+	observers []ckit.Observer
+}
+
+var _ cluster.Node = (*fakeNode)(nil)
+
+func (n *fakeNode) notify() {
+	n.mut.Lock()
+	observers := n.observers
+	n.observers = nil
+	n.mut.Unlock()
+
+	for _, o := range observers {
+		if o.NotifyPeersChanged(n.peers) {
+			n.Observe(o)
+		}
+	}
+}
+
+func (n *fakeNode) Lookup(shard.Key, int, shard.Op) ([]peer.Peer, error) { return n.peers, nil }
+
+func (n *fakeNode) Observe(o ckit.Observer) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	n.observers = append(n.observers, o)
+}
+
+func (n *fakeNode) Peers() []peer.Peer { return n.peers }
+
+func (n *fakeNode) Handler() (string, http.Handler) { return "/", http.NotFoundHandler() }
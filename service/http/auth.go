@@ -0,0 +1,99 @@
+package http
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// AuthArguments configures the credentials which clients must present to
+// use the HTTP server.
+type AuthArguments struct {
+	Basic       *BasicAuthArguments `river:"basic,block,optional"`
+	BearerToken rivertypes.Secret   `river:"bearer_token,attr,optional"`
+
+	// UnauthenticatedPaths are paths which can be requested without
+	// credentials. Paths ending in a slash match every path under them.
+	UnauthenticatedPaths []string `river:"unauthenticated_paths,attr,optional"`
+}
+
+// BasicAuthArguments configures the credentials for HTTP basic
+// authentication.
+type BasicAuthArguments struct {
+	Username string            `river:"username,attr"`
+	Password rivertypes.Secret `river:"password,attr"`
+}
+
+// Validate implements river.Validator.
+func (args *AuthArguments) Validate() error {
+	if args.Basic == nil && args.BearerToken == "" {
+		return fmt.Errorf("at least one of the basic block or bearer_token must be configured")
+	}
+	for _, p := range args.UnauthenticatedPaths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("unauthenticated path %q must start with /", p)
+		}
+	}
+	return nil
+}
+
+// authenticated returns true if r presents credentials which match args, or
+// requests an unauthenticated path.
+func (args *AuthArguments) authenticated(r *http.Request) bool {
+	// Clean the path so that unauthenticated paths can't be used to reach
+	// other paths through relative path segments.
+	reqPath := path.Clean("/" + r.URL.Path)
+	for _, p := range args.UnauthenticatedPaths {
+		if reqPath == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(reqPath, p)) {
+			return true
+		}
+	}
+
+	if args.Basic != nil {
+		if username, password, ok := r.BasicAuth(); ok {
+			// Both comparisons are always made so that the response time doesn't
+			// reveal which one failed.
+			usernameOK := secureCompare(username, args.Basic.Username)
+			passwordOK := secureCompare(password, string(args.Basic.Password))
+			if usernameOK && passwordOK {
+				return true
+			}
+		}
+	}
+
+	if args.BearerToken != "" {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") && secureCompare(token, string(args.BearerToken)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secureCompare compares a and b in constant time.
+func secureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// authHandler wraps next to reject requests which aren't authenticated by the
+// arguments returned by getArgs. Authentication is disabled while getArgs
+// returns nil.
+func authHandler(getArgs func() *AuthArguments, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		args := getArgs()
+		if args == nil || args.authenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if args.Basic != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="grafana-agent"`)
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+}
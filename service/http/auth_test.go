@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler(t *testing.T) {
+	args := &AuthArguments{
+		Basic: &BasicAuthArguments{
+			Username: "admin",
+			Password: "secret",
+		},
+		BearerToken:          "token",
+		UnauthenticatedPaths: []string{"/-/ready", "/public/"},
+	}
+	handler := authHandler(
+		func() *AuthArguments { return args },
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }),
+	)
+
+	tt := []struct {
+		name       string
+		path       string
+		setAuth    func(r *http.Request)
+		expectCode int
+	}{
+		{
+			name:       "no credentials",
+			path:       "/metrics",
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "basic auth",
+			path:       "/metrics",
+			setAuth:    func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "wrong password",
+			path:       "/metrics",
+			setAuth:    func(r *http.Request) { r.SetBasicAuth("admin", "wrong") },
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "bearer token",
+			path:       "/metrics",
+			setAuth:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") },
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "wrong bearer token",
+			path:       "/metrics",
+			setAuth:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") },
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "unauthenticated path",
+			path:       "/-/ready",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "unauthenticated prefix",
+			path:       "/public/file.txt",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "path under exact unauthenticated path",
+			path:       "/-/ready/other",
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "relative segments out of unauthenticated path",
+			path:       "/public/../metrics",
+			expectCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://agent"+tc.path, nil)
+			if tc.setAuth != nil {
+				tc.setAuth(req)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tc.expectCode, rec.Code)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		handler := authHandler(
+			func() *AuthArguments { return nil },
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }),
+		)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://agent/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -7,15 +7,20 @@ import (
 	"net"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/profiling"
+	"github.com/grafana/agent/pkg/supportbundle"
 	"github.com/grafana/agent/service"
+	clusterservice "github.com/grafana/agent/service/cluster"
 	"github.com/grafana/agent/web/api"
 	"github.com/grafana/agent/web/ui"
 	"github.com/grafana/ckit/memconn"
@@ -34,14 +39,48 @@ type Options struct {
 	Tracer   trace.TracerProvider // Where to send traces.
 	Gatherer prometheus.Gatherer  // Where to collect metrics from.
 
-	Clusterer  *cluster.Clusterer
 	ReadyFunc  func() bool
-	ReloadFunc func() error
+	ReloadFunc func() (string, error) // Reloads the config, returning a summary of changes.
 
 	HTTPListenAddr   string // Address to listen for HTTP traffic on.
 	MemoryListenAddr string // Address to accept in-memory traffic on.
 	UIPrefix         string // Path prefix to host the UI at.
 	EnablePProf      bool   // Whether pprof endpoints should be exposed.
+
+	// CPUAccountant provides CPU profiles of components when set. If
+	// EnablePProf is true and CPUAccountant is nil, CPU profiles of components
+	// are recorded on demand.
+	CPUAccountant *profiling.CPUAccountant
+
+	// EnableSupportBundle exposes the /-/support endpoint, which generates a
+	// support bundle. RecentLogs provides the logs, and LoadedConfig the
+	// config file with its secrets redacted, included in support bundles.
+	// Both may be nil.
+	EnableSupportBundle bool
+	RecentLogs          func() []byte
+	LoadedConfig        func() ([]byte, error)
+}
+
+// ServiceName defines the name used for the HTTP service.
+const ServiceName = "http"
+
+// ServiceHandler is a Service which exposes custom HTTP handlers. The
+// handlers of services which the HTTP service depends on are registered when
+// the HTTP service starts. The handlers of services which depend on the HTTP
+// service and implement ServiceHandler are registered once the HTTP service
+// has started listening.
+type ServiceHandler interface {
+	service.Service
+
+	// ServiceHandler returns the base route and HTTP handler to register for
+	// the service.
+	ServiceHandler(host service.Host) (base string, handler http.Handler)
+}
+
+// Arguments configure the HTTP service at runtime through the http block.
+type Arguments struct {
+	TLS  *TLSArguments  `river:"tls,block,optional"`
+	Auth *AuthArguments `river:"auth,block,optional"`
 }
 
 type Service struct {
@@ -51,12 +90,22 @@ type Service struct {
 	opts     Options
 
 	memLis *memconn.Listener
-	node   cluster.Node
 
 	componentHttpPathPrefix string
+
+	mut           sync.RWMutex
+	args          Arguments
+	tlsLoader     *tlsLoader  // nil when TLS is disabled
+	serviceRoutes *mux.Router // nil until the routes of consumers are known
 }
 
-var _ service.Service = (*Service)(nil)
+var (
+	_ service.Service = (*Service)(nil)
+
+	// The clustering service serves the transport used by nodes to gossip
+	// with each other through the HTTP service.
+	_ ServiceHandler = (*clusterservice.Service)(nil)
+)
 
 // New returns a new, unstarted instance of the HTTP service.
 func New(opts Options) *Service {
@@ -64,8 +113,6 @@ func New(opts Options) *Service {
 		l = opts.Logger
 		t = opts.Tracer
 		r = opts.Gatherer
-
-		n cluster.Node
 	)
 
 	if l == nil {
@@ -78,10 +125,6 @@ func New(opts Options) *Service {
 		r = prometheus.NewRegistry()
 	}
 
-	if opts.Clusterer != nil {
-		n = opts.Clusterer.Node
-	}
-
 	return &Service{
 		log:      l,
 		tracer:   t,
@@ -89,7 +132,6 @@ func New(opts Options) *Service {
 		opts:     opts,
 
 		memLis: memconn.NewListener(l),
-		node:   n,
 
 		componentHttpPathPrefix: "/api/v0/component/",
 	}
@@ -98,9 +140,13 @@ func New(opts Options) *Service {
 // Definition returns the definition of the HTTP service.
 func (s *Service) Definition() service.Definition {
 	return service.Definition{
-		Name:       "http",
-		ConfigType: nil, // http does not accept configuration
-		DependsOn:  nil, // http has no dependencies.
+		Name:       ServiceName,
+		ConfigType: Arguments{},
+		DependsOn: []string{
+			// The HTTP service exposes the peers of the cluster and serves the
+			// transport used by nodes to gossip with each other.
+			clusterservice.ServiceName,
+		},
 	}
 }
 
@@ -134,9 +180,30 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 
 	r.PathPrefix(s.componentHttpPathPrefix).Handler(s.componentHandler(host))
 
-	if s.node != nil {
-		cr, ch := s.node.Handler()
-		r.PathPrefix(cr).Handler(ch)
+	// Services we depend on are known ahead of time, so their routes are
+	// registered immediately.
+	for _, name := range s.Definition().DependsOn {
+		svc, ok := host.GetService(name)
+		if !ok {
+			continue
+		}
+		if sh, ok := svc.(ServiceHandler); ok {
+			base, handler := sh.ServiceHandler(host)
+			r.PathPrefix(base).Handler(handler)
+		}
+	}
+
+	// Services which depend on us can register their own routes. Their routes
+	// are only known once the server is listening; see registerServiceRoutes.
+	r.MatcherFunc(s.matchServiceRoute).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.currentServiceRoutes().ServeHTTP(w, req)
+	})
+
+	// Fall back to a local node which owns all work if the clustering service
+	// isn't available.
+	var node cluster.Node = cluster.NewLocalNode(s.opts.HTTPListenAddr)
+	if svc, ok := host.GetService(clusterservice.ServiceName); ok {
+		node = svc.Data().(clusterservice.Node)
 	}
 
 	if s.opts.ReadyFunc != nil {
@@ -156,45 +223,109 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 			level.Info(s.log).Log("msg", "reload requested via /-/reload endpoint")
 			defer level.Info(s.log).Log("msg", "config reloaded")
 
-			err := s.opts.ReloadFunc()
+			summary, err := s.opts.ReloadFunc()
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 			fmt.Fprintln(w, "config reloaded")
+			if summary != "" {
+				fmt.Fprintln(w, summary)
+			}
 		}).Methods(http.MethodGet, http.MethodPost)
 	}
 
+	if s.opts.EnableSupportBundle {
+		r.HandleFunc("/-/support", s.supportHandler(host, node))
+	}
+
 	// NOTE(rfratto): keep this at the bottom of all other routes, otherwise it
 	// will take precedence over anything else with collides with
 	// s.opts.UIPrefix.
-	fa := api.NewFlowAPI(host, s.node)
+	fa := api.NewFlowAPI(host, node)
+	if s.opts.EnablePProf {
+		fa.EnableProfiles(s.opts.CPUAccountant)
+	}
 	fa.RegisterRoutes(path.Join(s.opts.UIPrefix, "/api/v0/web"), r)
 	ui.RegisterRoutes(s.opts.UIPrefix, r)
 
-	srv := &http.Server{Handler: h2c.NewHandler(r, &http2.Server{})}
+	// Network traffic is served over TLS and authenticated when configured.
+	// In-memory traffic is deliberately not authenticated: it can only be
+	// dialed from within the process through Data.DialFunc, and the components
+	// which use it, such as a prometheus.scrape component scraping the agent's
+	// own exporters, can't present the credentials configured in the http
+	// block. The http block reference documents this exception.
+	var (
+		netSrv = &http.Server{Handler: authHandler(s.authArguments, h2c.NewHandler(r, &http2.Server{}))}
+		memSrv = &http.Server{Handler: h2c.NewHandler(r, &http2.Server{})}
+	)
+	if err := http2.ConfigureServer(netSrv, &http2.Server{}); err != nil {
+		return fmt.Errorf("failed to configure http2: %w", err)
+	}
 
 	level.Info(s.log).Log("msg", "now listening for http traffic", "addr", s.opts.HTTPListenAddr)
 
-	listeners := []net.Listener{netLis, s.memLis}
-	for _, lis := range listeners {
+	servers := map[net.Listener]*http.Server{
+		&tlsListener{Listener: netLis, getLoader: s.currentTLSLoader}: netSrv,
+		s.memLis: memSrv,
+	}
+	for lis, srv := range servers {
 		wg.Add(1)
-		go func(lis net.Listener) {
+		go func(lis net.Listener, srv *http.Server) {
 			defer wg.Done()
 			defer cancel()
 
 			if err := srv.Serve(lis); err != nil {
 				level.Info(s.log).Log("msg", "http server closed", "addr", lis.Addr(), "err", err)
 			}
-		}(lis)
+		}(lis, srv)
 	}
 
-	defer func() { _ = srv.Shutdown(ctx) }()
+	defer func() {
+		for _, srv := range servers {
+			_ = srv.Shutdown(ctx)
+		}
+	}()
+
+	// Finding our consumers waits for the controller to finish loading the
+	// config, so it must not delay serving other routes such as /metrics.
+	go s.registerServiceRoutes(host)
 
 	<-ctx.Done()
 	return nil
 }
 
+// registerServiceRoutes registers the routes of every service which depends
+// on the HTTP service and implements ServiceHandler.
+func (s *Service) registerServiceRoutes(host service.Host) {
+	r := mux.NewRouter()
+	for _, consumer := range host.GetServiceConsumers(ServiceName) {
+		sh, ok := consumer.(ServiceHandler)
+		if !ok {
+			continue
+		}
+		base, handler := sh.ServiceHandler(host)
+		r.PathPrefix(base).Handler(handler)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.serviceRoutes = r
+}
+
+func (s *Service) currentServiceRoutes() *mux.Router {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.serviceRoutes
+}
+
+// matchServiceRoute reports whether r is for a route registered by a
+// consumer of the HTTP service.
+func (s *Service) matchServiceRoute(r *http.Request, _ *mux.RouteMatch) bool {
+	routes := s.currentServiceRoutes()
+	return routes != nil && routes.Match(r, &mux.RouteMatch{})
+}
+
 func (s *Service) componentHandler(host service.Host) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Trim the path prefix to get our full path.
@@ -231,10 +362,88 @@ func (s *Service) componentHandler(host service.Host) http.HandlerFunc {
 	}
 }
 
-// Update implements [service.Service]. It is a no-op since the HTTP service
-// does not support runtime configuration.
+// defaultSupportBundleDuration and maxSupportBundleDuration bound how long
+// profiles are recorded for when generating a support bundle.
+const (
+	defaultSupportBundleDuration = 30 * time.Second
+	maxSupportBundleDuration     = 5 * time.Minute
+)
+
+func (s *Service) supportHandler(host service.Host, node cluster.Node) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		duration := defaultSupportBundleDuration
+		if r.URL.Query().Has("duration") {
+			d, err := strconv.Atoi(r.URL.Query().Get("duration"))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("duration value (in seconds) should be a positive integer: %s", err), http.StatusBadRequest)
+				return
+			}
+			if d < 1 {
+				http.Error(w, "duration value (in seconds) should be larger than 1", http.StatusBadRequest)
+				return
+			}
+			if time.Duration(d)*time.Second > maxSupportBundleDuration {
+				http.Error(w, fmt.Sprintf("duration value exceeds the maximum of %s", maxSupportBundleDuration), http.StatusBadRequest)
+				return
+			}
+			duration = time.Duration(d) * time.Second
+		}
+
+		bundle, err := supportbundle.ExportFlow(r.Context(), supportbundle.FlowOptions{
+			Components:    host,
+			Node:          node,
+			Gatherer:      s.gatherer,
+			RecentLogs:    s.opts.RecentLogs,
+			Config:        s.opts.LoadedConfig,
+			CPUAccountant: s.opts.CPUAccountant,
+			Duration:      duration,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := supportbundle.ServeFlow(w, bundle); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// Update implements [service.Service]. TLS settings apply to new
+// connections, while authentication settings apply to new requests.
 func (s *Service) Update(newConfig any) error {
-	return fmt.Errorf("HTTP service does not support configuration")
+	args := newConfig.(Arguments)
+
+	var loader *tlsLoader
+	if args.TLS != nil {
+		var err error
+		loader, err = newTLSLoader(s.log, *args.TLS)
+		if err != nil {
+			return fmt.Errorf("invalid tls block: %w", err)
+		}
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.args = args
+	s.tlsLoader = loader
+	return nil
+}
+
+// currentTLSLoader returns the tlsLoader for new connections, or nil if TLS
+// is disabled.
+func (s *Service) currentTLSLoader() *tlsLoader {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.tlsLoader
+}
+
+// authArguments returns the credentials to authenticate requests with, or nil
+// if authentication is disabled.
+func (s *Service) authArguments() *AuthArguments {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args.Auth
 }
 
 // Data returns an instance of [Data]. Calls to Data are cachable by the
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceRoutes(t *testing.T) {
+	var (
+		s       = New(Options{})
+		release = make(chan struct{})
+		host    = &blockingHost{release: release, consumers: []any{&testServiceHandler{}}}
+	)
+
+	r := mux.NewRouter()
+	r.MatcherFunc(s.matchServiceRoute).HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.currentServiceRoutes().ServeHTTP(w, req)
+	})
+	r.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.registerServiceRoutes(host)
+	}()
+
+	// Other routes are served while consumers are still being resolved.
+	require.Equal(t, http.StatusOK, serve("/metrics"))
+	require.Equal(t, http.StatusNotFound, serve("/api/v1/test/"))
+
+	close(release)
+	<-done
+
+	require.Equal(t, http.StatusTeapot, serve("/api/v1/test/"))
+	require.Equal(t, http.StatusOK, serve("/metrics"))
+}
+
+// blockingHost is a service.Host whose GetServiceConsumers blocks until
+// release is closed.
+type blockingHost struct {
+	release   chan struct{}
+	consumers []any
+}
+
+func (h *blockingHost) GetComponent(component.ID, component.InfoOptions) (*component.Info, error) {
+	return nil, component.ErrComponentNotFound
+}
+
+func (h *blockingHost) ListComponents(string, component.InfoOptions) ([]*component.Info, error) {
+	return nil, nil
+}
+
+func (h *blockingHost) GetServiceConsumers(string) []any {
+	<-h.release
+	return h.consumers
+}
+
+func (h *blockingHost) GetService(string) (service.Service, bool) {
+	return nil, false
+}
+
+type testServiceHandler struct{}
+
+func (testServiceHandler) Definition() service.Definition {
+	return service.Definition{Name: "test", DependsOn: []string{ServiceName}}
+}
+
+func (testServiceHandler) Run(ctx context.Context, _ service.Host) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (testServiceHandler) Update(any) error { return nil }
+
+func (testServiceHandler) Data() any { return nil }
+
+func (testServiceHandler) ServiceHandler(service.Host) (string, http.Handler) {
+	return "/api/v1/test/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	common_config "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// TLSArguments configures the HTTP server to serve traffic over TLS.
+type TLSArguments struct {
+	Cert         string                   `river:"cert_pem,attr,optional"`
+	CertFile     string                   `river:"cert_file,attr,optional"`
+	Key          rivertypes.Secret        `river:"key_pem,attr,optional"`
+	KeyFile      string                   `river:"key_file,attr,optional"`
+	ClientCA     string                   `river:"client_ca_pem,attr,optional"`
+	ClientCAFile string                   `river:"client_ca_file,attr,optional"`
+	ClientAuth   ClientAuth               `river:"client_auth_type,attr,optional"`
+	MinVersion   common_config.TLSVersion `river:"min_version,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (args *TLSArguments) Validate() error {
+	switch {
+	case len(args.Cert) > 0 && len(args.CertFile) > 0:
+		return fmt.Errorf("at most one of cert_pem and cert_file must be configured")
+	case len(args.Cert) == 0 && len(args.CertFile) == 0:
+		return fmt.Errorf("exactly one of cert_pem or cert_file must be configured")
+	case len(args.Key) > 0 && len(args.KeyFile) > 0:
+		return fmt.Errorf("at most one of key_pem and key_file must be configured")
+	case len(args.Key) == 0 && len(args.KeyFile) == 0:
+		return fmt.Errorf("exactly one of key_pem or key_file must be configured")
+	case len(args.ClientCA) > 0 && len(args.ClientCAFile) > 0:
+		return fmt.Errorf("at most one of client_ca_pem and client_ca_file must be configured")
+	}
+
+	hasClientCA := len(args.ClientCA) > 0 || len(args.ClientCAFile) > 0
+	switch tls.ClientAuthType(args.ClientAuth) {
+	case tls.NoClientCert:
+		if hasClientCA {
+			return fmt.Errorf("client_auth_type must be set when a client CA is configured")
+		}
+	case tls.VerifyClientCertIfGiven, tls.RequireAndVerifyClientCert:
+		if !hasClientCA {
+			return fmt.Errorf("client_ca_pem or client_ca_file must be configured to verify client certificates")
+		}
+	}
+	return nil
+}
+
+// ClientAuth is the policy the HTTP server follows for TLS client
+// authentication.
+type ClientAuth tls.ClientAuthType
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (ca ClientAuth) MarshalText() (text []byte, err error) {
+	for s, v := range clientAuthTypes {
+		if tls.ClientAuthType(ca) == v {
+			return []byte(s), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown client auth type: %d", ca)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (ca *ClientAuth) UnmarshalText(text []byte) error {
+	if v, ok := clientAuthTypes[string(text)]; ok {
+		*ca = ClientAuth(v)
+		return nil
+	}
+	return fmt.Errorf("unknown client auth type: %s", string(text))
+}
+
+// tlsLoader builds the TLS config for incoming connections. Files referenced
+// by the arguments are reloaded when they change on disk, so that renewed
+// certificates are used without reloading the agent.
+type tlsLoader struct {
+	log  log.Logger
+	args TLSArguments
+
+	mut      sync.Mutex
+	config   *tls.Config
+	modTimes map[string]time.Time // Modification times of the files config was built from
+}
+
+// newTLSLoader creates a tlsLoader for args. An error is returned if the TLS
+// config can't be built from args.
+func newTLSLoader(l log.Logger, args TLSArguments) (*tlsLoader, error) {
+	tl := &tlsLoader{log: l, args: args}
+	if _, err := tl.Config(); err != nil {
+		return nil, err
+	}
+	return tl, nil
+}
+
+// Config returns the current TLS config. If any of the referenced files
+// changed since the config was built, the config is rebuilt. When rebuilding
+// fails, the error is logged and the previous config is kept.
+func (tl *tlsLoader) Config() (*tls.Config, error) {
+	tl.mut.Lock()
+	defer tl.mut.Unlock()
+
+	modTimes := tl.fileModTimes()
+	if tl.config != nil && reflect.DeepEqual(modTimes, tl.modTimes) {
+		return tl.config, nil
+	}
+
+	// Record the modification times even if loading fails so that broken files
+	// are only reported once per change.
+	tl.modTimes = modTimes
+
+	config, err := buildTLSConfig(tl.args)
+	if err != nil {
+		if tl.config == nil {
+			return nil, err
+		}
+		level.Error(tl.log).Log("msg", "failed to reload TLS certificates, keeping the previous certificates", "err", err)
+		return tl.config, nil
+	}
+	tl.config = config
+	return config, nil
+}
+
+// fileModTimes returns the modification times of the files referenced by the
+// arguments of tl. Files which can't be read are omitted.
+func (tl *tlsLoader) fileModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, name := range []string{tl.args.CertFile, tl.args.KeyFile, tl.args.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil {
+			modTimes[name] = fi.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// buildTLSConfig builds a server TLS config from args.
+func buildTLSConfig(args TLSArguments) (*tls.Config, error) {
+	certPEM, err := readPEM(args.Cert, args.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading certificate: %w", err)
+	}
+	keyPEM, err := readPEM(string(args.Key), args.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("loading key pair: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.ClientAuthType(args.ClientAuth),
+		MinVersion:   uint16(args.MinVersion),
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if args.ClientCA != "" || args.ClientCAFile != "" {
+		caPEM, err := readPEM(args.ClientCA, args.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("client CA does not contain any PEM-encoded certificates")
+		}
+		config.ClientCAs = pool
+	}
+
+	return config, nil
+}
+
+// readPEM returns inline if it is set, and otherwise the content of filename.
+func readPEM(inline, filename string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	return os.ReadFile(filename)
+}
+
+// tlsListener is a net.Listener which establishes TLS connections when TLS
+// is enabled, and plaintext connections otherwise. The TLS config is looked
+// up for every accepted connection, so enabling TLS or changing certificates
+// only affects new connections.
+type tlsListener struct {
+	net.Listener
+
+	// getLoader returns the current tlsLoader, or nil if TLS is disabled.
+	getLoader func() *tlsLoader
+}
+
+// Accept implements net.Listener.
+func (l *tlsListener) Accept() (net.Conn, error) {
+	nc, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	loader := l.getLoader()
+	if loader == nil {
+		return nc, nil
+	}
+	return tls.Server(nc, &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return loader.Config()
+		},
+	}), nil
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSLoader_Reload(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+	)
+
+	ca := newTestCA(t)
+	writeKeyPair(t, ca.issue(t, "first", x509.ExtKeyUsageServerAuth), certFile, keyFile)
+
+	loader, err := newTLSLoader(log.NewNopLogger(), TLSArguments{CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	require.Equal(t, "first", leafCommonName(t, loader))
+
+	// Replacing the files reloads the certificate.
+	writeKeyPair(t, ca.issue(t, "second", x509.ExtKeyUsageServerAuth), certFile, keyFile)
+	touch(t, time.Now().Add(time.Hour), certFile, keyFile)
+	require.Equal(t, "second", leafCommonName(t, loader))
+
+	// Invalid files are ignored and the previous certificate is kept.
+	require.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0600))
+	touch(t, time.Now().Add(2*time.Hour), certFile)
+	require.Equal(t, "second", leafCommonName(t, loader))
+}
+
+func TestTLSListener_ClientAuth(t *testing.T) {
+	var (
+		serverCA = newTestCA(t)
+		clientCA = newTestCA(t)
+		server   = serverCA.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	)
+
+	loader, err := newTLSLoader(log.NewNopLogger(), TLSArguments{
+		Cert:       string(server.certPEM),
+		KeyFile:    writeTemp(t, server.keyPEM),
+		ClientCA:   string(clientCA.certPEM),
+		ClientAuth: ClientAuth(tls.RequireAndVerifyClientCert),
+	})
+	require.NoError(t, err)
+
+	netLis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	go func() { _ = srv.Serve(&tlsListener{Listener: netLis, getLoader: func() *tlsLoader { return loader }}) }()
+	defer srv.Close()
+
+	url := "https://" + netLis.Addr().String() + "/"
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(serverCA.certPEM)
+
+	t.Run("without client certificate", func(t *testing.T) {
+		cli := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: rootCAs, ServerName: "server"},
+		}}
+		_, err := cli.Get(url)
+		require.Error(t, err)
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		client := clientCA.issue(t, "client", x509.ExtKeyUsageClientAuth)
+		cert, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+		require.NoError(t, err)
+
+		cli := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: rootCAs, ServerName: "server", Certificates: []tls.Certificate{cert}},
+		}}
+		resp, err := cli.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestTLSArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name        string
+		args        TLSArguments
+		expectError string
+	}{
+		{
+			name:        "missing certificate",
+			args:        TLSArguments{KeyFile: "key.pem"},
+			expectError: "exactly one of cert_pem or cert_file must be configured",
+		},
+		{
+			name:        "missing key",
+			args:        TLSArguments{CertFile: "cert.pem"},
+			expectError: "exactly one of key_pem or key_file must be configured",
+		},
+		{
+			name:        "client CA without client auth",
+			args:        TLSArguments{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem"},
+			expectError: "client_auth_type must be set when a client CA is configured",
+		},
+		{
+			name:        "verifying client certificates without client CA",
+			args:        TLSArguments{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: ClientAuth(tls.RequireAndVerifyClientCert)},
+			expectError: "client_ca_pem or client_ca_file must be configured to verify client certificates",
+		},
+		{
+			name: "valid",
+			args: TLSArguments{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem", ClientAuth: ClientAuth(tls.RequireAndVerifyClientCert)},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.args.Validate()
+			if tc.expectError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectError)
+			}
+		})
+	}
+}
+
+type testKeyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCA returns a self-signed certificate authority.
+func newTestCA(t *testing.T) *testKeyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	return newTestKeyPair(t, tmpl, tmpl, key, key)
+}
+
+// issue returns a certificate for name signed by ca.
+func (ca *testKeyPair) issue(t *testing.T, name string, usage x509.ExtKeyUsage) *testKeyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	return newTestKeyPair(t, tmpl, ca.cert, key, ca.key)
+}
+
+func newTestKeyPair(t *testing.T, tmpl, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) *testKeyPair {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testKeyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeKeyPair(t *testing.T, kp *testKeyPair, certFile, keyFile string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(certFile, kp.certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, kp.keyPEM, 0600))
+}
+
+func writeTemp(t *testing.T, content []byte) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "file.pem")
+	require.NoError(t, os.WriteFile(name, content, 0600))
+	return name
+}
+
+// touch sets the modification time of files to at, so that changes are
+// detected on filesystems with coarse timestamps.
+func touch(t *testing.T, at time.Time, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		require.NoError(t, os.Chtimes(f, at, at))
+	}
+}
+
+func leafCommonName(t *testing.T, loader *tlsLoader) string {
+	t.Helper()
+
+	config, err := loader.Config()
+	require.NoError(t, err)
+	require.Len(t, config.Certificates, 1)
+
+	leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
@@ -55,6 +55,12 @@ type Host interface {
 	// values will be an instance of [component.Component] or
 	// [Service].
 	GetServiceConsumers(serviceName string) []any
+
+	// GetService gets a service by name. Services should only get the
+	// services they declared a dependency on.
+	//
+	// GetService returns false if the named service doesn't exist.
+	GetService(name string) (Service, bool)
 }
 
 // Service is an individual service to run.
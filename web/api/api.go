@@ -5,13 +5,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/pprof/profile"
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/livedebugging"
+	"github.com/grafana/agent/pkg/flow/profiling"
 	"github.com/prometheus/prometheus/util/httputil"
 )
 
@@ -19,6 +27,9 @@ import (
 type FlowAPI struct {
 	flow component.Provider
 	node cluster.Node
+
+	profiles bool                     // Whether component profiles can be downloaded.
+	cpu      *profiling.CPUAccountant // Source of CPU profiles; may be nil.
 }
 
 // NewFlowAPI instantiates a new Flow API.
@@ -26,6 +37,14 @@ func NewFlowAPI(flow component.Provider, node cluster.Node) *FlowAPI {
 	return &FlowAPI{flow: flow, node: node}
 }
 
+// EnableProfiles allows downloading profiles of individual components. CPU
+// profiles are taken from cpu if it is non-nil; otherwise, a CPU profile is
+// recorded for every request.
+func (f *FlowAPI) EnableProfiles(cpu *profiling.CPUAccountant) {
+	f.profiles = true
+	f.cpu = cpu
+}
+
 // RegisterRoutes registers all the API's routes.
 func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	// NOTE(rfratto): {id:.+} is used in routes below to allow the
@@ -34,6 +53,14 @@ func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 
 	r.Handle(path.Join(urlPrefix, "/modules/{moduleID:.+}/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
+	if f.profiles {
+		// Registered before the route for getting a component so that it takes
+		// precedence. Profiles are already compressed.
+		r.Handle(path.Join(urlPrefix, "/components/{id:.+}/profile/{profile}"), f.getComponentProfileHandler())
+	}
+	// Live debugging streams must not be compressed, since compression buffers
+	// events until the stream ends.
+	r.Handle(path.Join(urlPrefix, "/components/{id:.+}/live-debugging"), f.getLiveDebuggingHandler())
 	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), httputil.CompressionHandler{Handler: f.getComponentHandler()})
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: f.getClusteringPeersHandler()})
 }
@@ -102,3 +129,171 @@ func (f *FlowAPI) getClusteringPeersHandler() http.HandlerFunc {
 		_, _ = w.Write(bb)
 	}
 }
+
+// liveDebuggingEvent is an event of a live debugging stream, encoded as JSON
+// in the data field of a server-sent event.
+type liveDebuggingEvent struct {
+	Time    time.Time `json:"time"`
+	Data    string    `json:"data"`
+	Dropped uint64    `json:"dropped"` // Events dropped so far by rate limiting
+}
+
+// liveDebuggingKeepalive is how often a comment is written to live debugging
+// streams without events, so that idle streams aren't closed by proxies.
+const liveDebuggingKeepalive = 15 * time.Second
+
+// getLiveDebuggingHandler streams the data flowing through a component as
+// server-sent events. The rate and sample_ratio query parameters configure
+// the subscription; see livedebugging.Options.
+func (f *FlowAPI) getLiveDebuggingHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id := component.ParseID(vars["id"])
+
+		info, err := f.flow.GetComponent(id, component.InfoOptions{})
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		ldc, ok := info.Component.(component.LiveDebuggingComponent)
+		if !ok {
+			http.Error(w, "component does not support live debugging", http.StatusBadRequest)
+			return
+		}
+
+		var opts livedebugging.Options
+		for name, dest := range map[string]*float64{"rate": &opts.Rate, "sample_ratio": &opts.SampleRatio} {
+			if v := r.FormValue(name); v != "" {
+				if *dest, err = strconv.ParseFloat(v, 64); err != nil {
+					http.Error(w, fmt.Sprintf("invalid %s %q", name, v), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+
+		sub, err := ldc.LiveDebugging().Subscribe(opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		defer sub.Close()
+
+		rc := http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		keepalive := time.NewTicker(liveDebuggingKeepalive)
+		defer keepalive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepalive.C:
+				_, err = fmt.Fprint(w, ": keepalive\n\n")
+			case ev := <-sub.Events():
+				var bb []byte
+				bb, err = json.Marshal(liveDebuggingEvent{Time: ev.Time, Data: ev.Data, Dropped: sub.Dropped()})
+				if err == nil {
+					_, err = fmt.Fprintf(w, "data: %s\n\n", bb)
+				}
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// defaultCPUProfileDuration is the duration of CPU profiles recorded for a
+// component when the seconds query parameter isn't set.
+const defaultCPUProfileDuration = 30 * time.Second
+
+func (f *FlowAPI) getComponentProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id := component.ParseID(vars["id"])
+
+		info, err := f.flow.GetComponent(id, component.InfoOptions{})
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		var p *profile.Profile
+		switch name := vars["profile"]; name {
+		case "goroutine":
+			p, err = profiling.Lookup(name)
+			if err == nil {
+				p = profiling.FilterComponent(p, id.String())
+			}
+
+		case "profile":
+			d := defaultCPUProfileDuration
+			if sec := r.FormValue("seconds"); sec != "" {
+				n, parseErr := strconv.ParseInt(sec, 10, 64)
+				if parseErr != nil || n <= 0 {
+					http.Error(w, fmt.Sprintf("invalid seconds %q", sec), http.StatusBadRequest)
+					return
+				}
+				d = time.Duration(n) * time.Second
+			}
+
+			p, err = f.cpuProfile(r.Context(), d)
+			if err == nil {
+				p = profiling.FilterComponent(p, id.String())
+			}
+
+		case "heap", "allocs":
+			// Allocations can't be attributed to component IDs, so heap profiles
+			// include allocations of all components of the same type.
+			pkg := profiling.FuncPackage(info.Registration.Build)
+			if pkg == "" || pkg == flowPackage || strings.HasPrefix(pkg, flowPackage+"/") {
+				http.Error(w, "heap profiles are only available for builtin components", http.StatusBadRequest)
+				return
+			}
+			p, err = profiling.Lookup(name)
+			if err == nil {
+				p = profiling.FilterPackage(p, pkg)
+			}
+
+		default:
+			http.Error(w, fmt.Sprintf("unsupported profile %q", name), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pb.gz"`, vars["profile"]))
+		_ = p.Write(w)
+	}
+}
+
+// flowPackage is the package which implements custom components; custom
+// components don't have a package of their own to attribute heap profiles
+// to.
+const flowPackage = "github.com/grafana/agent/pkg/flow"
+
+// cpuProfile returns the CPU profile to use for a request. A new CPU profile
+// is only recorded for the duration d if CPU accounting is disabled.
+func (f *FlowAPI) cpuProfile(ctx context.Context, d time.Duration) (*profile.Profile, error) {
+	if f.cpu != nil {
+		p := f.cpu.LastProfile()
+		if p == nil {
+			return nil, fmt.Errorf("no CPU profile has been recorded yet")
+		}
+		return p, nil
+	}
+	return profiling.RecordCPU(ctx, d)
+}
@@ -1,6 +1,6 @@
 import { FC, Fragment, ReactElement } from 'react';
 import { Link } from 'react-router-dom';
-import { faCubes, faLink } from '@fortawesome/free-solid-svg-icons';
+import { faBug, faCubes, faLink } from '@fortawesome/free-solid-svg-icons';
 import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
 
 import { partitionBody } from '../../utils/partition';
@@ -100,6 +100,14 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           </a>
         </div>
 
+        {props.component.liveDebugging && (
+          <div className={styles.docsLink}>
+            <Link to={`/live-debugging/${pathJoin([props.component.moduleID, props.component.localID])}`}>
+              Live debugging <FontAwesomeIcon icon={faBug} />
+            </Link>
+          </div>
+        )}
+
         {props.component.health.message && (
           <blockquote>
             <h1>
@@ -109,6 +117,9 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
               )}
             </h1>
             <p>{props.component.health.message}</p>
+            {props.component.health.restarts !== undefined && props.component.health.restarts > 0 && (
+              <p>Restarted {props.component.health.restarts} time(s)</p>
+            )}
           </blockquote>
         )}
 
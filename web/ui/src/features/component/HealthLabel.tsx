@@ -14,6 +14,7 @@ export const HealthLabel: FC<HealthLabelProps> = ({ health }) => {
     [ComponentHealthState.UNHEALTHY]: `${styles.health} ${styles['state-error']}`,
     [ComponentHealthState.UNKNOWN]: `${styles.health} ${styles['state-warn']}`,
     [ComponentHealthState.EXITED]: `${styles.health} ${styles['state-error']}`,
+    [ComponentHealthState.STUCK]: `${styles.health} ${styles['state-error']}`,
   };
   const healthClass = healthMappings[health];
 
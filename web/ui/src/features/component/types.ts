@@ -66,6 +66,8 @@ export interface ComponentHealth {
   message?: string;
   /** Timestamp when health last changed. */
   updatedTime?: string;
+  /** Number of times the component was restarted after exiting. */
+  restarts?: number;
 }
 
 /**
@@ -76,6 +78,7 @@ export enum ComponentHealthState {
   UNHEALTHY = 'unhealthy',
   UNKNOWN = 'unknown',
   EXITED = 'exited',
+  STUCK = 'stuck',
 }
 
 /*
@@ -106,6 +109,12 @@ export interface ComponentDetail extends ComponentInfo {
    */
   createdModuleIDs?: string[];
 
+  /**
+   * Whether the component can stream the data flowing through it to the live
+   * debugging page.
+   */
+  liveDebugging?: boolean;
+
   /**
    * If a component is a module loader, the loaded components from the module are included here.
    */
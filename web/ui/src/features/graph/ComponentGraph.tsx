@@ -384,6 +384,8 @@ export const ComponentGraph: FC<ComponentGraphProps> = (props) => {
             return '#d2476d';
           case ComponentHealthState.EXITED:
             return '#d2476d';
+          case ComponentHealthState.STUCK:
+            return '#d2476d';
           case ComponentHealthState.UNKNOWN:
             return '#f5d65b';
         }
@@ -0,0 +1,64 @@
+import { FC, useState } from 'react';
+import { Link } from 'react-router-dom';
+
+import { useLiveDebugging } from '../../hooks/liveDebugging';
+
+import { LiveDebuggingOptions } from './types';
+
+import styles from './LiveDebuggingView.module.css';
+
+export interface LiveDebuggingViewProps {
+  id: string;
+}
+
+export const LiveDebuggingView: FC<LiveDebuggingViewProps> = ({ id }) => {
+  const [options, setOptions] = useState<LiveDebuggingOptions>({ rate: 10, sampleRatio: 1 });
+  const [paused, setPaused] = useState(false);
+  const { events, dropped, error, clear } = useLiveDebugging(id, options, paused);
+
+  return (
+    <div className={styles.view}>
+      <div className={styles.controls}>
+        <Link to={`/component/${id}`}>{id}</Link>
+        <label>
+          Rate (events/s)
+          <input
+            type="number"
+            min={1}
+            max={100}
+            value={options.rate}
+            onChange={(e) => setOptions({ ...options, rate: Number(e.target.value) })}
+          />
+        </label>
+        <label>
+          Sample ratio
+          <input
+            type="number"
+            min={0.01}
+            max={1}
+            step={0.01}
+            value={options.sampleRatio}
+            onChange={(e) => setOptions({ ...options, sampleRatio: Number(e.target.value) })}
+          />
+        </label>
+        <button onClick={() => setPaused(!paused)}>{paused ? 'Resume' : 'Pause'}</button>
+        <button onClick={clear}>Clear</button>
+        <span className={styles.stats}>
+          {events.length} events, {dropped} dropped by rate limiting
+        </span>
+      </div>
+
+      {error && <blockquote className={styles.error}>{error}</blockquote>}
+
+      <div className={styles.events}>
+        {events.length === 0 && <em>Waiting for data to flow through the component…</em>}
+        {events.map((event, idx) => (
+          <div key={idx} className={styles.event}>
+            <span className={styles.time}>{event.time}</span>
+            <span>{event.data}</span>
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+};
@@ -0,0 +1,35 @@
+/**
+ * LiveDebuggingEvent is a sample of the data flowing through a component.
+ */
+export interface LiveDebuggingEvent {
+  /**
+   * Time the component published the data.
+   */
+  time: string;
+
+  /**
+   * Human-readable representation of the data.
+   */
+  data: string;
+
+  /**
+   * Number of events dropped by the server so far because of the rate limit.
+   */
+  dropped: number;
+}
+
+/**
+ * LiveDebuggingOptions configure a live debugging stream.
+ */
+export interface LiveDebuggingOptions {
+  /**
+   * Maximum number of events per second. The server caps the rate at 100.
+   */
+  rate: number;
+
+  /**
+   * Fraction of the data flowing through the component to consider, between
+   * 0 and 1.
+   */
+  sampleRatio: number;
+}
@@ -0,0 +1,69 @@
+import { useEffect, useState } from 'react';
+
+import { LiveDebuggingEvent, LiveDebuggingOptions } from '../features/livedebugging/types';
+
+/**
+ * maxEvents is the number of most recent events kept by useLiveDebugging.
+ */
+const maxEvents = 500;
+
+export interface LiveDebuggingStream {
+  events: LiveDebuggingEvent[];
+
+  /**
+   * Number of events the server dropped because of the rate limit.
+   */
+  dropped: number;
+
+  /**
+   * Error reported by the stream, if any.
+   */
+  error?: string;
+
+  /**
+   * Removes all received events.
+   */
+  clear: () => void;
+}
+
+/**
+ * useLiveDebugging streams the data flowing through a component from the API.
+ * The stream is closed while paused is true.
+ *
+ * @param id The ID of the component to stream data from.
+ * @param options Options of the stream.
+ * @param paused Whether the stream is paused.
+ */
+export const useLiveDebugging = (id: string, options: LiveDebuggingOptions, paused: boolean): LiveDebuggingStream => {
+  const [events, setEvents] = useState<LiveDebuggingEvent[]>([]);
+  const [dropped, setDropped] = useState(0);
+  const [error, setError] = useState<string | undefined>(undefined);
+
+  useEffect(
+    function () {
+      if (paused) {
+        return;
+      }
+
+      const params = new URLSearchParams({
+        rate: options.rate.toString(),
+        sample_ratio: options.sampleRatio.toString(),
+      });
+
+      // Request is relative to the <base> tag inside of <head>.
+      const source = new EventSource(`./api/v0/web/components/${id}/live-debugging?${params}`);
+      source.onopen = () => setError(undefined);
+      source.onerror = () => setError('Connection to the stream was lost, retrying.');
+      source.onmessage = (msg: MessageEvent<string>) => {
+        const event: LiveDebuggingEvent = JSON.parse(msg.data);
+        setDropped(event.dropped);
+        setEvents((prev) => prev.concat(event).slice(-maxEvents));
+      };
+
+      return () => source.close();
+    },
+    [id, options.rate, options.sampleRatio, paused]
+  );
+
+  return { events, dropped, error, clear: () => setEvents([]) };
+};
@@ -0,0 +1,18 @@
+import { FC } from 'react';
+import { useParams } from 'react-router-dom';
+import { faBug } from '@fortawesome/free-solid-svg-icons';
+
+import Page from '../features/layout/Page';
+import { LiveDebuggingView } from '../features/livedebugging/LiveDebuggingView';
+
+const PageLiveDebugging: FC = () => {
+  const { '*': id } = useParams();
+
+  return (
+    <Page name="Live debugging" desc="Sampled data flowing through the component" icon={faBug}>
+      {id && <LiveDebuggingView id={id} />}
+    </Page>
+  );
+};
+
+export default PageLiveDebugging;